@@ -2,37 +2,82 @@ package application
 
 import (
   "context"
+  "errors"
   "fmt"
   "net/http"
   "time"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+
+  "github.com/F-Dupraz/ecommerce-with-go/job"
 )
 
 type App struct {
+  config Config
+  db     *pgxpool.Pool
   router http.Handler
+  jobs   *job.Scheduler
 }
 
-func New() *App {
+func New(ctx context.Context, config Config) (*App, error) {
+  db, err := pgxpool.New(ctx, config.DatabaseURL)
+  if err != nil {
+    return nil, fmt.Errorf("failed to connect to database: %w", err)
+  }
+
+  s, err := loadServices(db, config)
+  if err != nil {
+    db.Close()
+    return nil, err
+  }
+
   app := &App{
-    router: loadRoutes(),
+    config: config,
+    db:     db,
+    router: loadRoutes(s, config),
+    jobs:   loadJobs(s.product, s.image, s.productImport, s.trash, s.price, s.inventory, s.subscription, s.tokenRevocation, s.passwordReset, s.emailVerification, s.mfa, s.jwtManager),
   }
 
-  return app
+  return app, nil
 }
 
+// Start serves requests and runs the background jobs until ctx is cancelled,
+// then drains in-flight requests, waits for running jobs and closes the
+// database pool.
 func (a *App) Start(ctx context.Context) error {
+  defer a.db.Close()
+
   server := &http.Server{
-    Addr:         ":3000",
+    Addr:         a.config.Addr,
     Handler:      a.router,
     ReadTimeout:  15 * time.Second,
     WriteTimeout: 15 * time.Second,
     IdleTimeout:  60 * time.Second,
   }
 
-  fmt.Println("Server starting on port 3000...")
+  jobCtx, stopJobs := context.WithCancel(ctx)
+  a.jobs.Start(jobCtx)
+  defer a.jobs.Wait()
+  defer stopJobs()
 
-  err := server.ListenAndServe()
-  if err != nil {
+  fmt.Printf("Server starting on %s...\n", a.config.Addr)
+
+  errCh := make(chan error, 1)
+  go func() {
+    errCh <- server.ListenAndServe()
+  }()
+
+  select {
+  case err := <-errCh:
     return fmt.Errorf("failed to start server: %w", err)
+  case <-ctx.Done():
+  }
+
+  shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+  defer cancel()
+
+  if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+    return fmt.Errorf("failed to shut down server: %w", err)
   }
 
   return nil
@@ -0,0 +1,113 @@
+package application
+
+import (
+  "fmt"
+  "os"
+  "strings"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+)
+
+// Config is read from the environment. Only DATABASE_URL and one of
+// JWT_KEY_DIR or JWT_SECRET are required, everything else has a default.
+type Config struct {
+  Addr        string
+  DatabaseURL string
+
+  // Access tokens are signed with the keys in JWTKeyDir when it is set, and
+  // with JWTSecret otherwise.
+  JWTKeyDir string
+  JWTSecret string
+  // How long a token revoked on another instance can still be accepted here.
+  RevocationRecheck time.Duration
+
+  // Uploaded images are stored under UploadDir and served from UploadURL.
+  UploadDir string
+  UploadURL string
+
+  // Frontend pages the emailed links point at.
+  PasswordResetURL string
+  EmailVerifyURL   string
+
+  MFAPolicy         model.MFAPolicy
+  MFAIssuer         string
+  EmailVerification model.EmailVerificationPolicy
+  Allocation        model.AllocationStrategy
+  TrashRetention    time.Duration
+
+  LowStockRecipients []string
+
+  // Emails go out over SMTP when SMTPAddr is set, to NotifyWebhookURL when
+  // that is set, and to the log otherwise.
+  SMTPAddr         string
+  SMTPUsername     string
+  SMTPPassword     string
+  SMTPFrom         string
+  NotifyWebhookURL string
+}
+
+func LoadConfig() (Config, error) {
+  config := Config{
+    Addr: env("ADDR", ":3000"),
+    DatabaseURL: os.Getenv("DATABASE_URL"),
+    JWTKeyDir: os.Getenv("JWT_KEY_DIR"),
+    JWTSecret: os.Getenv("JWT_SECRET"),
+    UploadDir: env("UPLOAD_DIR", "uploads"),
+    UploadURL: env("UPLOAD_URL", "/uploads"),
+    PasswordResetURL: env("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+    EmailVerifyURL: env("EMAIL_VERIFY_URL", "http://localhost:3000/verify-email"),
+    MFAPolicy: model.MFAPolicy(env("MFA_POLICY", string(model.MFAOptional))),
+    MFAIssuer: env("MFA_ISSUER", "ecommerce-with-go"),
+    EmailVerification: model.EmailVerificationPolicy(env("EMAIL_VERIFICATION", string(model.EmailVerificationOptional))),
+    Allocation: model.AllocationStrategy(env("ORDER_ALLOCATION", string(model.AllocationClosest))),
+    SMTPAddr: os.Getenv("SMTP_ADDR"),
+    SMTPUsername: os.Getenv("SMTP_USERNAME"),
+    SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+    SMTPFrom: os.Getenv("SMTP_FROM"),
+    NotifyWebhookURL: os.Getenv("NOTIFY_WEBHOOK_URL"),
+  }
+
+  if config.DatabaseURL == "" {
+    return Config{}, fmt.Errorf("DATABASE_URL is not set")
+  }
+  if config.JWTKeyDir == "" && config.JWTSecret == "" {
+    return Config{}, fmt.Errorf("JWT_KEY_DIR or JWT_SECRET must be set")
+  }
+
+  var err error
+  if config.RevocationRecheck, err = envDuration("REVOCATION_RECHECK", 30*time.Second); err != nil {
+    return Config{}, err
+  }
+  if config.TrashRetention, err = envDuration("TRASH_RETENTION", 30*24*time.Hour); err != nil {
+    return Config{}, err
+  }
+
+  for _, recipient := range strings.Split(os.Getenv("LOW_STOCK_RECIPIENTS"), ",") {
+    if recipient = strings.TrimSpace(recipient); recipient != "" {
+      config.LowStockRecipients = append(config.LowStockRecipients, recipient)
+    }
+  }
+
+  return config, nil
+}
+
+func env(key, fallback string) string {
+  if value := os.Getenv(key); value != "" {
+    return value
+  }
+  return fallback
+}
+
+func envDuration(key string, fallback time.Duration) (time.Duration, error) {
+  value := os.Getenv(key)
+  if value == "" {
+    return fallback, nil
+  }
+
+  d, err := time.ParseDuration(value)
+  if err != nil {
+    return 0, fmt.Errorf("invalid %s: %w", key, err)
+  }
+  return d, nil
+}
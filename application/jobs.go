@@ -0,0 +1,29 @@
+package application
+
+import (
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/job"
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+)
+
+func loadJobs(productService *service.ProductService, imageService *service.ImageService, importService *service.ProductImportService, trashService *service.ProductTrashService, priceService *service.PriceService, inventoryService *service.InventoryService, subscriptionService *service.SubscriptionService, tokenRevocationService *service.TokenRevocationService, passwordResetService *service.PasswordResetService, emailVerificationService *service.EmailVerificationService, mfaService *service.MFAService, jwtManager *auth.JWTManager) *job.Scheduler {
+  scheduler := job.NewScheduler()
+
+  scheduler.Every("related-products", 6*time.Hour, productService.RefreshRelatedProducts)
+  scheduler.Every("product-popularity", 15*time.Minute, productService.RefreshPopularity)
+  scheduler.Every("orphaned-images", time.Hour, imageService.RemoveOrphanedImages)
+  scheduler.Every("product-imports", 5*time.Second, importService.ProcessPendingImports)
+  scheduler.Every("product-trash-purge", time.Hour, trashService.PurgeExpired)
+  scheduler.Every("scheduled-prices", time.Minute, priceService.ApplyScheduledPrices)
+  scheduler.Every("low-stock-alerts", time.Minute, inventoryService.SendLowStockAlerts)
+  scheduler.Every("product-subscriptions", 5*time.Minute, subscriptionService.SendNotifications)
+  scheduler.Every("token-revocation-purge", time.Hour, tokenRevocationService.PurgeExpired)
+  scheduler.Every("password-reset-purge", 6*time.Hour, passwordResetService.PurgeExpired)
+  scheduler.Every("email-verification-purge", 6*time.Hour, emailVerificationService.PurgeExpired)
+  scheduler.Every("mfa-challenge-purge", time.Hour, mfaService.PurgeExpired)
+  scheduler.Every("signing-keys-reload", time.Minute, jwtManager.ReloadKeys)
+
+  return scheduler
+}
@@ -1,62 +1,63 @@
 package application
 
 import (
-  // "database/sql"
+  "net/http"
+  "strings"
 
   "github.com/go-chi/chi/v5"
   "github.com/go-chi/chi/v5/middleware"
-  "github.com/go-playground/validator/v10"
 
   "github.com/F-Dupraz/ecommerce-with-go/handler"
-  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  authmiddleware "github.com/F-Dupraz/ecommerce-with-go/middleware"
 )
 
-func loadRoutes() *chi.Mux {
+type routeRegistrar interface {
+  RegisterRoutes(router chi.Router)
+}
+
+func loadRoutes(s *services, config Config) *chi.Mux {
   router := chi.NewRouter()
 
   router.Use(middleware.Logger)
-  router.Use(middleware.Recoverer) // Agregá este, es importante
-  router.Use(middleware.RequestID)  // Para trackear requests
+  router.Use(middleware.Recoverer)
+  router.Use(middleware.RequestID)
+
+  authMiddleware := authmiddleware.NewAuthMiddleware(s.jwtManager, s.revocations)
 
-  validator, err := dto.NewValidator()
-  if err != nil {
-    panic("Failed to initialize validator: " + err.Error())
+  handler.NewJWKSHandler(s.jwtManager).RegisterRoutes(router)
+
+  // Uploads are only served from here when they live under this server.
+  if strings.HasPrefix(config.UploadURL, "/") {
+    prefix := strings.TrimSuffix(config.UploadURL, "/") + "/"
+    router.Get(prefix+"*", http.StripPrefix(prefix, http.FileServer(http.Dir(config.UploadDir))).ServeHTTP)
+  }
+
+  handlers := []routeRegistrar{
+    handler.NewAuthHandler(s.auth, s.user, s.passwordReset, s.emailVerification, s.mfa, authMiddleware, s.validator),
+    handler.NewUserHandler(s.user, authMiddleware, s.validator),
+    handler.NewProductHandler(s.product, authMiddleware, s.validator),
+    handler.NewBrandHandler(s.brand, s.product, authMiddleware, s.validator),
+    handler.NewCategoryHandler(s.category, authMiddleware, s.validator),
+    handler.NewTagHandler(s.tag, authMiddleware, s.validator),
+    handler.NewVariantHandler(s.variant, authMiddleware, s.validator),
+    handler.NewImageHandler(s.image, authMiddleware, s.validator),
+    handler.NewProductImportHandler(s.productImport, authMiddleware, s.validator),
+    handler.NewProductTrashHandler(s.trash, authMiddleware, s.validator),
+    handler.NewPriceHandler(s.price, authMiddleware, s.validator),
+    handler.NewInventoryHandler(s.inventory, authMiddleware, s.validator),
+    handler.NewWarehouseHandler(s.warehouse, authMiddleware, s.validator),
+    handler.NewSubscriptionHandler(s.subscription, authMiddleware, s.validator),
+    handler.NewReviewHandler(s.review, authMiddleware, s.validator),
+    handler.NewWishlistHandler(s.wishlist, authMiddleware, s.validator),
+    handler.NewCartHandler(s.cart, authMiddleware, s.validator),
+    handler.NewOrderHandler(s.order, authMiddleware, s.validator),
   }
 
   router.Route("/api/v1", func(r chi.Router) {
-    r.Route("/users", func(r chi.Router) {
-      loadUsersRoutes(r, validator)
-    })
+    for _, h := range handlers {
+      h.RegisterRoutes(r)
+    }
   })
 
   return router
 }
-
-func loadUsersRoutes(router chi.Router, validator *validator.Validate) {
-  // mockUserService := &MockUserService{}
-
-  userHandler := handler.NewUserHandler(mockUserService, validator)
-
-  userHandler.RegisterRoutes(router)
-}
-
-func loadOrderRoutes(router chi.Router) {
-  orderHandler := &handler.Order{}
-
-  router.Get("/", orderHandler.ListAllOrders)
-  router.Get("/{id}", orderHandler.GetByID)
-  router.Post("/", orderHandler.Create)
-  router.Put("/{id}", orderHandler.UpdateByID)
-  router.Delete("/{id}", orderHandler.DeleteByID)
-}
-
-func loadProductRoutes(router chi.Router) {
-  productHandler := &handler.Product{}
-
-  router.Get("/", productHandler.ListAllOrders)
-  router.Get("/{id}", productHandler.GetByID)
-  router.Post("/", productHandler.Create)
-  router.Put("/{id}", productHandler.UpdateByID)
-  router.Delete("/{id}", productHandler.DeleteByID)
-}
-
@@ -0,0 +1,119 @@
+package application
+
+import (
+  "fmt"
+
+  "github.com/go-playground/validator/v10"
+  "github.com/jackc/pgx/v5/pgxpool"
+
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/notify"
+  "github.com/F-Dupraz/ecommerce-with-go/storage"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+)
+
+// services holds everything the routes and the background jobs share.
+type services struct {
+  validator   *validator.Validate
+  jwtManager  *auth.JWTManager
+  revocations auth.RevocationStore
+  uploads     *storage.LocalStore
+
+  auth              *service.AuthService
+  user              *service.UserService
+  passwordReset     *service.PasswordResetService
+  emailVerification *service.EmailVerificationService
+  mfa               *service.MFAService
+  tokenRevocation   *service.TokenRevocationService
+
+  product      *service.ProductService
+  brand        *service.BrandService
+  category     *service.CategoryService
+  tag          *service.TagService
+  variant      *service.VariantService
+  image        *service.ImageService
+  productImport *service.ProductImportService
+  trash        *service.ProductTrashService
+  price        *service.PriceService
+  inventory    *service.InventoryService
+  warehouse    *service.WarehouseService
+  subscription *service.SubscriptionService
+  review       *service.ReviewService
+  wishlist     *service.WishlistService
+  cart         *service.CartService
+  order        *service.OrderService
+}
+
+func loadServices(db *pgxpool.Pool, config Config) (*services, error) {
+  validator, err := dto.NewValidator()
+  if err != nil {
+    return nil, fmt.Errorf("failed to initialize validator: %w", err)
+  }
+
+  jwtManager := auth.NewJWTManager(config.JWTSecret)
+  if config.JWTKeyDir != "" {
+    jwtManager, err = auth.NewJWTManagerFromDir(config.JWTKeyDir)
+    if err != nil {
+      return nil, err
+    }
+  }
+
+  uploads, err := storage.NewLocalStore(config.UploadDir, config.UploadURL)
+  if err != nil {
+    return nil, fmt.Errorf("failed to open upload directory: %w", err)
+  }
+
+  var notifier notify.Notifier = notify.NewLogNotifier()
+  switch {
+  case config.SMTPAddr != "":
+    notifier = notify.NewSMTPNotifier(config.SMTPAddr, config.SMTPUsername, config.SMTPPassword, config.SMTPFrom)
+  case config.NotifyWebhookURL != "":
+    notifier = notify.NewWebhookNotifier(config.NotifyWebhookURL)
+  }
+
+  userRepo := repository.NewUserRepository(db)
+  productRepo := repository.NewProductRepository(db)
+  brandRepo := repository.NewBrandRepository(db)
+  categoryRepo := repository.NewCategoryRepository(db)
+  variantRepo := repository.NewVariantRepository(db)
+  priceRepo := repository.NewPriceRepository(db)
+  warehouseRepo := repository.NewWarehouseRepository(db)
+  tokenRevocationRepo := repository.NewTokenRevocationRepository(db)
+
+  revocations := auth.NewCachedRevocationStore(tokenRevocationRepo, config.RevocationRecheck)
+
+  s := &services{
+    validator: validator,
+    jwtManager: jwtManager,
+    revocations: revocations,
+    uploads: uploads,
+  }
+
+  s.emailVerification = service.NewEmailVerificationService(repository.NewEmailVerificationRepository(db), userRepo, notifier, config.EmailVerifyURL)
+  s.user = service.NewUserService(userRepo, revocations, s.emailVerification)
+  s.mfa = service.NewMFAService(repository.NewMFARepository(db), userRepo, config.MFAPolicy, config.MFAIssuer)
+  s.auth = service.NewAuthService(userRepo, repository.NewSessionRepository(db), repository.NewSecurityEventRepository(db), s.mfa, revocations, jwtManager)
+  s.passwordReset = service.NewPasswordResetService(repository.NewPasswordResetRepository(db), userRepo, repository.NewSessionRepository(db), revocations, notifier, config.PasswordResetURL)
+  s.tokenRevocation = service.NewTokenRevocationService(tokenRevocationRepo)
+
+  s.product = service.NewProductService(productRepo, repository.NewRecommendationRepository(db), categoryRepo, brandRepo, variantRepo, priceRepo, warehouseRepo, repository.NewPopularityRepository(db))
+  s.brand = service.NewBrandService(brandRepo)
+  s.category = service.NewCategoryService(categoryRepo)
+  s.tag = service.NewTagService(repository.NewTagRepository(db))
+  s.variant = service.NewVariantService(variantRepo, productRepo, warehouseRepo)
+  s.image = service.NewImageService(repository.NewImageRepository(db), productRepo, uploads)
+  s.productImport = service.NewProductImportService(repository.NewImportJobRepository(db), productRepo, categoryRepo, brandRepo, uploads, validator)
+  s.trash = service.NewProductTrashService(productRepo, config.TrashRetention)
+  s.price = service.NewPriceService(priceRepo, productRepo)
+  s.inventory = service.NewInventoryService(repository.NewInventoryRepository(db), productRepo, notifier, config.LowStockRecipients)
+  s.warehouse = service.NewWarehouseService(warehouseRepo, productRepo)
+  s.subscription = service.NewSubscriptionService(repository.NewSubscriptionRepository(db), productRepo, variantRepo, notifier)
+  s.review = service.NewReviewService(repository.NewReviewRepository(db), productRepo)
+  s.cart = service.NewCartService(repository.NewCartRepository(db), productRepo, variantRepo)
+  s.wishlist = service.NewWishlistService(repository.NewWishlistRepository(db), productRepo, variantRepo, s.cart)
+  s.order = service.NewOrderService(repository.NewOrderRepository(db), productRepo, variantRepo, warehouseRepo, userRepo, config.Allocation, config.EmailVerification)
+
+  return s, nil
+}
@@ -6,25 +6,40 @@ import (
 	"github.com/F-Dupraz/ecommerce-with-go/model"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// AccessTokenTTL is how long an access token is valid. Revocations of access
+// tokens only need to be kept this long.
+const AccessTokenTTL = 15 * time.Minute
+
 type Claims struct {
     UserID   string         `json:"user_id"`
     Email    string         `json:"email"`
     Role     model.UserRole `json:"role"`
 	IsAdmin  bool           `json:"is_admin"`
+    SessionID string        `json:"sid,omitempty"`
+    // IssuedAtMicros is the issue time to the microsecond. iat only has
+    // second precision, too coarse to tell tokens issued right after a
+    // user-wide revocation from the ones it covers.
+    IssuedAtMicros int64    `json:"iat_us,omitempty"`
     jwt.RegisteredClaims
 }
 
-func NewClaims(userID, email, role string) *Claims {
+// NewClaims builds the claims of an access token issued to sessionID. Every
+// token gets its own jti so it can be revoked on its own.
+func NewClaims(userID, email, role, sessionID string) *Claims {
     now := time.Now()
     return &Claims{
         UserID:  userID,
         Email:   email,
-        Role:    role,
+        Role:    model.UserRole(role),
         IsAdmin: role == "admin",
+        SessionID: sessionID,
+        IssuedAtMicros: now.UnixMicro(),
         RegisteredClaims: jwt.RegisteredClaims{
-            ExpiresAt: jwt.NewNumericDate(now.Add(15 * time.Minute)),
+            ID:        uuid.New().String(),
+            ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(now),
             NotBefore: jwt.NewNumericDate(now),
             Issuer:    "your-ecommerce",
@@ -32,3 +47,15 @@ func NewClaims(userID, email, role string) *Claims {
         },
     }
 }
+
+// IssueTime returns when the token was issued, to the microsecond when the
+// token carries it and to the second otherwise.
+func (c *Claims) IssueTime() time.Time {
+    if c.IssuedAtMicros != 0 {
+        return time.UnixMicro(c.IssuedAtMicros)
+    }
+    if c.IssuedAt != nil {
+        return c.IssuedAt.Time
+    }
+    return time.Time{}
+}
@@ -1,62 +1,119 @@
 package auth
 
 import (
+    "context"
     "crypto/rand"
     "encoding/base64"
     "errors"
     "fmt"
+    "sync"
     "time"
 
     "github.com/golang-jwt/jwt/v5"
 )
 
+// JWTManager issues and verifies access tokens. Refresh tokens are opaque
+// random strings: they are only ever looked up by hash in the sessions table,
+// so signing them would add nothing.
 type JWTManager struct {
-    secretKey     []byte
-    refreshSecret []byte
-    accessTTL     time.Duration
-    refreshTTL    time.Duration
+    mu         sync.RWMutex
+    keys       *KeySet
+    keyDir     string
+    accessTTL  time.Duration
 }
 
+// NewJWTManager signs with HS256 and a shared secret. Other services can't
+// verify such tokens without the secret, so it's meant for development; use
+// NewJWTManagerFromDir in production.
 func NewJWTManager(secretKey string) *JWTManager {
     return &JWTManager{
-		secretKey:     []byte(secretKey),
-		refreshSecret: []byte(secretKey + "_refresh"),
-		accessTTL:     15 * time.Minute,
-		refreshTTL:    7 * 24 * time.Hour,
+		keys:       NewKeySet(newHMACKey([]byte(secretKey))),
+		accessTTL:  AccessTokenTTL,
     }
 }
 
-func (j *JWTManager) GenerateTokenPair(userID, email, role string) (access, refresh string, err error) {
-    claims := NewClaims(userID, email, role)
+// NewJWTManagerFromDir signs with the active key of a key directory and
+// accepts tokens from any key in it.
+func NewJWTManagerFromDir(dir string) (*JWTManager, error) {
+    keys, err := LoadKeySet(dir)
+    if err != nil {
+        return nil, err
+    }
+
+    return &JWTManager{
+		keys:       keys,
+		keyDir:     dir,
+		accessTTL:  AccessTokenTTL,
+    }, nil
+}
+
+// ReloadKeys rereads the key directory so keys added, activated or removed
+// with the jwt-keys command take effect without a restart. A directory that
+// fails to load leaves the current keys in place.
+func (j *JWTManager) ReloadKeys(ctx context.Context) error {
+    if j.keyDir == "" {
+        return nil
+    }
 
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    access, err = token.SignedString(j.secretKey)
+    keys, err := LoadKeySet(j.keyDir)
     if err != nil {
-        return "", "", fmt.Errorf("failed to sign access token: %w", err)
+        return fmt.Errorf("failed to reload signing keys: %w", err)
+    }
+
+    j.mu.Lock()
+    j.keys = keys
+    j.mu.Unlock()
+    return nil
+}
+
+func (j *JWTManager) keySet() *KeySet {
+    j.mu.RLock()
+    defer j.mu.RUnlock()
+    return j.keys
+}
+
+// JWKS returns the public keys tokens may be signed with.
+func (j *JWTManager) JWKS() JWKS {
+    return j.keySet().JWKS()
+}
+
+// GenerateTokenPair issues an access and a refresh token for sessionID and
+// returns the jti of the access token along with them.
+func (j *JWTManager) GenerateTokenPair(userID, email, role, sessionID string) (access, refresh, jti string, err error) {
+    access, jti, err = j.GenerateAccessToken(userID, email, role, sessionID)
+    if err != nil {
+        return "", "", "", err
     }
 
-    refreshClaims := jwt.RegisteredClaims{
-        Subject:   userID,
-        ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.refreshTTL)),
-        IssuedAt:  jwt.NewNumericDate(time.Now()),
+    refresh, err = RandomToken(32)
+    if err != nil {
+        return "", "", "", err
     }
 
-    refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-    refresh, err = refreshToken.SignedString(j.refreshSecret)
+    return access, refresh, jti, nil
+}
+
+// GenerateAccessToken issues an access token alone, for a session whose
+// refresh token stays as it is.
+func (j *JWTManager) GenerateAccessToken(userID, email, role, sessionID string) (access, jti string, err error) {
+    claims := NewClaims(userID, email, role, sessionID)
+    key := j.keySet().signing
+
+    token := jwt.NewWithClaims(key.Method, claims)
+    if key.ID != "" {
+        token.Header["kid"] = key.ID
+    }
+    access, err = token.SignedString(key.signKey)
     if err != nil {
-        return "", "", fmt.Errorf("failed to sign refresh token: %w", err)
+        return "", "", fmt.Errorf("failed to sign access token: %w", err)
     }
 
-    return access, refresh, nil
+    return access, claims.ID, nil
 }
 
 func (j *JWTManager) ValidateAccessToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-	  if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-            return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-        }
-        return j.secretKey, nil
-    })
+    keys := j.keySet()
+    token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.lookup, jwt.WithValidMethods(keys.methods()))
     
     if err != nil {
         return nil, fmt.Errorf("invalid token: %w", err)
@@ -70,32 +127,12 @@ func (j *JWTManager) ValidateAccessToken(tokenString string) (*Claims, error) {
     return claims, nil
 }
 
-func (j *JWTManager) RefreshAccessToken(refreshToken string) (string, error) {
-    token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
-        if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-            return nil, fmt.Errorf("unexpected signing method")
-        }
-        return j.refreshSecret, nil
-    })
-
-    if err != nil || !token.Valid {
-        return "", errors.New("invalid refresh token")
+// RandomToken returns a URL-safe random string built from size bytes of
+// crypto/rand output, for links and one-time codes that must be unguessable.
+func RandomToken(size int) (string, error) {
+    b := make([]byte, size)
+    if _, err := rand.Read(b); err != nil {
+        return "", fmt.Errorf("failed to generate token: %w", err)
     }
-
-    subject, err := token.Claims.GetSubject()
-    if err != nil {
-        return "", err
-    }
-
-    // TODO: Acá deberías ir a la DB a buscar el user actual
-    // user := getUserFromDB(subject)
-    // Por ahora hardcodeamos
-	fmt.Println(subject)
-
-
-    claims := NewClaims(subject, "user@email.com", "customer")
-    newToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-    return newToken.SignedString(j.secretKey)
+    return base64.RawURLEncoding.EncodeToString(b), nil
 }
-
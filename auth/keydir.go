@@ -0,0 +1,167 @@
+package auth
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+)
+
+// A key directory holds one "<kid>.pem" file per private key and an "active"
+// file naming the kid new tokens are signed with. Every key in the directory
+// is accepted for verification and published in the JWKS, which is what lets
+// a new key be rolled out before it signs anything and an old one be kept
+// until the tokens it signed have expired.
+
+const (
+    activeKeyFile = "active"
+    keyFileExt    = ".pem"
+)
+
+var ErrActiveKey = errors.New("the active key cannot be removed")
+
+// LoadKeySet reads every key of a key directory.
+func LoadKeySet(dir string) (*KeySet, error) {
+    activeID, err := ActiveKeyID(dir)
+    if err != nil {
+        return nil, err
+    }
+
+    ids, err := ListKeyIDs(dir)
+    if err != nil {
+        return nil, err
+    }
+
+    var active *Key
+    others := []*Key{}
+    for _, id := range ids {
+        data, err := os.ReadFile(keyPath(dir, id))
+        if err != nil {
+            return nil, fmt.Errorf("failed to read key %s: %w", id, err)
+        }
+        private, err := ParsePrivateKeyPEM(data)
+        if err != nil {
+            return nil, fmt.Errorf("key %s: %w", id, err)
+        }
+        key, err := NewSigningKey(id, private)
+        if err != nil {
+            return nil, fmt.Errorf("key %s: %w", id, err)
+        }
+
+        if id == activeID {
+            active = key
+        } else {
+            others = append(others, key)
+        }
+    }
+
+    if active == nil {
+        return nil, fmt.Errorf("%w %q: active key is not in %s", ErrUnknownKeyID, activeID, dir)
+    }
+
+    return NewKeySet(active, others...), nil
+}
+
+// ListKeyIDs returns the kids of the keys in dir, oldest first.
+func ListKeyIDs(dir string) ([]string, error) {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read key directory: %w", err)
+    }
+
+    ids := []string{}
+    for _, entry := range entries {
+        if entry.IsDir() || !strings.HasSuffix(entry.Name(), keyFileExt) {
+            continue
+        }
+        ids = append(ids, strings.TrimSuffix(entry.Name(), keyFileExt))
+    }
+
+    sort.Strings(ids)
+    return ids, nil
+}
+
+// ActiveKeyID returns the kid new tokens are signed with.
+func ActiveKeyID(dir string) (string, error) {
+    data, err := os.ReadFile(filepath.Join(dir, activeKeyFile))
+    if err != nil {
+        return "", fmt.Errorf("failed to read active key: %w", err)
+    }
+
+    id := strings.TrimSpace(string(data))
+    if id == "" {
+        return "", fmt.Errorf("no active key set in %s", dir)
+    }
+    return id, nil
+}
+
+// ActiveKeyChangedAt returns when the active key was last set, which is when
+// the previous one stopped signing.
+func ActiveKeyChangedAt(dir string) (time.Time, error) {
+    info, err := os.Stat(filepath.Join(dir, activeKeyFile))
+    if err != nil {
+        return time.Time{}, fmt.Errorf("failed to read active key: %w", err)
+    }
+    return info.ModTime(), nil
+}
+
+// WriteKey stores a new private key under id, readable by its owner only.
+func WriteKey(dir, id string, private []byte) error {
+    if err := os.MkdirAll(dir, 0o700); err != nil {
+        return fmt.Errorf("failed to create key directory: %w", err)
+    }
+
+    f, err := os.OpenFile(keyPath(dir, id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+    if err != nil {
+        return fmt.Errorf("failed to create key file: %w", err)
+    }
+    if _, err := f.Write(private); err != nil {
+        f.Close()
+        return fmt.Errorf("failed to write key file: %w", err)
+    }
+    return f.Close()
+}
+
+// SetActiveKey makes id the signing key. The file is replaced atomically so a
+// server reloading keys never sees it half written.
+func SetActiveKey(dir, id string) error {
+    if _, err := os.Stat(keyPath(dir, id)); err != nil {
+        return fmt.Errorf("%w %q: %v", ErrUnknownKeyID, id, err)
+    }
+
+    tmp := filepath.Join(dir, activeKeyFile+".tmp")
+    if err := os.WriteFile(tmp, []byte(id+"\n"), 0o600); err != nil {
+        return fmt.Errorf("failed to write active key: %w", err)
+    }
+    if err := os.Rename(tmp, filepath.Join(dir, activeKeyFile)); err != nil {
+        return fmt.Errorf("failed to write active key: %w", err)
+    }
+    return nil
+}
+
+// RemoveKey deletes a key that is no longer used for signing. Tokens it
+// signed stop verifying immediately.
+func RemoveKey(dir, id string) error {
+    activeID, err := ActiveKeyID(dir)
+    if err != nil {
+        return err
+    }
+    if id == activeID {
+        return ErrActiveKey
+    }
+
+    if err := os.Remove(keyPath(dir, id)); err != nil {
+        if errors.Is(err, os.ErrNotExist) {
+            return fmt.Errorf("%w %q", ErrUnknownKeyID, id)
+        }
+        return fmt.Errorf("failed to remove key: %w", err)
+    }
+    return nil
+}
+
+func keyPath(dir, id string) string {
+    return filepath.Join(dir, id+keyFileExt)
+}
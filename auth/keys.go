@@ -0,0 +1,215 @@
+package auth
+
+import (
+    "crypto"
+    "crypto/ed25519"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "math/big"
+    "sort"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+    AlgRS256 = "RS256"
+    AlgEdDSA = "EdDSA"
+
+    minRSABits = 2048
+    rsaBits    = 3072
+)
+
+var (
+    ErrUnsupportedKey = errors.New("unsupported key type, use an RSA or Ed25519 key")
+    ErrUnknownKeyID   = errors.New("unknown signing key")
+)
+
+// Key signs and verifies tokens with one algorithm.
+type Key struct {
+    ID        string
+    Method    jwt.SigningMethod
+    signKey   interface{}
+    verifyKey interface{}
+}
+
+// NewSigningKey wraps an RSA or Ed25519 private key, picking RS256 or EdDSA
+// to match.
+func NewSigningKey(id string, private crypto.Signer) (*Key, error) {
+    switch k := private.(type) {
+    case *rsa.PrivateKey:
+        if k.N.BitLen() < minRSABits {
+            return nil, fmt.Errorf("RSA key %s has %d bits, at least %d are required", id, k.N.BitLen(), minRSABits)
+        }
+        return &Key{ID: id, Method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+    case ed25519.PrivateKey:
+        return &Key{ID: id, Method: jwt.SigningMethodEdDSA, signKey: k, verifyKey: k.Public()}, nil
+    }
+    return nil, ErrUnsupportedKey
+}
+
+func newHMACKey(secret []byte) *Key {
+    return &Key{Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+func (k *Key) symmetric() bool {
+    _, ok := k.Method.(*jwt.SigningMethodHMAC)
+    return ok
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens are
+// still accepted from. Keeping the previous key in the set after a rotation
+// lets tokens it signed live out their TTL.
+type KeySet struct {
+    signing *Key
+    keys    map[string]*Key
+}
+
+func NewKeySet(signing *Key, others ...*Key) *KeySet {
+    ks := &KeySet{
+        signing: signing,
+        keys:    map[string]*Key{signing.ID: signing},
+    }
+    for _, k := range others {
+        ks.keys[k.ID] = k
+    }
+    return ks
+}
+
+// lookup finds the key a token claims to be signed with. Tokens issued before
+// kid headers existed are checked against the signing key. The algorithm in
+// the header must be the key's own, so a public key is never used as an HMAC
+// secret.
+func (ks *KeySet) lookup(token *jwt.Token) (interface{}, error) {
+    key := ks.signing
+    if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+        if key, ok = ks.keys[kid]; !ok {
+            return nil, fmt.Errorf("%w %q", ErrUnknownKeyID, kid)
+        }
+    }
+
+    if token.Method.Alg() != key.Method.Alg() {
+        return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+    }
+    return key.verifyKey, nil
+}
+
+func (ks *KeySet) methods() []string {
+    seen := map[string]bool{}
+    methods := []string{}
+    for _, k := range ks.keys {
+        if alg := k.Method.Alg(); !seen[alg] {
+            seen[alg] = true
+            methods = append(methods, alg)
+        }
+    }
+    return methods
+}
+
+// JWK is the public half of a key in RFC 7517 form.
+type JWK struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use"`
+    Alg string `json:"alg"`
+    N   string `json:"n,omitempty"`
+    E   string `json:"e,omitempty"`
+    Crv string `json:"crv,omitempty"`
+    X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+    Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, sorted by kid. HMAC secrets are
+// never published, so a set built from a shared secret has no keys.
+func (ks *KeySet) JWKS() JWKS {
+    set := JWKS{Keys: []JWK{}}
+    for _, k := range ks.keys {
+        if k.symmetric() {
+            continue
+        }
+
+        jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+        switch pub := k.verifyKey.(type) {
+        case *rsa.PublicKey:
+            jwk.Kty = "RSA"
+            jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+            jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+        case ed25519.PublicKey:
+            jwk.Kty = "OKP"
+            jwk.Crv = "Ed25519"
+            jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+        default:
+            continue
+        }
+        set.Keys = append(set.Keys, jwk)
+    }
+
+    sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+    return set
+}
+
+// GenerateKey creates a private key for alg, RS256 or EdDSA.
+func GenerateKey(alg string) (crypto.Signer, error) {
+    switch alg {
+    case AlgRS256:
+        return rsa.GenerateKey(rand.Reader, rsaBits)
+    case AlgEdDSA:
+        _, private, err := ed25519.GenerateKey(rand.Reader)
+        return private, err
+    }
+    return nil, fmt.Errorf("unsupported algorithm %q, use %s or %s", alg, AlgRS256, AlgEdDSA)
+}
+
+// NewKeyID returns an identifier for a new key that sorts by creation date.
+func NewKeyID() (string, error) {
+    suffix, err := RandomToken(6)
+    if err != nil {
+        return "", err
+    }
+    return time.Now().UTC().Format("20060102") + "-" + suffix, nil
+}
+
+// EncodePrivateKeyPEM encodes a private key as a PKCS #8 PEM block.
+func EncodePrivateKeyPEM(private crypto.Signer) ([]byte, error) {
+    der, err := x509.MarshalPKCS8PrivateKey(private)
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode private key: %w", err)
+    }
+    return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM reads a PKCS #8 or PKCS #1 PEM encoded private key.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+    block, _ := pem.Decode(data)
+    if block == nil {
+        return nil, errors.New("no PEM block found")
+    }
+
+    switch block.Type {
+    case "PRIVATE KEY":
+        key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+        if err != nil {
+            return nil, fmt.Errorf("failed to parse private key: %w", err)
+        }
+        signer, ok := key.(crypto.Signer)
+        if !ok {
+            return nil, ErrUnsupportedKey
+        }
+        return signer, nil
+    case "RSA PRIVATE KEY":
+        key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+        if err != nil {
+            return nil, fmt.Errorf("failed to parse private key: %w", err)
+        }
+        return key, nil
+    }
+    return nil, fmt.Errorf("unexpected PEM block %q", block.Type)
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+    "crypto/ed25519"
+    "crypto/rand"
+    "crypto/rsa"
+    "encoding/base64"
+    "errors"
+    "math/big"
+    "testing"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+func newTestRSAKey(t *testing.T, id string) *Key {
+    t.Helper()
+
+    private, err := rsa.GenerateKey(rand.Reader, minRSABits)
+    if err != nil {
+        t.Fatal(err)
+    }
+    key, err := NewSigningKey(id, private)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return key
+}
+
+func newTestEd25519Key(t *testing.T, id string) *Key {
+    t.Helper()
+
+    _, private, err := ed25519.GenerateKey(rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    key, err := NewSigningKey(id, private)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return key
+}
+
+func TestRetiredKeyStillVerifies(t *testing.T) {
+    retired := newTestRSAKey(t, "2024-old")
+    active := newTestEd25519Key(t, "2025-new")
+
+    before := &JWTManager{keys: NewKeySet(retired), accessTTL: AccessTokenTTL}
+    token, jti, err := before.GenerateAccessToken("user-1", "user@example.com", "customer", "session-1")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // After the rotation the old key no longer signs but stays in the set.
+    after := &JWTManager{keys: NewKeySet(active, retired), accessTTL: AccessTokenTTL}
+    claims, err := after.ValidateAccessToken(token)
+    if err != nil {
+        t.Fatalf("token signed by the retired key was rejected: %v", err)
+    }
+    if claims.ID != jti || claims.UserID != "user-1" {
+        t.Errorf("got claims %+v, want jti %s for user-1", claims, jti)
+    }
+
+    fresh, _, err := after.GenerateAccessToken("user-1", "user@example.com", "customer", "session-1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    parsed, _, err := jwt.NewParser().ParseUnverified(fresh, &Claims{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if kid := parsed.Header["kid"]; kid != active.ID {
+        t.Errorf("new token signed with kid %v, want %s", kid, active.ID)
+    }
+}
+
+func TestUnknownKeyIDIsRejected(t *testing.T) {
+    removed := newTestEd25519Key(t, "2024-removed")
+    active := newTestEd25519Key(t, "2025-active")
+
+    before := &JWTManager{keys: NewKeySet(removed), accessTTL: AccessTokenTTL}
+    token, _, err := before.GenerateAccessToken("user-1", "user@example.com", "customer", "session-1")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    after := &JWTManager{keys: NewKeySet(active), accessTTL: AccessTokenTTL}
+    if _, err := after.ValidateAccessToken(token); !errors.Is(err, ErrUnknownKeyID) {
+        t.Errorf("got error %v, want %v", err, ErrUnknownKeyID)
+    }
+}
+
+func TestPublicKeyIsNotAcceptedAsHMACSecret(t *testing.T) {
+    key := newTestEd25519Key(t, "2025-active")
+    manager := &JWTManager{keys: NewKeySet(key), accessTTL: AccessTokenTTL}
+
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, NewClaims("user-1", "user@example.com", "admin", "session-1"))
+    token.Header["kid"] = key.ID
+    forged, err := token.SignedString([]byte(key.verifyKey.(ed25519.PublicKey)))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if _, err := manager.ValidateAccessToken(forged); err == nil {
+        t.Error("HS256 token signed with the public key was accepted")
+    }
+}
+
+func TestRSAJWK(t *testing.T) {
+    key := newTestRSAKey(t, "rsa-1")
+    pub := key.verifyKey.(*rsa.PublicKey)
+
+    set := NewKeySet(key).JWKS()
+    if len(set.Keys) != 1 {
+        t.Fatalf("got %d keys, want 1", len(set.Keys))
+    }
+
+    jwk := set.Keys[0]
+    if jwk.Kty != "RSA" || jwk.Kid != "rsa-1" || jwk.Use != "sig" || jwk.Alg != AlgRS256 {
+        t.Errorf("got %+v, want an RSA signing key rsa-1 for %s", jwk, AlgRS256)
+    }
+    if jwk.Crv != "" || jwk.X != "" {
+        t.Errorf("RSA key has OKP members: %+v", jwk)
+    }
+    // 65537, big-endian without leading zeros.
+    if jwk.E != "AQAB" {
+        t.Errorf("got e %q, want AQAB", jwk.E)
+    }
+
+    n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+    if err != nil {
+        t.Fatalf("n isn't unpadded base64url: %v", err)
+    }
+    if n[0] == 0 {
+        t.Error("n has a leading zero byte")
+    }
+    if new(big.Int).SetBytes(n).Cmp(pub.N) != 0 {
+        t.Error("n doesn't match the key modulus")
+    }
+}
+
+func TestEd25519JWK(t *testing.T) {
+    // The key of RFC 8037, appendix A.1.
+    seed, err := base64.RawURLEncoding.DecodeString("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+    if err != nil {
+        t.Fatal(err)
+    }
+    key, err := NewSigningKey("ed-1", ed25519.NewKeyFromSeed(seed))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    set := NewKeySet(key).JWKS()
+    if len(set.Keys) != 1 {
+        t.Fatalf("got %d keys, want 1", len(set.Keys))
+    }
+
+    want := JWK{Kty: "OKP", Kid: "ed-1", Use: "sig", Alg: AlgEdDSA, Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+    if got := set.Keys[0]; got != want {
+        t.Errorf("got %+v, want %+v", got, want)
+    }
+}
+
+func TestJWKSListsEveryKeyButSecrets(t *testing.T) {
+    set := NewKeySet(newTestEd25519Key(t, "b"), newTestRSAKey(t, "a")).JWKS()
+    if len(set.Keys) != 2 || set.Keys[0].Kid != "a" || set.Keys[1].Kid != "b" {
+        t.Errorf("got %+v, want keys a and b in kid order", set.Keys)
+    }
+
+    if keys := NewKeySet(newHMACKey([]byte("secret"))).JWKS().Keys; len(keys) != 0 {
+        t.Errorf("HMAC secret was published: %+v", keys)
+    }
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+// RevocationStore remembers access tokens that must stop working before they
+// expire, either one token by its jti or every token of a user issued up to
+// some moment. Cutoffs and issue times are compared to the microsecond, so a
+// user revocation covers the tokens issued before it and none issued after.
+// Entries only matter until expiresAt, the latest time a token they cover
+// could still be valid.
+type RevocationStore interface {
+    RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+    RevokeUser(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error
+    IsRevoked(ctx context.Context, userID, jti string, issuedAt time.Time) (bool, error)
+}
+
+const pruneInterval = time.Minute
+
+type userRevocation struct {
+    issuedBefore time.Time
+    expiresAt    time.Time
+}
+
+// MemoryRevocationStore keeps revocations in process memory, dropping them
+// once they expire. On its own it only suits a single instance.
+type MemoryRevocationStore struct {
+    mu        sync.Mutex
+    tokens    map[string]time.Time
+    users     map[string]userRevocation
+    lastPrune time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+    return &MemoryRevocationStore{
+        tokens:    make(map[string]time.Time),
+        users:     make(map[string]userRevocation),
+        lastPrune: time.Now(),
+    }
+}
+
+func (m *MemoryRevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if expiresAt.After(m.tokens[jti]) {
+        m.tokens[jti] = expiresAt
+    }
+    m.pruneLocked(time.Now())
+    return nil
+}
+
+func (m *MemoryRevocationStore) RevokeUser(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    issuedBefore = truncateCutoff(issuedBefore)
+    current := m.users[userID]
+    if issuedBefore.After(current.issuedBefore) {
+        current.issuedBefore = issuedBefore
+    }
+    if expiresAt.After(current.expiresAt) {
+        current.expiresAt = expiresAt
+    }
+    m.users[userID] = current
+    m.pruneLocked(time.Now())
+    return nil
+}
+
+func (m *MemoryRevocationStore) IsRevoked(ctx context.Context, userID, jti string, issuedAt time.Time) (bool, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    now := time.Now()
+    if expiresAt, ok := m.tokens[jti]; ok && expiresAt.After(now) {
+        return true, nil
+    }
+    if user, ok := m.users[userID]; ok && user.expiresAt.After(now) {
+        return issuedByCutoff(issuedAt, user.issuedBefore), nil
+    }
+    return false, nil
+}
+
+func (m *MemoryRevocationStore) pruneLocked(now time.Time) {
+    if now.Sub(m.lastPrune) < pruneInterval {
+        return
+    }
+    m.lastPrune = now
+
+    for jti, expiresAt := range m.tokens {
+        if !expiresAt.After(now) {
+            delete(m.tokens, jti)
+        }
+    }
+    for userID, user := range m.users {
+        if !user.expiresAt.After(now) {
+            delete(m.users, userID)
+        }
+    }
+}
+
+// truncateCutoff brings a user revocation cutoff down to the microsecond
+// precision of token issue times, which is also what Postgres keeps.
+func truncateCutoff(cutoff time.Time) time.Time {
+    return cutoff.Truncate(time.Microsecond)
+}
+
+// issuedByCutoff reports whether a token issued at issuedAt is covered by a
+// truncated cutoff. A token issued after the revocation never is, even in the
+// same microsecond, so the flows that revoke and then issue a new token hand
+// out one that works.
+func issuedByCutoff(issuedAt, cutoff time.Time) bool {
+    return issuedAt.Before(cutoff)
+}
+
+// CachedRevocationStore puts a MemoryRevocationStore in front of a shared
+// store. Revocations are written to both; checks are answered from memory
+// when the token is known to be revoked and otherwise go to the shared store
+// at most once per recheck interval per token, which bounds how long a
+// revocation made by another instance takes to be seen.
+type CachedRevocationStore struct {
+    local   *MemoryRevocationStore
+    shared  RevocationStore
+    recheck time.Duration
+
+    mu        sync.Mutex
+    checked   map[string]time.Time
+    lastPrune time.Time
+}
+
+func NewCachedRevocationStore(shared RevocationStore, recheck time.Duration) *CachedRevocationStore {
+    return &CachedRevocationStore{
+        local:     NewMemoryRevocationStore(),
+        shared:    shared,
+        recheck:   recheck,
+        checked:   make(map[string]time.Time),
+        lastPrune: time.Now(),
+    }
+}
+
+func (c *CachedRevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+    if err := c.shared.RevokeToken(ctx, jti, expiresAt); err != nil {
+        return err
+    }
+    return c.local.RevokeToken(ctx, jti, expiresAt)
+}
+
+func (c *CachedRevocationStore) RevokeUser(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error {
+    if err := c.shared.RevokeUser(ctx, userID, issuedBefore, expiresAt); err != nil {
+        return err
+    }
+    return c.local.RevokeUser(ctx, userID, issuedBefore, expiresAt)
+}
+
+func (c *CachedRevocationStore) IsRevoked(ctx context.Context, userID, jti string, issuedAt time.Time) (bool, error) {
+    revoked, err := c.local.IsRevoked(ctx, userID, jti, issuedAt)
+    if err != nil || revoked {
+        return revoked, err
+    }
+
+    now := time.Now()
+    c.mu.Lock()
+    checkedAt, ok := c.checked[jti]
+    c.mu.Unlock()
+    if ok && now.Sub(checkedAt) < c.recheck {
+        return false, nil
+    }
+
+    revoked, err = c.shared.IsRevoked(ctx, userID, jti, issuedAt)
+    if err != nil {
+        return false, err
+    }
+
+    if revoked {
+        // Only remembered for the recheck interval, the expiry of the token
+        // isn't known here.
+        return true, c.local.RevokeToken(ctx, jti, now.Add(c.recheck))
+    }
+
+    c.mu.Lock()
+    c.checked[jti] = now
+    if now.Sub(c.lastPrune) >= pruneInterval {
+        c.lastPrune = now
+        for id, at := range c.checked {
+            if now.Sub(at) >= c.recheck {
+                delete(c.checked, id)
+            }
+        }
+    }
+    c.mu.Unlock()
+
+    return false, nil
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+    "context"
+    "testing"
+    "time"
+)
+
+// A cutoff in the middle of a second, as time.Now() returns it.
+var revocationCutoff = time.Now().Truncate(time.Second).Add(500 * time.Millisecond)
+
+func TestMemoryRevocationStoreRevokesToken(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryRevocationStore()
+
+    if err := store.RevokeToken(ctx, "jti-1", time.Now().Add(time.Hour)); err != nil {
+        t.Fatal(err)
+    }
+
+    assertRevoked(t, store, "user-1", "jti-1", time.Now(), true)
+    assertRevoked(t, store, "user-1", "jti-2", time.Now(), false)
+}
+
+func TestMemoryRevocationStoreUserCutoff(t *testing.T) {
+    testUserCutoff(t, NewMemoryRevocationStore())
+}
+
+func TestMemoryRevocationStoreIgnoresExpiredRevocations(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryRevocationStore()
+    past := time.Now().Add(-time.Minute)
+
+    if err := store.RevokeToken(ctx, "jti-1", past); err != nil {
+        t.Fatal(err)
+    }
+    if err := store.RevokeUser(ctx, "user-1", time.Now(), past); err != nil {
+        t.Fatal(err)
+    }
+
+    assertRevoked(t, store, "user-1", "jti-1", past.Add(-time.Hour), false)
+}
+
+func TestMemoryRevocationStoreKeepsLatestCutoff(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryRevocationStore()
+    expiresAt := time.Now().Add(time.Hour)
+
+    if err := store.RevokeUser(ctx, "user-1", revocationCutoff, expiresAt); err != nil {
+        t.Fatal(err)
+    }
+    if err := store.RevokeUser(ctx, "user-1", revocationCutoff.Add(-time.Minute), expiresAt); err != nil {
+        t.Fatal(err)
+    }
+
+    assertRevoked(t, store, "user-1", "jti-1", revocationCutoff.Add(-time.Second), true)
+}
+
+func TestCachedRevocationStoreUserCutoff(t *testing.T) {
+    testUserCutoff(t, NewCachedRevocationStore(NewMemoryRevocationStore(), time.Minute))
+}
+
+func TestCachedRevocationStoreSeesSharedRevocationsAfterRecheck(t *testing.T) {
+    ctx := context.Background()
+    shared := NewMemoryRevocationStore()
+    store := NewCachedRevocationStore(shared, 50*time.Millisecond)
+    expiresAt := time.Now().Add(time.Hour)
+
+    assertRevoked(t, store, "user-1", "jti-1", time.Now(), false)
+
+    // Revoked by another instance, straight in the shared store.
+    if err := shared.RevokeToken(ctx, "jti-1", expiresAt); err != nil {
+        t.Fatal(err)
+    }
+    if err := shared.RevokeUser(ctx, "user-2", revocationCutoff, expiresAt); err != nil {
+        t.Fatal(err)
+    }
+
+    assertRevoked(t, store, "user-1", "jti-1", time.Now(), false)
+    // Tokens not checked before go to the shared store right away.
+    assertRevoked(t, store, "user-2", "jti-2", revocationCutoff.Truncate(time.Second), true)
+
+    time.Sleep(60 * time.Millisecond)
+    assertRevoked(t, store, "user-1", "jti-1", time.Now(), true)
+}
+
+// testUserCutoff checks that a user revocation covers tokens issued before
+// its cutoff, down to the microsecond, and none issued after it.
+func testUserCutoff(t *testing.T, store RevocationStore) {
+    t.Helper()
+    ctx := context.Background()
+
+    if err := store.RevokeUser(ctx, "user-1", revocationCutoff, time.Now().Add(time.Hour)); err != nil {
+        t.Fatal(err)
+    }
+
+    sameSecond := revocationCutoff.Truncate(time.Second)
+    tests := []struct {
+        name     string
+        userID   string
+        jti      string
+        issuedAt time.Time
+        revoked  bool
+    }{
+        {"issued earlier", "user-1", "jti-earlier", sameSecond.Add(-time.Second), true},
+        {"issued earlier in the same second", "user-1", "jti-same", sameSecond, true},
+        {"issued a microsecond before the cutoff", "user-1", "jti-just-before", revocationCutoff.Add(-time.Microsecond), true},
+        {"issued at the cutoff", "user-1", "jti-at", revocationCutoff, false},
+        {"issued in the same second after the cutoff", "user-1", "jti-same-after", revocationCutoff.Add(100 * time.Millisecond), false},
+        {"issued in the next second", "user-1", "jti-next", sameSecond.Add(time.Second), false},
+        {"other user", "user-2", "jti-other", sameSecond, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            assertRevoked(t, store, tt.userID, tt.jti, tt.issuedAt, tt.revoked)
+        })
+    }
+}
+
+func TestTokenIssuedRightAfterUserRevocationIsValid(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryRevocationStore()
+    manager := NewJWTManager("secret")
+
+    // Revoking and then issuing is what a password change does; the new token
+    // must not be caught by its own revocation.
+    for i := 0; i < 100; i++ {
+        if err := store.RevokeUser(ctx, "user-1", time.Now(), time.Now().Add(AccessTokenTTL)); err != nil {
+            t.Fatal(err)
+        }
+        token, _, err := manager.GenerateAccessToken("user-1", "user@example.com", "customer", "session-1")
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        claims, err := manager.ValidateAccessToken(token)
+        if err != nil {
+            t.Fatal(err)
+        }
+        revoked, err := store.IsRevoked(ctx, claims.UserID, claims.ID, claims.IssueTime())
+        if err != nil {
+            t.Fatal(err)
+        }
+        if revoked {
+            t.Fatalf("token issued at %s was revoked", claims.IssueTime().Format(time.RFC3339Nano))
+        }
+    }
+}
+
+func TestClaimsIssueTime(t *testing.T) {
+    manager := NewJWTManager("secret")
+    before := time.Now().Truncate(time.Microsecond)
+
+    token, _, err := manager.GenerateAccessToken("user-1", "user@example.com", "customer", "session-1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    claims, err := manager.ValidateAccessToken(token)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    issued := claims.IssueTime()
+    if issued.Before(before) || issued.After(time.Now()) {
+        t.Errorf("issue time %s isn't when the token was issued", issued.Format(time.RFC3339Nano))
+    }
+
+    // Tokens issued before iat_us existed fall back to iat.
+    claims.IssuedAtMicros = 0
+    if !claims.IssueTime().Equal(claims.IssuedAt.Time) {
+        t.Errorf("got %s, want iat %s", claims.IssueTime(), claims.IssuedAt.Time)
+    }
+}
+
+func assertRevoked(t *testing.T, store RevocationStore, userID, jti string, issuedAt time.Time, want bool) {
+    t.Helper()
+
+    revoked, err := store.IsRevoked(context.Background(), userID, jti, issuedAt)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if revoked != want {
+        t.Errorf("IsRevoked(%s, %s, %s) = %v, want %v", userID, jti, issuedAt.Format(time.RFC3339Nano), revoked, want)
+    }
+}
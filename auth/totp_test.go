@@ -0,0 +1,118 @@
+package auth
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+// The SHA-1 secret of RFC 6238, appendix B: the ASCII "12345678901234567890".
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The SHA-1 test vectors of RFC 6238, appendix B, cut to six digits.
+var rfc6238Vectors = []struct {
+    unix int64
+    code string
+}{
+    {59, "287082"},
+    {1111111109, "081804"},
+    {1111111111, "050471"},
+    {1234567890, "005924"},
+    {2000000000, "279037"},
+    {20000000000, "353130"},
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+    for _, v := range rfc6238Vectors {
+        step := TOTPStep(time.Unix(v.unix, 0))
+        code, err := TOTPCode(rfc6238Secret, step)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if code != v.code {
+            t.Errorf("T=%d: got %s, want %s", v.unix, code, v.code)
+        }
+    }
+}
+
+func TestTOTPCodeAcceptsLowercaseSecret(t *testing.T) {
+    code, err := TOTPCode(strings.ToLower(rfc6238Secret), 1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if code != "287082" {
+        t.Errorf("got %s, want 287082", code)
+    }
+}
+
+func TestValidateTOTP(t *testing.T) {
+    now := time.Unix(1111111111, 0)
+    current := TOTPStep(now)
+
+    tests := []struct {
+        name string
+        at   time.Time
+        ok   bool
+    }{
+        {"current step", now, true},
+        {"previous step", now.Add(-TOTPPeriod), true},
+        {"next step", now.Add(TOTPPeriod), true},
+        {"two steps back", now.Add(-2 * TOTPPeriod), false},
+        {"two steps ahead", now.Add(2 * TOTPPeriod), false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            step := TOTPStep(tt.at)
+            code, err := TOTPCode(rfc6238Secret, step)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            got, ok := ValidateTOTP(rfc6238Secret, code, now)
+            if ok != tt.ok {
+                t.Fatalf("ValidateTOTP at step %d from step %d = %v, want %v", step, current, ok, tt.ok)
+            }
+            if ok && got != step {
+                t.Errorf("matched step %d, want %d", got, step)
+            }
+        })
+    }
+}
+
+func TestValidateTOTPRejectsMalformedCodes(t *testing.T) {
+    now := time.Unix(1111111111, 0)
+
+    for _, code := range []string{"", "50471", "0504710", "abcdef"} {
+        if _, ok := ValidateTOTP(rfc6238Secret, code, now); ok {
+            t.Errorf("code %q was accepted", code)
+        }
+    }
+    if _, ok := ValidateTOTP("not base32!", "050471", now); ok {
+        t.Error("code for an invalid secret was accepted")
+    }
+}
+
+func TestRecoveryCodeFormat(t *testing.T) {
+    code, err := NewRecoveryCode()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(code) != recoveryCodeLength+1 || code[recoveryCodeLength/2] != '-' {
+        t.Fatalf("got %q, want two groups of %d", code, recoveryCodeLength/2)
+    }
+    for _, r := range strings.Replace(code, "-", "", 1) {
+        if !strings.ContainsRune(recoveryCodeAlphabet, r) {
+            t.Errorf("%q has %q, which isn't in the alphabet", code, r)
+        }
+    }
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+    for _, typed := range []string{"abcde-fghjk", "ABCDE-FGHJK", " abcde fghjk ", "abcdefghjk"} {
+        if got := NormalizeRecoveryCode(typed); got != "abcdefghjk" {
+            t.Errorf("NormalizeRecoveryCode(%q) = %q, want abcdefghjk", typed, got)
+        }
+    }
+}
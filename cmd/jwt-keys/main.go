@@ -0,0 +1,173 @@
+// Command jwt-keys manages the key directory access tokens are signed with.
+//
+//	jwt-keys [-dir DIR] list
+//	jwt-keys [-dir DIR] generate [-alg RS256|EdDSA] [-activate]
+//	jwt-keys [-dir DIR] activate KID
+//	jwt-keys [-dir DIR] retire [-force] KID
+//	jwt-keys [-dir DIR] rotate [-alg RS256|EdDSA]
+//
+// The directory defaults to $JWT_KEY_DIR. Servers reload it every minute and
+// other services cache the JWKS for five, so a rotation without downtime is:
+// generate a key, wait until it's published everywhere, activate it, then
+// retire the old key once the tokens it signed have expired. rotate generates
+// and activates in one step, for setups with no other verifiers.
+package main
+
+import (
+  "os"
+  "fmt"
+  "flag"
+  "time"
+  "errors"
+
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+)
+
+// retireGrace covers the access token TTL plus the time servers take to
+// notice a new active key.
+const retireGrace = auth.AccessTokenTTL + 2*time.Minute
+
+func main() {
+  os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+  flags := flag.NewFlagSet("jwt-keys", flag.ContinueOnError)
+  dir := flags.String("dir", os.Getenv("JWT_KEY_DIR"), "key directory")
+  if err := flags.Parse(args); err != nil {
+	return 2
+  }
+
+  if *dir == "" {
+	fmt.Fprintln(os.Stderr, "no key directory, set -dir or JWT_KEY_DIR")
+	return 2
+  }
+  if flags.NArg() == 0 {
+	fmt.Fprintln(os.Stderr, "usage: jwt-keys [-dir DIR] list|generate|activate|retire|rotate")
+	return 2
+  }
+
+  var err error
+  switch cmd, rest := flags.Arg(0), flags.Args()[1:]; cmd {
+  case "list":
+	err = list(*dir)
+  case "generate":
+	err = generate(*dir, rest, false)
+  case "rotate":
+	err = generate(*dir, rest, true)
+  case "activate":
+	err = activate(*dir, rest)
+  case "retire":
+	err = retire(*dir, rest)
+  default:
+	fmt.Fprintf(os.Stderr, "unknown command %q\n", cmd)
+	return 2
+  }
+
+  if err != nil {
+	fmt.Fprintln(os.Stderr, err)
+	return 1
+  }
+  return 0
+}
+
+func list(dir string) error {
+  ids, err := auth.ListKeyIDs(dir)
+  if err != nil {
+	return err
+  }
+
+  activeID, _ := auth.ActiveKeyID(dir)
+  for _, id := range ids {
+	if id == activeID {
+	  fmt.Printf("%s (active)\n", id)
+	} else {
+	  fmt.Println(id)
+	}
+  }
+  return nil
+}
+
+func generate(dir string, args []string, rotate bool) error {
+  flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+  alg := flags.String("alg", auth.AlgEdDSA, "signing algorithm, RS256 or EdDSA")
+  activateKey := flags.Bool("activate", false, "sign new tokens with the key right away")
+  if err := flags.Parse(args); err != nil {
+	return err
+  }
+
+  private, err := auth.GenerateKey(*alg)
+  if err != nil {
+	return err
+  }
+  data, err := auth.EncodePrivateKeyPEM(private)
+  if err != nil {
+	return err
+  }
+  id, err := auth.NewKeyID()
+  if err != nil {
+	return err
+  }
+
+  if err := auth.WriteKey(dir, id, data); err != nil {
+	return err
+  }
+
+  // The first key of a directory has to be active for servers to load it.
+  _, activeErr := auth.ActiveKeyID(dir)
+  if rotate || *activateKey || activeErr != nil {
+	if err := auth.SetActiveKey(dir, id); err != nil {
+	  return err
+	}
+	fmt.Printf("generated %s key %s and made it active\n", *alg, id)
+	return nil
+  }
+
+  fmt.Printf("generated %s key %s, activate it once it's published\n", *alg, id)
+  return nil
+}
+
+func activate(dir string, args []string) error {
+  if len(args) != 1 {
+	return errors.New("usage: jwt-keys activate KID")
+  }
+
+  if err := auth.SetActiveKey(dir, args[0]); err != nil {
+	return err
+  }
+
+  fmt.Printf("key %s is now active\n", args[0])
+  return nil
+}
+
+// retire refuses to remove a key while tokens it signed may still be valid,
+// judged by when the current key was activated.
+func retire(dir string, args []string) error {
+  flags := flag.NewFlagSet("retire", flag.ContinueOnError)
+  force := flags.Bool("force", false, "remove the key even if tokens it signed may still be valid")
+  if err := flags.Parse(args); err != nil {
+	return err
+  }
+  if flags.NArg() != 1 {
+	return errors.New("usage: jwt-keys retire [-force] KID")
+  }
+  id := flags.Arg(0)
+
+  if !*force {
+	changedAt, err := auth.ActiveKeyChangedAt(dir)
+	if err != nil {
+	  return err
+	}
+	if wait := retireGrace - time.Since(changedAt); wait > 0 {
+	  return fmt.Errorf("the active key changed %s ago, tokens signed by %s may still be valid for %s; use -force to remove it anyway",
+		time.Since(changedAt).Round(time.Second), id, wait.Round(time.Second))
+	}
+  }
+
+  if err := auth.RemoveKey(dir, id); err != nil {
+	return err
+  }
+
+  fmt.Printf("removed key %s\n", id)
+  return nil
+}
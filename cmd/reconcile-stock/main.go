@@ -0,0 +1,67 @@
+// Command reconcile-stock checks that the stock of every product and variant
+// matches the sum of its stock movements and of its per-warehouse stock. It prints each mismatch and exits
+// with status 1 if any is found, so it can run from cron or CI.
+package main
+
+import (
+  "os"
+  "fmt"
+  "context"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+
+  "github.com/F-Dupraz/ecommerce-with-go/notify"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+)
+
+func main() {
+  os.Exit(run())
+}
+
+func run() int {
+  dsn := os.Getenv("DATABASE_URL")
+  if dsn == "" {
+	fmt.Fprintln(os.Stderr, "DATABASE_URL is not set")
+	return 2
+  }
+
+  ctx := context.Background()
+
+  pool, err := pgxpool.New(ctx, dsn)
+  if err != nil {
+	fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+	return 2
+  }
+  defer pool.Close()
+
+  inventory := service.NewInventoryService(
+	repository.NewInventoryRepository(pool),
+	repository.NewProductRepository(pool),
+	notify.NewLogNotifier(),
+	nil,
+  )
+
+  discrepancies, err := inventory.Reconcile(ctx)
+  if err != nil {
+	fmt.Fprintln(os.Stderr, err)
+	return 2
+  }
+
+  if len(discrepancies) == 0 {
+	fmt.Println("stock matches the ledger and warehouses")
+	return 0
+  }
+
+  for _, d := range discrepancies {
+	target := "product " + d.ProductID
+	if d.VariantID != nil {
+	  target = fmt.Sprintf("variant %s of product %s", *d.VariantID, d.ProductID)
+	}
+	fmt.Printf("%s (%s): stock %d, ledger %d, warehouses %d; reserved %d, ledger %d, warehouses %d\n",
+	  d.SKU, target, d.Stock, d.LedgerStock, d.WarehouseStock, d.ReservedStock, d.LedgerReserved, d.WarehouseReserved)
+  }
+  fmt.Printf("%d discrepancies found\n", len(discrepancies))
+
+  return 1
+}
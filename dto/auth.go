@@ -7,6 +7,7 @@ type UserInfo struct {
     Email string `json:"email"`
     Name  string `json:"name,omitempty"`
     Role  string `json:"role"`
+    EmailVerified bool `json:"email_verified"`
 }
 
 type LoginRequest struct {
@@ -16,9 +17,59 @@ type LoginRequest struct {
 
 type LoginResponse struct {
     AccessToken string   `json:"access_token"`
+    RefreshToken string  `json:"refresh_token,omitempty"` // moved to a cookie by the handler
     TokenType   string   `json:"token_type"`
     ExpiresIn   int      `json:"expires_in"`
     User        UserInfo `json:"user"`
+    // Set when the login confirmed a 2FA enrollment; they are shown once.
+    RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// MFAChallengeResponse is what a login answers with, instead of tokens, for
+// an account that needs a second factor. MFAToken is traded for the tokens
+// along with a code. EnrollmentRequired means the account must set up 2FA
+// first, which it can do with the same token.
+type MFAChallengeResponse struct {
+    MFARequired        bool   `json:"mfa_required"`
+    MFAToken           string `json:"mfa_token"`
+    ExpiresIn          int    `json:"expires_in"`
+    EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+// MFALoginRequest completes a login with a code from the authenticator app or
+// a recovery code.
+type MFALoginRequest struct {
+    MFAToken string `json:"mfa_token" validate:"required,max=128"`
+    Code     string `json:"code" validate:"required,max=32"`
+}
+
+type MFALoginEnrollmentRequest struct {
+    MFAToken string `json:"mfa_token" validate:"required,max=128"`
+}
+
+type MFACodeRequest struct {
+    Code string `json:"code" validate:"required,max=32"`
+}
+
+// MFAEnrollmentResponse holds what an authenticator app needs, both as an
+// otpauth:// URI and as a QR code of it in a PNG data URL. Secret is for
+// typing in by hand.
+type MFAEnrollmentResponse struct {
+    Secret     string `json:"secret"`
+    OTPAuthURI string `json:"otpauth_uri"`
+    QRCode     string `json:"qr_code"`
+}
+
+type MFARecoveryCodesResponse struct {
+    Message       string   `json:"message"`
+    RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFAStatusResponse struct {
+    Enabled                bool       `json:"enabled"`
+    EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+    Required               bool       `json:"required"`
+    RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
 }
 
 type SignupRequest struct {
@@ -45,29 +96,61 @@ type RefreshRequest struct {
 
 type RefreshResponse struct {
     AccessToken string    `json:"access_token"`
+    RefreshToken string   `json:"refresh_token,omitempty"` // moved to a cookie by the handler
     TokenType   string    `json:"token_type"`
     ExpiresIn   int       `json:"expires_in"`
     User        *UserInfo `json:"user,omitempty"`
 }
 
 type ChangePasswordRequest struct {
-    CurrentPassword string `json:"current_password" validate:"required"`
-    NewPassword     string `json:"new_password" validate:"required,min=8"`
+    CurrentPassword string `json:"current_password" validate:"required,max=72"`
+    NewPassword     string `json:"new_password" validate:"required,min=8,max=72,password"`
     ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
 }
 
+// ChangePasswordResponse carries a new access token for the current session:
+// every token issued before the change, including the one the request was
+// made with, is revoked.
+type ChangePasswordResponse struct {
+    Message         string `json:"message"`
+    AccessToken     string `json:"access_token"`
+    TokenType       string `json:"token_type"`
+    ExpiresIn       int    `json:"expires_in"`
+    SessionsRevoked int64  `json:"sessions_revoked"`
+}
+
 type ForgotPasswordRequest struct {
     Email string `json:"email" validate:"required,email"`
 }
 
+type ForgotPasswordResponse struct {
+    Message string `json:"message"`
+}
+
 type ResetPasswordRequest struct {
-    Token           string `json:"token" validate:"required"`
-    NewPassword     string `json:"new_password" validate:"required,min=8"`
+    Token           string `json:"token" validate:"required,max=128"`
+    NewPassword     string `json:"new_password" validate:"required,min=8,max=72,password"`
     ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
 }
 
+type ResetPasswordResponse struct {
+    Message string `json:"message"`
+}
+
 type VerifyEmailRequest struct {
-    Token string `json:"token" validate:"required"`
+    Token string `json:"token" validate:"required,max=128"`
+}
+
+type VerifyEmailResponse struct {
+    Message string `json:"message"`
+}
+
+type ResendVerificationRequest struct {
+    Email string `json:"email" validate:"required,email"`
+}
+
+type ResendVerificationResponse struct {
+    Message string `json:"message"`
 }
 
 type SessionInfo struct {
@@ -79,6 +162,16 @@ type SessionInfo struct {
     IsCurrent    bool      `json:"is_current"`
 }
 
+type ListSessionsResponse struct {
+    Sessions []SessionInfo `json:"sessions"`
+    Total    int           `json:"total"`
+}
+
+type RevokeSessionsResponse struct {
+    Revoked int64  `json:"revoked"`
+    Message string `json:"message"`
+}
+
 type ClientInfo struct {
     IPAddress string
     UserAgent string
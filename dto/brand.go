@@ -0,0 +1,53 @@
+package dto
+
+import (
+  "time"
+)
+
+// Requests
+
+type CreateBrandRequest struct {
+  Name        string `json:"name" validate:"required,min=2,max=100"`
+  Slug        string `json:"slug,omitempty" validate:"omitempty,min=2,max=100,slug"`
+  Description string `json:"description,omitempty" validate:"max=1000"`
+  LogoURL     string `json:"logo_url,omitempty" validate:"omitempty,url"`
+  WebsiteURL  string `json:"website_url,omitempty" validate:"omitempty,url"`
+}
+
+type UpdateBrandRequest struct {
+  Name        *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+  Slug        *string `json:"slug,omitempty" validate:"omitempty,min=2,max=100,slug"`
+  Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+  LogoURL     *string `json:"logo_url,omitempty" validate:"omitempty,url"`
+  WebsiteURL  *string `json:"website_url,omitempty" validate:"omitempty,url"`
+}
+
+type GetProductsByBrandRequest struct {
+  Slug   string `param:"slug" validate:"required,min=2,max=100,slug"`
+  Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+  Offset int    `query:"offset" validate:"omitempty,gte=0"`
+}
+
+// Responses
+
+type ListBrandsResponse struct {
+  Brands []BrandResponse `json:"brands"`
+  Total  int             `json:"total"`
+}
+
+type CreateBrandResponse struct {
+  ID      string         `json:"id"`
+  Brand   *BrandResponse `json:"brand"`
+  Message string         `json:"message"`
+}
+
+type UpdateBrandResponse struct {
+  Brand   *BrandResponse `json:"brand"`
+  Message string         `json:"message"`
+}
+
+type DeleteBrandResponse struct {
+  ID        string    `json:"id"`
+  Message   string    `json:"message"`
+  DeletedAt time.Time `json:"deleted_at"`
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+  "time"
+)
+
+// Requests
+
+type CreateCategoryRequest struct {
+  Name        string  `json:"name" validate:"required,min=2,max=100"`
+  Slug        string  `json:"slug,omitempty" validate:"omitempty,min=2,max=100,slug"`
+  Description string  `json:"description,omitempty" validate:"max=1000"`
+  ParentID    *string `json:"parent_id,omitempty" validate:"omitempty,uuid"`
+  ImageURL    string  `json:"image_url,omitempty" validate:"omitempty,url"`
+  SortOrder   int     `json:"sort_order" validate:"gte=0"`
+  IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+type UpdateCategoryRequest struct {
+  Name        *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+  Slug        *string `json:"slug,omitempty" validate:"omitempty,min=2,max=100,slug"`
+  Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+  ParentID    *string `json:"parent_id,omitempty" validate:"omitempty,uuid"`
+  MoveToRoot  bool    `json:"move_to_root"`
+  ImageURL    *string `json:"image_url,omitempty" validate:"omitempty,url"`
+  SortOrder   *int    `json:"sort_order,omitempty" validate:"omitempty,gte=0"`
+  IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+type GetCategoryByIDRequest struct {
+  ID string `param:"id" validate:"required,uuid"`
+}
+
+// Responses
+
+type CategoryTreeNode struct {
+  CategoryResponse
+  Children []*CategoryTreeNode `json:"children"`
+}
+
+type CategoryTreeResponse struct {
+  Categories []*CategoryTreeNode `json:"categories"`
+}
+
+type ListCategoriesResponse struct {
+  Categories []CategoryResponse `json:"categories"`
+  Total      int                `json:"total"`
+}
+
+type CategoryBreadcrumbsResponse struct {
+  CategoryID string             `json:"category_id"`
+  Path       []CategoryResponse `json:"path"`
+}
+
+type CreateCategoryResponse struct {
+  ID       string            `json:"id"`
+  Category *CategoryResponse `json:"category"`
+  Message  string            `json:"message"`
+}
+
+type UpdateCategoryResponse struct {
+  Category *CategoryResponse `json:"category"`
+  Message  string            `json:"message"`
+}
+
+type DeleteCategoryResponse struct {
+  ID        string    `json:"id"`
+  Message   string    `json:"message"`
+  DeletedAt time.Time `json:"deleted_at"`
+}
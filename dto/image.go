@@ -0,0 +1,37 @@
+package dto
+
+import (
+  "time"
+)
+
+type ReorderProductImagesRequest struct {
+  ImageIDs []string `json:"image_ids" validate:"required,min=1,max=10,unique,dive,uuid"`
+}
+
+type ProductImageResponse struct {
+  ID          string            `json:"id"`
+  URL         string            `json:"url"`
+  ContentType string            `json:"content_type"`
+  Size        int64             `json:"size"`
+  Width       int               `json:"width"`
+  Height      int               `json:"height"`
+  Position    int               `json:"position"`
+  Thumbnails  map[string]string `json:"thumbnails"`
+  CreatedAt   time.Time         `json:"created_at"`
+}
+
+type ListProductImagesResponse struct {
+  ProductID string                 `json:"product_id"`
+  Images    []ProductImageResponse `json:"images"`
+}
+
+type UploadProductImageResponse struct {
+  ID      string                `json:"id"`
+  Image   *ProductImageResponse `json:"image"`
+  Message string                `json:"message"`
+}
+
+type DeleteProductImageResponse struct {
+  ID      string `json:"id"`
+  Message string `json:"message"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+import (
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+)
+
+type ExportProductsRequest struct {
+  CategoryID *string  `query:"category_id" validate:"omitempty,uuid"`
+  BrandID    *string  `query:"brand_id" validate:"omitempty,uuid"`
+  MinPrice   *float64 `query:"min_price" validate:"omitempty,gte=0"`
+  MaxPrice   *float64 `query:"max_price" validate:"omitempty,gt=0"`
+  InStock    *bool    `query:"in_stock"`
+  Status     *model.ProductStatus `query:"status" validate:"omitempty,oneof=active inactive out_of_stock discontinued"`
+  Tags       []string `query:"tags" validate:"omitempty,dive,min=2,max=30"`
+  TagMatch   string   `query:"tag_match" validate:"omitempty,oneof=any all"`
+}
+
+type ImportJobResponse struct {
+  ID            string                 `json:"id"`
+  Status        model.ImportJobStatus  `json:"status"`
+  DryRun        bool                   `json:"dry_run"`
+  TotalRows     int                    `json:"total_rows"`
+  ProcessedRows int                    `json:"processed_rows"`
+  Progress      float64                `json:"progress"`
+  CreatedCount  int                    `json:"created_count"`
+  UpdatedCount  int                    `json:"updated_count"`
+  FailedCount   int                    `json:"failed_count"`
+  Errors        []model.ImportRowError `json:"errors"`
+  FailureReason *string                `json:"failure_reason,omitempty"`
+  CreatedAt     time.Time              `json:"created_at"`
+  StartedAt     *time.Time             `json:"started_at,omitempty"`
+  FinishedAt    *time.Time             `json:"finished_at,omitempty"`
+}
+
+type StartImportResponse struct {
+  Job     *ImportJobResponse `json:"job"`
+  Message string             `json:"message"`
+}
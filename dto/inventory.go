@@ -0,0 +1,62 @@
+package dto
+
+import (
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+)
+
+type GetStockMovementsRequest struct {
+  VariantID *string `query:"variant_id" validate:"omitempty,uuid"`
+  Limit     int     `query:"limit" validate:"omitempty,min=1,max=100"`
+  Offset    int     `query:"offset" validate:"omitempty,gte=0"`
+}
+
+type StockMovementResponse struct {
+  ID               string                  `json:"id"`
+  VariantID        *string                 `json:"variant_id,omitempty"`
+  WarehouseID      *string                 `json:"warehouse_id,omitempty"`
+  Type             model.StockMovementType `json:"type"`
+  Quantity         int                     `json:"quantity"`
+  ReservedQuantity int                     `json:"reserved_quantity"`
+  StockBefore      int                     `json:"stock_before"`
+  StockAfter       int                     `json:"stock_after"`
+  ReservedBefore   int                     `json:"reserved_before"`
+  ReservedAfter    int                     `json:"reserved_after"`
+  Reason           string                  `json:"reason,omitempty"`
+  ActorID          *string                 `json:"actor_id,omitempty"`
+  OrderID          *string                 `json:"order_id,omitempty"`
+  CreatedAt        time.Time               `json:"created_at"`
+}
+
+type ListStockMovementsResponse struct {
+  ProductID string                  `json:"product_id"`
+  Movements []StockMovementResponse `json:"movements"`
+  Total     int                     `json:"total"`
+  Limit     int                     `json:"limit"`
+  Offset    int                     `json:"offset"`
+}
+
+type GetLowStockRequest struct {
+  Limit  int `query:"limit" validate:"omitempty,min=1,max=100"`
+  Offset int `query:"offset" validate:"omitempty,gte=0"`
+}
+
+type LowStockItemResponse struct {
+  ProductID        string              `json:"product_id"`
+  VariantID        *string             `json:"variant_id,omitempty"`
+  SKU              string              `json:"sku"`
+  Name             string              `json:"name"`
+  Status           model.ProductStatus `json:"status"`
+  Stock            int                 `json:"stock"`
+  ReservedStock    int                 `json:"reserved_stock"`
+  Available        int                 `json:"available"`
+  ReorderThreshold int                 `json:"reorder_threshold"`
+}
+
+type LowStockReportResponse struct {
+  Items  []LowStockItemResponse `json:"items"`
+  Total  int                    `json:"total"`
+  Limit  int                    `json:"limit"`
+  Offset int                    `json:"offset"`
+}
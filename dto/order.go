@@ -8,6 +8,7 @@ import (
 // Requests
 
 type CreateOrderRequest struct {
+  UserID          string               `json:"-"`
   Items          []OrderItemInput      `json:"items" validate:"required,min=1,dive"`
   ShippingMethod  model.ShippingMethod `json:"shipping_method" validate:"required,oneof=standard express overnight pickup"`
   ShippingAddress string               `json:"shipping_address" validate:"required,min=10,max=200"`
@@ -24,6 +25,7 @@ type OrderItemInput struct {
 }
 
 type UpdateOrderStatusRequest struct {
+  ActorID       string            `json:"-"`
   Status        model.OrderStatus `json:"status" validate:"required,oneof=pending paid processing shipped delivered cancelled refunded failed"`
   InternalNotes string            `json:"internal_notes,omitempty" validate:"omitempty,max=500"`
   NotifyUser    bool              `json:"notify_user"`
@@ -37,6 +39,7 @@ type AddTrackingInfoRequest struct {
 }
 
 type CancelOrderRequest struct {
+  ActorID       string `json:"-"`
   Reason        string `json:"reason" validate:"required,min=10,max=500"`
   RefundPayment bool   `json:"refund_payment"`
   RestockItems  bool   `json:"restock_items"`
@@ -54,6 +57,8 @@ type ListOrdersRequest struct {
   OrderNumber *string           `query:"order_number" validate:"omitempty,min=1,max=50"`
   SortBy    string `query:"sort_by" validate:"omitempty,oneof=created_at total_amount status"`
   SortOrder string `query:"sort_order" validate:"omitempty,oneof=asc desc"`
+  Cursor string `query:"cursor" validate:"omitempty,max=512"`
+  Total  string `query:"total" validate:"omitempty,oneof=exact estimated"`
 }
 
 type GetOrderByIDRequest struct {
@@ -123,7 +128,7 @@ type OrderResponse struct {
 type OrderItemResponse struct {
   ID             string  `json:"id"`
   OrderID        string  `json:"order_id"`
-  ProductID      string  `json:"product_id"`
+  ProductID      *string `json:"product_id"`
   VariantID      *string `json:"variant_id,omitempty"`
   ProductSKU     string  `json:"product_sku"`
   ProductName    string  `json:"product_name"`
@@ -135,10 +140,11 @@ type OrderItemResponse struct {
 }
 
 type CreateOrderResponse struct {
-  ID          string         `json:"id"`
-  OrderNumber string         `json:"order_number"`
-  Order       *OrderResponse `json:"order"`
-  Message     string         `json:"message"`
+  ID          string                    `json:"id"`
+  OrderNumber string                    `json:"order_number"`
+  Order       *OrderResponse            `json:"order"`
+  Allocations []StockAllocationResponse `json:"allocations"`
+  Message     string                    `json:"message"`
 }
 
 type UpdateOrderStatusResponse struct {
@@ -157,10 +163,9 @@ type CancelOrderResponse struct {
 
 type ListOrdersResponse struct {
   Orders  []OrderResponse `json:"orders"`
-  Total   int             `json:"total"`
   Limit   int             `json:"limit"`
   Offset  int             `json:"offset"`
-  HasMore bool            `json:"has_more"`
+  PageInfo
 }
 
 type OrderItemsResponse struct {
@@ -191,6 +196,8 @@ type CartResponse struct {
 type CartItemResponse struct {
   ID           string  `json:"id"`
   ProductID    string  `json:"product_id"`
+  VariantID    *string `json:"variant_id,omitempty"`
+  SKU          string  `json:"sku"`
   ProductName  string  `json:"product_name"`
   ProductImage string  `json:"product_image"`
   UnitPrice    float64 `json:"unit_price"`
@@ -0,0 +1,10 @@
+package dto
+
+// PageInfo is embedded in cursor-paginated list responses. Pass NextCursor
+// back as the cursor query parameter to get the following page.
+type PageInfo struct {
+  NextCursor     string `json:"next_cursor,omitempty"`
+  HasMore        bool   `json:"has_more"`
+  Total          *int   `json:"total,omitempty"`
+  TotalEstimated bool   `json:"total_estimated,omitempty"`
+}
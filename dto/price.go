@@ -0,0 +1,71 @@
+package dto
+
+import (
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+)
+
+type GetPriceHistoryRequest struct {
+  Limit  int `query:"limit" validate:"omitempty,min=1,max=100"`
+  Offset int `query:"offset" validate:"omitempty,gte=0"`
+}
+
+type CreateScheduledPriceRequest struct {
+  Price     float64    `json:"price" validate:"required,gt=0"`
+  StartsAt  time.Time  `json:"starts_at" validate:"required"`
+  EndsAt    *time.Time `json:"ends_at,omitempty" validate:"omitempty,gtfield=StartsAt"`
+  CreatedBy string     `json:"-"`
+}
+
+type PriceChangeResponse struct {
+  ID               string                  `json:"id"`
+  OldPrice         float64                 `json:"old_price"`
+  NewPrice         float64                 `json:"new_price"`
+  OldCostPrice     float64                 `json:"old_cost_price"`
+  NewCostPrice     float64                 `json:"new_cost_price"`
+  Source           model.PriceChangeSource `json:"source"`
+  ChangedBy        *string                 `json:"changed_by,omitempty"`
+  ScheduledPriceID *string                 `json:"scheduled_price_id,omitempty"`
+  ChangedAt        time.Time               `json:"changed_at"`
+}
+
+type PriceHistoryResponse struct {
+  ProductID         string                `json:"product_id"`
+  CurrentPrice      float64               `json:"current_price"`
+  LowestPrice30Days float64               `json:"lowest_price_30_days"`
+  Changes           []PriceChangeResponse `json:"changes"`
+  Total             int                   `json:"total"`
+  Limit             int                   `json:"limit"`
+  Offset            int                   `json:"offset"`
+}
+
+type ScheduledPriceResponse struct {
+  ID            string                     `json:"id"`
+  ProductID     string                     `json:"product_id"`
+  Price         float64                    `json:"price"`
+  PreviousPrice *float64                   `json:"previous_price,omitempty"`
+  StartsAt      time.Time                  `json:"starts_at"`
+  EndsAt        *time.Time                 `json:"ends_at,omitempty"`
+  Status        model.ScheduledPriceStatus `json:"status"`
+  CreatedBy     string                     `json:"created_by"`
+  CreatedAt     time.Time                  `json:"created_at"`
+  AppliedAt     *time.Time                 `json:"applied_at,omitempty"`
+  EndedAt       *time.Time                 `json:"ended_at,omitempty"`
+}
+
+type ListScheduledPricesResponse struct {
+  ProductID string                   `json:"product_id"`
+  Schedules []ScheduledPriceResponse `json:"schedules"`
+}
+
+type CreateScheduledPriceResponse struct {
+  ID       string                  `json:"id"`
+  Schedule *ScheduledPriceResponse `json:"schedule"`
+  Message  string                  `json:"message"`
+}
+
+type CancelScheduledPriceResponse struct {
+  Schedule *ScheduledPriceResponse `json:"schedule"`
+  Message  string                  `json:"message"`
+}
@@ -20,6 +20,7 @@ type CreateProductRequest struct {
   Weight      float64  `json:"weight" validate:"required,gt=0"` // grams
   Images      []string `json:"images" validate:"required,min=1,max=10,dive,url"`
   Tags        []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,min=2,max=30"`
+  ReorderThreshold *int `json:"reorder_threshold,omitempty" validate:"omitempty,gte=0"`
 }
 
 type UpdateProductRequest struct {
@@ -33,11 +34,17 @@ type UpdateProductRequest struct {
   Images      []string  `json:"images,omitempty" validate:"omitempty,min=1,max=10,dive,url"`
   Tags        []string  `json:"tags,omitempty" validate:"omitempty,max=20,dive,min=2,max=30"`
   Status      *model.ProductStatus `json:"status,omitempty" validate:"omitempty,oneof=active inactive out_of_stock discontinued"`
+  ReorderThreshold *int     `json:"reorder_threshold,omitempty" validate:"omitempty,gte=0"`
+  ActorID     string    `json:"-"`
 }
 
 type UpdateProductStockRequest struct {
-  Stock     int  `json:"stock" validate:"required,gte=0"`
-  Increment bool `json:"increment"`
+  Stock     int    `json:"stock" validate:"required,gte=0"`
+  Increment bool   `json:"increment"`
+  WarehouseID *string `json:"warehouse_id,omitempty" validate:"omitempty,uuid"`
+  Type      model.StockMovementType `json:"type" validate:"omitempty,oneof=adjustment receiving"`
+  Reason    string `json:"reason" validate:"required,min=3,max=200"`
+  ActorID   string `json:"-"`
 }
 
 type ReserveStockRequest struct {
@@ -57,14 +64,19 @@ type ListProductsRequest struct {
   InStock    *bool    `query:"in_stock"`
   Status     *model.ProductStatus `query:"status" validate:"omitempty,oneof=active inactive out_of_stock discontinued"`
   Tags       []string `query:"tags" validate:"omitempty,dive,min=2,max=30"`
-  SortBy    string `query:"sort_by" validate:"omitempty,oneof=price name created_at stock popularity"`
+  TagMatch   string   `query:"tag_match" validate:"omitempty,oneof=any all"`
+  SortBy    string `query:"sort_by" validate:"omitempty,oneof=price name created_at stock popularity rating"`
   SortOrder string `query:"sort_order" validate:"omitempty,oneof=asc desc"`
+  Cursor string `query:"cursor" validate:"omitempty,max=512"`
+  Total  string `query:"total" validate:"omitempty,oneof=exact estimated"`
 }
 
 type SearchProductsRequest struct {
   Query     string `query:"q" validate:"required,min=2,max=100"`
   Limit     int    `query:"limit" validate:"omitempty,min=1,max=50"`
   Offset    int    `query:"offset" validate:"omitempty,gte=0"`
+  Cursor string `query:"cursor" validate:"omitempty,max=512"`
+  Total  string `query:"total" validate:"omitempty,oneof=exact estimated"`
   CategoryID *string `query:"category_id" validate:"omitempty,uuid"`
 }
 
@@ -79,10 +91,10 @@ type GetProductsByCategoryRequest struct {
   Offset           int    `query:"offset" validate:"omitempty,gte=0"`
 }
 
-// type GetRelatedProductsRequest struct {
-//   ProductID string `param:"product_id" validate:"required,uuid"`
-//   Limit     int    `query:"limit" validate:"omitempty,min=1,max=20"`
-// }
+type GetRelatedProductsRequest struct {
+  ProductID string `param:"product_id" validate:"required,uuid"`
+  Limit     int    `query:"limit" validate:"omitempty,min=1,max=20"`
+}
 
 type DeleteProductRequest struct {
   ID string `param:"id" validate:"required,uuid"`
@@ -100,6 +112,14 @@ type ProductResponse struct {
     Available   int       `json:"available"`
     Images      []string  `json:"images"`
     Tags        []string  `json:"tags"`
+    CategoryID  string            `json:"category_id"`
+    Category    *CategoryResponse `json:"category,omitempty"`
+    BrandID     *string           `json:"brand_id,omitempty"`
+    Brand       *BrandResponse    `json:"brand,omitempty"`
+    Variants    []VariantResponse `json:"variants,omitempty"`
+    LowestPrice30Days *float64    `json:"lowest_price_30_days,omitempty"`
+    RatingAverage float64         `json:"rating_average"`
+    RatingCount   int             `json:"rating_count"`
     CreatedAt   time.Time `json:"created_at"`
     UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -111,13 +131,18 @@ type CategoryResponse struct {
   Description string    `json:"description"`
   ParentID    *string   `json:"parent_id,omitempty"`
   ImageURL    string    `json:"image_url"`
+  SortOrder   int       `json:"sort_order"`
+  IsActive    bool      `json:"is_active"`
 }
 
 type BrandResponse struct {
-  ID         string `json:"id"`
-  Name       string `json:"name"`
-  Slug       string `json:"slug"`
-  LogoURL    string `json:"logo_url"`
+  ID           string `json:"id"`
+  Name         string `json:"name"`
+  Slug         string `json:"slug"`
+  Description  string `json:"description,omitempty"`
+  LogoURL      string `json:"logo_url"`
+  WebsiteURL   string `json:"website_url,omitempty"`
+  ProductCount *int   `json:"product_count,omitempty"`
 }
 
 type CreateProductResponse struct {
@@ -149,6 +174,7 @@ type ListProductsResponse struct {
   Products   []ProductResponse `json:"products"`
   Limit      int               `json:"limit"`
   Offset     int               `json:"offset"`
+  PageInfo
 }
 
 type SearchProductsResponse struct {
@@ -156,6 +182,7 @@ type SearchProductsResponse struct {
   Query      string            `json:"query"`
   Limit      int               `json:"limit"`
   Offset     int               `json:"offset"`
+  PageInfo
 }
 
 type DeleteProductResponse struct {
@@ -163,3 +190,26 @@ type DeleteProductResponse struct {
   Message   string    `json:"message"`
   DeletedAt time.Time `json:"deleted_at"`
 }
+
+type ListDeletedProductsRequest struct {
+  Limit  int `query:"limit" validate:"omitempty,min=1,max=100"`
+  Offset int `query:"offset" validate:"omitempty,gte=0"`
+}
+
+type DeletedProductResponse struct {
+  ProductResponse
+  DeletedAt time.Time `json:"deleted_at"`
+  PurgeAt   time.Time `json:"purge_at"`
+}
+
+type ListDeletedProductsResponse struct {
+  Products []DeletedProductResponse `json:"products"`
+  Total    int                      `json:"total"`
+  Limit    int                      `json:"limit"`
+  Offset   int                      `json:"offset"`
+}
+
+type RestoreProductResponse struct {
+  Product *ProductResponse `json:"product"`
+  Message string           `json:"message"`
+}
@@ -0,0 +1,92 @@
+package dto
+
+import (
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+)
+
+// Requests
+
+type CreateReviewRequest struct {
+  Rating int    `json:"rating" validate:"required,min=1,max=5"`
+  Title  string `json:"title" validate:"required,min=3,max=150"`
+  Body   string `json:"body" validate:"required,min=10,max=5000"`
+  UserID string `json:"-"`
+}
+
+type ListReviewsRequest struct {
+  SortBy string `query:"sort_by" validate:"omitempty,oneof=recent helpful rating_high rating_low"`
+  Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+  Offset int    `query:"offset" validate:"omitempty,gte=0"`
+}
+
+type ListReviewQueueRequest struct {
+  Status model.ReviewStatus `query:"status" validate:"omitempty,oneof=pending approved rejected"`
+  Limit  int                `query:"limit" validate:"omitempty,min=1,max=100"`
+  Offset int                `query:"offset" validate:"omitempty,gte=0"`
+}
+
+type ModerateReviewRequest struct {
+  Status      model.ReviewStatus `json:"status" validate:"required,oneof=approved rejected"`
+  Note        *string            `json:"note,omitempty" validate:"omitempty,max=500"`
+  ModeratorID string             `json:"-"`
+}
+
+type VoteReviewRequest struct {
+  Helpful *bool  `json:"helpful" validate:"required"`
+  UserID  string `json:"-"`
+}
+
+// Responses
+
+type ReviewResponse struct {
+  ID             string             `json:"id"`
+  ProductID      string             `json:"product_id"`
+  UserID         string             `json:"user_id"`
+  Rating         int                `json:"rating"`
+  Title          string             `json:"title"`
+  Body           string             `json:"body"`
+  Status         model.ReviewStatus `json:"status"`
+  ModerationNote *string            `json:"moderation_note,omitempty"`
+  ModeratedAt    *time.Time         `json:"moderated_at,omitempty"`
+  HelpfulCount   int                `json:"helpful_count"`
+  UnhelpfulCount int                `json:"unhelpful_count"`
+  CreatedAt      time.Time          `json:"created_at"`
+  UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+type CreateReviewResponse struct {
+  Review  *ReviewResponse `json:"review"`
+  Message string          `json:"message"`
+}
+
+type ListReviewsResponse struct {
+  ProductID     string           `json:"product_id"`
+  RatingAverage float64          `json:"rating_average"`
+  RatingCount   int              `json:"rating_count"`
+  Reviews       []ReviewResponse `json:"reviews"`
+  Total         int              `json:"total"`
+  Limit         int              `json:"limit"`
+  Offset        int              `json:"offset"`
+}
+
+type ReviewQueueResponse struct {
+  Status  model.ReviewStatus `json:"status"`
+  Reviews []ReviewResponse   `json:"reviews"`
+  Total   int                `json:"total"`
+  Limit   int                `json:"limit"`
+  Offset  int                `json:"offset"`
+}
+
+type ModerateReviewResponse struct {
+  Review  *ReviewResponse `json:"review"`
+  Message string          `json:"message"`
+}
+
+type VoteReviewResponse struct {
+  ReviewID       string `json:"review_id"`
+  HelpfulCount   int    `json:"helpful_count"`
+  UnhelpfulCount int    `json:"unhelpful_count"`
+  Message        string `json:"message"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+)
+
+type CreateSubscriptionRequest struct {
+  Type        model.SubscriptionType `json:"type" validate:"required,oneof=back_in_stock price_below"`
+  VariantID   *string                `json:"variant_id,omitempty" validate:"omitempty,uuid"`
+  TargetPrice *float64               `json:"target_price,omitempty" validate:"required_if=Type price_below,omitempty,gt=0"`
+  UserID      string                 `json:"-"`
+}
+
+type SubscriptionResponse struct {
+  ID          string                 `json:"id"`
+  ProductID   string                 `json:"product_id"`
+  VariantID   *string                `json:"variant_id,omitempty"`
+  Type        model.SubscriptionType `json:"type"`
+  TargetPrice *float64               `json:"target_price,omitempty"`
+  TriggeredAt *time.Time             `json:"triggered_at,omitempty"`
+  CreatedAt   time.Time              `json:"created_at"`
+}
+
+type CreateSubscriptionResponse struct {
+  Subscription *SubscriptionResponse `json:"subscription"`
+  Message      string                `json:"message"`
+}
+
+type ListSubscriptionsResponse struct {
+  Subscriptions []SubscriptionResponse `json:"subscriptions"`
+}
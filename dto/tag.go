@@ -0,0 +1,44 @@
+package dto
+
+import (
+  "time"
+)
+
+// Requests
+
+type RenameTagRequest struct {
+  Name string `json:"name" validate:"required,min=2,max=30"`
+  Slug string `json:"slug,omitempty" validate:"omitempty,min=2,max=30,slug"`
+}
+
+type MergeTagRequest struct {
+  Into string `json:"into" validate:"required,min=2,max=30,slug"`
+}
+
+// Responses
+
+type TagResponse struct {
+  ID           string    `json:"id"`
+  Name         string    `json:"name"`
+  Slug         string    `json:"slug"`
+  ProductCount *int      `json:"product_count,omitempty"`
+  CreatedAt    time.Time `json:"created_at"`
+  UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ListTagsResponse struct {
+  Tags  []TagResponse `json:"tags"`
+  Total int           `json:"total"`
+}
+
+type RenameTagResponse struct {
+  Tag             *TagResponse `json:"tag"`
+  ProductsUpdated int64        `json:"products_updated"`
+  Message         string       `json:"message"`
+}
+
+type MergeTagResponse struct {
+  Tag             *TagResponse `json:"tag"`
+  ProductsUpdated int64        `json:"products_updated"`
+  Message         string       `json:"message"`
+}
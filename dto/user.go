@@ -18,12 +18,15 @@ type CreateUserRequest struct {
 type UpdateUserRequest struct {
   Username *string `json:"username,omitempty" validate:"omitempty,min=3,max=50,alphanum"`
   Email    *string `json:"email,omitempty" validate:"omitempty,email"`
-  Password *string `json:"password,omitempty" validate:"omitempty,min=8,max=72,password"`
   Address  *string `json:"address,omitempty" validate:"omitempty,max=200"`
   City     *string `json:"city,omitempty" validate:"omitempty,max=100"`
   Country  *string `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
 }
 
+type UpdateUserRoleRequest struct {
+  Role string `json:"role" validate:"required,oneof=admin customer"`
+}
+
 type GetUserByIDRequest struct {
   ID string `param:"id" validate:"required,uuid"`
 }
@@ -36,12 +39,25 @@ type DeleteUserRequest struct {
   ID string `param:"id" validate:"required,uuid"`
 }
 
+type ListUsersRequest struct {
+  Limit     int     `query:"limit" validate:"omitempty,min=1,max=100"`
+  Offset    int     `query:"offset" validate:"omitempty,gte=0"`
+  Query     *string `query:"q" validate:"omitempty,min=2,max=100"`
+  Role      *string `query:"role" validate:"omitempty,oneof=admin customer"`
+  SortBy    string  `query:"sort_by" validate:"omitempty,oneof=created_at username email"`
+  SortOrder string  `query:"sort_order" validate:"omitempty,oneof=asc desc"`
+  Cursor string `query:"cursor" validate:"omitempty,max=512"`
+  Total  string `query:"total" validate:"omitempty,oneof=exact estimated"`
+}
+
 // Responses
 
 type UserResponse struct {
   ID        string    `json:"id"`
   Username  string    `json:"username"`
   Email     string    `json:"email"`
+  Role      string    `json:"role,omitempty"`
+  EmailVerifiedAt *time.Time `json:"email_verified_at"`
   Address   string    `json:"address,omitempty"`
   City      string    `json:"city,omitempty"`
   Country   string    `json:"country,omitempty"`
@@ -60,6 +76,13 @@ type UpdateUserResponse struct {
   Message string        `json:"message"`
 }
 
+type ListUsersResponse struct {
+  Users  []UserResponse `json:"users"`
+  Limit  int            `json:"limit"`
+  Offset int            `json:"offset"`
+  PageInfo
+}
+
 type DeleteUserResponse struct {
   ID        string    `json:"id"`
   Message   string    `json:"message"`
@@ -17,6 +17,12 @@ func ValidatePassword(fl validator.FieldLevel) bool {
   return hasUpper && hasLower && hasNumber && hasSpecial
 }
 
+var slugRegex = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+func ValidateSlug(fl validator.FieldLevel) bool {
+  return slugRegex.MatchString(fl.Field().String())
+}
+
 func ValidateISO3166Alpha2(fl validator.FieldLevel) bool {
   country := fl.Field().String()
 
@@ -71,6 +77,10 @@ func RegisterCustomValidators(v *validator.Validate) error {
 	return err
   }
 
+  if err := v.RegisterValidation("slug", ValidateSlug); err != nil {
+	return err
+  }
+
   return nil
 }
 
@@ -109,6 +119,8 @@ func FormatValidationErrors(err error) map[string]string {
 		errors[field] = field + " must be a valid ISO 3166-1 alpha-2 country code"
 	  case "uuid":
 		errors[field] = field + " must be a valid UUID"
+	  case "slug":
+		errors[field] = field + " must contain only lowercase letters, numbers and single hyphens"
 	  default:
 		errors[field] = field + " failed " + tag + " validation"
 	  }
@@ -0,0 +1,99 @@
+package dto
+
+import (
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+)
+
+// Requests
+
+type ProductOptionInput struct {
+  Name   string   `json:"name" validate:"required,min=1,max=50"`
+  Values []string `json:"values" validate:"required,min=1,max=50,unique,dive,min=1,max=50"`
+}
+
+type SetProductOptionsRequest struct {
+  Options []ProductOptionInput `json:"options" validate:"required,min=1,max=5,unique=Name,dive"`
+}
+
+type CreateVariantRequest struct {
+  SKU        string            `json:"sku" validate:"required,min=3,max=50"`
+  Name       string            `json:"name,omitempty" validate:"omitempty,min=1,max=200"`
+  Price      float64           `json:"price" validate:"required,gt=0"`
+  Stock      int               `json:"stock" validate:"gte=0"`
+  Attributes map[string]string `json:"attributes" validate:"required,min=1,dive,keys,min=1,max=50,endkeys,min=1,max=50"`
+}
+
+type UpdateVariantRequest struct {
+  SKU   *string  `json:"sku,omitempty" validate:"omitempty,min=3,max=50"`
+  Name  *string  `json:"name,omitempty" validate:"omitempty,min=1,max=200"`
+  Price *float64 `json:"price,omitempty" validate:"omitempty,gt=0"`
+}
+
+type UpdateVariantStockRequest struct {
+  Stock     int    `json:"stock" validate:"gte=0"`
+  Increment bool   `json:"increment"`
+  WarehouseID *string `json:"warehouse_id,omitempty" validate:"omitempty,uuid"`
+  Type      model.StockMovementType `json:"type" validate:"omitempty,oneof=adjustment receiving"`
+  Reason    string `json:"reason" validate:"required,min=3,max=200"`
+  ActorID   string `json:"-"`
+}
+
+type GenerateVariantsRequest struct {
+  Price *float64 `json:"price,omitempty" validate:"omitempty,gt=0"`
+  Stock int      `json:"stock" validate:"gte=0"`
+}
+
+// Responses
+
+type ProductOptionResponse struct {
+  Name   string   `json:"name"`
+  Values []string `json:"values"`
+}
+
+type ProductOptionsResponse struct {
+  ProductID string                  `json:"product_id"`
+  Options   []ProductOptionResponse `json:"options"`
+}
+
+type VariantResponse struct {
+  ID         string            `json:"id"`
+  ProductID  string            `json:"product_id"`
+  SKU        string            `json:"sku"`
+  Name       string            `json:"name"`
+  Price      float64           `json:"price"`
+  Stock      int               `json:"stock"`
+  Available  int               `json:"available"`
+  Attributes map[string]string `json:"attributes"`
+  CreatedAt  time.Time         `json:"created_at"`
+  UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+type ListVariantsResponse struct {
+  ProductID string            `json:"product_id"`
+  Variants  []VariantResponse `json:"variants"`
+}
+
+type CreateVariantResponse struct {
+  ID      string           `json:"id"`
+  Variant *VariantResponse `json:"variant"`
+  Message string           `json:"message"`
+}
+
+type UpdateVariantResponse struct {
+  Variant *VariantResponse `json:"variant"`
+  Message string           `json:"message"`
+}
+
+type GenerateVariantsResponse struct {
+  Created []VariantResponse `json:"created"`
+  Skipped int               `json:"skipped"`
+  Message string            `json:"message"`
+}
+
+type DeleteVariantResponse struct {
+  ID        string    `json:"id"`
+  Message   string    `json:"message"`
+  DeletedAt time.Time `json:"deleted_at"`
+}
@@ -0,0 +1,77 @@
+package dto
+
+import (
+  "time"
+)
+
+// Requests
+
+type CreateWarehouseRequest struct {
+  Code      string   `json:"code" validate:"required,alphanum,min=2,max=20"`
+  Name      string   `json:"name" validate:"required,min=2,max=100"`
+  Country   string   `json:"country" validate:"required,iso3166_1_alpha2"`
+  ShipsTo   []string `json:"ships_to,omitempty" validate:"omitempty,max=250,unique,dive,iso3166_1_alpha2"`
+  Priority  int      `json:"priority" validate:"gte=0"`
+  IsDefault bool     `json:"is_default"`
+}
+
+type UpdateWarehouseRequest struct {
+  Name      *string  `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+  Country   *string  `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+  ShipsTo   []string `json:"ships_to,omitempty" validate:"omitempty,max=250,unique,dive,iso3166_1_alpha2"`
+  Priority  *int     `json:"priority,omitempty" validate:"omitempty,gte=0"`
+  IsDefault *bool    `json:"is_default,omitempty"`
+  IsActive  *bool    `json:"is_active,omitempty"`
+}
+
+// Responses
+
+type WarehouseResponse struct {
+  ID        string    `json:"id"`
+  Code      string    `json:"code"`
+  Name      string    `json:"name"`
+  Country   string    `json:"country"`
+  ShipsTo   []string  `json:"ships_to"`
+  Priority  int       `json:"priority"`
+  IsDefault bool      `json:"is_default"`
+  IsActive  bool      `json:"is_active"`
+  CreatedAt time.Time `json:"created_at"`
+  UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ListWarehousesResponse struct {
+  Warehouses []WarehouseResponse `json:"warehouses"`
+}
+
+type CreateWarehouseResponse struct {
+  ID        string             `json:"id"`
+  Warehouse *WarehouseResponse `json:"warehouse"`
+  Message   string             `json:"message"`
+}
+
+type UpdateWarehouseResponse struct {
+  Warehouse *WarehouseResponse `json:"warehouse"`
+  Message   string             `json:"message"`
+}
+
+type WarehouseStockResponse struct {
+  WarehouseID   string  `json:"warehouse_id"`
+  WarehouseCode string  `json:"warehouse_code"`
+  VariantID     *string `json:"variant_id,omitempty"`
+  Stock         int     `json:"stock"`
+  ReservedStock int     `json:"reserved_stock"`
+  Available     int     `json:"available"`
+}
+
+type ProductWarehouseStockResponse struct {
+  ProductID string                   `json:"product_id"`
+  Stock     []WarehouseStockResponse `json:"stock"`
+}
+
+type StockAllocationResponse struct {
+  OrderItemID string  `json:"order_item_id"`
+  WarehouseID string  `json:"warehouse_id"`
+  ProductID   string  `json:"product_id"`
+  VariantID   *string `json:"variant_id,omitempty"`
+  Quantity    int     `json:"quantity"`
+}
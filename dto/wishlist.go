@@ -0,0 +1,70 @@
+package dto
+
+import (
+  "time"
+)
+
+// Requests
+
+type CreateWishlistRequest struct {
+  Name   string `json:"name" validate:"required,min=1,max=100"`
+  Shared bool   `json:"shared"`
+}
+
+type UpdateWishlistRequest struct {
+  Name   *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+  Shared *bool   `json:"shared,omitempty"`
+}
+
+type AddWishlistItemRequest struct {
+  ProductID string  `json:"product_id" validate:"required,uuid"`
+  VariantID *string `json:"variant_id,omitempty" validate:"omitempty,uuid"`
+}
+
+type MoveToCartRequest struct {
+  Quantity int `json:"quantity" validate:"omitempty,min=1,max=100"`
+}
+
+// Responses
+
+type WishlistResponse struct {
+  ID         string                 `json:"id"`
+  Name       string                 `json:"name"`
+  Shared     bool                   `json:"shared"`
+  ShareToken *string                `json:"share_token,omitempty"`
+  ItemCount  int                    `json:"item_count"`
+  Items      []WishlistItemResponse `json:"items,omitempty"`
+  CreatedAt  time.Time              `json:"created_at"`
+  UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+type WishlistItemResponse struct {
+  ID           string    `json:"id"`
+  ProductID    string    `json:"product_id"`
+  VariantID    *string   `json:"variant_id,omitempty"`
+  SKU          string    `json:"sku"`
+  ProductName  string    `json:"product_name"`
+  ProductImage string    `json:"product_image"`
+  Price        float64   `json:"price"`
+  Available    bool      `json:"available"`
+  AddedAt      time.Time `json:"added_at"`
+}
+
+type ListWishlistsResponse struct {
+  Wishlists []WishlistResponse `json:"wishlists"`
+}
+
+type CreateWishlistResponse struct {
+  Wishlist *WishlistResponse `json:"wishlist"`
+  Message  string            `json:"message"`
+}
+
+type UpdateWishlistResponse struct {
+  Wishlist *WishlistResponse `json:"wishlist"`
+  Message  string            `json:"message"`
+}
+
+type AddWishlistItemResponse struct {
+  Wishlist *WishlistResponse `json:"wishlist"`
+  Message  string            `json:"message"`
+}
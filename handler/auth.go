@@ -1,6 +1,8 @@
 package handler
 
 import (
+    "net"
+    "time"
     "encoding/json"
     "net/http"
     "errors"
@@ -10,28 +12,104 @@ import (
     
     "github.com/F-Dupraz/ecommerce-with-go/dto"
     "github.com/F-Dupraz/ecommerce-with-go/service"
+    "github.com/F-Dupraz/ecommerce-with-go/middleware"
 )
 
 type AuthHandler struct {
     BaseHandler
     authService *service.AuthService
     userService *service.UserService
+    passwordResetService *service.PasswordResetService
+    emailVerificationService *service.EmailVerificationService
+    mfaService *service.MFAService
+    authMiddleware *middleware.AuthMiddleware
+    loginLimiter *middleware.RateLimiter
+    passwordResetLimiter *middleware.RateLimiter
+    emailVerificationLimiter *middleware.RateLimiter
+    mfaLimiter *middleware.RateLimiter
 }
 
-func NewAuthHandler(authService *service.AuthService, userService *service.UserService, validator *validator.Validate) *AuthHandler {
+func NewAuthHandler(authService *service.AuthService, userService *service.UserService, passwordResetService *service.PasswordResetService, emailVerificationService *service.EmailVerificationService, mfaService *service.MFAService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *AuthHandler {
     return &AuthHandler{
         authService: authService,
         userService: userService,
+        passwordResetService: passwordResetService,
+        emailVerificationService: emailVerificationService,
+        mfaService: mfaService,
+        authMiddleware: authMiddleware,
+        loginLimiter: middleware.NewRateLimiter(10, 15*time.Minute),
+        passwordResetLimiter: middleware.NewRateLimiter(5, 15*time.Minute),
+        emailVerificationLimiter: middleware.NewRateLimiter(10, 15*time.Minute),
+        mfaLimiter: middleware.NewRateLimiter(10, 15*time.Minute),
         BaseHandler: BaseHandler{validator: validator},
     }
 }
 
 func (h *AuthHandler) RegisterRoutes(router chi.Router) {
     router.Route("/auth", func(r chi.Router) {
-        r.Post("/login", h.Login)
+        r.Use(h.authMiddleware.Authenticate)
+
+        r.With(h.loginLimiter.Limit).Post("/login", h.Login)
         r.Post("/signup", h.Signup)
         r.Post("/refresh", h.Refresh)
         r.Post("/logout", h.Logout)
+
+        r.Group(func(r chi.Router) {
+            r.Use(h.passwordResetLimiter.Limit)
+
+            r.Post("/forgot-password", h.ForgotPassword)
+            r.Post("/reset-password", h.ResetPassword)
+        })
+
+        r.Group(func(r chi.Router) {
+            r.Use(h.emailVerificationLimiter.Limit)
+
+            r.Post("/verify-email", h.VerifyEmail)
+            r.Post("/verify-email/resend", h.ResendVerificationByEmail)
+        })
+
+        r.Group(func(r chi.Router) {
+            r.Use(h.mfaLimiter.Limit)
+
+            r.Post("/login/mfa", h.LoginMFA)
+            r.Post("/login/mfa/enroll", h.BeginLoginEnrollment)
+        })
+    })
+
+    router.Route("/me/2fa", func(r chi.Router) {
+        r.Use(h.authMiddleware.Authenticate)
+        r.Use(middleware.RequireAuth)
+        r.Use(h.mfaLimiter.Limit)
+
+        r.Get("/", h.MFAStatus)
+        r.Post("/", h.BeginEnrollment)
+        r.Delete("/", h.DisableMFA)
+        r.Post("/confirm", h.ConfirmEnrollment)
+        r.Post("/recovery-codes", h.RegenerateRecoveryCodes)
+    })
+
+    router.Route("/me/password", func(r chi.Router) {
+        r.Use(h.authMiddleware.Authenticate)
+        r.Use(middleware.RequireAuth)
+
+        r.Post("/", h.ChangePassword)
+    })
+
+    router.Route("/me/email", func(r chi.Router) {
+        r.Use(h.authMiddleware.Authenticate)
+        r.Use(middleware.RequireAuth)
+        r.Use(h.emailVerificationLimiter.Limit)
+
+        r.Post("/verification", h.ResendVerification)
+    })
+
+    router.Route("/me/sessions", func(r chi.Router) {
+        r.Use(h.authMiddleware.Authenticate)
+        r.Use(middleware.RequireAuth)
+
+        r.Get("/", h.ListSessions)
+        r.Delete("/", h.RevokeOtherSessions)
+        r.Delete("/{id}", h.RevokeSession)
     })
 }
 
@@ -48,28 +126,25 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
         return
     }
     
-    ctx := context.WithValue(r.Context(), "ip_address", r.RemoteAddr)
-    ctx = context.WithValue(ctx, "user_agent", r.Header.Get("User-Agent"))
-    
-    response, err := h.authService.Login(ctx, req.Email, req.Password)
+    response, challenge, err := h.authService.Login(r.Context(), req.Email, req.Password, clientInfo(r))
     if err != nil {
         if errors.Is(err, service.ErrInvalidCredentials) {
             h.respondWithError(w, http.StatusUnauthorized, "Invalid email or password", nil)
             return
         }
+        if errors.Is(err, service.ErrTooManyMFAAttempts) {
+            h.respondWithError(w, http.StatusTooManyRequests, "Too many authentication attempts, try again later", nil)
+            return
+        }
         h.respondWithError(w, http.StatusInternalServerError, "Login failed", nil)
         return
     }
+    if challenge != nil {
+        h.respondWithSuccess(w, http.StatusOK, challenge)
+        return
+    }
     
-    http.SetCookie(w, &http.Cookie{
-        Name:     "refresh_token",
-        Value:    response.RefreshToken,
-        Path:     "/api/v1/auth/refresh",
-        HttpOnly: true,
-        Secure:   true, // false para desarrollo local
-        SameSite: http.SameSiteStrictMode,
-        MaxAge:   7 * 24 * 60 * 60, // 7 días
-    })
+    setRefreshCookie(w, response.RefreshToken)
     
     response.RefreshToken = ""
     h.respondWithSuccess(w, http.StatusOK, response)
@@ -107,30 +182,25 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
         return
     }
     
-    ctx := context.WithValue(r.Context(), "ip_address", r.RemoteAddr)
-    ctx = context.WithValue(ctx, "user_agent", r.Header.Get("User-Agent"))
-    
-    loginResponse, err := h.authService.Login(ctx, req.Email, req.Password)
-    if err != nil {
+    loginResponse, _, err := h.authService.Login(r.Context(), req.Email, req.Password, clientInfo(r))
+    if err != nil || loginResponse == nil {
         h.respondWithSuccess(w, http.StatusCreated, dto.SignupResponse{
             Message: "Account created successfully. Please login.",
-            User:    userResponse.User,
+            User: dto.UserInfo{
+                ID:    userResponse.User.ID,
+                Email: userResponse.User.Email,
+                Name:  userResponse.User.Username,
+                Role:  userResponse.User.Role,
+                EmailVerified: userResponse.User.EmailVerifiedAt != nil,
+            },
         })
         return
     }
     
-    http.SetCookie(w, &http.Cookie{
-        Name:     "refresh_token",
-        Value:    loginResponse.RefreshToken,
-        Path:     "/api/v1/auth/refresh",
-        HttpOnly: true,
-        Secure:   true,
-        SameSite: http.SameSiteStrictMode,
-        MaxAge:   7 * 24 * 60 * 60,
-    })
+    setRefreshCookie(w, loginResponse.RefreshToken)
     
     h.respondWithSuccess(w, http.StatusCreated, dto.SignupResponse{
-        Message:     "Account created successfully",
+        Message:     "Account created successfully, check your email to verify your address",
         AccessToken: loginResponse.AccessToken,
         TokenType:   "Bearer",
         ExpiresIn:   900,
@@ -145,16 +215,15 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
         return
     }
     
-    response, err := h.authService.RefreshToken(r.Context(), cookie.Value)
+    response, err := h.authService.RefreshToken(r.Context(), cookie.Value, clientInfo(r))
     if err != nil {
+        if errors.Is(err, service.ErrTokenReused) {
+            clearRefreshCookie(w)
+            h.respondWithError(w, http.StatusUnauthorized, "Refresh token was already used, the session has been revoked", nil)
+            return
+        }
         if errors.Is(err, service.ErrInvalidToken) || errors.Is(err, service.ErrSessionExpired) {
-            http.SetCookie(w, &http.Cookie{
-                Name:     "refresh_token",
-                Value:    "",
-                Path:     "/api/v1/auth/refresh",
-                HttpOnly: true,
-                MaxAge:   -1,
-            })
+            clearRefreshCookie(w)
             h.respondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token", nil)
             return
         }
@@ -162,15 +231,398 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
         return
     }
     
+    setRefreshCookie(w, response.RefreshToken)
+    response.RefreshToken = ""
     h.respondWithSuccess(w, http.StatusOK, response)
 }
 
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-    userID, ok := r.Context().Value("user_id").(string)
-    if ok && userID != "" {
-        h.authService.Logout(r.Context(), userID)
+    userID, _ := middleware.GetUserID(r.Context())
+    if userID != "" {
+        sessionID, _ := middleware.GetSessionID(r.Context())
+        tokenID, _ := middleware.GetTokenID(r.Context())
+        if err := h.authService.Logout(r.Context(), userID, sessionID, tokenID); err != nil {
+            h.respondWithError(w, http.StatusInternalServerError, "Failed to log out", nil)
+            return
+        }
     }
     
+    clearRefreshCookie(w)
+    
+    h.respondWithSuccess(w, http.StatusOK, map[string]string{
+        "message": "Logged out successfully",
+    })
+}
+
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+    var req dto.ForgotPasswordRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        h.respondWithError(w, http.StatusBadRequest, "Invalid request format", nil)
+        return
+    }
+
+    if err := h.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusAccepted, h.passwordResetService.ForgotPassword(r.Context(), req))
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+    var req dto.ResetPasswordRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        h.respondWithError(w, http.StatusBadRequest, "Invalid request format", nil)
+        return
+    }
+
+    if err := h.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return
+    }
+
+    response, err := h.passwordResetService.ResetPassword(r.Context(), req)
+    if err != nil {
+        if errors.Is(err, service.ErrInvalidResetToken) {
+            h.respondWithError(w, http.StatusBadRequest, "The reset link is invalid or has expired", nil)
+            return
+        }
+        h.respondWithError(w, http.StatusInternalServerError, "Could not reset password", nil)
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+    var req dto.VerifyEmailRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        h.respondWithError(w, http.StatusBadRequest, "Invalid request format", nil)
+        return
+    }
+
+    if err := h.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return
+    }
+
+    response, err := h.emailVerificationService.VerifyEmail(r.Context(), req)
+    if err != nil {
+        if errors.Is(err, service.ErrInvalidVerificationToken) {
+            h.respondWithError(w, http.StatusBadRequest, "The verification link is invalid or has expired", nil)
+            return
+        }
+        h.respondWithError(w, http.StatusInternalServerError, "Could not verify email", nil)
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) ResendVerificationByEmail(w http.ResponseWriter, r *http.Request) {
+    var req dto.ResendVerificationRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        h.respondWithError(w, http.StatusBadRequest, "Invalid request format", nil)
+        return
+    }
+
+    if err := h.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusAccepted, h.emailVerificationService.ResendVerificationByEmail(r.Context(), req))
+}
+
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+    userID, _ := middleware.GetUserID(r.Context())
+
+    response, err := h.emailVerificationService.ResendVerification(r.Context(), userID)
+    if err != nil {
+        switch {
+        case errors.Is(err, service.ErrEmailAlreadyVerified):
+            h.respondWithError(w, http.StatusConflict, "Email is already verified", nil)
+        case errors.Is(err, service.ErrVerificationLimit):
+            h.respondWithError(w, http.StatusTooManyRequests, "Too many verification emails, try again later", nil)
+        case errors.Is(err, service.ErrUserNotFound):
+            h.respondWithError(w, http.StatusNotFound, "User not found", nil)
+        default:
+            h.respondWithError(w, http.StatusInternalServerError, "Could not send verification email", nil)
+        }
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusAccepted, response)
+}
+
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+    var req dto.ChangePasswordRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        h.respondWithError(w, http.StatusBadRequest, "Invalid request format", nil)
+        return
+    }
+
+    if err := h.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return
+    }
+
+    userID, _ := middleware.GetUserID(r.Context())
+    sessionID, _ := middleware.GetSessionID(r.Context())
+    tokenID, _ := middleware.GetTokenID(r.Context())
+
+    response, err := h.authService.ChangePassword(r.Context(), userID, sessionID, tokenID, req)
+    if err != nil {
+        switch {
+        case errors.Is(err, service.ErrIncorrectPassword):
+            h.respondWithError(w, http.StatusForbidden, "Current password is incorrect", nil)
+        case errors.Is(err, service.ErrPasswordUnchanged):
+            h.respondWithError(w, http.StatusUnprocessableEntity, "The new password must be different from the current one", nil)
+        case errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrSessionNotFound):
+            h.respondWithError(w, http.StatusUnauthorized, "Session not found, please log in again", nil)
+        case errors.Is(err, service.ErrUserNotFound):
+            h.respondWithError(w, http.StatusNotFound, "User not found", nil)
+        default:
+            h.respondWithError(w, http.StatusInternalServerError, "Could not change password", nil)
+        }
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+    var req dto.MFALoginRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        h.respondWithError(w, http.StatusBadRequest, "Invalid request format", nil)
+        return
+    }
+
+    if err := h.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return
+    }
+
+    response, err := h.authService.LoginMFA(r.Context(), req, clientInfo(r))
+    if err != nil {
+        if errors.Is(err, service.ErrInvalidMFACode) {
+            h.respondWithError(w, http.StatusUnauthorized, "Invalid authentication code", nil)
+            return
+        }
+        h.handleMFAError(w, err, "Login failed")
+        return
+    }
+
+    setRefreshCookie(w, response.RefreshToken)
+
+    response.RefreshToken = ""
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) BeginLoginEnrollment(w http.ResponseWriter, r *http.Request) {
+    var req dto.MFALoginEnrollmentRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        h.respondWithError(w, http.StatusBadRequest, "Invalid request format", nil)
+        return
+    }
+
+    if err := h.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return
+    }
+
+    response, err := h.mfaService.BeginChallengeEnrollment(r.Context(), req)
+    if err != nil {
+        h.handleMFAError(w, err, "Could not start two-factor enrollment")
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) MFAStatus(w http.ResponseWriter, r *http.Request) {
+    userID, _ := middleware.GetUserID(r.Context())
+
+    response, err := h.mfaService.Status(r.Context(), userID)
+    if err != nil {
+        h.handleMFAError(w, err, "Failed to get two-factor status")
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) BeginEnrollment(w http.ResponseWriter, r *http.Request) {
+    userID, _ := middleware.GetUserID(r.Context())
+
+    response, err := h.mfaService.BeginEnrollment(r.Context(), userID)
+    if err != nil {
+        h.handleMFAError(w, err, "Could not start two-factor enrollment")
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) ConfirmEnrollment(w http.ResponseWriter, r *http.Request) {
+    req, ok := h.decodeMFACode(w, r)
+    if !ok {
+        return
+    }
+    userID, _ := middleware.GetUserID(r.Context())
+
+    response, err := h.mfaService.ConfirmEnrollment(r.Context(), userID, req)
+    if err != nil {
+        h.handleMFAError(w, err, "Could not enable two-factor authentication")
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+    req, ok := h.decodeMFACode(w, r)
+    if !ok {
+        return
+    }
+    userID, _ := middleware.GetUserID(r.Context())
+
+    if err := h.mfaService.Disable(r.Context(), userID, req); err != nil {
+        h.handleMFAError(w, err, "Could not disable two-factor authentication")
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, map[string]string{
+        "message": "Two-factor authentication disabled",
+    })
+}
+
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+    req, ok := h.decodeMFACode(w, r)
+    if !ok {
+        return
+    }
+    userID, _ := middleware.GetUserID(r.Context())
+
+    response, err := h.mfaService.RegenerateRecoveryCodes(r.Context(), userID, req)
+    if err != nil {
+        h.handleMFAError(w, err, "Could not generate recovery codes")
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) decodeMFACode(w http.ResponseWriter, r *http.Request) (dto.MFACodeRequest, bool) {
+    var req dto.MFACodeRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        h.respondWithError(w, http.StatusBadRequest, "Invalid request format", nil)
+        return req, false
+    }
+
+    if err := h.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return req, false
+    }
+
+    return req, true
+}
+
+func (h *AuthHandler) handleMFAError(w http.ResponseWriter, err error, fallback string) {
+    switch {
+    case errors.Is(err, service.ErrInvalidMFACode):
+        h.respondWithError(w, http.StatusForbidden, "Invalid authentication code", nil)
+    case errors.Is(err, service.ErrInvalidMFAChallenge):
+        h.respondWithError(w, http.StatusUnauthorized, "The login has expired, please log in again", nil)
+    case errors.Is(err, service.ErrTooManyMFAAttempts):
+        h.respondWithError(w, http.StatusTooManyRequests, "Too many authentication attempts, try again later", nil)
+    case errors.Is(err, service.ErrMFAAlreadyEnabled):
+        h.respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+    case errors.Is(err, service.ErrMFANotEnabled):
+        h.respondWithError(w, http.StatusConflict, "Two-factor authentication is not enabled", nil)
+    case errors.Is(err, service.ErrMFANotEnrolled):
+        h.respondWithError(w, http.StatusConflict, "Start two-factor enrollment first", nil)
+    case errors.Is(err, service.ErrMFARequired):
+        h.respondWithError(w, http.StatusForbidden, "Two-factor authentication is required for this account", nil)
+    case errors.Is(err, service.ErrUserNotFound):
+        h.respondWithError(w, http.StatusNotFound, "User not found", nil)
+    default:
+        h.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+    }
+}
+
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+    userID, _ := middleware.GetUserID(r.Context())
+    sessionID, _ := middleware.GetSessionID(r.Context())
+
+    response, err := h.authService.ListSessions(r.Context(), userID, sessionID)
+    if err != nil {
+        h.handleSessionError(w, err, "Failed to get sessions")
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+    userID, _ := middleware.GetUserID(r.Context())
+
+    if err := h.authService.RevokeSession(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+        h.handleSessionError(w, err, "Failed to revoke session")
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+    userID, _ := middleware.GetUserID(r.Context())
+    sessionID, _ := middleware.GetSessionID(r.Context())
+
+    response, err := h.authService.RevokeOtherSessions(r.Context(), userID, sessionID)
+    if err != nil {
+        h.handleSessionError(w, err, "Failed to revoke sessions")
+        return
+    }
+
+    h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) handleSessionError(w http.ResponseWriter, err error, fallback string) {
+    switch {
+    case errors.Is(err, service.ErrInvalidID):
+        h.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+    case errors.Is(err, service.ErrSessionNotFound):
+        h.respondWithError(w, http.StatusNotFound, "Session not found", nil)
+    case errors.Is(err, service.ErrInvalidToken):
+        h.respondWithError(w, http.StatusUnauthorized, "Token is not bound to a session, please sign in again", nil)
+    default:
+        h.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+    }
+}
+
+// The refresh token travels in a cookie only sent to the refresh endpoint.
+func setRefreshCookie(w http.ResponseWriter, token string) {
+    http.SetCookie(w, &http.Cookie{
+        Name:     "refresh_token",
+        Value:    token,
+        Path:     "/api/v1/auth/refresh",
+        HttpOnly: true,
+        Secure:   true, // false para desarrollo local
+        SameSite: http.SameSiteStrictMode,
+        MaxAge:   7 * 24 * 60 * 60, // 7 días
+    })
+}
+
+func clearRefreshCookie(w http.ResponseWriter) {
     http.SetCookie(w, &http.Cookie{
         Name:     "refresh_token",
         Value:    "",
@@ -178,8 +630,17 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
         HttpOnly: true,
         MaxAge:   -1,
     })
-    
-    h.respondWithSuccess(w, http.StatusOK, map[string]string{
-        "message": "Logged out successfully",
-    })
+}
+
+// clientInfo describes the device a request comes from, for the session list.
+func clientInfo(r *http.Request) dto.ClientInfo {
+    ip, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        ip = r.RemoteAddr
+    }
+
+    return dto.ClientInfo{
+        IPAddress: ip,
+        UserAgent: r.Header.Get("User-Agent"),
+    }
 }
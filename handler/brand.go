@@ -0,0 +1,196 @@
+package handler
+
+import (
+  "fmt"
+  "errors"
+  "context"
+  "strconv"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type BrandService interface {
+  CreateBrand(ctx context.Context, req *dto.CreateBrandRequest) (*dto.CreateBrandResponse, error)
+  ListBrands(ctx context.Context) (*dto.ListBrandsResponse, error)
+  GetBrandBySlug(ctx context.Context, slug string) (*dto.BrandResponse, error)
+  UpdateBrand(ctx context.Context, slug string, req *dto.UpdateBrandRequest) (*dto.UpdateBrandResponse, error)
+  DeleteBrand(ctx context.Context, slug string) (*dto.DeleteBrandResponse, error)
+}
+
+type BrandHandler struct {
+  BaseHandler
+  brandService BrandService
+  productService ProductService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewBrandHandler(brandService BrandService, productService ProductService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *BrandHandler {
+  return &BrandHandler{
+	brandService: brandService,
+	productService: productService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (b *BrandHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/brands", func(r chi.Router) {
+	r.Use(b.authMiddleware.Authenticate)
+
+	r.Get("/", b.ListBrands)
+	r.Get("/{slug}", b.GetBrandBySlug)
+	r.Get("/{slug}/products", b.GetBrandProducts)
+
+	r.Group(func(r chi.Router) {
+	  r.Use(middleware.RequireAuth)
+	  r.Use(middleware.RequireAdmin)
+
+	  r.Post("/", b.CreateBrand)
+	  r.Put("/{slug}", b.UpdateBrand)
+	  r.Delete("/{slug}", b.DeleteBrand)
+	})
+  })
+}
+
+func (b *BrandHandler) ListBrands(w http.ResponseWriter, r *http.Request) {
+  response, err := b.brandService.ListBrands(r.Context())
+  if err != nil {
+	b.respondWithError(w, http.StatusInternalServerError, "Failed to get brands", nil)
+	return
+  }
+
+  b.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (b *BrandHandler) GetBrandBySlug(w http.ResponseWriter, r *http.Request) {
+  slug := chi.URLParam(r, "slug")
+
+  response, err := b.brandService.GetBrandBySlug(r.Context(), slug)
+  if err != nil {
+	b.handleBrandError(w, err, "Failed to get brand")
+	return
+  }
+
+  b.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (b *BrandHandler) GetBrandProducts(w http.ResponseWriter, r *http.Request) {
+  req := dto.GetProductsByBrandRequest{
+	Slug: chi.URLParam(r, "slug"),
+	Limit: 20,
+	Offset: 0,
+  }
+
+  if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+	  b.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit value '%s': must be an integer", limitStr), nil)
+	  return
+	}
+	req.Limit = limit
+  }
+
+  if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+	  b.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset value '%s': must be an integer", offsetStr), nil)
+	  return
+	}
+	req.Offset = offset
+  }
+
+  if err := b.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	b.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := b.productService.GetProductsByBrand(r.Context(), &req)
+  if err != nil {
+	b.handleBrandError(w, err, "Failed to get products")
+	return
+  }
+
+  b.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (b *BrandHandler) CreateBrand(w http.ResponseWriter, r *http.Request) {
+  var req dto.CreateBrandRequest
+
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	b.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := b.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	b.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := b.brandService.CreateBrand(r.Context(), &req)
+  if err != nil {
+	b.handleBrandError(w, err, "Failed to create brand")
+	return
+  }
+
+  b.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (b *BrandHandler) UpdateBrand(w http.ResponseWriter, r *http.Request) {
+  slug := chi.URLParam(r, "slug")
+
+  var req dto.UpdateBrandRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	b.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := b.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	b.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := b.brandService.UpdateBrand(r.Context(), slug, &req)
+  if err != nil {
+	b.handleBrandError(w, err, "Failed to update brand")
+	return
+  }
+
+  b.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (b *BrandHandler) DeleteBrand(w http.ResponseWriter, r *http.Request) {
+  slug := chi.URLParam(r, "slug")
+
+  response, err := b.brandService.DeleteBrand(r.Context(), slug)
+  if err != nil {
+	b.handleBrandError(w, err, "Failed to delete brand")
+	return
+  }
+
+  b.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (b *BrandHandler) handleBrandError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrBrandNotFound):
+	b.respondWithError(w, http.StatusNotFound, "Brand not found", nil)
+  case errors.Is(err, service.ErrDuplicateBrandSlug):
+	b.respondWithError(w, http.StatusConflict, "Brand with this slug already exists", nil)
+  case errors.Is(err, service.ErrBrandInUse):
+	b.respondWithError(w, http.StatusConflict, "Brand still has products", nil)
+  case errors.Is(err, service.ErrInvalidSlug):
+	b.respondWithError(w, http.StatusUnprocessableEntity, "A slug could not be generated from the name", nil)
+  default:
+	b.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
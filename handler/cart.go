@@ -0,0 +1,141 @@
+package handler
+
+import (
+  "errors"
+  "context"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type CartService interface {
+  GetCart(ctx context.Context, userID string) (*dto.CartResponse, error)
+  AddToCart(ctx context.Context, userID string, req *dto.AddToCartRequest) (*dto.AddToCartResponse, error)
+  UpdateCartItem(ctx context.Context, userID, itemID string, req *dto.UpdateCartItemRequest) (*dto.CartResponse, error)
+  RemoveFromCart(ctx context.Context, userID, itemID string) (*dto.CartResponse, error)
+}
+
+type CartHandler struct {
+  BaseHandler
+  cartService CartService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewCartHandler(cartService CartService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *CartHandler {
+  return &CartHandler{
+	cartService: cartService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (c *CartHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/cart", func(r chi.Router) {
+	r.Use(c.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+
+	r.Get("/", c.GetCart)
+	r.Post("/items", c.AddToCart)
+	r.Put("/items/{item_id}", c.UpdateCartItem)
+	r.Delete("/items/{item_id}", c.RemoveFromCart)
+  })
+}
+
+func (c *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  response, err := c.cartService.GetCart(r.Context(), userID)
+  if err != nil {
+	c.handleCartError(w, err, "Failed to get cart")
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (c *CartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  var req dto.AddToCartRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	c.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := c.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	c.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := c.cartService.AddToCart(r.Context(), userID, &req)
+  if err != nil {
+	c.handleCartError(w, err, "Failed to add item to cart")
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (c *CartHandler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  var req dto.UpdateCartItemRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	c.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := c.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	c.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := c.cartService.UpdateCartItem(r.Context(), userID, chi.URLParam(r, "item_id"), &req)
+  if err != nil {
+	c.handleCartError(w, err, "Failed to update cart item")
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (c *CartHandler) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  response, err := c.cartService.RemoveFromCart(r.Context(), userID, chi.URLParam(r, "item_id"))
+  if err != nil {
+	c.handleCartError(w, err, "Failed to remove cart item")
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (c *CartHandler) handleCartError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrInvalidID):
+	c.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+  case errors.Is(err, service.ErrProductNotFound):
+	c.respondWithError(w, http.StatusNotFound, "Product not found", nil)
+  case errors.Is(err, service.ErrVariantNotFound):
+	c.respondWithError(w, http.StatusNotFound, "Variant not found", nil)
+  case errors.Is(err, service.ErrCartItemNotFound):
+	c.respondWithError(w, http.StatusNotFound, "Cart item not found", nil)
+  case errors.Is(err, service.ErrVariantRequired):
+	c.respondWithError(w, http.StatusUnprocessableEntity, "This product has variants, please select one", nil)
+  case errors.Is(err, service.ErrProductUnavailable):
+	c.respondWithError(w, http.StatusConflict, "Product is not available for sale", nil)
+  case errors.Is(err, service.ErrInsufficientStock):
+	c.respondWithError(w, http.StatusConflict, "Insufficient stock available", nil)
+  default:
+	c.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+  "fmt"
+  "errors"
+  "context"
+  "strconv"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type CategoryService interface {
+  CreateCategory(ctx context.Context, req *dto.CreateCategoryRequest) (*dto.CreateCategoryResponse, error)
+  ListCategories(ctx context.Context, includeInactive bool) (*dto.ListCategoriesResponse, error)
+  GetCategoryByID(ctx context.Context, categoryID string) (*dto.CategoryResponse, error)
+  GetCategoryTree(ctx context.Context, includeInactive bool) (*dto.CategoryTreeResponse, error)
+  GetBreadcrumbs(ctx context.Context, categoryID string) (*dto.CategoryBreadcrumbsResponse, error)
+  UpdateCategory(ctx context.Context, categoryID string, req *dto.UpdateCategoryRequest) (*dto.UpdateCategoryResponse, error)
+  DeleteCategory(ctx context.Context, categoryID string) (*dto.DeleteCategoryResponse, error)
+}
+
+type CategoryHandler struct {
+  BaseHandler
+  categoryService CategoryService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewCategoryHandler(categoryService CategoryService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *CategoryHandler {
+  return &CategoryHandler{
+	categoryService: categoryService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (c *CategoryHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/categories", func(r chi.Router) {
+	r.Use(c.authMiddleware.Authenticate)
+
+	r.Get("/", c.ListCategories)
+	r.Get("/tree", c.GetCategoryTree)
+	r.Get("/{id}", c.GetCategoryByID)
+	r.Get("/{id}/breadcrumbs", c.GetBreadcrumbs)
+
+	r.Group(func(r chi.Router) {
+	  r.Use(middleware.RequireAuth)
+	  r.Use(middleware.RequireAdmin)
+
+	  r.Post("/", c.CreateCategory)
+	  r.Put("/{id}", c.UpdateCategory)
+	  r.Delete("/{id}", c.DeleteCategory)
+	})
+  })
+}
+
+func (c *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+  includeInactive, ok := c.parseIncludeInactive(w, r)
+  if !ok {
+	return
+  }
+
+  response, err := c.categoryService.ListCategories(r.Context(), includeInactive)
+  if err != nil {
+	c.respondWithError(w, http.StatusInternalServerError, "Failed to get categories", nil)
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (c *CategoryHandler) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+  includeInactive, ok := c.parseIncludeInactive(w, r)
+  if !ok {
+	return
+  }
+
+  response, err := c.categoryService.GetCategoryTree(r.Context(), includeInactive)
+  if err != nil {
+	c.respondWithError(w, http.StatusInternalServerError, "Failed to get category tree", nil)
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (c *CategoryHandler) GetCategoryByID(w http.ResponseWriter, r *http.Request) {
+  req := dto.GetCategoryByIDRequest{
+	ID: chi.URLParam(r, "id"),
+  }
+
+  if err := c.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	c.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := c.categoryService.GetCategoryByID(r.Context(), req.ID)
+  if err != nil {
+	c.handleCategoryError(w, err, "Failed to get category")
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (c *CategoryHandler) GetBreadcrumbs(w http.ResponseWriter, r *http.Request) {
+  req := dto.GetCategoryByIDRequest{
+	ID: chi.URLParam(r, "id"),
+  }
+
+  if err := c.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	c.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := c.categoryService.GetBreadcrumbs(r.Context(), req.ID)
+  if err != nil {
+	c.handleCategoryError(w, err, "Failed to get breadcrumbs")
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (c *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+  var req dto.CreateCategoryRequest
+
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	c.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := c.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	c.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := c.categoryService.CreateCategory(r.Context(), &req)
+  if err != nil {
+	c.handleCategoryError(w, err, "Failed to create category")
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (c *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+  categoryID := chi.URLParam(r, "id")
+
+  var req dto.UpdateCategoryRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	c.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := c.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	c.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := c.categoryService.UpdateCategory(r.Context(), categoryID, &req)
+  if err != nil {
+	c.handleCategoryError(w, err, "Failed to update category")
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (c *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+  categoryID := chi.URLParam(r, "id")
+
+  response, err := c.categoryService.DeleteCategory(r.Context(), categoryID)
+  if err != nil {
+	c.handleCategoryError(w, err, "Failed to delete category")
+	return
+  }
+
+  c.respondWithSuccess(w, http.StatusOK, response)
+}
+
+// parseIncludeInactive only honours include_inactive for admins; everyone
+// else always gets the active catalog.
+func (c *CategoryHandler) parseIncludeInactive(w http.ResponseWriter, r *http.Request) (bool, bool) {
+  includeStr := r.URL.Query().Get("include_inactive")
+  if includeStr == "" {
+	return false, true
+  }
+
+  includeInactive, err := strconv.ParseBool(includeStr)
+  if err != nil {
+	c.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid include_inactive value: %s", includeStr), nil)
+	return false, false
+  }
+
+  if userID, ok := middleware.GetUserID(r.Context()); !ok || userID == "" || !middleware.IsAdmin(r.Context()) {
+	return false, true
+  }
+
+  return includeInactive, true
+}
+
+func (c *CategoryHandler) handleCategoryError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrInvalidID):
+	c.respondWithError(w, http.StatusBadRequest, "Invalid category ID", nil)
+  case errors.Is(err, service.ErrCategoryNotFound):
+	c.respondWithError(w, http.StatusNotFound, "Category not found", nil)
+  case errors.Is(err, service.ErrParentCategoryNotFound):
+	c.respondWithError(w, http.StatusUnprocessableEntity, "Parent category not found", nil)
+  case errors.Is(err, service.ErrCategoryCycle):
+	c.respondWithError(w, http.StatusConflict, "Category cannot be moved under itself or one of its descendants", nil)
+  case errors.Is(err, service.ErrDuplicateCategorySlug):
+	c.respondWithError(w, http.StatusConflict, "Category with this slug already exists", nil)
+  case errors.Is(err, service.ErrCategoryHasChildren):
+	c.respondWithError(w, http.StatusConflict, "Category has subcategories", nil)
+  case errors.Is(err, service.ErrCategoryInUse):
+	c.respondWithError(w, http.StatusConflict, "Category still has products", nil)
+  case errors.Is(err, service.ErrInvalidSlug):
+	c.respondWithError(w, http.StatusUnprocessableEntity, "A slug could not be generated from the name", nil)
+  default:
+	c.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
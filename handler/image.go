@@ -0,0 +1,156 @@
+package handler
+
+import (
+  "io"
+  "errors"
+  "context"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+const (
+  // maxImageUploadBytes leaves room for the multipart envelope around the
+  // image itself.
+  maxImageUploadBytes = service.MaxImageSize + 1<<20
+  maxImageFormMemory = 2 << 20
+)
+
+type ImageService interface {
+  UploadImage(ctx context.Context, productID string, r io.Reader) (*dto.UploadProductImageResponse, error)
+  ListImages(ctx context.Context, productID string) (*dto.ListProductImagesResponse, error)
+  ReorderImages(ctx context.Context, productID string, req *dto.ReorderProductImagesRequest) (*dto.ListProductImagesResponse, error)
+  DeleteImage(ctx context.Context, productID, imageID string) (*dto.DeleteProductImageResponse, error)
+}
+
+type ImageHandler struct {
+  BaseHandler
+  imageService ImageService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewImageHandler(imageService ImageService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *ImageHandler {
+  return &ImageHandler{
+	imageService: imageService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (i *ImageHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/products/{id}/images", func(r chi.Router) {
+	r.Use(i.authMiddleware.Authenticate)
+
+	r.Get("/", i.ListImages)
+
+	r.Group(func(r chi.Router) {
+	  r.Use(middleware.RequireAuth)
+	  r.Use(middleware.RequireAdmin)
+
+	  r.Post("/", i.UploadImage)
+	  r.Put("/order", i.ReorderImages)
+	  r.Delete("/{image_id}", i.DeleteImage)
+	})
+  })
+}
+
+func (i *ImageHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
+  r.Body = http.MaxBytesReader(w, r.Body, maxImageUploadBytes)
+
+  if err := r.ParseMultipartForm(maxImageFormMemory); err != nil {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+	  i.respondWithError(w, http.StatusRequestEntityTooLarge, "Image exceeds the maximum upload size", nil)
+	  return
+	}
+	i.respondWithError(w, http.StatusBadRequest, "Cannot parse multipart form: " + err.Error(), nil)
+	return
+  }
+  defer r.MultipartForm.RemoveAll()
+
+  file, _, err := r.FormFile("image")
+  if err != nil {
+	i.respondWithError(w, http.StatusBadRequest, "Missing image file", map[string]string{"image": "image is required"})
+	return
+  }
+  defer file.Close()
+
+  response, err := i.imageService.UploadImage(r.Context(), chi.URLParam(r, "id"), file)
+  if err != nil {
+	i.handleImageError(w, err, "Failed to upload image")
+	return
+  }
+
+  i.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (i *ImageHandler) ListImages(w http.ResponseWriter, r *http.Request) {
+  response, err := i.imageService.ListImages(r.Context(), chi.URLParam(r, "id"))
+  if err != nil {
+	i.handleImageError(w, err, "Failed to get images")
+	return
+  }
+
+  i.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (i *ImageHandler) ReorderImages(w http.ResponseWriter, r *http.Request) {
+  var req dto.ReorderProductImagesRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	i.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := i.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	i.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := i.imageService.ReorderImages(r.Context(), chi.URLParam(r, "id"), &req)
+  if err != nil {
+	i.handleImageError(w, err, "Failed to reorder images")
+	return
+  }
+
+  i.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (i *ImageHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+  response, err := i.imageService.DeleteImage(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "image_id"))
+  if err != nil {
+	i.handleImageError(w, err, "Failed to delete image")
+	return
+  }
+
+  i.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (i *ImageHandler) handleImageError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrInvalidID):
+	i.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+  case errors.Is(err, service.ErrProductNotFound):
+	i.respondWithError(w, http.StatusNotFound, "Product not found", nil)
+  case errors.Is(err, service.ErrImageNotFound):
+	i.respondWithError(w, http.StatusNotFound, "Image not found", nil)
+  case errors.Is(err, service.ErrImageTooLarge):
+	i.respondWithError(w, http.StatusRequestEntityTooLarge, "Image exceeds the maximum size or resolution", nil)
+  case errors.Is(err, service.ErrUnsupportedImageType):
+	i.respondWithError(w, http.StatusUnsupportedMediaType, "Only JPEG, PNG and GIF images are supported", nil)
+  case errors.Is(err, service.ErrInvalidImage):
+	i.respondWithError(w, http.StatusUnprocessableEntity, "Image file is corrupt or truncated", nil)
+  case errors.Is(err, service.ErrImageLimitReached):
+	i.respondWithError(w, http.StatusConflict, "Product already has the maximum number of images", nil)
+  case errors.Is(err, service.ErrImageOrderMismatch):
+	i.respondWithError(w, http.StatusUnprocessableEntity, "Image order must list every product image exactly once", nil)
+  default:
+	i.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+  "fmt"
+  "errors"
+  "context"
+  "strconv"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type InventoryService interface {
+  ListStockMovements(ctx context.Context, productID string, req dto.GetStockMovementsRequest) (*dto.ListStockMovementsResponse, error)
+  GetLowStockReport(ctx context.Context, req dto.GetLowStockRequest) (*dto.LowStockReportResponse, error)
+}
+
+type InventoryHandler struct {
+  BaseHandler
+  inventoryService InventoryService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewInventoryHandler(inventoryService InventoryService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *InventoryHandler {
+  return &InventoryHandler{
+	inventoryService: inventoryService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (i *InventoryHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/products/{id}/stock-movements", func(r chi.Router) {
+	r.Use(i.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+	r.Use(middleware.RequireAdmin)
+
+	r.Get("/", i.ListStockMovements)
+  })
+
+  router.Route("/admin/inventory", func(r chi.Router) {
+	r.Use(i.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+	r.Use(middleware.RequireAdmin)
+
+	r.Get("/low-stock", i.GetLowStockReport)
+  })
+}
+
+func (i *InventoryHandler) ListStockMovements(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+
+  req := dto.GetStockMovementsRequest{
+	Limit: 50,
+	Offset: 0,
+  }
+
+  if variantID := query.Get("variant_id"); variantID != "" {
+	req.VariantID = &variantID
+  }
+
+  if limitStr := query.Get("limit"); limitStr != "" {
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+	  i.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit value '%s': must be an integer", limitStr), nil)
+	  return
+	}
+	req.Limit = limit
+  }
+
+  if offsetStr := query.Get("offset"); offsetStr != "" {
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+	  i.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset value '%s': must be an integer", offsetStr), nil)
+	  return
+	}
+	req.Offset = offset
+  }
+
+  if err := i.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	i.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := i.inventoryService.ListStockMovements(r.Context(), chi.URLParam(r, "id"), req)
+  if err != nil {
+	i.handleInventoryError(w, err, "Failed to get stock movements")
+	return
+  }
+
+  i.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (i *InventoryHandler) GetLowStockReport(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+
+  req := dto.GetLowStockRequest{
+	Limit: 50,
+	Offset: 0,
+  }
+
+  if limitStr := query.Get("limit"); limitStr != "" {
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+	  i.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit value '%s': must be an integer", limitStr), nil)
+	  return
+	}
+	req.Limit = limit
+  }
+
+  if offsetStr := query.Get("offset"); offsetStr != "" {
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+	  i.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset value '%s': must be an integer", offsetStr), nil)
+	  return
+	}
+	req.Offset = offset
+  }
+
+  if err := i.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	i.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := i.inventoryService.GetLowStockReport(r.Context(), req)
+  if err != nil {
+	i.handleInventoryError(w, err, "Failed to get low stock report")
+	return
+  }
+
+  i.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (i *InventoryHandler) handleInventoryError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrInvalidID):
+	i.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+  case errors.Is(err, service.ErrProductNotFound):
+	i.respondWithError(w, http.StatusNotFound, "Product not found", nil)
+  default:
+	i.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
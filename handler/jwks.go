@@ -0,0 +1,37 @@
+package handler
+
+import (
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+)
+
+type KeyPublisher interface {
+  JWKS() auth.JWKS
+}
+
+// JWKSHandler publishes the public keys access tokens are signed with, so
+// other services can verify them without sharing a secret.
+type JWKSHandler struct {
+  BaseHandler
+  keys KeyPublisher
+}
+
+func NewJWKSHandler(keys KeyPublisher) *JWKSHandler {
+  return &JWKSHandler{
+	keys: keys,
+  }
+}
+
+func (h *JWKSHandler) RegisterRoutes(router chi.Router) {
+  router.Get("/.well-known/jwks.json", h.GetJWKS)
+}
+
+// GetJWKS is cached for a few minutes only: a key has to be published for at
+// least that long before it starts signing.
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+  w.Header().Set("Cache-Control", "public, max-age=300")
+  h.respondWithSuccess(w, http.StatusOK, h.keys.JWKS())
+}
@@ -14,14 +14,10 @@ import (
 
   "github.com/F-Dupraz/ecommerce-with-go/dto"
   "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
 )
 
-type AuthUser struct {
-    ID    string
-    Email string
-    Role  string
-}
-
 type OrderService interface {
     CreateOrder(ctx context.Context, req *dto.CreateOrderRequest) (*dto.CreateOrderResponse, error)
     ListOrders(ctx context.Context, req dto.ListOrdersRequest) (*dto.ListOrdersResponse, error)
@@ -33,32 +29,34 @@ type OrderService interface {
 type OrderHandler struct {
   BaseHandler
   orderService OrderService
+  authMiddleware *middleware.AuthMiddleware
 }
 
-func NewOrderHandler(orderService OrderService, validator *validator.Validate) *OrderHandler {
+func NewOrderHandler(orderService OrderService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *OrderHandler {
   return &OrderHandler{
 	orderService: orderService,
-	BaseHandler: BaseHandler{validator: validator}
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
   }
 }
 
 func (o *OrderHandler) RegisterRoutes(router chi.Router) {
     router.Route("/orders", func(r chi.Router) {
+        r.Use(o.authMiddleware.Authenticate)
+        r.Use(middleware.RequireAuth)
+
         r.Get("/", o.GetOrders)
         r.Get("/{id}", o.GetOrderByID)
         r.Post("/", o.CreateOrder)
-        r.Put("/{id}", o.UpdateOrderStatus)
         r.Delete("/{id}", o.DeleteOrder)
+
+        r.With(middleware.RequireAdmin).Put("/{id}", o.UpdateOrderStatus)
     })
 }
 
 func (o *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
-    user, ok := r.Context().Value("user").(AuthUser)
-    if !ok {
-        o.respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
-        return
-    }
-    
+    userID, _ := middleware.GetUserID(r.Context())
+
     var req dto.CreateOrderRequest
     
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -71,16 +69,24 @@ func (o *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
         o.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
         return
     }
+
+    req.UserID = userID
     
     response, err := o.orderService.CreateOrder(r.Context(), &req)
     if err != nil {
         switch {
-        case errors.Is(err, ErrInsufficientStock):
+        case errors.Is(err, service.ErrInsufficientStock):
             o.respondWithError(w, http.StatusConflict, "Insufficient stock for one or more items", nil)
-        case errors.Is(err, ErrProductNotFound):
+        case errors.Is(err, service.ErrProductNotFound):
             o.respondWithError(w, http.StatusNotFound, "One or more products not found", nil)
-        case errors.Is(err, ErrInvalidShippingAddress):
-            o.respondWithError(w, http.StatusBadRequest, "Invalid shipping address", nil)
+        case errors.Is(err, service.ErrVariantNotFound):
+            o.respondWithError(w, http.StatusNotFound, "One or more variants not found", nil)
+        case errors.Is(err, service.ErrVariantRequired):
+            o.respondWithError(w, http.StatusUnprocessableEntity, "A variant must be selected for products with variants", nil)
+        case errors.Is(err, service.ErrProductUnavailable):
+            o.respondWithError(w, http.StatusConflict, "One or more products are not available for sale", nil)
+        case errors.Is(err, service.ErrEmailNotVerified):
+            o.respondWithError(w, http.StatusForbidden, "Verify your email address before placing an order", nil)
         default:
             o.respondWithError(w, http.StatusInternalServerError, "Failed to create order", nil)
         }
@@ -91,11 +97,7 @@ func (o *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 }
 
 func (o *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
-    user, ok := r.Context().Value("user").(AuthUser)
-    if !ok {
-        o.respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
-        return
-    }
+    userID, _ := middleware.GetUserID(r.Context())
 
     req := dto.ListOrdersRequest{
         Limit:     10,
@@ -165,9 +167,11 @@ func (o *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
         req.MaxAmount = &maxAmount
     }
 
-    if user.Role == "admin" && query.Get("user_id") != "" {
-        userID := query.Get("user_id")
+    // Customers only ever see their own orders.
+    if !middleware.IsAdmin(r.Context()) {
         req.UserID = &userID
+    } else if filterUserID := query.Get("user_id"); filterUserID != "" {
+        req.UserID = &filterUserID
     }
 
     if orderNumber := query.Get("order_number"); orderNumber != "" {
@@ -182,6 +186,9 @@ func (o *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
         req.SortOrder = sortOrder
     }
 
+    req.Cursor = query.Get("cursor")
+    req.Total = query.Get("total")
+
     if err := o.validator.Struct(req); err != nil {
         validationErrors := dto.FormatValidationErrors(err)
         o.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
@@ -194,6 +201,10 @@ func (o *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
     orders, err = o.orderService.ListOrders(r.Context(), req)
 
     if err != nil {
+        if errors.Is(err, service.ErrInvalidCursor) {
+            o.respondWithError(w, http.StatusBadRequest, "Invalid cursor, it doesn't match the requested sort", nil)
+            return
+        }
         o.respondWithError(w, http.StatusInternalServerError, "Failed to get orders", nil)
         return
     }
@@ -202,12 +213,6 @@ func (o *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
 }
 
 func (o *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
-    user, ok := r.Context().Value("user").(AuthUser)
-    if !ok {
-        o.respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
-        return
-    }
-
     orderID := chi.URLParam(r, "id")
     if orderID == "" {
         o.respondWithError(w, http.StatusBadRequest, "Order ID is required", nil)
@@ -224,16 +229,8 @@ func (o *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
         includeItems = parsedBool
     }
 
-    response, err := o.orderService.GetOrderByID(r.Context(), orderID, includeItems)
-    if err != nil {
-        switch {
-        case errors.Is(err, ErrOrderNotFound):
-            o.respondWithError(w, http.StatusNotFound, "Order not found", nil)
-        case errors.Is(err, ErrForbidden):
-            o.respondWithError(w, http.StatusForbidden, "You don't have permission to view this order", nil)
-        default:
-            o.respondWithError(w, http.StatusInternalServerError, "Failed to get order", nil)
-        }
+    response, ok := o.getOwnOrder(w, r, orderID, includeItems, "You don't have permission to view this order")
+    if !ok {
         return
     }
 
@@ -241,22 +238,11 @@ func (o *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (o *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
-	user, ok := r.Context().Value("user").(AuthUser)
-    if !ok {
-        o.respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
+    orderID := chi.URLParam(r, "id")
+    if orderID == "" {
+        o.respondWithError(w, http.StatusBadRequest, "Order ID is required", nil)
         return
     }
-   
-	if user.Role != "admin" {
-		o.respondWithError(w, http.StatusForbidden, "Only admins can update the status of orders", nil)
-		return
-	}
-
-	orderID := chi.URLParam(r, "id")
-	if orderID == "" {
-		o.respondWithError(w, http.StatusBadRequest, "Order ID is required", nil)
-		return
-	}
 
     var req dto.UpdateOrderStatusRequest
     
@@ -270,58 +256,92 @@ func (o *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request)
         o.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
         return
     }
+
+    req.ActorID, _ = middleware.GetUserID(r.Context())
     
     response, err := o.orderService.UpdateOrderStatus(r.Context(), orderID, &req)
     if err != nil {
-	  o.respondWithError(w, http.StatusInternalServerError, "Failed to update the order status!", nil)
-	  return
+        switch {
+        case errors.Is(err, service.ErrOrderNotFound):
+            o.respondWithError(w, http.StatusNotFound, "Order not found", nil)
+        case errors.Is(err, service.ErrInvalidStatusTransition):
+            o.respondWithError(w, http.StatusConflict, "The order can't move to that status from its current one", nil)
+        case errors.Is(err, service.ErrOrderStatusChanged):
+            o.respondWithError(w, http.StatusConflict, "The order status changed in the meantime, try again", nil)
+        case errors.Is(err, service.ErrInsufficientStock):
+            o.respondWithError(w, http.StatusConflict, "Not enough stock to apply the status change", nil)
+        default:
+            o.respondWithError(w, http.StatusInternalServerError, "Failed to update the order status", nil)
+        }
+        return
     }
 
     o.respondWithSuccess(w, http.StatusOK, response)
 }
 
+// DeleteOrder cancels an order. Customers can only cancel their own.
 func (o *OrderHandler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
-	user, ok := r.Context().Value("user").(AuthUser)
-    if !ok {
-        o.respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
+    orderID := chi.URLParam(r, "id")
+    if orderID == "" {
+        o.respondWithError(w, http.StatusBadRequest, "Order ID is required", nil)
         return
     }
 
-	order_id := chi.URLParam(r, "id")
-	if order_id == "" {
-        o.respondWithError(w, http.StatusBadRequest, "Order ID is required", nil)
+    var req dto.CancelOrderRequest
+
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        o.respondWithError(w, http.StatusBadRequest, "Invalid request body", nil)
+        return
+    }
+
+    if err := o.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        o.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return
+    }
+
+    if _, ok := o.getOwnOrder(w, r, orderID, false, "You don't have permission to cancel this order"); !ok {
+        return
+    }
+
+    req.ActorID, _ = middleware.GetUserID(r.Context())
+
+    response, err := o.orderService.CancelOrder(r.Context(), orderID, &req)
+    if err != nil {
+        switch {
+        case errors.Is(err, service.ErrOrderNotFound):
+            o.respondWithError(w, http.StatusNotFound, "Order not found", nil)
+        case errors.Is(err, service.ErrOrderNotCancellable):
+            o.respondWithError(w, http.StatusConflict, err.Error(), nil)
+        case errors.Is(err, service.ErrOrderStatusChanged):
+            o.respondWithError(w, http.StatusConflict, "The order status changed in the meantime, try again", nil)
+        default:
+            o.respondWithError(w, http.StatusInternalServerError, "Failed to cancel order", nil)
+        }
         return
     }
 
-	var req dto.CancelOrderRequest
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		o.respondWithError(w, http.StatusBadRequest, "Invalid request body", nil)
-		return
-	}
-
-	if err := o.validator.Struct(cancelReq); err != nil {
-		validationErrors := dto.FormatValidationErrors(err)
-		o.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
-		return
-	}
-
-	response, err := o.orderService.CancelOrder(r.Context(), orderID, &cancelReq)
-	if err != nil {
-		switch {
-		case errors.Is(err, ErrOrderNotFound):
-			o.respondWithError(w, http.StatusNotFound, "Order not found", nil)
-		case errors.Is(err, ErrForbidden):
-			o.respondWithError(w, http.StatusForbidden, "You don't have permission to cancel this order", nil)
-		case errors.Is(err, ErrOrderNotCancellable):
-			o.respondWithError(w, http.StatusConflict, err.Error(), nil)
-		case errors.Is(err, ErrMissingReason):
-			o.respondWithError(w, http.StatusBadRequest, "Cancellation reason is required", nil)
-		default:
-			o.respondWithError(w, http.StatusInternalServerError, "Failed to cancel order", nil)
-		}
-		return
-	}
-
-	o.respondWithSuccess(w, http.StatusOK, response)
+    o.respondWithSuccess(w, http.StatusOK, response)
+}
+
+// getOwnOrder loads an order the caller may act on: any order for admins,
+// only their own for customers. On failure it writes the error response.
+func (o *OrderHandler) getOwnOrder(w http.ResponseWriter, r *http.Request, orderID string, includeItems bool, forbidden string) (*dto.OrderResponse, bool) {
+    order, err := o.orderService.GetOrderByID(r.Context(), orderID, includeItems)
+    if err != nil {
+        if errors.Is(err, service.ErrOrderNotFound) {
+            o.respondWithError(w, http.StatusNotFound, "Order not found", nil)
+        } else {
+            o.respondWithError(w, http.StatusInternalServerError, "Failed to get order", nil)
+        }
+        return nil, false
+    }
+
+    userID, _ := middleware.GetUserID(r.Context())
+    if !middleware.IsAdmin(r.Context()) && order.UserID != userID {
+        o.respondWithError(w, http.StatusForbidden, forbidden, nil)
+        return nil, false
+    }
+
+    return order, true
 }
@@ -0,0 +1,162 @@
+package handler
+
+import (
+  "fmt"
+  "errors"
+  "context"
+  "strconv"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type PriceService interface {
+  GetPriceHistory(ctx context.Context, productID string, req dto.GetPriceHistoryRequest) (*dto.PriceHistoryResponse, error)
+  ListScheduledPrices(ctx context.Context, productID string) (*dto.ListScheduledPricesResponse, error)
+  SchedulePrice(ctx context.Context, productID string, req *dto.CreateScheduledPriceRequest) (*dto.CreateScheduledPriceResponse, error)
+  CancelScheduledPrice(ctx context.Context, productID, scheduleID string) (*dto.CancelScheduledPriceResponse, error)
+}
+
+type PriceHandler struct {
+  BaseHandler
+  priceService PriceService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewPriceHandler(priceService PriceService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *PriceHandler {
+  return &PriceHandler{
+	priceService: priceService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (p *PriceHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/products/{id}/price-history", func(r chi.Router) {
+	r.Use(p.authMiddleware.Authenticate)
+
+	r.Get("/", p.GetPriceHistory)
+  })
+
+  router.Route("/products/{id}/scheduled-prices", func(r chi.Router) {
+	r.Use(p.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+	r.Use(middleware.RequireAdmin)
+
+	r.Get("/", p.ListScheduledPrices)
+	r.Post("/", p.SchedulePrice)
+	r.Delete("/{schedule_id}", p.CancelScheduledPrice)
+  })
+}
+
+func (p *PriceHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+
+  req := dto.GetPriceHistoryRequest{
+	Limit: 50,
+	Offset: 0,
+  }
+
+  if limitStr := query.Get("limit"); limitStr != "" {
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+	  p.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit value '%s': must be an integer", limitStr), nil)
+	  return
+	}
+	req.Limit = limit
+  }
+
+  if offsetStr := query.Get("offset"); offsetStr != "" {
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+	  p.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset value '%s': must be an integer", offsetStr), nil)
+	  return
+	}
+	req.Offset = offset
+  }
+
+  if err := p.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	p.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := p.priceService.GetPriceHistory(r.Context(), chi.URLParam(r, "id"), req)
+  if err != nil {
+	p.handlePriceError(w, err, "Failed to get price history")
+	return
+  }
+
+  p.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (p *PriceHandler) ListScheduledPrices(w http.ResponseWriter, r *http.Request) {
+  response, err := p.priceService.ListScheduledPrices(r.Context(), chi.URLParam(r, "id"))
+  if err != nil {
+	p.handlePriceError(w, err, "Failed to get scheduled prices")
+	return
+  }
+
+  p.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (p *PriceHandler) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+  var req dto.CreateScheduledPriceRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	p.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := p.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	p.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  req.CreatedBy, _ = middleware.GetUserID(r.Context())
+
+  response, err := p.priceService.SchedulePrice(r.Context(), chi.URLParam(r, "id"), &req)
+  if err != nil {
+	p.handlePriceError(w, err, "Failed to schedule price")
+	return
+  }
+
+  p.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (p *PriceHandler) CancelScheduledPrice(w http.ResponseWriter, r *http.Request) {
+  response, err := p.priceService.CancelScheduledPrice(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "schedule_id"))
+  if err != nil {
+	p.handlePriceError(w, err, "Failed to cancel scheduled price")
+	return
+  }
+
+  p.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (p *PriceHandler) handlePriceError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrInvalidID):
+	p.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+  case errors.Is(err, service.ErrProductNotFound):
+	p.respondWithError(w, http.StatusNotFound, "Product not found", nil)
+  case errors.Is(err, service.ErrScheduledPriceNotFound):
+	p.respondWithError(w, http.StatusNotFound, "Scheduled price not found", nil)
+  case errors.Is(err, service.ErrInvalidPrice):
+	p.respondWithError(w, http.StatusUnprocessableEntity, "Price must be greater than cost", nil)
+  case errors.Is(err, service.ErrScheduleInPast):
+	p.respondWithError(w, http.StatusUnprocessableEntity, "Scheduled price must end in the future", nil)
+  case errors.Is(err, service.ErrScheduledPriceOverlap):
+	p.respondWithError(w, http.StatusConflict, "Another price change is scheduled for this period", nil)
+  case errors.Is(err, service.ErrScheduledPriceNotCancellable):
+	p.respondWithError(w, http.StatusConflict, "Scheduled price has already finished", nil)
+  default:
+	p.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
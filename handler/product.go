@@ -20,7 +20,10 @@ type ProductService interface {
 	CreateProduct(ctx context.Context, prod *dto.CreateProductRequest) (*dto.CreateProductResponse, error)
     ListProducts(ctx context.Context, req dto.ListProductsRequest) (*dto.ListProductsResponse, error)
     GetProductByID(ctx context.Context, prodID string) (*dto.ProductResponse, error)
+    RecordProductView(ctx context.Context, prodID string)
     GetProductsByCategory(ctx context.Context, req *dto.GetProductsByCategoryRequest) (*dto.ListProductsResponse, error)
+    GetRelatedProducts(ctx context.Context, prodID string, limit int) (*dto.ListProductsResponse, error)
+    GetProductsByBrand(ctx context.Context, req *dto.GetProductsByBrandRequest) (*dto.ListProductsResponse, error)
     SearchProducts(ctx context.Context, req dto.SearchProductsRequest) (*dto.SearchProductsResponse, error)
     UpdateProduct(ctx context.Context, prodID string, prod *dto.UpdateProductRequest) (*dto.UpdateProductResponse, error)
     UpdateProductStock(ctx context.Context, prodID string, req *dto.UpdateProductStockRequest) (*dto.UpdateProductStockResponse, error)
@@ -46,16 +49,18 @@ func (p *ProductHandler) RegisterRoutes(router chi.Router) {
 		r.Use(p.authMiddleware.Authenticate)
 
 		r.Group(func(r chi.Router) {
-			r.Use(p.authMiddleware.RequireAuth)
+			r.Use(middleware.RequireAuth)
 
 			r.Get("/", p.GetProducts)
+			r.Get("/search", p.SearchProducts)
 			r.Get("/{id}", p.GetProductByID)
+			r.Get("/{id}/related", p.GetRelatedProducts)
 			r.Get("/category/{id}", p.GetProductByCategory)
 		})
 	
 		r.Group(func(r chi.Router) {
-			r.Use(p.authMiddleware.RequireAuth)
-			r.Use(p.authMiddleware.RequireAdmin)
+			r.Use(middleware.RequireAuth)
+			r.Use(middleware.RequireAdmin)
 
 			r.Post("/", p.CreateProduct)
 			r.Put("/{id}", p.UpdateProduct)
@@ -125,6 +130,21 @@ func (p *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
     if tags := query["tags"]; len(tags) > 0 {
         req.Tags = tags
     }
+
+    if tagMatch := query.Get("tag_match"); tagMatch != "" {
+        req.TagMatch = tagMatch
+    }
+
+    if sortBy := query.Get("sort_by"); sortBy != "" {
+        req.SortBy = sortBy
+    }
+
+    if sortOrder := query.Get("sort_order"); sortOrder != "" {
+        req.SortOrder = sortOrder
+    }
+
+    req.Cursor = query.Get("cursor")
+    req.Total = query.Get("total")
     
     if err := p.validator.Struct(req); err != nil {
         validationErrors := dto.FormatValidationErrors(err)
@@ -139,6 +159,8 @@ func (p *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
             p.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
         case errors.Is(err, service.ErrInvalidParams):
             p.respondWithError(w, http.StatusBadRequest, "Invalid parameters", nil)
+        case errors.Is(err, service.ErrInvalidCursor):
+            p.respondWithError(w, http.StatusBadRequest, "Invalid cursor, it doesn't match the requested sort", nil)
         default:
             p.respondWithError(w, http.StatusInternalServerError, "Failed to get products", nil)
         }
@@ -148,6 +170,59 @@ func (p *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
     p.respondWithSuccess(w, http.StatusOK, response)
 }
 
+func (p *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query()
+
+    req := dto.SearchProductsRequest{
+        Query:  query.Get("q"),
+        Limit:  20,
+        Offset: 0,
+        Cursor: query.Get("cursor"),
+        Total:  query.Get("total"),
+    }
+
+    if limitStr := query.Get("limit"); limitStr != "" {
+        limit, err := strconv.Atoi(limitStr)
+        if err != nil {
+            p.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit value '%s': must be an integer", limitStr), nil)
+            return
+        }
+        req.Limit = limit
+    }
+
+    if offsetStr := query.Get("offset"); offsetStr != "" {
+        offset, err := strconv.Atoi(offsetStr)
+        if err != nil {
+            p.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset value '%s': must be an integer", offsetStr), nil)
+            return
+        }
+        req.Offset = offset
+    }
+
+    if categoryID := query.Get("category_id"); categoryID != "" {
+        req.CategoryID = &categoryID
+    }
+
+    if err := p.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        p.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return
+    }
+
+    response, err := p.productService.SearchProducts(r.Context(), req)
+    if err != nil {
+        switch {
+        case errors.Is(err, service.ErrInvalidCursor):
+            p.respondWithError(w, http.StatusBadRequest, "Invalid cursor, it doesn't match the requested search", nil)
+        default:
+            p.respondWithError(w, http.StatusInternalServerError, "Failed to search products", nil)
+        }
+        return
+    }
+
+    p.respondWithSuccess(w, http.StatusOK, response)
+}
+
 func (p *ProductHandler) GetProductByCategory(w http.ResponseWriter, r *http.Request) {
     categoryID := chi.URLParam(r, "id")
     if categoryID == "" {
@@ -176,8 +251,19 @@ func (p *ProductHandler) GetProductByCategory(w http.ResponseWriter, r *http.Req
         offset = parsedOffset
     }
     
+    includeSubcategories := false
+    if includeStr := r.URL.Query().Get("include_subcategories"); includeStr != "" {
+        parsed, err := strconv.ParseBool(includeStr)
+        if err != nil {
+            p.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid include_subcategories value: %s", includeStr), nil)
+            return
+        }
+        includeSubcategories = parsed
+    }
+    
     req := &dto.GetProductsByCategoryRequest{
         CategoryID: categoryID,
+        IncludeSubcategories: includeSubcategories,
         Limit: limit,
         Offset: offset,
     }
@@ -213,10 +299,49 @@ func (p *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request)
         }
         return
     }
+
+    p.productService.RecordProductView(r.Context(), response.ID)
     
 	p.respondWithSuccess(w, http.StatusOK, response)
 }
 
+func (p *ProductHandler) GetRelatedProducts(w http.ResponseWriter, r *http.Request) {
+    req := dto.GetRelatedProductsRequest{
+        ProductID: chi.URLParam(r, "id"),
+        Limit:     10,
+    }
+
+    if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+        limit, err := strconv.Atoi(limitStr)
+        if err != nil {
+            p.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit value '%s': must be an integer", limitStr), nil)
+            return
+        }
+        req.Limit = limit
+    }
+
+    if err := p.validator.Struct(req); err != nil {
+        validationErrors := dto.FormatValidationErrors(err)
+        p.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+        return
+    }
+
+    response, err := p.productService.GetRelatedProducts(r.Context(), req.ProductID, req.Limit)
+    if err != nil {
+        switch {
+        case errors.Is(err, service.ErrInvalidID):
+            p.respondWithError(w, http.StatusBadRequest, "Invalid product ID format", nil)
+        case errors.Is(err, service.ErrProductNotFound):
+            p.respondWithError(w, http.StatusNotFound, "Product not found", nil)
+        default:
+            p.respondWithError(w, http.StatusInternalServerError, "Failed to get related products", nil)
+        }
+        return
+    }
+
+    p.respondWithSuccess(w, http.StatusOK, response)
+}
+
 func (p *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
     if !middleware.IsAdmin(r.Context()) {
         p.respondWithError(w, http.StatusForbidden, "Admin access required", nil)
@@ -243,6 +368,10 @@ func (p *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
             p.respondWithError(w, http.StatusBadRequest, "Price must be greater than cost price", nil)
         case errors.Is(err, service.ErrDuplicateSKU):
             p.respondWithError(w, http.StatusConflict, "Product with this SKU already exists", nil)
+        case errors.Is(err, service.ErrCategoryNotFound):
+            p.respondWithError(w, http.StatusUnprocessableEntity, "Category not found", nil)
+        case errors.Is(err, service.ErrBrandNotFound):
+            p.respondWithError(w, http.StatusUnprocessableEntity, "Brand not found", nil)
         default:
             p.respondWithError(w, http.StatusInternalServerError, "Failed to create product", nil)
         }
@@ -272,6 +401,8 @@ func (p *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	return
   }
 
+  req.ActorID, _ = middleware.GetUserID(r.Context())
+
   response, err := p.productService.UpdateProduct(r.Context(), prodID, &req)  // <-- UpdateProduct, no UpdateProductStock
   if err != nil {
     switch {
@@ -281,6 +412,10 @@ func (p *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
       p.respondWithError(w, http.StatusNotFound, "Product not found", nil)
     case errors.Is(err, service.ErrInvalidPrice):
       p.respondWithError(w, http.StatusBadRequest, "Price must be greater than cost", nil)
+    case errors.Is(err, service.ErrCategoryNotFound):
+      p.respondWithError(w, http.StatusUnprocessableEntity, "Category not found", nil)
+    case errors.Is(err, service.ErrBrandNotFound):
+      p.respondWithError(w, http.StatusUnprocessableEntity, "Brand not found", nil)
     default:
       p.respondWithError(w, http.StatusInternalServerError, "Failed to update product", nil)
     }
@@ -315,6 +450,8 @@ func (p *ProductHandler) UpdateProductStock(w http.ResponseWriter, r *http.Reque
         return
     }
     
+    req.ActorID, _ = middleware.GetUserID(r.Context())
+
     response, err := p.productService.UpdateProductStock(r.Context(), prodID, &req)
     if err != nil {
         switch {
@@ -326,6 +463,10 @@ func (p *ProductHandler) UpdateProductStock(w http.ResponseWriter, r *http.Reque
             p.respondWithError(w, http.StatusBadRequest, "Insufficient stock available", nil)
         case errors.Is(err, service.ErrStockBelowReserved):
             p.respondWithError(w, http.StatusConflict, "Cannot reduce stock below reserved amount", nil)
+        case errors.Is(err, service.ErrWarehouseNotFound):
+            p.respondWithError(w, http.StatusUnprocessableEntity, "Warehouse not found", nil)
+        case errors.Is(err, service.ErrWarehouseInactive):
+            p.respondWithError(w, http.StatusConflict, "Warehouse is inactive", nil)
         default:
             p.respondWithError(w, http.StatusInternalServerError, "Failed to update stock", nil)
         }
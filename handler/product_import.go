@@ -0,0 +1,194 @@
+package handler
+
+import (
+  "io"
+  "fmt"
+  "time"
+  "errors"
+  "context"
+  "strconv"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+const (
+  maxImportUploadBytes = service.MaxImportSize + 1<<20
+  maxImportFormMemory = 8 << 20
+)
+
+type ProductImportService interface {
+  StartImport(ctx context.Context, userID string, r io.Reader, dryRun bool) (*dto.StartImportResponse, error)
+  GetImportJob(ctx context.Context, jobID string) (*dto.ImportJobResponse, error)
+  ExportProducts(ctx context.Context, req *dto.ExportProductsRequest, w io.Writer) error
+}
+
+type ProductImportHandler struct {
+  BaseHandler
+  importService ProductImportService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewProductImportHandler(importService ProductImportService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *ProductImportHandler {
+  return &ProductImportHandler{
+	importService: importService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (p *ProductImportHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/admin/products", func(r chi.Router) {
+	r.Use(p.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+	r.Use(middleware.RequireAdmin)
+
+	r.Post("/import", p.StartImport)
+	r.Get("/imports/{id}", p.GetImportJob)
+	r.Get("/export", p.ExportProducts)
+  })
+}
+
+// StartImport accepts a multipart form with the CSV in the "file" field.
+// Passing dry_run=true only validates the rows.
+func (p *ProductImportHandler) StartImport(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  dryRun := false
+  if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+	value, err := strconv.ParseBool(dryRunStr)
+	if err != nil {
+	  p.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid dry_run value '%s': must be a boolean", dryRunStr), nil)
+	  return
+	}
+	dryRun = value
+  }
+
+  r.Body = http.MaxBytesReader(w, r.Body, maxImportUploadBytes)
+
+  if err := r.ParseMultipartForm(maxImportFormMemory); err != nil {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+	  p.respondWithError(w, http.StatusRequestEntityTooLarge, "Import file exceeds the maximum upload size", nil)
+	  return
+	}
+	p.respondWithError(w, http.StatusBadRequest, "Cannot parse multipart form: " + err.Error(), nil)
+	return
+  }
+  defer r.MultipartForm.RemoveAll()
+
+  file, _, err := r.FormFile("file")
+  if err != nil {
+	p.respondWithError(w, http.StatusBadRequest, "Missing import file", map[string]string{"file": "file is required"})
+	return
+  }
+  defer file.Close()
+
+  response, err := p.importService.StartImport(r.Context(), userID, file, dryRun)
+  if err != nil {
+	switch {
+	case errors.Is(err, service.ErrImportTooLarge):
+	  p.respondWithError(w, http.StatusRequestEntityTooLarge, "Import file exceeds the maximum upload size", nil)
+	case errors.Is(err, service.ErrInvalidImportFile):
+	  p.respondWithError(w, http.StatusUnprocessableEntity, err.Error(), nil)
+	default:
+	  p.respondWithError(w, http.StatusInternalServerError, "Failed to start import", nil)
+	}
+	return
+  }
+
+  p.respondWithSuccess(w, http.StatusAccepted, response)
+}
+
+func (p *ProductImportHandler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+  response, err := p.importService.GetImportJob(r.Context(), chi.URLParam(r, "id"))
+  if err != nil {
+	switch {
+	case errors.Is(err, service.ErrInvalidID):
+	  p.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+	case errors.Is(err, service.ErrImportJobNotFound):
+	  p.respondWithError(w, http.StatusNotFound, "Import job not found", nil)
+	default:
+	  p.respondWithError(w, http.StatusInternalServerError, "Failed to get import job", nil)
+	}
+	return
+  }
+
+  p.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (p *ProductImportHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+  req := dto.ExportProductsRequest{}
+
+  if categoryID := query.Get("category_id"); categoryID != "" {
+	req.CategoryID = &categoryID
+  }
+
+  if brandID := query.Get("brand_id"); brandID != "" {
+	req.BrandID = &brandID
+  }
+
+  if minPriceStr := query.Get("min_price"); minPriceStr != "" {
+	minPrice, err := strconv.ParseFloat(minPriceStr, 64)
+	if err != nil {
+	  p.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid min_price value '%s': must be a number", minPriceStr), nil)
+	  return
+	}
+	req.MinPrice = &minPrice
+  }
+
+  if maxPriceStr := query.Get("max_price"); maxPriceStr != "" {
+	maxPrice, err := strconv.ParseFloat(maxPriceStr, 64)
+	if err != nil {
+	  p.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid max_price value '%s': must be a number", maxPriceStr), nil)
+	  return
+	}
+	req.MaxPrice = &maxPrice
+  }
+
+  if inStockStr := query.Get("in_stock"); inStockStr != "" {
+	inStock, err := strconv.ParseBool(inStockStr)
+	if err != nil {
+	  p.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid in_stock value '%s': must be a boolean", inStockStr), nil)
+	  return
+	}
+	req.InStock = &inStock
+  }
+
+  if statusStr := query.Get("status"); statusStr != "" {
+	status := model.ProductStatus(statusStr)
+	req.Status = &status
+  }
+
+  if tags := query["tags"]; len(tags) > 0 {
+	req.Tags = tags
+  }
+
+  if tagMatch := query.Get("tag_match"); tagMatch != "" {
+	req.TagMatch = tagMatch
+  }
+
+  if err := p.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	p.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  filename := fmt.Sprintf("products-%s.csv", time.Now().UTC().Format("20060102-150405"))
+  w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+  w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+  w.WriteHeader(http.StatusOK)
+
+  // Headers are already sent, so a failure halfway can only cut the
+  // download short.
+  if err := p.importService.ExportProducts(r.Context(), &req, w); err != nil {
+	panic(http.ErrAbortHandler)
+  }
+}
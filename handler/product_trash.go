@@ -0,0 +1,106 @@
+package handler
+
+import (
+  "fmt"
+  "errors"
+  "context"
+  "strconv"
+  "net/http"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type ProductTrashService interface {
+  ListDeletedProducts(ctx context.Context, req dto.ListDeletedProductsRequest) (*dto.ListDeletedProductsResponse, error)
+  RestoreProduct(ctx context.Context, prodID string) (*dto.RestoreProductResponse, error)
+}
+
+type ProductTrashHandler struct {
+  BaseHandler
+  trashService ProductTrashService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewProductTrashHandler(trashService ProductTrashService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *ProductTrashHandler {
+  return &ProductTrashHandler{
+	trashService: trashService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (t *ProductTrashHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/admin/products/trash", func(r chi.Router) {
+	r.Use(t.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+	r.Use(middleware.RequireAdmin)
+
+	r.Get("/", t.ListDeletedProducts)
+	r.Post("/{id}/restore", t.RestoreProduct)
+  })
+}
+
+func (t *ProductTrashHandler) ListDeletedProducts(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+
+  req := dto.ListDeletedProductsRequest{
+	Limit: 20,
+	Offset: 0,
+  }
+
+  if limitStr := query.Get("limit"); limitStr != "" {
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+	  t.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit value '%s': must be an integer", limitStr), nil)
+	  return
+	}
+	req.Limit = limit
+  }
+
+  if offsetStr := query.Get("offset"); offsetStr != "" {
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+	  t.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset value '%s': must be an integer", offsetStr), nil)
+	  return
+	}
+	req.Offset = offset
+  }
+
+  if err := t.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	t.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := t.trashService.ListDeletedProducts(r.Context(), req)
+  if err != nil {
+	t.respondWithError(w, http.StatusInternalServerError, "Failed to get deleted products", nil)
+	return
+  }
+
+  t.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (t *ProductTrashHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+  response, err := t.trashService.RestoreProduct(r.Context(), chi.URLParam(r, "id"))
+  if err != nil {
+	switch {
+	case errors.Is(err, service.ErrInvalidID):
+	  t.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+	case errors.Is(err, service.ErrProductNotFound):
+	  t.respondWithError(w, http.StatusNotFound, "Deleted product not found", nil)
+	case errors.Is(err, service.ErrDuplicateSKU):
+	  t.respondWithError(w, http.StatusConflict, "Another product is already using this SKU", nil)
+	default:
+	  t.respondWithError(w, http.StatusInternalServerError, "Failed to restore product", nil)
+	}
+	return
+  }
+
+  t.respondWithSuccess(w, http.StatusOK, response)
+}
@@ -0,0 +1,251 @@
+package handler
+
+import (
+  "fmt"
+  "errors"
+  "context"
+  "strconv"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type ReviewService interface {
+  CreateReview(ctx context.Context, productID string, req *dto.CreateReviewRequest) (*dto.CreateReviewResponse, error)
+  ListProductReviews(ctx context.Context, productID string, req dto.ListReviewsRequest) (*dto.ListReviewsResponse, error)
+  ListReviewQueue(ctx context.Context, req dto.ListReviewQueueRequest) (*dto.ReviewQueueResponse, error)
+  ModerateReview(ctx context.Context, reviewID string, req *dto.ModerateReviewRequest) (*dto.ModerateReviewResponse, error)
+  VoteReview(ctx context.Context, reviewID string, req *dto.VoteReviewRequest) (*dto.VoteReviewResponse, error)
+}
+
+type ReviewHandler struct {
+  BaseHandler
+  reviewService ReviewService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewReviewHandler(reviewService ReviewService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *ReviewHandler {
+  return &ReviewHandler{
+	reviewService: reviewService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (h *ReviewHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/products/{id}/reviews", func(r chi.Router) {
+	r.Use(h.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+
+	r.Get("/", h.ListProductReviews)
+	r.Post("/", h.CreateReview)
+  })
+
+  router.Route("/reviews/{review_id}/votes", func(r chi.Router) {
+	r.Use(h.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+
+	r.Put("/", h.VoteReview)
+  })
+
+  router.Route("/admin/reviews", func(r chi.Router) {
+	r.Use(h.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+	r.Use(middleware.RequireAdmin)
+
+	r.Get("/", h.ListReviewQueue)
+	r.Patch("/{review_id}", h.ModerateReview)
+  })
+}
+
+func (h *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
+  var req dto.CreateReviewRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	h.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := h.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  req.UserID, _ = middleware.GetUserID(r.Context())
+
+  response, err := h.reviewService.CreateReview(r.Context(), chi.URLParam(r, "id"), &req)
+  if err != nil {
+	h.handleReviewError(w, err, "Failed to create review")
+	return
+  }
+
+  h.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (h *ReviewHandler) ListProductReviews(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+
+  req := dto.ListReviewsRequest{
+	SortBy: "recent",
+	Limit: 20,
+	Offset: 0,
+  }
+
+  if sortBy := query.Get("sort_by"); sortBy != "" {
+	req.SortBy = sortBy
+  }
+
+  if limitStr := query.Get("limit"); limitStr != "" {
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+	  h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit value '%s': must be an integer", limitStr), nil)
+	  return
+	}
+	req.Limit = limit
+  }
+
+  if offsetStr := query.Get("offset"); offsetStr != "" {
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+	  h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset value '%s': must be an integer", offsetStr), nil)
+	  return
+	}
+	req.Offset = offset
+  }
+
+  if err := h.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := h.reviewService.ListProductReviews(r.Context(), chi.URLParam(r, "id"), req)
+  if err != nil {
+	h.handleReviewError(w, err, "Failed to get reviews")
+	return
+  }
+
+  h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *ReviewHandler) VoteReview(w http.ResponseWriter, r *http.Request) {
+  var req dto.VoteReviewRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	h.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := h.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  req.UserID, _ = middleware.GetUserID(r.Context())
+
+  response, err := h.reviewService.VoteReview(r.Context(), chi.URLParam(r, "review_id"), &req)
+  if err != nil {
+	h.handleReviewError(w, err, "Failed to vote on review")
+	return
+  }
+
+  h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *ReviewHandler) ListReviewQueue(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+
+  req := dto.ListReviewQueueRequest{
+	Status: model.ReviewStatusPending,
+	Limit: 50,
+	Offset: 0,
+  }
+
+  if status := query.Get("status"); status != "" {
+	req.Status = model.ReviewStatus(status)
+  }
+
+  if limitStr := query.Get("limit"); limitStr != "" {
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+	  h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit value '%s': must be an integer", limitStr), nil)
+	  return
+	}
+	req.Limit = limit
+  }
+
+  if offsetStr := query.Get("offset"); offsetStr != "" {
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+	  h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset value '%s': must be an integer", offsetStr), nil)
+	  return
+	}
+	req.Offset = offset
+  }
+
+  if err := h.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := h.reviewService.ListReviewQueue(r.Context(), req)
+  if err != nil {
+	h.handleReviewError(w, err, "Failed to get reviews")
+	return
+  }
+
+  h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *ReviewHandler) ModerateReview(w http.ResponseWriter, r *http.Request) {
+  var req dto.ModerateReviewRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	h.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := h.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	h.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  req.ModeratorID, _ = middleware.GetUserID(r.Context())
+
+  response, err := h.reviewService.ModerateReview(r.Context(), chi.URLParam(r, "review_id"), &req)
+  if err != nil {
+	h.handleReviewError(w, err, "Failed to moderate review")
+	return
+  }
+
+  h.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (h *ReviewHandler) handleReviewError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrInvalidID):
+	h.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+  case errors.Is(err, service.ErrProductNotFound):
+	h.respondWithError(w, http.StatusNotFound, "Product not found", nil)
+  case errors.Is(err, service.ErrReviewNotFound):
+	h.respondWithError(w, http.StatusNotFound, "Review not found", nil)
+  case errors.Is(err, service.ErrNotVerifiedPurchase):
+	h.respondWithError(w, http.StatusForbidden, "Only customers who received this product can review it", nil)
+  case errors.Is(err, service.ErrDuplicateReview):
+	h.respondWithError(w, http.StatusConflict, "You have already reviewed this product", nil)
+  case errors.Is(err, service.ErrReviewNotPublished):
+	h.respondWithError(w, http.StatusConflict, "Only published reviews can be voted on", nil)
+  case errors.Is(err, service.ErrOwnReviewVote):
+	h.respondWithError(w, http.StatusForbidden, "You can't vote on your own review", nil)
+  default:
+	h.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
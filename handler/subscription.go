@@ -0,0 +1,120 @@
+package handler
+
+import (
+  "errors"
+  "context"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type SubscriptionService interface {
+  Subscribe(ctx context.Context, productID string, req *dto.CreateSubscriptionRequest) (*dto.CreateSubscriptionResponse, error)
+  ListSubscriptions(ctx context.Context, userID string) (*dto.ListSubscriptionsResponse, error)
+  Unsubscribe(ctx context.Context, userID, id string) error
+}
+
+type SubscriptionHandler struct {
+  BaseHandler
+  subscriptionService SubscriptionService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewSubscriptionHandler(subscriptionService SubscriptionService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *SubscriptionHandler {
+  return &SubscriptionHandler{
+	subscriptionService: subscriptionService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (s *SubscriptionHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/products/{id}/subscriptions", func(r chi.Router) {
+	r.Use(s.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+
+	r.Post("/", s.Subscribe)
+  })
+
+  router.Route("/subscriptions", func(r chi.Router) {
+	r.Use(s.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+
+	r.Get("/", s.ListSubscriptions)
+	r.Delete("/{subscription_id}", s.Unsubscribe)
+  })
+}
+
+func (s *SubscriptionHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+  var req dto.CreateSubscriptionRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	s.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := s.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	s.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  req.UserID, _ = middleware.GetUserID(r.Context())
+
+  response, err := s.subscriptionService.Subscribe(r.Context(), chi.URLParam(r, "id"), &req)
+  if err != nil {
+	s.handleSubscriptionError(w, err, "Failed to subscribe")
+	return
+  }
+
+  s.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (s *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  response, err := s.subscriptionService.ListSubscriptions(r.Context(), userID)
+  if err != nil {
+	s.handleSubscriptionError(w, err, "Failed to get subscriptions")
+	return
+  }
+
+  s.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (s *SubscriptionHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  if err := s.subscriptionService.Unsubscribe(r.Context(), userID, chi.URLParam(r, "subscription_id")); err != nil {
+	s.handleSubscriptionError(w, err, "Failed to unsubscribe")
+	return
+  }
+
+  s.respondWithSuccess(w, http.StatusNoContent, nil)
+}
+
+func (s *SubscriptionHandler) handleSubscriptionError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrInvalidID):
+	s.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+  case errors.Is(err, service.ErrProductNotFound):
+	s.respondWithError(w, http.StatusNotFound, "Product not found", nil)
+  case errors.Is(err, service.ErrVariantNotFound):
+	s.respondWithError(w, http.StatusNotFound, "Variant not found", nil)
+  case errors.Is(err, service.ErrSubscriptionNotFound):
+	s.respondWithError(w, http.StatusNotFound, "Subscription not found", nil)
+  case errors.Is(err, service.ErrAlreadyInStock):
+	s.respondWithError(w, http.StatusConflict, "Product is already in stock", nil)
+  case errors.Is(err, service.ErrPriceAlreadyBelowTarget):
+	s.respondWithError(w, http.StatusConflict, "Price is already at or below the target price", nil)
+  case errors.Is(err, service.ErrVariantPriceSubscription):
+	s.respondWithError(w, http.StatusUnprocessableEntity, "Price subscriptions can't target a variant", nil)
+  default:
+	s.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
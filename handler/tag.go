@@ -0,0 +1,120 @@
+package handler
+
+import (
+  "errors"
+  "context"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type TagService interface {
+  ListTags(ctx context.Context) (*dto.ListTagsResponse, error)
+  RenameTag(ctx context.Context, slug string, req *dto.RenameTagRequest) (*dto.RenameTagResponse, error)
+  MergeTag(ctx context.Context, slug string, req *dto.MergeTagRequest) (*dto.MergeTagResponse, error)
+}
+
+type TagHandler struct {
+  BaseHandler
+  tagService TagService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewTagHandler(tagService TagService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *TagHandler {
+  return &TagHandler{
+	tagService: tagService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (t *TagHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/tags", func(r chi.Router) {
+	r.Use(t.authMiddleware.Authenticate)
+
+	r.Get("/", t.ListTags)
+
+	r.Group(func(r chi.Router) {
+	  r.Use(middleware.RequireAuth)
+	  r.Use(middleware.RequireAdmin)
+
+	  r.Patch("/{slug}", t.RenameTag)
+	  r.Post("/{slug}/merge", t.MergeTag)
+	})
+  })
+}
+
+func (t *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+  response, err := t.tagService.ListTags(r.Context())
+  if err != nil {
+	t.respondWithError(w, http.StatusInternalServerError, "Failed to get tags", nil)
+	return
+  }
+
+  t.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (t *TagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+  var req dto.RenameTagRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	t.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := t.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	t.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := t.tagService.RenameTag(r.Context(), chi.URLParam(r, "slug"), &req)
+  if err != nil {
+	t.handleTagError(w, err, "Failed to rename tag")
+	return
+  }
+
+  t.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (t *TagHandler) MergeTag(w http.ResponseWriter, r *http.Request) {
+  var req dto.MergeTagRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	t.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := t.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	t.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := t.tagService.MergeTag(r.Context(), chi.URLParam(r, "slug"), &req)
+  if err != nil {
+	t.handleTagError(w, err, "Failed to merge tags")
+	return
+  }
+
+  t.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (t *TagHandler) handleTagError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrTagNotFound):
+	t.respondWithError(w, http.StatusNotFound, "Tag not found", nil)
+  case errors.Is(err, service.ErrDuplicateTag):
+	t.respondWithError(w, http.StatusConflict, "A tag with this slug already exists, merge them instead", nil)
+  case errors.Is(err, service.ErrTagMergeSelf):
+	t.respondWithError(w, http.StatusUnprocessableEntity, "A tag cannot be merged into itself", nil)
+  case errors.Is(err, service.ErrInvalidSlug):
+	t.respondWithError(w, http.StatusUnprocessableEntity, "A slug could not be generated from the name", nil)
+  default:
+	t.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
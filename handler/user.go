@@ -1,6 +1,9 @@
 package handler
 
 import (
+  "fmt"
+  "errors"
+  "strconv"
   "context"
   "net/http"
   "encoding/json"
@@ -9,38 +12,145 @@ import (
   "github.com/go-playground/validator/v10"
 
   "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
 )
 
 type UserService interface {
   CreateUser(ctx context.Context, usr dto.CreateUserRequest) (*dto.CreateUserResponse, error)
-  GetUserById(ctx context.Context, user_id dto.GetUserByIDRequest) (*dto.UserResponse, error)
+  GetUserByID(ctx context.Context, user_id dto.GetUserByIDRequest) (*dto.UserResponse, error)
   GetUserByEmail(ctx context.Context, email dto.GetUserByEmailRequest) (*dto.UserResponse, error)
   UpdateUser(ctx context.Context, user_id string, usr dto.UpdateUserRequest) (*dto.UpdateUserResponse, error)
   DeleteUser(ctx context.Context, user_id dto.DeleteUserRequest) (*dto.DeleteUserResponse, error)
+  ListUsers(ctx context.Context, req dto.ListUsersRequest) (*dto.ListUsersResponse, error)
+  UpdateUserRole(ctx context.Context, user_id string, req dto.UpdateUserRoleRequest) (*dto.UpdateUserResponse, error)
 }
 
 type UserHandler struct {
   BaseHandler
   userService UserService
+  authMiddleware *middleware.AuthMiddleware
 }
 
-func NewUserHandler(userService UserService, validator *validator.Validate) *UserHandler {
+func NewUserHandler(userService UserService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *UserHandler {
   return &UserHandler{
 	userService: userService,
 	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
   }
 }
 
 func (u *UserHandler) RegisterRoutes(router chi.Router) {
   router.Route("/users", func (r chi.Router) {
-	router.Get("/", u.GetUserByEmail)
-	router.Get("/{id}", u.GetUserByID)
-	router.Post("/", u.CreateUser)
-	router.Put("/{id}", u.UpdateUser)
-	router.Delete("/{id}", u.DeleteUser)
+	r.Post("/", u.CreateUser)
+
+	r.Group(func(r chi.Router) {
+	  r.Use(u.authMiddleware.Authenticate)
+	  r.Use(middleware.RequireAuth)
+
+	  r.With(middleware.RequireAdmin).Get("/", u.GetUserByEmail)
+	  r.Get("/{id}", u.GetUserByID)
+	  r.Put("/{id}", u.UpdateUser)
+	  r.Delete("/{id}", u.DeleteUser)
+	})
+  })
+
+  router.Route("/admin/users", func(r chi.Router) {
+	r.Use(u.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+	r.Use(middleware.RequireAdmin)
+
+	r.Get("/", u.ListUsers)
+	r.Put("/{id}/role", u.UpdateUserRole)
   })
 }
 
+func (u *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+
+  req := dto.ListUsersRequest{
+	Limit: 20,
+	Offset: 0,
+	SortBy: query.Get("sort_by"),
+	SortOrder: query.Get("sort_order"),
+	Cursor: query.Get("cursor"),
+	Total: query.Get("total"),
+  }
+
+  if limitStr := query.Get("limit"); limitStr != "" {
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+	  u.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit value '%s': must be an integer", limitStr), nil)
+	  return
+	}
+	req.Limit = limit
+  }
+
+  if offsetStr := query.Get("offset"); offsetStr != "" {
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+	  u.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset value '%s': must be an integer", offsetStr), nil)
+	  return
+	}
+	req.Offset = offset
+  }
+
+  if q := query.Get("q"); q != "" {
+	req.Query = &q
+  }
+
+  if role := query.Get("role"); role != "" {
+	req.Role = &role
+  }
+
+  if err := u.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	u.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := u.userService.ListUsers(r.Context(), req)
+  if err != nil {
+	if errors.Is(err, service.ErrInvalidCursor) {
+	  u.respondWithError(w, http.StatusBadRequest, "Invalid cursor, it doesn't match the requested sort", nil)
+	  return
+	}
+	u.respondWithError(w, http.StatusInternalServerError, "Failed to get users", nil)
+	return
+  }
+
+  u.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (u *UserHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+  var req dto.UpdateUserRoleRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	u.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := u.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	u.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := u.userService.UpdateUserRole(r.Context(), chi.URLParam(r, "id"), req)
+  if err != nil {
+	switch {
+	case errors.Is(err, service.ErrInvalidUserID):
+	  u.respondWithError(w, http.StatusBadRequest, "Invalid user ID", nil)
+	case errors.Is(err, service.ErrUserNotFound):
+	  u.respondWithError(w, http.StatusNotFound, "User not found", nil)
+	default:
+	  u.respondWithError(w, http.StatusInternalServerError, "Failed to update user role", nil)
+	}
+	return
+  }
+
+  u.respondWithSuccess(w, http.StatusOK, response)
+}
+
 func (u *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
   var req dto.CreateUserRequest
 
@@ -79,6 +189,10 @@ func (u *UserHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 
   response, err := u.userService.GetUserByEmail(r.Context(), req)
   if err != nil {
+	if errors.Is(err, service.ErrUserNotFound) {
+	  u.respondWithError(w, http.StatusNotFound, "User not found", nil)
+	  return
+	}
 	u.respondWithError(w, http.StatusInternalServerError, "Failed to get user by email", nil)
 	return
   }
@@ -99,8 +213,17 @@ func (u *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	return
   }
 
-  response, err := u.userService.GetUserById(r.Context(), req)
+  if !u.canManage(r, req.ID) {
+	u.respondWithError(w, http.StatusForbidden, "You can only view your own account", nil)
+	return
+  }
+
+  response, err := u.userService.GetUserByID(r.Context(), req)
   if err != nil {
+	if errors.Is(err, service.ErrUserNotFound) {
+	  u.respondWithError(w, http.StatusNotFound, "User not found", nil)
+	  return
+	}
 	u.respondWithError(w, http.StatusInternalServerError, "Failed to get user by id", nil)
 	return
   }
@@ -124,6 +247,11 @@ func (u *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	return
   }
 
+  if !u.canManage(r, userId) {
+	u.respondWithError(w, http.StatusForbidden, "You can only update your own account", nil)
+	return
+  }
+
   response, err := u.userService.UpdateUser(r.Context(), userId, req)
   if err != nil {
 	u.respondWithError(w, http.StatusInternalServerError, "Failed to update user by id", nil)
@@ -134,11 +262,8 @@ func (u *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (u *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-  var req dto.DeleteUserRequest
-
-  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-	u.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
-	return
+  req := dto.DeleteUserRequest{
+	ID: chi.URLParam(r, "id"),
   }
 
   if err := u.validator.Struct(req); err != nil {
@@ -147,6 +272,11 @@ func (u *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	return
   }
 
+  if !u.canManage(r, req.ID) {
+	u.respondWithError(w, http.StatusForbidden, "You can only delete your own account", nil)
+	return
+  }
+
   response, err := u.userService.DeleteUser(r.Context(), req)
   if err != nil {
 	u.respondWithError(w, http.StatusInternalServerError, "Failed to delete user", nil)
@@ -155,3 +285,10 @@ func (u *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
   u.respondWithSuccess(w, http.StatusOK, response)
 }
+
+// canManage reports whether the caller may act on the account with the given
+// id: admins on any account, everyone else only on their own.
+func (u *UserHandler) canManage(r *http.Request, id string) bool {
+  userID, _ := middleware.GetUserID(r.Context())
+  return userID == id || middleware.IsAdmin(r.Context())
+}
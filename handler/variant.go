@@ -0,0 +1,233 @@
+package handler
+
+import (
+  "errors"
+  "context"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type VariantService interface {
+  SetOptions(ctx context.Context, productID string, req *dto.SetProductOptionsRequest) (*dto.ProductOptionsResponse, error)
+  GetOptions(ctx context.Context, productID string) (*dto.ProductOptionsResponse, error)
+  ListVariants(ctx context.Context, productID string) (*dto.ListVariantsResponse, error)
+  GetVariant(ctx context.Context, productID, variantID string) (*dto.VariantResponse, error)
+  CreateVariant(ctx context.Context, productID string, req *dto.CreateVariantRequest) (*dto.CreateVariantResponse, error)
+  GenerateVariants(ctx context.Context, productID string, req *dto.GenerateVariantsRequest) (*dto.GenerateVariantsResponse, error)
+  UpdateVariant(ctx context.Context, productID, variantID string, req *dto.UpdateVariantRequest) (*dto.UpdateVariantResponse, error)
+  UpdateVariantStock(ctx context.Context, productID, variantID string, req *dto.UpdateVariantStockRequest) (*dto.UpdateProductStockResponse, error)
+  DeleteVariant(ctx context.Context, productID, variantID string) (*dto.DeleteVariantResponse, error)
+}
+
+type VariantHandler struct {
+  BaseHandler
+  variantService VariantService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewVariantHandler(variantService VariantService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *VariantHandler {
+  return &VariantHandler{
+	variantService: variantService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (v *VariantHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/products/{id}/options", func(r chi.Router) {
+	r.Use(v.authMiddleware.Authenticate)
+
+	r.Get("/", v.GetOptions)
+
+	r.Group(func(r chi.Router) {
+	  r.Use(middleware.RequireAuth)
+	  r.Use(middleware.RequireAdmin)
+
+	  r.Put("/", v.SetOptions)
+	})
+  })
+
+  router.Route("/products/{id}/variants", func(r chi.Router) {
+	r.Use(v.authMiddleware.Authenticate)
+
+	r.Get("/", v.ListVariants)
+	r.Get("/{variant_id}", v.GetVariant)
+
+	r.Group(func(r chi.Router) {
+	  r.Use(middleware.RequireAuth)
+	  r.Use(middleware.RequireAdmin)
+
+	  r.Post("/", v.CreateVariant)
+	  r.Post("/generate", v.GenerateVariants)
+	  r.Put("/{variant_id}", v.UpdateVariant)
+	  r.Put("/{variant_id}/stock", v.UpdateVariantStock)
+	  r.Delete("/{variant_id}", v.DeleteVariant)
+	})
+  })
+}
+
+func (v *VariantHandler) GetOptions(w http.ResponseWriter, r *http.Request) {
+  response, err := v.variantService.GetOptions(r.Context(), chi.URLParam(r, "id"))
+  if err != nil {
+	v.handleVariantError(w, err, "Failed to get product options")
+	return
+  }
+
+  v.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (v *VariantHandler) SetOptions(w http.ResponseWriter, r *http.Request) {
+  var req dto.SetProductOptionsRequest
+  if !v.decodeAndValidate(w, r, &req) {
+	return
+  }
+
+  response, err := v.variantService.SetOptions(r.Context(), chi.URLParam(r, "id"), &req)
+  if err != nil {
+	v.handleVariantError(w, err, "Failed to set product options")
+	return
+  }
+
+  v.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (v *VariantHandler) ListVariants(w http.ResponseWriter, r *http.Request) {
+  response, err := v.variantService.ListVariants(r.Context(), chi.URLParam(r, "id"))
+  if err != nil {
+	v.handleVariantError(w, err, "Failed to get variants")
+	return
+  }
+
+  v.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (v *VariantHandler) GetVariant(w http.ResponseWriter, r *http.Request) {
+  response, err := v.variantService.GetVariant(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"))
+  if err != nil {
+	v.handleVariantError(w, err, "Failed to get variant")
+	return
+  }
+
+  v.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (v *VariantHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+  var req dto.CreateVariantRequest
+  if !v.decodeAndValidate(w, r, &req) {
+	return
+  }
+
+  response, err := v.variantService.CreateVariant(r.Context(), chi.URLParam(r, "id"), &req)
+  if err != nil {
+	v.handleVariantError(w, err, "Failed to create variant")
+	return
+  }
+
+  v.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (v *VariantHandler) GenerateVariants(w http.ResponseWriter, r *http.Request) {
+  var req dto.GenerateVariantsRequest
+  if !v.decodeAndValidate(w, r, &req) {
+	return
+  }
+
+  response, err := v.variantService.GenerateVariants(r.Context(), chi.URLParam(r, "id"), &req)
+  if err != nil {
+	v.handleVariantError(w, err, "Failed to generate variants")
+	return
+  }
+
+  v.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (v *VariantHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+  var req dto.UpdateVariantRequest
+  if !v.decodeAndValidate(w, r, &req) {
+	return
+  }
+
+  response, err := v.variantService.UpdateVariant(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"), &req)
+  if err != nil {
+	v.handleVariantError(w, err, "Failed to update variant")
+	return
+  }
+
+  v.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (v *VariantHandler) UpdateVariantStock(w http.ResponseWriter, r *http.Request) {
+  var req dto.UpdateVariantStockRequest
+  if !v.decodeAndValidate(w, r, &req) {
+	return
+  }
+
+  req.ActorID, _ = middleware.GetUserID(r.Context())
+
+  response, err := v.variantService.UpdateVariantStock(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"), &req)
+  if err != nil {
+	v.handleVariantError(w, err, "Failed to update variant stock")
+	return
+  }
+
+  v.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (v *VariantHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+  response, err := v.variantService.DeleteVariant(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"))
+  if err != nil {
+	v.handleVariantError(w, err, "Failed to delete variant")
+	return
+  }
+
+  v.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (v *VariantHandler) decodeAndValidate(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+  if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+	v.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return false
+  }
+
+  if err := v.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	v.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return false
+  }
+
+  return true
+}
+
+func (v *VariantHandler) handleVariantError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrInvalidID):
+	v.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+  case errors.Is(err, service.ErrProductNotFound):
+	v.respondWithError(w, http.StatusNotFound, "Product not found", nil)
+  case errors.Is(err, service.ErrVariantNotFound):
+	v.respondWithError(w, http.StatusNotFound, "Variant not found", nil)
+  case errors.Is(err, service.ErrDuplicateVariantSKU):
+	v.respondWithError(w, http.StatusConflict, "Variant with this SKU already exists", nil)
+  case errors.Is(err, service.ErrDuplicateVariant):
+	v.respondWithError(w, http.StatusConflict, "A variant with these attributes already exists", nil)
+  case errors.Is(err, service.ErrInvalidVariantAttributes):
+	v.respondWithError(w, http.StatusUnprocessableEntity, "Variant attributes must use the product options and their values", nil)
+  case errors.Is(err, service.ErrNoProductOptions):
+	v.respondWithError(w, http.StatusUnprocessableEntity, "Define product options before generating variants", nil)
+  case errors.Is(err, service.ErrStockBelowReserved):
+	v.respondWithError(w, http.StatusConflict, "Cannot reduce stock below reserved amount", nil)
+  case errors.Is(err, service.ErrWarehouseNotFound):
+	v.respondWithError(w, http.StatusUnprocessableEntity, "Warehouse not found", nil)
+  case errors.Is(err, service.ErrWarehouseInactive):
+	v.respondWithError(w, http.StatusConflict, "Warehouse is inactive", nil)
+  default:
+	v.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+  "errors"
+  "context"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type WarehouseService interface {
+  CreateWarehouse(ctx context.Context, req *dto.CreateWarehouseRequest) (*dto.CreateWarehouseResponse, error)
+  ListWarehouses(ctx context.Context) (*dto.ListWarehousesResponse, error)
+  UpdateWarehouse(ctx context.Context, id string, req *dto.UpdateWarehouseRequest) (*dto.UpdateWarehouseResponse, error)
+  GetProductStock(ctx context.Context, productID string) (*dto.ProductWarehouseStockResponse, error)
+}
+
+type WarehouseHandler struct {
+  BaseHandler
+  warehouseService WarehouseService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewWarehouseHandler(warehouseService WarehouseService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *WarehouseHandler {
+  return &WarehouseHandler{
+	warehouseService: warehouseService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (wh *WarehouseHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/admin/warehouses", func(r chi.Router) {
+	r.Use(wh.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+	r.Use(middleware.RequireAdmin)
+
+	r.Get("/", wh.ListWarehouses)
+	r.Post("/", wh.CreateWarehouse)
+	r.Patch("/{id}", wh.UpdateWarehouse)
+  })
+
+  router.Route("/products/{id}/warehouse-stock", func(r chi.Router) {
+	r.Use(wh.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+	r.Use(middleware.RequireAdmin)
+
+	r.Get("/", wh.GetProductStock)
+  })
+}
+
+func (wh *WarehouseHandler) ListWarehouses(w http.ResponseWriter, r *http.Request) {
+  response, err := wh.warehouseService.ListWarehouses(r.Context())
+  if err != nil {
+	wh.respondWithError(w, http.StatusInternalServerError, "Failed to get warehouses", nil)
+	return
+  }
+
+  wh.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (wh *WarehouseHandler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+  var req dto.CreateWarehouseRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	wh.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := wh.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	wh.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := wh.warehouseService.CreateWarehouse(r.Context(), &req)
+  if err != nil {
+	wh.handleWarehouseError(w, err, "Failed to create warehouse")
+	return
+  }
+
+  wh.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (wh *WarehouseHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+  var req dto.UpdateWarehouseRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	wh.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := wh.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	wh.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  response, err := wh.warehouseService.UpdateWarehouse(r.Context(), chi.URLParam(r, "id"), &req)
+  if err != nil {
+	wh.handleWarehouseError(w, err, "Failed to update warehouse")
+	return
+  }
+
+  wh.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (wh *WarehouseHandler) GetProductStock(w http.ResponseWriter, r *http.Request) {
+  response, err := wh.warehouseService.GetProductStock(r.Context(), chi.URLParam(r, "id"))
+  if err != nil {
+	wh.handleWarehouseError(w, err, "Failed to get warehouse stock")
+	return
+  }
+
+  wh.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (wh *WarehouseHandler) handleWarehouseError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrInvalidID):
+	wh.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+  case errors.Is(err, service.ErrProductNotFound):
+	wh.respondWithError(w, http.StatusNotFound, "Product not found", nil)
+  case errors.Is(err, service.ErrWarehouseNotFound):
+	wh.respondWithError(w, http.StatusNotFound, "Warehouse not found", nil)
+  case errors.Is(err, service.ErrDuplicateWarehouseCode):
+	wh.respondWithError(w, http.StatusConflict, "Warehouse with this code already exists", nil)
+  case errors.Is(err, service.ErrWarehouseHasStock):
+	wh.respondWithError(w, http.StatusConflict, "Move or remove the warehouse stock before deactivating it", nil)
+  case errors.Is(err, service.ErrWarehouseInactive):
+	wh.respondWithError(w, http.StatusConflict, "An inactive warehouse can't be the default", nil)
+  case errors.Is(err, service.ErrDefaultWarehouseRequired):
+	wh.respondWithError(w, http.StatusConflict, "Make another warehouse the default first", nil)
+  default:
+	wh.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+  "errors"
+  "context"
+  "net/http"
+  "encoding/json"
+
+  "github.com/go-chi/chi/v5"
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/service"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+)
+
+type WishlistService interface {
+  CreateWishlist(ctx context.Context, userID string, req *dto.CreateWishlistRequest) (*dto.CreateWishlistResponse, error)
+  ListWishlists(ctx context.Context, userID string) (*dto.ListWishlistsResponse, error)
+  GetWishlist(ctx context.Context, userID, wishlistID string) (*dto.WishlistResponse, error)
+  GetSharedWishlist(ctx context.Context, token string) (*dto.WishlistResponse, error)
+  UpdateWishlist(ctx context.Context, userID, wishlistID string, req *dto.UpdateWishlistRequest) (*dto.UpdateWishlistResponse, error)
+  DeleteWishlist(ctx context.Context, userID, wishlistID string) error
+  AddItem(ctx context.Context, userID, wishlistID string, req *dto.AddWishlistItemRequest) (*dto.AddWishlistItemResponse, error)
+  RemoveItem(ctx context.Context, userID, wishlistID, itemID string) (*dto.WishlistResponse, error)
+  MoveToCart(ctx context.Context, userID, wishlistID, itemID string, req *dto.MoveToCartRequest) (*dto.AddToCartResponse, error)
+}
+
+type WishlistHandler struct {
+  BaseHandler
+  wishlistService WishlistService
+  authMiddleware *middleware.AuthMiddleware
+}
+
+func NewWishlistHandler(wishlistService WishlistService, authMiddleware *middleware.AuthMiddleware, validator *validator.Validate) *WishlistHandler {
+  return &WishlistHandler{
+	wishlistService: wishlistService,
+	BaseHandler: BaseHandler{validator: validator},
+	authMiddleware: authMiddleware,
+  }
+}
+
+func (wl *WishlistHandler) RegisterRoutes(router chi.Router) {
+  router.Route("/me/wishlists", func(r chi.Router) {
+	r.Use(wl.authMiddleware.Authenticate)
+	r.Use(middleware.RequireAuth)
+
+	r.Get("/", wl.ListWishlists)
+	r.Post("/", wl.CreateWishlist)
+	r.Get("/{wishlist_id}", wl.GetWishlist)
+	r.Patch("/{wishlist_id}", wl.UpdateWishlist)
+	r.Delete("/{wishlist_id}", wl.DeleteWishlist)
+	r.Post("/{wishlist_id}/items", wl.AddItem)
+	r.Delete("/{wishlist_id}/items/{item_id}", wl.RemoveItem)
+	r.Post("/{wishlist_id}/items/{item_id}/move-to-cart", wl.MoveToCart)
+  })
+
+  // Share links work without an account.
+  router.Get("/wishlists/shared/{token}", wl.GetSharedWishlist)
+}
+
+func (wl *WishlistHandler) ListWishlists(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  response, err := wl.wishlistService.ListWishlists(r.Context(), userID)
+  if err != nil {
+	wl.handleWishlistError(w, err, "Failed to get wishlists")
+	return
+  }
+
+  wl.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (wl *WishlistHandler) CreateWishlist(w http.ResponseWriter, r *http.Request) {
+  var req dto.CreateWishlistRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	wl.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := wl.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	wl.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  userID, _ := middleware.GetUserID(r.Context())
+
+  response, err := wl.wishlistService.CreateWishlist(r.Context(), userID, &req)
+  if err != nil {
+	wl.handleWishlistError(w, err, "Failed to create wishlist")
+	return
+  }
+
+  wl.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (wl *WishlistHandler) GetWishlist(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  response, err := wl.wishlistService.GetWishlist(r.Context(), userID, chi.URLParam(r, "wishlist_id"))
+  if err != nil {
+	wl.handleWishlistError(w, err, "Failed to get wishlist")
+	return
+  }
+
+  wl.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (wl *WishlistHandler) GetSharedWishlist(w http.ResponseWriter, r *http.Request) {
+  response, err := wl.wishlistService.GetSharedWishlist(r.Context(), chi.URLParam(r, "token"))
+  if err != nil {
+	wl.handleWishlistError(w, err, "Failed to get wishlist")
+	return
+  }
+
+  wl.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (wl *WishlistHandler) UpdateWishlist(w http.ResponseWriter, r *http.Request) {
+  var req dto.UpdateWishlistRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	wl.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := wl.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	wl.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  userID, _ := middleware.GetUserID(r.Context())
+
+  response, err := wl.wishlistService.UpdateWishlist(r.Context(), userID, chi.URLParam(r, "wishlist_id"), &req)
+  if err != nil {
+	wl.handleWishlistError(w, err, "Failed to update wishlist")
+	return
+  }
+
+  wl.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (wl *WishlistHandler) DeleteWishlist(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  if err := wl.wishlistService.DeleteWishlist(r.Context(), userID, chi.URLParam(r, "wishlist_id")); err != nil {
+	wl.handleWishlistError(w, err, "Failed to delete wishlist")
+	return
+  }
+
+  wl.respondWithSuccess(w, http.StatusNoContent, nil)
+}
+
+func (wl *WishlistHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+  var req dto.AddWishlistItemRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	wl.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	return
+  }
+
+  if err := wl.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	wl.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  userID, _ := middleware.GetUserID(r.Context())
+
+  response, err := wl.wishlistService.AddItem(r.Context(), userID, chi.URLParam(r, "wishlist_id"), &req)
+  if err != nil {
+	wl.handleWishlistError(w, err, "Failed to add item to wishlist")
+	return
+  }
+
+  wl.respondWithSuccess(w, http.StatusCreated, response)
+}
+
+func (wl *WishlistHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+  userID, _ := middleware.GetUserID(r.Context())
+
+  response, err := wl.wishlistService.RemoveItem(r.Context(), userID, chi.URLParam(r, "wishlist_id"), chi.URLParam(r, "item_id"))
+  if err != nil {
+	wl.handleWishlistError(w, err, "Failed to remove item from wishlist")
+	return
+  }
+
+  wl.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (wl *WishlistHandler) MoveToCart(w http.ResponseWriter, r *http.Request) {
+  var req dto.MoveToCartRequest
+  if r.ContentLength != 0 {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	  wl.respondWithError(w, http.StatusBadRequest, "Cannot parse JSON: " + err.Error(), nil)
+	  return
+	}
+  }
+
+  if err := wl.validator.Struct(req); err != nil {
+	validationErrors := dto.FormatValidationErrors(err)
+	wl.respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+	return
+  }
+
+  userID, _ := middleware.GetUserID(r.Context())
+
+  response, err := wl.wishlistService.MoveToCart(r.Context(), userID, chi.URLParam(r, "wishlist_id"), chi.URLParam(r, "item_id"), &req)
+  if err != nil {
+	wl.handleWishlistError(w, err, "Failed to move item to cart")
+	return
+  }
+
+  wl.respondWithSuccess(w, http.StatusOK, response)
+}
+
+func (wl *WishlistHandler) handleWishlistError(w http.ResponseWriter, err error, fallback string) {
+  switch {
+  case errors.Is(err, service.ErrInvalidID):
+	wl.respondWithError(w, http.StatusBadRequest, "Invalid ID format", nil)
+  case errors.Is(err, service.ErrWishlistNotFound):
+	wl.respondWithError(w, http.StatusNotFound, "Wishlist not found", nil)
+  case errors.Is(err, service.ErrWishlistItemNotFound):
+	wl.respondWithError(w, http.StatusNotFound, "Wishlist item not found", nil)
+  case errors.Is(err, service.ErrDuplicateWishlistName):
+	wl.respondWithError(w, http.StatusConflict, "You already have a wishlist with this name", nil)
+  case errors.Is(err, service.ErrProductNotFound):
+	wl.respondWithError(w, http.StatusNotFound, "Product not found", nil)
+  case errors.Is(err, service.ErrVariantNotFound):
+	wl.respondWithError(w, http.StatusNotFound, "Variant not found", nil)
+  case errors.Is(err, service.ErrVariantRequired):
+	wl.respondWithError(w, http.StatusUnprocessableEntity, "This product has variants, please select one", nil)
+  case errors.Is(err, service.ErrProductUnavailable):
+	wl.respondWithError(w, http.StatusConflict, "Product is not available for sale", nil)
+  case errors.Is(err, service.ErrInsufficientStock):
+	wl.respondWithError(w, http.StatusConflict, "Insufficient stock available", nil)
+  default:
+	wl.respondWithError(w, http.StatusInternalServerError, fallback, nil)
+  }
+}
@@ -0,0 +1,69 @@
+package job
+
+import (
+  "context"
+  "log"
+  "sync"
+  "time"
+)
+
+type Func func(ctx context.Context) error
+
+type scheduledJob struct {
+  name     string
+  interval time.Duration
+  fn       Func
+}
+
+// Scheduler runs background jobs at a fixed interval until its context is
+// cancelled. Every job runs once on start so fresh deployments don't wait a
+// full interval for their first run.
+type Scheduler struct {
+  jobs []scheduledJob
+  wg   sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+  return &Scheduler{}
+}
+
+func (s *Scheduler) Every(name string, interval time.Duration, fn Func) {
+  s.jobs = append(s.jobs, scheduledJob{
+	name:     name,
+	interval: interval,
+	fn:       fn,
+  })
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+  for _, j := range s.jobs {
+	s.wg.Add(1)
+	go s.run(ctx, j)
+  }
+}
+
+func (s *Scheduler) Wait() {
+  s.wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, j scheduledJob) {
+  defer s.wg.Done()
+
+  ticker := time.NewTicker(j.interval)
+  defer ticker.Stop()
+
+  for {
+	start := time.Now()
+	if err := j.fn(ctx); err != nil {
+	  log.Printf("job %s failed: %v", j.name, err)
+	} else {
+	  log.Printf("job %s finished in %s", j.name, time.Since(start))
+	}
+
+	select {
+	case <-ctx.Done():
+	  return
+	case <-ticker.C:
+	}
+  }
+}
@@ -1,18 +1,33 @@
 package main
 
 import (
+  "os"
   "fmt"
   "context"
+  "os/signal"
+  "syscall"
 
   "github.com/F-Dupraz/ecommerce-with-go/application"
 )
 
 func main() {
-  app := application.New()
+  ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+  defer stop()
 
-  err := app.Start(context.TODO())
+  config, err := application.LoadConfig()
   if err != nil {
-	fmt.Println("failed to start the server: %w", err)
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+  }
+
+  app, err := application.New(ctx, config)
+  if err != nil {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
   }
-}
 
+  if err := app.Start(ctx); err != nil {
+	fmt.Fprintf(os.Stderr, "failed to start the server: %v\n", err)
+	os.Exit(1)
+  }
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
     "context"
+    "log"
     "net/http"
     "strings"
 
@@ -9,11 +10,15 @@ import (
 )
 
 type AuthMiddleware struct {
-    jwtManager *auth.JWTManager
+    jwtManager  *auth.JWTManager
+    revocations auth.RevocationStore
 }
 
-func NewAuthMiddleware(jwtManager *auth.JWTManager) *AuthMiddleware {
-    return &AuthMiddleware{jwtManager: jwtManager}
+func NewAuthMiddleware(jwtManager *auth.JWTManager, revocations auth.RevocationStore) *AuthMiddleware {
+    return &AuthMiddleware{
+        jwtManager:  jwtManager,
+        revocations: revocations,
+    }
 }
 
 func (am *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
@@ -37,11 +42,26 @@ func (am *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
             http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
             return
         }
+
+        revoked, err := am.revocations.IsRevoked(r.Context(), claims.UserID, claims.ID, claims.IssueTime())
+        if err != nil {
+            // Fail closed, a revoked token must not slip through while the
+            // store is unavailable.
+            log.Printf("failed to check revocation of token %s: %v", claims.ID, err)
+            http.Error(w, "Could not verify token", http.StatusServiceUnavailable)
+            return
+        }
+        if revoked {
+            http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+            return
+        }
         
         ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
         ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
         ctx = context.WithValue(ctx, IsAdminKey, claims.IsAdmin)
         ctx = context.WithValue(ctx, EmailKey, claims.Email)
+        ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
+        ctx = context.WithValue(ctx, TokenIDKey, claims.ID)
         
         next.ServeHTTP(w, r.WithContext(ctx))
     })
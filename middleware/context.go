@@ -11,6 +11,8 @@
   UserRoleKey contextKey = "user_role"
   IsAdminKey contextKey = "is_admin"
   EmailKey contextKey = "user_email"
+  SessionIDKey contextKey = "session_id"
+  TokenIDKey contextKey = "token_id"
  )
 
  func GetUserID (ctx context.Context) (string, bool) {
@@ -23,3 +25,15 @@ func IsAdmin(ctx context.Context) bool {
   return isUserAdmin
 }
 
+
+// GetSessionID returns the session the access token was issued to.
+func GetSessionID(ctx context.Context) (string, bool) {
+  sessionID, ok := ctx.Value(SessionIDKey).(string)
+  return sessionID, ok && sessionID != ""
+}
+
+// GetTokenID returns the jti of the access token the request was made with.
+func GetTokenID(ctx context.Context) (string, bool) {
+  tokenID, ok := ctx.Value(TokenIDKey).(string)
+  return tokenID, ok && tokenID != ""
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+    "net"
+    "net/http"
+    "strconv"
+    "sync"
+    "time"
+)
+
+// RateLimiter allows each client IP a fixed number of requests per window.
+// Counts live in memory, so with several instances the effective limit is
+// multiplied by their number; it is meant to slow down abuse of expensive or
+// sensitive endpoints, not to meter usage.
+type RateLimiter struct {
+    mu        sync.Mutex
+    limit     int
+    window    time.Duration
+    clients   map[string]*rateWindow
+    lastPrune time.Time
+}
+
+type rateWindow struct {
+    start time.Time
+    count int
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+    return &RateLimiter{
+        limit:     limit,
+        window:    window,
+        clients:   make(map[string]*rateWindow),
+        lastPrune: time.Now(),
+    }
+}
+
+func (rl *RateLimiter) Limit(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        ip, _, err := net.SplitHostPort(r.RemoteAddr)
+        if err != nil {
+            ip = r.RemoteAddr
+        }
+
+        if retryAfter, ok := rl.allow(ip, time.Now()); !ok {
+            w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+            http.Error(w, "Too many requests", http.StatusTooManyRequests)
+            return
+        }
+
+        next.ServeHTTP(w, r)
+    })
+}
+
+// allow counts a request from key and reports whether it is within the
+// limit, or how long until it would be.
+func (rl *RateLimiter) allow(key string, now time.Time) (time.Duration, bool) {
+    rl.mu.Lock()
+    defer rl.mu.Unlock()
+
+    if now.Sub(rl.lastPrune) >= rl.window {
+        rl.lastPrune = now
+        for k, win := range rl.clients {
+            if now.Sub(win.start) >= rl.window {
+                delete(rl.clients, k)
+            }
+        }
+    }
+
+    win, ok := rl.clients[key]
+    if !ok || now.Sub(win.start) >= rl.window {
+        win = &rateWindow{start: now}
+        rl.clients[key] = win
+    }
+
+    if win.count >= rl.limit {
+        return win.start.Add(rl.window).Sub(now), false
+    }
+    win.count++
+    return 0, true
+}
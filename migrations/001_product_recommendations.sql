@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS product_recommendations (
+  product_id          UUID          NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  related_product_id  UUID          NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  score               NUMERIC(8,6)  NOT NULL,
+  content_score       NUMERIC(8,6)  NOT NULL DEFAULT 0,
+  co_purchase_score   NUMERIC(8,6)  NOT NULL DEFAULT 0,
+  co_purchase_count   INTEGER       NOT NULL DEFAULT 0,
+  computed_at         TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (product_id, related_product_id),
+  CHECK (product_id <> related_product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_recommendations_score
+  ON product_recommendations (product_id, score DESC);
@@ -0,0 +1,8 @@
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id
+  ON categories (parent_id) WHERE deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug
+  ON categories (slug) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_category_id
+  ON products (category_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,5 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_brands_slug
+  ON brands (slug) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_brand_id
+  ON products (brand_id) WHERE deleted_at IS NULL;
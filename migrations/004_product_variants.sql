@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS product_options (
+  id          UUID         PRIMARY KEY,
+  product_id  UUID         NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  name        VARCHAR(50)  NOT NULL,
+  values      TEXT[]       NOT NULL,
+  position    INTEGER      NOT NULL DEFAULT 0,
+  created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+  updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+  UNIQUE (product_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+  id              UUID           PRIMARY KEY,
+  product_id      UUID           NOT NULL REFERENCES products(id),
+  sku             VARCHAR(50)    NOT NULL,
+  name            VARCHAR(200)   NOT NULL,
+  price           NUMERIC(12,2)  NOT NULL CHECK (price > 0),
+  stock           INTEGER        NOT NULL DEFAULT 0 CHECK (stock >= 0),
+  reserved_stock  INTEGER        NOT NULL DEFAULT 0 CHECK (reserved_stock >= 0),
+  attributes      JSONB          NOT NULL DEFAULT '{}',
+  created_at      TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+  updated_at      TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+  deleted_at      TIMESTAMPTZ,
+  CHECK (reserved_stock <= stock)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_sku
+  ON product_variants (sku) WHERE deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_attributes
+  ON product_variants (product_id, attributes) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id
+  ON product_variants (product_id) WHERE deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shopping_carts_user_id
+  ON shopping_carts (user_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_items_line
+  ON cart_items (cart_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'));
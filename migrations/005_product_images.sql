@@ -0,0 +1,17 @@
+-- product_id has no foreign key: image rows outlive a purged product until the
+-- orphaned image cleanup has removed their stored files, and then the rows.
+CREATE TABLE IF NOT EXISTS product_images (
+  id            UUID          PRIMARY KEY,
+  product_id    UUID          NOT NULL,
+  storage_key   TEXT          NOT NULL,
+  url           TEXT          NOT NULL,
+  content_type  VARCHAR(50)   NOT NULL,
+  size          BIGINT        NOT NULL,
+  width         INTEGER       NOT NULL,
+  height        INTEGER       NOT NULL,
+  position      INTEGER       NOT NULL DEFAULT 0,
+  created_at    TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_images_product_id
+  ON product_images (product_id, position);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS product_import_jobs (
+  id              UUID          PRIMARY KEY,
+  created_by      UUID          NOT NULL REFERENCES users(id),
+  status          VARCHAR(20)   NOT NULL DEFAULT 'pending',
+  dry_run         BOOLEAN       NOT NULL DEFAULT FALSE,
+  source_key      TEXT          NOT NULL,
+  total_rows      INTEGER       NOT NULL DEFAULT 0,
+  processed_rows  INTEGER       NOT NULL DEFAULT 0,
+  created_count   INTEGER       NOT NULL DEFAULT 0,
+  updated_count   INTEGER       NOT NULL DEFAULT 0,
+  failed_count    INTEGER       NOT NULL DEFAULT 0,
+  errors          JSONB         NOT NULL DEFAULT '[]',
+  failure_reason  TEXT,
+  created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  updated_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  started_at      TIMESTAMPTZ,
+  finished_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_import_jobs_status
+  ON product_import_jobs (status, created_at);
+
+-- Imports upsert by SKU, which needs a unique target among live products.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku_live
+  ON products (sku) WHERE deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- Purged products leave their order history behind. Order items keep the
+-- SKU, name, image and price snapshot but lose the link to the product.
+ALTER TABLE order_items ALTER COLUMN product_id DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at
+  ON products (deleted_at) WHERE deleted_at IS NOT NULL;
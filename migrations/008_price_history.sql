@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS scheduled_prices (
+  id              UUID           PRIMARY KEY,
+  product_id      UUID           NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  price           NUMERIC(12,2)  NOT NULL CHECK (price > 0),
+  previous_price  NUMERIC(12,2),
+  starts_at       TIMESTAMPTZ    NOT NULL,
+  ends_at         TIMESTAMPTZ,
+  status          VARCHAR(20)    NOT NULL DEFAULT 'scheduled',
+  created_by      UUID           NOT NULL REFERENCES users(id),
+  created_at      TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+  updated_at      TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+  applied_at      TIMESTAMPTZ,
+  ended_at        TIMESTAMPTZ,
+  CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_prices_due
+  ON scheduled_prices (status, starts_at) WHERE status IN ('scheduled', 'active');
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_prices_product_id
+  ON scheduled_prices (product_id, starts_at);
+
+CREATE TABLE IF NOT EXISTS product_price_changes (
+  id                  UUID           PRIMARY KEY,
+  product_id          UUID           NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  old_price           NUMERIC(12,2)  NOT NULL,
+  new_price           NUMERIC(12,2)  NOT NULL,
+  old_cost_price      NUMERIC(12,2)  NOT NULL,
+  new_cost_price      NUMERIC(12,2)  NOT NULL,
+  source              VARCHAR(20)    NOT NULL,
+  changed_by          UUID           REFERENCES users(id),
+  scheduled_price_id  UUID           REFERENCES scheduled_prices(id) ON DELETE SET NULL,
+  changed_at          TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_price_changes_product_id
+  ON product_price_changes (product_id, changed_at DESC);
@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS stock_movements (
+  id                 UUID          PRIMARY KEY,
+  product_id         UUID          NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  variant_id         UUID          REFERENCES product_variants(id) ON DELETE CASCADE,
+  type               VARCHAR(20)   NOT NULL,
+  quantity           INTEGER       NOT NULL,
+  reserved_quantity  INTEGER       NOT NULL DEFAULT 0,
+  stock_before       INTEGER       NOT NULL,
+  stock_after        INTEGER       NOT NULL,
+  reserved_before    INTEGER       NOT NULL,
+  reserved_after     INTEGER       NOT NULL,
+  reason             TEXT          NOT NULL DEFAULT '',
+  actor_id           UUID          REFERENCES users(id),
+  order_id           UUID          REFERENCES orders(id),
+  created_at         TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id
+  ON stock_movements (product_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_variant_id
+  ON stock_movements (variant_id, created_at DESC) WHERE variant_id IS NOT NULL;
+
+-- The ledger is append-only.
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_movements_no_update ON stock_movements;
+CREATE TRIGGER stock_movements_no_update
+  BEFORE UPDATE ON stock_movements
+  FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- Opening balances, so the ledger of existing products sums to their stock.
+INSERT INTO stock_movements (id, product_id, variant_id, type, quantity, reserved_quantity,
+  stock_before, stock_after, reserved_before, reserved_after, reason)
+SELECT gen_random_uuid(), p.id, NULL, 'adjustment', p.stock, p.reserved_stock,
+  0, p.stock, 0, p.reserved_stock, 'opening balance'
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = p.id AND m.variant_id IS NULL);
+
+INSERT INTO stock_movements (id, product_id, variant_id, type, quantity, reserved_quantity,
+  stock_before, stock_after, reserved_before, reserved_after, reason)
+SELECT gen_random_uuid(), v.product_id, v.id, 'adjustment', v.stock, v.reserved_stock,
+  0, v.stock, 0, v.reserved_stock, 'opening balance'
+FROM product_variants v
+WHERE NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.variant_id = v.id);
@@ -0,0 +1,68 @@
+CREATE TABLE IF NOT EXISTS warehouses (
+  id          UUID          PRIMARY KEY,
+  code        VARCHAR(20)   NOT NULL UNIQUE,
+  name        VARCHAR(100)  NOT NULL,
+  country     CHAR(2)       NOT NULL,
+  ships_to    TEXT[]        NOT NULL DEFAULT '{}',
+  priority    INTEGER       NOT NULL DEFAULT 0,
+  is_default  BOOLEAN       NOT NULL DEFAULT FALSE,
+  is_active   BOOLEAN       NOT NULL DEFAULT TRUE,
+  created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  updated_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  CHECK (is_active OR NOT is_default)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_default
+  ON warehouses (is_default) WHERE is_default;
+
+-- One row per product (variant_id NULL) or variant per warehouse.
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+  warehouse_id    UUID         NOT NULL REFERENCES warehouses(id),
+  product_id      UUID         NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  variant_id      UUID         REFERENCES product_variants(id) ON DELETE CASCADE,
+  stock           INTEGER      NOT NULL DEFAULT 0 CHECK (stock >= 0),
+  reserved_stock  INTEGER      NOT NULL DEFAULT 0 CHECK (reserved_stock >= 0),
+  updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+  CHECK (reserved_stock <= stock)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouse_stock_line
+  ON warehouse_stock (product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'), warehouse_id);
+
+CREATE INDEX IF NOT EXISTS idx_warehouse_stock_warehouse_id
+  ON warehouse_stock (warehouse_id);
+
+CREATE TABLE IF NOT EXISTS order_allocations (
+  id             UUID         PRIMARY KEY,
+  order_id       UUID         NOT NULL REFERENCES orders(id),
+  order_item_id  UUID         NOT NULL REFERENCES order_items(id),
+  warehouse_id   UUID         NOT NULL REFERENCES warehouses(id),
+  product_id     UUID         REFERENCES products(id) ON DELETE SET NULL,
+  variant_id     UUID         REFERENCES product_variants(id) ON DELETE SET NULL,
+  quantity       INTEGER      NOT NULL CHECK (quantity > 0),
+  created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_allocations_order_id
+  ON order_allocations (order_id);
+
+ALTER TABLE stock_movements
+  ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses(id);
+
+-- Existing stock moves to a default warehouse so the per-warehouse rows add up
+-- to products.stock from the start.
+INSERT INTO warehouses (id, code, name, country, is_default)
+SELECT gen_random_uuid(), 'MAIN', 'Main warehouse', 'US', TRUE
+WHERE NOT EXISTS (SELECT 1 FROM warehouses WHERE is_default);
+
+INSERT INTO warehouse_stock (warehouse_id, product_id, variant_id, stock, reserved_stock)
+SELECT w.id, p.id, NULL, p.stock, p.reserved_stock
+FROM products p, warehouses w
+WHERE w.is_default
+ON CONFLICT DO NOTHING;
+
+INSERT INTO warehouse_stock (warehouse_id, product_id, variant_id, stock, reserved_stock)
+SELECT w.id, v.product_id, v.id, v.stock, v.reserved_stock
+FROM product_variants v, warehouses w
+WHERE w.is_default
+ON CONFLICT DO NOTHING;
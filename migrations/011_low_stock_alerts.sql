@@ -0,0 +1,32 @@
+ALTER TABLE products
+  ADD COLUMN IF NOT EXISTS reorder_threshold INTEGER CHECK (reorder_threshold >= 0);
+
+CREATE TABLE IF NOT EXISTS low_stock_alerts (
+  id           UUID          PRIMARY KEY,
+  product_id   UUID          NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  variant_id   UUID          REFERENCES product_variants(id) ON DELETE CASCADE,
+  sku          VARCHAR(50)   NOT NULL,
+  name         VARCHAR(200)  NOT NULL,
+  available    INTEGER       NOT NULL,
+  threshold    INTEGER       NOT NULL,
+  created_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  notified_at  TIMESTAMPTZ
+);
+
+-- At most one undelivered alert per product or variant.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_low_stock_alerts_pending
+  ON low_stock_alerts (product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'))
+  WHERE notified_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_low_stock_alerts_created_at
+  ON low_stock_alerts (created_at) WHERE notified_at IS NULL;
+
+-- Bring existing statuses in line with stock. Products with variants are
+-- available while any live variant is.
+UPDATE products p SET status = 'out_of_stock', updated_at = NOW()
+WHERE p.status = 'active' AND p.deleted_at IS NULL
+  AND CASE
+	WHEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id AND v.deleted_at IS NULL)
+	THEN (SELECT SUM(v.stock - v.reserved_stock) FROM product_variants v WHERE v.product_id = p.id AND v.deleted_at IS NULL)
+	ELSE p.stock - p.reserved_stock
+  END <= 0;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS product_subscriptions (
+  id            UUID           PRIMARY KEY,
+  user_id       UUID           NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  product_id    UUID           NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  variant_id    UUID           REFERENCES product_variants(id) ON DELETE CASCADE,
+  type          VARCHAR(20)    NOT NULL CHECK (type IN ('back_in_stock', 'price_below')),
+  target_price  NUMERIC(12,2)  CHECK (target_price > 0),
+  triggered_at  TIMESTAMPTZ,
+  created_at    TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+  CHECK ((type = 'price_below') = (target_price IS NOT NULL)),
+  CHECK (type = 'back_in_stock' OR variant_id IS NULL)
+);
+
+-- One subscription per user, product, variant and type; subscribing again
+-- updates the target price.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_subscriptions_line
+  ON product_subscriptions (user_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'), type);
+
+CREATE INDEX IF NOT EXISTS idx_product_subscriptions_waiting
+  ON product_subscriptions (product_id, type) WHERE triggered_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_product_subscriptions_triggered
+  ON product_subscriptions (triggered_at) WHERE triggered_at IS NOT NULL;
@@ -0,0 +1,39 @@
+ALTER TABLE products
+  ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3,2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS rating_count   INTEGER      NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS product_reviews (
+  id               UUID          PRIMARY KEY,
+  product_id       UUID          NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  user_id          UUID          NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  rating           SMALLINT      NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  title            VARCHAR(150)  NOT NULL,
+  body             TEXT          NOT NULL,
+  status           VARCHAR(20)   NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+  moderation_note  VARCHAR(500),
+  moderated_by     UUID          REFERENCES users(id) ON DELETE SET NULL,
+  moderated_at     TIMESTAMPTZ,
+  helpful_count    INTEGER       NOT NULL DEFAULT 0,
+  unhelpful_count  INTEGER       NOT NULL DEFAULT 0,
+  created_at       TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  updated_at       TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  UNIQUE (product_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_reviews_published
+  ON product_reviews (product_id, created_at DESC) WHERE status = 'approved';
+
+CREATE INDEX IF NOT EXISTS idx_product_reviews_queue
+  ON product_reviews (status, created_at);
+
+CREATE TABLE IF NOT EXISTS review_votes (
+  review_id   UUID         NOT NULL REFERENCES product_reviews(id) ON DELETE CASCADE,
+  user_id     UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  helpful     BOOLEAN      NOT NULL,
+  created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (review_id, user_id)
+);
+
+-- Sorting the catalog by rating.
+CREATE INDEX IF NOT EXISTS idx_products_rating
+  ON products (rating_average DESC, rating_count DESC) WHERE deleted_at IS NULL;
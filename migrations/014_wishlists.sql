@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS wishlists (
+  id           UUID          PRIMARY KEY,
+  user_id      UUID          NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name         VARCHAR(100)  NOT NULL,
+  share_token  VARCHAR(64)   UNIQUE,
+  created_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  updated_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS wishlist_items (
+  id           UUID          PRIMARY KEY,
+  wishlist_id  UUID          NOT NULL REFERENCES wishlists(id) ON DELETE CASCADE,
+  product_id   UUID          NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  variant_id   UUID          REFERENCES product_variants(id) ON DELETE CASCADE,
+  created_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+-- A product or variant is saved at most once per wishlist.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wishlist_items_line
+  ON wishlist_items (wishlist_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'));
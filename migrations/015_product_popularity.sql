@@ -0,0 +1,17 @@
+ALTER TABLE products
+  ADD COLUMN IF NOT EXISTS popularity_score DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- Sorting the catalog by popularity.
+CREATE INDEX IF NOT EXISTS idx_products_popularity
+  ON products (popularity_score DESC, id) WHERE deleted_at IS NULL;
+
+-- Product page views, counted per day so a view costs one upsert.
+CREATE TABLE IF NOT EXISTS product_daily_views (
+  product_id  UUID     NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  day         DATE     NOT NULL,
+  views       INTEGER  NOT NULL DEFAULT 0,
+  PRIMARY KEY (product_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_daily_views_day
+  ON product_daily_views (day);
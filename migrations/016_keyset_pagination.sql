@@ -0,0 +1,13 @@
+-- Keyset pagination compares (sort column, id) row values, so each default
+-- listing order gets a matching index.
+CREATE INDEX IF NOT EXISTS idx_products_created_at_id
+  ON products (created_at, id) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_created_at_id
+  ON orders (created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_created_at_id
+  ON orders (user_id, created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id
+  ON users (created_at, id) WHERE deleted_at IS NULL;
@@ -0,0 +1,33 @@
+-- Products keep their tags as an array of tag slugs; this table holds the
+-- display name of each slug.
+CREATE TABLE IF NOT EXISTS tags (
+  id          UUID         PRIMARY KEY,
+  name        VARCHAR(50)  NOT NULL,
+  slug        VARCHAR(50)  NOT NULL UNIQUE,
+  created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+  updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Existing tags were free text: turn them into slugs, dropping duplicates
+-- and keeping the order they were entered in.
+UPDATE products p SET tags = COALESCE((
+  SELECT array_agg(slug ORDER BY ord)
+  FROM (
+    SELECT slug, MIN(ord) AS ord
+    FROM (
+      SELECT trim(BOTH '-' FROM regexp_replace(lower(t), '[^a-z0-9]+', '-', 'g')) AS slug, ord
+      FROM unnest(p.tags) WITH ORDINALITY AS u(t, ord)
+    ) normalized
+    WHERE slug <> ''
+    GROUP BY slug
+  ) deduped
+), '{}')
+WHERE cardinality(p.tags) > 0;
+
+INSERT INTO tags (id, name, slug)
+SELECT gen_random_uuid(), slug, slug
+FROM (SELECT DISTINCT unnest(tags) AS slug FROM products) used
+ON CONFLICT (slug) DO NOTHING;
+
+-- Tag filters use @> (all) and && (any).
+CREATE INDEX IF NOT EXISTS idx_products_tags ON products USING GIN (tags);
@@ -0,0 +1,16 @@
+-- One row per signed-in device. Only a hash of the refresh token is stored.
+CREATE TABLE IF NOT EXISTS sessions (
+  id                UUID          PRIMARY KEY,
+  user_id           UUID          NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  refresh_token     VARCHAR(64)   NOT NULL UNIQUE,
+  access_token_jti  VARCHAR(64)   NOT NULL DEFAULT '',
+  ip_address        VARCHAR(45)   NOT NULL DEFAULT '',
+  user_agent        VARCHAR(500)  NOT NULL DEFAULT '',
+  expires_at        TIMESTAMPTZ   NOT NULL,
+  last_activity_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  is_valid          BOOLEAN       NOT NULL DEFAULT TRUE,
+  created_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_active
+  ON sessions (user_id, last_activity_at DESC) WHERE is_valid;
@@ -0,0 +1,24 @@
+-- Refresh tokens a session has rotated away from. Each session is one token
+-- family: presenting a token from this table means it was stolen or replayed,
+-- so the whole session is revoked.
+CREATE TABLE IF NOT EXISTS session_rotated_tokens (
+  token_hash   VARCHAR(64)  PRIMARY KEY,
+  session_id   UUID         NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+  rotated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_rotated_tokens_session
+  ON session_rotated_tokens (session_id);
+
+CREATE TABLE IF NOT EXISTS security_events (
+  id          UUID          PRIMARY KEY,
+  user_id     UUID          NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  session_id  UUID          REFERENCES sessions(id) ON DELETE SET NULL,
+  kind        VARCHAR(50)   NOT NULL,
+  ip_address  VARCHAR(45)   NOT NULL DEFAULT '',
+  user_agent  VARCHAR(500)  NOT NULL DEFAULT '',
+  created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_events_user
+  ON security_events (user_id, created_at DESC);
@@ -0,0 +1,21 @@
+-- Access tokens that must stop working before they expire. Rows are only
+-- needed until the token would have expired anyway and are pruned after that.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+  jti         VARCHAR(64)   PRIMARY KEY,
+  expires_at  TIMESTAMPTZ   NOT NULL,
+  revoked_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at
+  ON revoked_tokens (expires_at);
+
+-- Revokes every access token of a user issued before revoked_before. Used when
+-- the user's password or role changes or the user is deleted.
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+  user_id         UUID          PRIMARY KEY,
+  revoked_before  TIMESTAMPTZ   NOT NULL,
+  expires_at      TIMESTAMPTZ   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_token_revocations_expires_at
+  ON user_token_revocations (expires_at);
@@ -0,0 +1,13 @@
+-- Single-use password reset tokens. Only a hash of the token is stored, the
+-- token itself is only ever in the email sent to the user.
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+  id          UUID          PRIMARY KEY,
+  user_id     UUID          NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash  VARCHAR(64)   NOT NULL UNIQUE,
+  expires_at  TIMESTAMPTZ   NOT NULL,
+  used_at     TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user
+  ON password_reset_tokens (user_id, created_at DESC);
@@ -0,0 +1,20 @@
+-- Accounts created before verification existed are treated as verified, so
+-- the checkout policy doesn't lock out existing customers.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- A token verifies the address it was sent to; changing the email again
+-- leaves older tokens unable to verify the new one.
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+  id          UUID          PRIMARY KEY,
+  user_id     UUID          NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  email       VARCHAR(255)  NOT NULL,
+  token_hash  VARCHAR(64)   NOT NULL UNIQUE,
+  expires_at  TIMESTAMPTZ   NOT NULL,
+  used_at     TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user
+  ON email_verification_tokens (user_id, created_at DESC);
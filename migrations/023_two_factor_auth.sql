@@ -0,0 +1,39 @@
+-- TOTP secrets. A row without confirmed_at is an enrollment the user hasn't
+-- confirmed with a code yet; 2FA is only enforced once it's confirmed.
+-- last_used_step stops a code from being accepted twice.
+CREATE TABLE IF NOT EXISTS user_totp (
+  user_id         UUID          PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  secret          VARCHAR(64)   NOT NULL,
+  confirmed_at    TIMESTAMPTZ,
+  last_used_step  BIGINT        NOT NULL DEFAULT 0,
+  created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+-- One-time recovery codes, stored hashed like every other secret token.
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+  id          UUID          PRIMARY KEY,
+  user_id     UUID          NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash   VARCHAR(64)   NOT NULL,
+  used_at     TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, code_hash)
+);
+
+-- Handed out by a login that checked the password and still needs a second
+-- factor. attempts caps how many codes can be tried against one challenge,
+-- and summed over a user's recent challenges caps them per account.
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+  id          UUID          PRIMARY KEY,
+  user_id     UUID          NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash  VARCHAR(64)   NOT NULL UNIQUE,
+  attempts    INT           NOT NULL DEFAULT 0,
+  expires_at  TIMESTAMPTZ   NOT NULL,
+  used_at     TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_challenges_expires
+  ON mfa_challenges (expires_at);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_challenges_user
+  ON mfa_challenges (user_id, created_at);
@@ -0,0 +1,39 @@
+package model
+
+import (
+  "time"
+)
+
+type ImportJobStatus string
+
+const (
+  ImportJobStatusPending   ImportJobStatus = "pending"
+  ImportJobStatusRunning   ImportJobStatus = "running"
+  ImportJobStatusCompleted ImportJobStatus = "completed"
+  ImportJobStatusFailed    ImportJobStatus = "failed"
+)
+
+type ImportRowError struct {
+  Row    int               `json:"row"`
+  SKU    string            `json:"sku,omitempty"`
+  Errors map[string]string `json:"errors"`
+}
+
+type ImportJob struct {
+  ID            string           `db:"id"`
+  CreatedBy     string           `db:"created_by"`
+  Status        ImportJobStatus  `db:"status"`
+  DryRun        bool             `db:"dry_run"`
+  SourceKey     string           `db:"source_key"`
+  TotalRows     int              `db:"total_rows"`
+  ProcessedRows int              `db:"processed_rows"`
+  CreatedCount  int              `db:"created_count"`
+  UpdatedCount  int              `db:"updated_count"`
+  FailedCount   int              `db:"failed_count"`
+  Errors        []ImportRowError `db:"errors"`
+  FailureReason *string          `db:"failure_reason"`
+  CreatedAt     time.Time        `db:"created_at"`
+  UpdatedAt     time.Time        `db:"updated_at"`
+  StartedAt     *time.Time       `db:"started_at"`
+  FinishedAt    *time.Time       `db:"finished_at"`
+}
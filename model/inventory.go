@@ -0,0 +1,80 @@
+package model
+
+import (
+  "time"
+)
+
+type StockMovementType string
+
+const (
+  StockMovementAdjustment   StockMovementType = "adjustment"
+  StockMovementReceiving    StockMovementType = "receiving"
+  StockMovementSale         StockMovementType = "sale"
+  StockMovementCancellation StockMovementType = "cancellation"
+  StockMovementRefund       StockMovementType = "refund"
+  StockMovementReservation  StockMovementType = "reservation"
+  StockMovementRelease      StockMovementType = "release"
+  StockMovementImport       StockMovementType = "import"
+)
+
+// StockMovement is one entry of the append-only inventory ledger. Quantity is
+// the change to on-hand stock and ReservedQuantity the change to reserved
+// stock, so summing a product's movements gives its current stock.
+type StockMovement struct {
+  ID               string            `db:"id"`
+  ProductID        string            `db:"product_id"`
+  VariantID        *string           `db:"variant_id"`
+  WarehouseID      *string           `db:"warehouse_id"`
+  Type             StockMovementType `db:"type"`
+  Quantity         int               `db:"quantity"`
+  ReservedQuantity int               `db:"reserved_quantity"`
+  StockBefore      int               `db:"stock_before"`
+  StockAfter       int               `db:"stock_after"`
+  ReservedBefore   int               `db:"reserved_before"`
+  ReservedAfter    int               `db:"reserved_after"`
+  Reason           string            `db:"reason"`
+  ActorID          *string           `db:"actor_id"`
+  OrderID          *string           `db:"order_id"`
+  CreatedAt        time.Time         `db:"created_at"`
+}
+
+// StockDiscrepancy reports a product or variant whose stock columns don't
+// match the sum of its ledger or of its per-warehouse stock.
+type StockDiscrepancy struct {
+  ProductID         string  `db:"product_id"`
+  VariantID         *string `db:"variant_id"`
+  SKU               string  `db:"sku"`
+  Stock             int     `db:"stock"`
+  LedgerStock       int     `db:"ledger_stock"`
+  WarehouseStock    int     `db:"warehouse_stock"`
+  ReservedStock     int     `db:"reserved_stock"`
+  LedgerReserved    int     `db:"ledger_reserved"`
+  WarehouseReserved int     `db:"warehouse_reserved"`
+}
+
+// LowStockAlert is raised when the available stock of a product, or of one of
+// its variants, drops to the product's reorder threshold. It waits in an
+// outbox until a notifier delivers it.
+type LowStockAlert struct {
+  ID         string     `db:"id"`
+  ProductID  string     `db:"product_id"`
+  VariantID  *string    `db:"variant_id"`
+  SKU        string     `db:"sku"`
+  Name       string     `db:"name"`
+  Available  int        `db:"available"`
+  Threshold  int        `db:"threshold"`
+  CreatedAt  time.Time  `db:"created_at"`
+  NotifiedAt *time.Time `db:"notified_at"`
+}
+
+// LowStockItem is a product or variant at or below its reorder threshold.
+type LowStockItem struct {
+  ProductID     string        `db:"product_id"`
+  VariantID     *string       `db:"variant_id"`
+  SKU           string        `db:"sku"`
+  Name          string        `db:"name"`
+  Status        ProductStatus `db:"status"`
+  Stock         int           `db:"stock"`
+  ReservedStock int           `db:"reserved_stock"`
+  Threshold     int           `db:"threshold"`
+}
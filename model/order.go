@@ -92,7 +92,7 @@ type Order struct {
 type OrderItem struct {
   ID              string         `db:"id"`
   OrderID         string         `db:"order_id"`
-  ProductID       string         `db:"product_id"`
+  ProductID       *string        `db:"product_id"` // nil once the product has been purged
   VariantID       *string        `db:"variant_id"`
   ProductSKU      string         `db:"product_sku"`
   ProductName     string         `db:"product_name"`
@@ -0,0 +1,50 @@
+package model
+
+import (
+  "time"
+)
+
+type PriceChangeSource string
+
+const (
+  PriceChangeSourceManual   PriceChangeSource = "manual"
+  PriceChangeSourceImport   PriceChangeSource = "import"
+  PriceChangeSourceSchedule PriceChangeSource = "schedule"
+)
+
+type PriceChange struct {
+  ID               string            `db:"id"`
+  ProductID        string            `db:"product_id"`
+  OldPrice         float64           `db:"old_price"`
+  NewPrice         float64           `db:"new_price"`
+  OldCostPrice     float64           `db:"old_cost_price"`
+  NewCostPrice     float64           `db:"new_cost_price"`
+  Source           PriceChangeSource `db:"source"`
+  ChangedBy        *string           `db:"changed_by"`
+  ScheduledPriceID *string           `db:"scheduled_price_id"`
+  ChangedAt        time.Time         `db:"changed_at"`
+}
+
+type ScheduledPriceStatus string
+
+const (
+  ScheduledPriceStatusScheduled ScheduledPriceStatus = "scheduled"
+  ScheduledPriceStatusActive    ScheduledPriceStatus = "active"
+  ScheduledPriceStatusCompleted ScheduledPriceStatus = "completed"
+  ScheduledPriceStatusCancelled ScheduledPriceStatus = "cancelled"
+)
+
+type ScheduledPrice struct {
+  ID            string               `db:"id"`
+  ProductID     string               `db:"product_id"`
+  Price         float64              `db:"price"`
+  PreviousPrice *float64             `db:"previous_price"`
+  StartsAt      time.Time            `db:"starts_at"`
+  EndsAt        *time.Time           `db:"ends_at"`
+  Status        ScheduledPriceStatus `db:"status"`
+  CreatedBy     string               `db:"created_by"`
+  CreatedAt     time.Time            `db:"created_at"`
+  UpdatedAt     time.Time            `db:"updated_at"`
+  AppliedAt     *time.Time           `db:"applied_at"`
+  EndedAt       *time.Time           `db:"ended_at"`
+}
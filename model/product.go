@@ -38,6 +38,10 @@ type Product struct {
   Status      ProductStatus  `db:"status"`
   Images      []string       `db:"images"`
   Tags        []string       `db:"tags"`
+  ReorderThreshold *int      `db:"reorder_threshold"` // alert when available stock drops to this
+  RatingAverage float64      `db:"rating_average"` // over approved reviews only
+  RatingCount int            `db:"rating_count"`
+  PopularityScore float64    `db:"popularity_score"` // time-decayed, recomputed by a background job
   CreatedAt   time.Time      `db:"created_at"`
   UpdatedAt   time.Time      `db:"updated_at"`
   DeletedAt   *time.Time     `db:"deleted_at"`
@@ -76,7 +80,32 @@ type ProductVariant struct {
   Name       string     `db:"name"`
   Price      float64    `db:"price"`
   Stock      int        `db:"stock"`
+  ReservedStock int     `db:"reserved_stock"`
   Attributes map[string]string `db:"attributes"`
   CreatedAt  time.Time  `db:"created_at"`
   UpdatedAt  time.Time  `db:"updated_at"`
+  DeletedAt  *time.Time `db:"deleted_at"`
+}
+
+type ProductOption struct {
+  ID         string     `db:"id"`
+  ProductID  string     `db:"product_id"`
+  Name       string     `db:"name"`
+  Values     []string   `db:"values"`
+  Position   int        `db:"position"`
+  CreatedAt  time.Time  `db:"created_at"`
+  UpdatedAt  time.Time  `db:"updated_at"`
+}
+
+type ProductImage struct {
+  ID          string     `db:"id"`
+  ProductID   string     `db:"product_id"`
+  StorageKey  string     `db:"storage_key"`
+  URL         string     `db:"url"`
+  ContentType string     `db:"content_type"`
+  Size        int64      `db:"size"`
+  Width       int        `db:"width"`
+  Height      int        `db:"height"`
+  Position    int        `db:"position"`
+  CreatedAt   time.Time  `db:"created_at"`
 }
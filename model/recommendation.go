@@ -0,0 +1,15 @@
+package model
+
+import (
+  "time"
+)
+
+type ProductRecommendation struct {
+  ProductID        string    `db:"product_id"`
+  RelatedProductID string    `db:"related_product_id"`
+  Score            float64   `db:"score"`
+  ContentScore     float64   `db:"content_score"`
+  CoPurchaseScore  float64   `db:"co_purchase_score"`
+  CoPurchaseCount  int       `db:"co_purchase_count"`
+  ComputedAt       time.Time `db:"computed_at"`
+}
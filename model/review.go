@@ -0,0 +1,32 @@
+package model
+
+import (
+  "time"
+)
+
+type ReviewStatus string
+
+const (
+  ReviewStatusPending  ReviewStatus = "pending"
+  ReviewStatusApproved ReviewStatus = "approved"
+  ReviewStatusRejected ReviewStatus = "rejected"
+)
+
+// Review is a verified buyer's rating of a product. Only approved reviews are
+// published and count towards the product's rating.
+type Review struct {
+  ID             string       `db:"id"`
+  ProductID      string       `db:"product_id"`
+  UserID         string       `db:"user_id"`
+  Rating         int          `db:"rating"`
+  Title          string       `db:"title"`
+  Body           string       `db:"body"`
+  Status         ReviewStatus `db:"status"`
+  ModerationNote *string      `db:"moderation_note"`
+  ModeratedBy    *string      `db:"moderated_by"`
+  ModeratedAt    *time.Time   `db:"moderated_at"`
+  HelpfulCount   int          `db:"helpful_count"`
+  UnhelpfulCount int          `db:"unhelpful_count"`
+  CreatedAt      time.Time    `db:"created_at"`
+  UpdatedAt      time.Time    `db:"updated_at"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+  "time"
+)
+
+type SecurityEventKind string
+
+const (
+  SecurityEventRefreshTokenReuse SecurityEventKind = "refresh_token_reuse"
+)
+
+// SecurityEvent records something suspicious or sensitive that happened to an
+// account, along with the device it came from.
+type SecurityEvent struct {
+  ID        string            `db:"id"`
+  UserID    string            `db:"user_id"`
+  SessionID *string           `db:"session_id"`
+  Kind      SecurityEventKind `db:"kind"`
+  IPAddress string            `db:"ip_address"`
+  UserAgent string            `db:"user_agent"`
+  CreatedAt time.Time         `db:"created_at"`
+}
+
+// TOTP is a user's authenticator app secret. ConfirmedAt is nil until the
+// user proves the app was set up by entering a code.
+type TOTP struct {
+  UserID       string     `db:"user_id"`
+  Secret       string     `db:"secret"`
+  ConfirmedAt  *time.Time `db:"confirmed_at"`
+  LastUsedStep int64      `db:"last_used_step"`
+  CreatedAt    time.Time  `db:"created_at"`
+}
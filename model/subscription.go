@@ -0,0 +1,36 @@
+package model
+
+import (
+  "time"
+)
+
+type SubscriptionType string
+
+const (
+  SubscriptionBackInStock SubscriptionType = "back_in_stock"
+  SubscriptionPriceBelow  SubscriptionType = "price_below"
+)
+
+// ProductSubscription asks for a notification when a product, or one of its
+// variants, is back in stock or drops below a target price. It is triggered
+// when the stock or price changes and deleted once the notification is sent.
+type ProductSubscription struct {
+  ID          string           `db:"id"`
+  UserID      string           `db:"user_id"`
+  ProductID   string           `db:"product_id"`
+  VariantID   *string          `db:"variant_id"`
+  Type        SubscriptionType `db:"type"`
+  TargetPrice *float64         `db:"target_price"`
+  TriggeredAt *time.Time       `db:"triggered_at"`
+  CreatedAt   time.Time        `db:"created_at"`
+}
+
+// TriggeredSubscription is a triggered subscription with what its
+// notification needs to say.
+type TriggeredSubscription struct {
+  ProductSubscription
+  Email       string  `db:"email"`
+  ProductName string  `db:"product_name"`
+  SKU         string  `db:"sku"`
+  Price       float64 `db:"price"`
+}
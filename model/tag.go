@@ -0,0 +1,15 @@
+package model
+
+import (
+  "time"
+)
+
+// Tag labels products. Products store the slugs of their tags, so renaming or
+// merging a tag rewrites every product that carries it.
+type Tag struct {
+  ID        string    `db:"id"`
+  Name      string    `db:"name"`
+  Slug      string    `db:"slug"`
+  CreatedAt time.Time `db:"created_at"`
+  UpdatedAt time.Time `db:"updated_at"`
+}
@@ -11,6 +11,29 @@ const (
 	Customer UserRole = "customer"
 )
 
+// EmailVerificationPolicy decides what accounts whose email address isn't
+// verified yet can do.
+type EmailVerificationPolicy string
+
+const (
+	// EmailVerificationOptional lets unverified accounts do everything.
+	EmailVerificationOptional EmailVerificationPolicy = "optional"
+	// EmailVerificationForCheckout lets unverified accounts browse but not
+	// place orders.
+	EmailVerificationForCheckout EmailVerificationPolicy = "checkout"
+)
+
+// MFAPolicy decides which accounts must use two-factor authentication.
+type MFAPolicy string
+
+const (
+	// MFAOptional lets every user choose whether to turn 2FA on.
+	MFAOptional MFAPolicy = "optional"
+	// MFARequiredForAdmins makes admins enroll before they can sign in, and
+	// keeps them from turning 2FA off.
+	MFARequiredForAdmins MFAPolicy = "admin"
+)
+
 type User struct {
     ID        string     `db:"id"`
     Username  string     `db:"username"`
@@ -22,6 +45,7 @@ type User struct {
     Country   string     `db:"country"`
     CreatedAt time.Time  `db:"created_at"`
     UpdatedAt time.Time  `db:"updated_at"`
+    EmailVerifiedAt *time.Time `db:"email_verified_at"`
     DeletedAt *time.Time `db:"deleted_at"`
 }
 
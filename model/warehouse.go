@@ -0,0 +1,60 @@
+package model
+
+import (
+  "time"
+)
+
+type AllocationStrategy string
+
+const (
+  // AllocationClosest fills every order line from the warehouses closest to
+  // the shipping country first, even if that splits the shipment.
+  AllocationClosest AllocationStrategy = "closest"
+  // AllocationFewestSplits ships from as few warehouses as possible and only
+  // uses distance to break ties.
+  AllocationFewestSplits AllocationStrategy = "fewest_splits"
+)
+
+type Warehouse struct {
+  ID        string    `db:"id"`
+  Code      string    `db:"code"`
+  Name      string    `db:"name"`
+  Country   string    `db:"country"`
+  // ShipsTo lists the destination countries this warehouse serves, nearest
+  // first. It decides which warehouse is closest to an order.
+  ShipsTo   []string  `db:"ships_to"`
+  Priority  int       `db:"priority"` // lower ships first
+  IsDefault bool      `db:"is_default"`
+  IsActive  bool      `db:"is_active"`
+  CreatedAt time.Time `db:"created_at"`
+  UpdatedAt time.Time `db:"updated_at"`
+}
+
+// WarehouseStock is the stock of a product, or one of its variants, held at a
+// single warehouse. products.stock and product_variants.stock are the sum of
+// these rows.
+type WarehouseStock struct {
+  WarehouseID   string    `db:"warehouse_id"`
+  ProductID     string    `db:"product_id"`
+  VariantID     *string   `db:"variant_id"`
+  Stock         int       `db:"stock"`
+  ReservedStock int       `db:"reserved_stock"`
+  UpdatedAt     time.Time `db:"updated_at"`
+}
+
+func (ws *WarehouseStock) Available() int {
+  return ws.Stock - ws.ReservedStock
+}
+
+// StockAllocation records how many units of an order item are reserved at
+// a warehouse.
+type StockAllocation struct {
+  ID          string    `db:"id"`
+  OrderID     string    `db:"order_id"`
+  OrderItemID string    `db:"order_item_id"`
+  WarehouseID string    `db:"warehouse_id"`
+  ProductID   string    `db:"product_id"`
+  VariantID   *string   `db:"variant_id"`
+  Quantity    int       `db:"quantity"`
+  CreatedAt   time.Time `db:"created_at"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+  "time"
+)
+
+// Wishlist is a named list of products a user saved for later. Anyone with
+// the share token can view it; a nil token means the list is private.
+type Wishlist struct {
+  ID         string    `db:"id"`
+  UserID     string    `db:"user_id"`
+  Name       string    `db:"name"`
+  ShareToken *string   `db:"share_token"`
+  ItemCount  int       `db:"item_count"`
+  CreatedAt  time.Time `db:"created_at"`
+  UpdatedAt  time.Time `db:"updated_at"`
+}
+
+type WishlistItem struct {
+  ID         string    `db:"id"`
+  WishlistID string    `db:"wishlist_id"`
+  ProductID  string    `db:"product_id"`
+  VariantID  *string   `db:"variant_id"`
+  CreatedAt  time.Time `db:"created_at"`
+}
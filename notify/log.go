@@ -0,0 +1,19 @@
+package notify
+
+import (
+  "context"
+  "log"
+)
+
+// LogNotifier writes messages to the standard logger. It is meant for
+// development, where no mail server or webhook is configured.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+  return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+  log.Printf("notification %s to %s: %s\n%s", msg.Kind, msg.To, msg.Subject, msg.Body)
+  return nil
+}
@@ -0,0 +1,37 @@
+package notify
+
+import (
+  "context"
+)
+
+// Message is a notification addressed to a single recipient. Kind identifies
+// what the message is about, e.g. "low_stock", so notifiers can route or
+// format it. Data carries the details as plain strings for notifiers that
+// don't use the rendered Subject and Body.
+type Message struct {
+  To      string            `json:"to"`
+  Kind    string            `json:"kind"`
+  Subject string            `json:"subject"`
+  Body    string            `json:"body"`
+  Data    map[string]string `json:"data,omitempty"`
+}
+
+// Notifier delivers messages, e.g. by email or webhook. Implementations must
+// be safe for concurrent use.
+type Notifier interface {
+  Notify(ctx context.Context, msg Message) error
+}
+
+// Multi delivers every message through all notifiers, stopping at the first
+// error.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, msg Message) error {
+  for _, n := range m {
+	if err := n.Notify(ctx, msg); err != nil {
+	  return err
+	}
+  }
+
+  return nil
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+  "context"
+  "fmt"
+  "net"
+  "net/smtp"
+  "strings"
+)
+
+// SMTPNotifier sends messages as plain text email.
+type SMTPNotifier struct {
+  addr string
+  auth smtp.Auth
+  from string
+}
+
+// NewSMTPNotifier sends through the server at addr (host:port). Without a
+// username no authentication is attempted.
+func NewSMTPNotifier(addr, username, password, from string) *SMTPNotifier {
+  n := &SMTPNotifier{
+	addr: addr,
+	from: from,
+  }
+
+  if username != "" {
+	host, _, _ := net.SplitHostPort(addr)
+	n.auth = smtp.PlainAuth("", username, password, host)
+  }
+
+  return n
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+  if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+	return fmt.Errorf("invalid notification header")
+  }
+
+  var b strings.Builder
+  fmt.Fprintf(&b, "From: %s\r\n", n.from)
+  fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+  fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+  b.WriteString("MIME-Version: 1.0\r\n")
+  b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+  b.WriteString("\r\n")
+  b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+  if err := smtp.SendMail(n.addr, n.auth, n.from, []string{msg.To}, []byte(b.String())); err != nil {
+	return fmt.Errorf("failed to send email: %w", err)
+  }
+
+  return nil
+}
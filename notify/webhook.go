@@ -0,0 +1,49 @@
+package notify
+
+import (
+  "bytes"
+  "context"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "time"
+)
+
+// WebhookNotifier POSTs every message as JSON to a fixed URL, e.g. a chat
+// integration or an internal alerting service.
+type WebhookNotifier struct {
+  url    string
+  client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+  return &WebhookNotifier{
+	url: url,
+	client: &http.Client{Timeout: 10 * time.Second},
+  }
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, msg Message) error {
+  body, err := json.Marshal(msg)
+  if err != nil {
+	return fmt.Errorf("failed to encode notification: %w", err)
+  }
+
+  req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+  if err != nil {
+	return fmt.Errorf("failed to build webhook request: %w", err)
+  }
+  req.Header.Set("Content-Type", "application/json")
+
+  resp, err := n.client.Do(req)
+  if err != nil {
+	return fmt.Errorf("failed to call webhook: %w", err)
+  }
+  defer resp.Body.Close()
+
+  if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+	return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+  }
+
+  return nil
+}
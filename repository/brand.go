@@ -0,0 +1,230 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrBrandNotFound = errors.New("brand not found")
+)
+
+const brandColumns = `b.id, b.name, b.slug, b.description, b.logo_url, b.website_url, b.created_at, b.updated_at, b.deleted_at`
+
+type BrandWithProductCount struct {
+  model.Brand
+  ProductCount int
+}
+
+type BrandRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewBrandRepository(db *pgxpool.Pool) *BrandRepository {
+  return &BrandRepository{
+	db: db,
+  }
+}
+
+func (r *BrandRepository) Create(ctx context.Context, brand *model.Brand) error {
+  err := r.db.QueryRow(ctx,
+	`INSERT INTO brands (id, name, slug, description, logo_url, website_url)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING created_at, updated_at`,
+	brand.ID, brand.Name, brand.Slug, brand.Description, brand.LogoURL, brand.WebsiteURL,
+  ).Scan(&brand.CreatedAt, &brand.UpdatedAt)
+
+  if err != nil {
+	return r.translateError(err)
+  }
+
+  return nil
+}
+
+func (r *BrandRepository) GetByID(ctx context.Context, id string) (*model.Brand, error) {
+  brand, err := scanBrand(r.db.QueryRow(ctx,
+	"SELECT "+brandColumns+" FROM brands b WHERE b.id = $1 AND b.deleted_at IS NULL", id))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrBrandNotFound
+	}
+
+	return nil, fmt.Errorf("failed to get brand by id: %w", err)
+  }
+
+  return brand, nil
+}
+
+func (r *BrandRepository) GetBySlug(ctx context.Context, slug string) (*model.Brand, error) {
+  brand, err := scanBrand(r.db.QueryRow(ctx,
+	"SELECT "+brandColumns+" FROM brands b WHERE b.slug = $1 AND b.deleted_at IS NULL", slug))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrBrandNotFound
+	}
+
+	return nil, fmt.Errorf("failed to get brand by slug: %w", err)
+  }
+
+  return brand, nil
+}
+
+func (r *BrandRepository) GetByIDs(ctx context.Context, ids []string) ([]*model.Brand, error) {
+  rows, err := r.db.Query(ctx,
+	"SELECT "+brandColumns+" FROM brands b WHERE b.id = ANY($1) AND b.deleted_at IS NULL", ids)
+  if err != nil {
+	return nil, fmt.Errorf("failed to get brands: %w", err)
+  }
+  defer rows.Close()
+
+  brands := []*model.Brand{}
+  for rows.Next() {
+	brand, err := scanBrand(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan brand: %w", err)
+	}
+	brands = append(brands, brand)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate brands: %w", err)
+  }
+
+  return brands, nil
+}
+
+// ListWithProductCounts returns every brand with the number of active,
+// non-deleted products it has.
+func (r *BrandRepository) ListWithProductCounts(ctx context.Context) ([]*BrandWithProductCount, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT `+brandColumns+`, COUNT(p.id)
+	FROM brands b
+	LEFT JOIN products p ON p.brand_id = b.id AND p.deleted_at IS NULL AND p.status = 'active'
+	WHERE b.deleted_at IS NULL
+	GROUP BY b.id
+	ORDER BY b.name`,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list brands: %w", err)
+  }
+  defer rows.Close()
+
+  brands := []*BrandWithProductCount{}
+  for rows.Next() {
+	var b BrandWithProductCount
+	err := rows.Scan(
+	  &b.ID, &b.Name, &b.Slug, &b.Description, &b.LogoURL, &b.WebsiteURL, &b.CreatedAt, &b.UpdatedAt, &b.DeletedAt,
+	  &b.ProductCount,
+	)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan brand: %w", err)
+	}
+	brands = append(brands, &b)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate brands: %w", err)
+  }
+
+  return brands, nil
+}
+
+func (r *BrandRepository) Update(ctx context.Context, id string, updates map[string]interface{}) (*model.Brand, error) {
+  setClauses := []string{}
+  args := []interface{}{}
+  argID := 1
+
+  for field, value := range updates {
+	setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field, argID))
+	args = append(args, value)
+	argID++
+  }
+
+  setClauses = append(setClauses, fmt.Sprintf("updated_at = $%d", argID))
+  args = append(args, time.Now())
+  argID++
+
+  args = append(args, id)
+
+  query := fmt.Sprintf(
+	"UPDATE brands b SET %s WHERE b.id = $%d AND b.deleted_at IS NULL RETURNING %s",
+	strings.Join(setClauses, ", "),
+	argID,
+	brandColumns,
+  )
+
+  brand, err := scanBrand(r.db.QueryRow(ctx, query, args...))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrBrandNotFound
+	}
+
+	return nil, r.translateError(err)
+  }
+
+  return brand, nil
+}
+
+func (r *BrandRepository) Delete(ctx context.Context, id string) (time.Time, error) {
+  var deletedAt time.Time
+  err := r.db.QueryRow(ctx, "UPDATE brands SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING deleted_at", id).Scan(&deletedAt)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return time.Time{}, ErrBrandNotFound
+	}
+
+	return time.Time{}, fmt.Errorf("failed to delete brand: %w", err)
+  }
+
+  return deletedAt, nil
+}
+
+func (r *BrandRepository) HasProducts(ctx context.Context, id string) (bool, error) {
+  var exists bool
+  err := r.db.QueryRow(ctx,
+	"SELECT EXISTS(SELECT 1 FROM products WHERE brand_id = $1 AND deleted_at IS NULL)",
+	id,
+  ).Scan(&exists)
+
+  if err != nil {
+	return false, fmt.Errorf("failed to check brand products: %w", err)
+  }
+
+  return exists, nil
+}
+
+func (r *BrandRepository) translateError(err error) error {
+  if err == nil {
+	return nil
+  }
+
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) {
+	if pgErr.Code == "23505" && strings.Contains(pgErr.Detail, "slug") { // unique_violation
+	  return ErrDuplicateSlug
+	}
+  }
+
+  return err
+}
+
+func scanBrand(row pgx.Row) (*model.Brand, error) {
+  var b model.Brand
+  err := row.Scan(&b.ID, &b.Name, &b.Slug, &b.Description, &b.LogoURL, &b.WebsiteURL, &b.CreatedAt, &b.UpdatedAt, &b.DeletedAt)
+  if err != nil {
+	return nil, err
+  }
+
+  return &b, nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrCartNotFound = errors.New("cart not found")
+  ErrCartItemNotFound = errors.New("cart item not found")
+)
+
+type CartRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewCartRepository(db *pgxpool.Pool) *CartRepository {
+  return &CartRepository{
+	db: db,
+  }
+}
+
+// GetOrCreate returns the user's cart, creating an empty one when there is
+// none yet. Expired carts are emptied and reused.
+func (r *CartRepository) GetOrCreate(ctx context.Context, userID string, cartID string, expiresAt time.Time) (*model.ShoppingCart, error) {
+  var cart model.ShoppingCart
+  err := r.db.QueryRow(ctx,
+	`INSERT INTO shopping_carts (id, user_id, expires_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE SET updated_at = shopping_carts.updated_at
+	RETURNING id, user_id, expires_at, created_at, updated_at`,
+	cartID, userID, expiresAt,
+  ).Scan(&cart.ID, &cart.UserID, &cart.ExpiresAt, &cart.CreatedAt, &cart.UpdatedAt)
+
+  if err != nil {
+	return nil, fmt.Errorf("failed to get cart: %w", err)
+  }
+
+  if time.Now().After(cart.ExpiresAt) {
+	if _, err := r.db.Exec(ctx, "DELETE FROM cart_items WHERE cart_id = $1", cart.ID); err != nil {
+	  return nil, fmt.Errorf("failed to clear expired cart: %w", err)
+	}
+	if err := r.Touch(ctx, cart.ID, expiresAt); err != nil {
+	  return nil, err
+	}
+	cart.ExpiresAt = expiresAt
+  }
+
+  return &cart, nil
+}
+
+func (r *CartRepository) Touch(ctx context.Context, cartID string, expiresAt time.Time) error {
+  _, err := r.db.Exec(ctx,
+	"UPDATE shopping_carts SET expires_at = $2, updated_at = NOW() WHERE id = $1",
+	cartID, expiresAt,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to touch cart: %w", err)
+  }
+
+  return nil
+}
+
+func (r *CartRepository) ListItems(ctx context.Context, cartID string) ([]*model.CartItem, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT id, cart_id, product_id, variant_id, quantity, reservation_id, added_at, updated_at
+	FROM cart_items WHERE cart_id = $1 ORDER BY added_at`,
+	cartID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list cart items: %w", err)
+  }
+  defer rows.Close()
+
+  items := []*model.CartItem{}
+  for rows.Next() {
+	item, err := scanCartItem(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan cart item: %w", err)
+	}
+	items = append(items, item)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate cart items: %w", err)
+  }
+
+  return items, nil
+}
+
+// AddItem adds quantity to the matching cart line, creating it if needed, and
+// returns the resulting line. Lines are unique per product and variant.
+func (r *CartRepository) AddItem(ctx context.Context, item *model.CartItem) (*model.CartItem, error) {
+  saved, err := scanCartItem(r.db.QueryRow(ctx,
+	`INSERT INTO cart_items (id, cart_id, product_id, variant_id, quantity)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (cart_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'))
+	DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = NOW()
+	RETURNING id, cart_id, product_id, variant_id, quantity, reservation_id, added_at, updated_at`,
+	item.ID, item.CartID, item.ProductID, item.VariantID, item.Quantity,
+  ))
+
+  if err != nil {
+	return nil, fmt.Errorf("failed to add cart item: %w", err)
+  }
+
+  return saved, nil
+}
+
+func (r *CartRepository) GetItem(ctx context.Context, cartID, itemID string) (*model.CartItem, error) {
+  item, err := scanCartItem(r.db.QueryRow(ctx,
+	`SELECT id, cart_id, product_id, variant_id, quantity, reservation_id, added_at, updated_at
+	FROM cart_items WHERE id = $1 AND cart_id = $2`,
+	itemID, cartID,
+  ))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrCartItemNotFound
+	}
+
+	return nil, fmt.Errorf("failed to get cart item: %w", err)
+  }
+
+  return item, nil
+}
+
+func (r *CartRepository) UpdateItemQuantity(ctx context.Context, cartID, itemID string, quantity int) error {
+  tag, err := r.db.Exec(ctx,
+	"UPDATE cart_items SET quantity = $3, updated_at = NOW() WHERE id = $1 AND cart_id = $2",
+	itemID, cartID, quantity,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to update cart item: %w", err)
+  }
+
+  if tag.RowsAffected() == 0 {
+	return ErrCartItemNotFound
+  }
+
+  return nil
+}
+
+func (r *CartRepository) RemoveItem(ctx context.Context, cartID, itemID string) error {
+  tag, err := r.db.Exec(ctx, "DELETE FROM cart_items WHERE id = $1 AND cart_id = $2", itemID, cartID)
+  if err != nil {
+	return fmt.Errorf("failed to remove cart item: %w", err)
+  }
+
+  if tag.RowsAffected() == 0 {
+	return ErrCartItemNotFound
+  }
+
+  return nil
+}
+
+func scanCartItem(row pgx.Row) (*model.CartItem, error) {
+  var i model.CartItem
+  err := row.Scan(&i.ID, &i.CartID, &i.ProductID, &i.VariantID, &i.Quantity, &i.ReservationID, &i.AddedAt, &i.UpdatedAt)
+  if err != nil {
+	return nil, err
+  }
+
+  return &i, nil
+}
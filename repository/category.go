@@ -0,0 +1,317 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrCategoryNotFound = errors.New("category not found")
+  ErrDuplicateSlug = errors.New("slug already exists")
+  ErrParentCategoryNotFound = errors.New("parent category not found")
+  ErrCategoryCycle = errors.New("category cycle")
+)
+
+// maxCategoryDepth bounds walks up the category tree.
+const maxCategoryDepth = 32
+
+const categoryColumns = `c.id, c.name, c.slug, c.description, c.parent_id, c.image_url, c.sort_order,
+  c.is_active, c.created_at, c.updated_at, c.deleted_at`
+
+type CategoryRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewCategoryRepository(db *pgxpool.Pool) *CategoryRepository {
+  return &CategoryRepository{
+	db: db,
+  }
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *model.Category) error {
+  err := r.db.QueryRow(ctx,
+	`INSERT INTO categories (id, name, slug, description, parent_id, image_url, sort_order, is_active)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING created_at, updated_at`,
+	category.ID, category.Name, category.Slug, category.Description, category.ParentID,
+	category.ImageURL, category.SortOrder, category.IsActive,
+  ).Scan(&category.CreatedAt, &category.UpdatedAt)
+
+  if err != nil {
+	return r.translateError(err)
+  }
+
+  return nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id string) (*model.Category, error) {
+  category, err := scanCategory(r.db.QueryRow(ctx,
+	"SELECT "+categoryColumns+" FROM categories c WHERE c.id = $1 AND c.deleted_at IS NULL", id))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrCategoryNotFound
+	}
+
+	return nil, fmt.Errorf("failed to get category by id: %w", err)
+  }
+
+  return category, nil
+}
+
+func (r *CategoryRepository) List(ctx context.Context, includeInactive bool) ([]*model.Category, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT `+categoryColumns+`
+	FROM categories c
+	WHERE c.deleted_at IS NULL AND ($1 OR c.is_active)
+	ORDER BY c.sort_order, c.name`,
+	includeInactive,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list categories: %w", err)
+  }
+
+  return scanCategories(rows)
+}
+
+func (r *CategoryRepository) GetByIDs(ctx context.Context, ids []string) ([]*model.Category, error) {
+  rows, err := r.db.Query(ctx,
+	"SELECT "+categoryColumns+" FROM categories c WHERE c.id = ANY($1) AND c.deleted_at IS NULL", ids)
+  if err != nil {
+	return nil, fmt.Errorf("failed to get categories: %w", err)
+  }
+
+  return scanCategories(rows)
+}
+
+// GetAncestors returns the path from the root down to the given category,
+// including the category itself. The walk stops after maxCategoryDepth
+// levels, so a cycle in the data can't make it run forever.
+func (r *CategoryRepository) GetAncestors(ctx context.Context, id string) ([]*model.Category, error) {
+  rows, err := r.db.Query(ctx,
+	`WITH RECURSIVE ancestors AS (
+	  SELECT c.*, 0 AS depth FROM categories c WHERE c.id = $1 AND c.deleted_at IS NULL
+	  UNION ALL
+	  SELECT c.*, a.depth + 1 FROM categories c
+	  JOIN ancestors a ON c.id = a.parent_id
+	  WHERE c.deleted_at IS NULL AND a.depth < $2
+	)
+	SELECT `+categoryColumns+` FROM ancestors c ORDER BY c.depth DESC`,
+	id, maxCategoryDepth,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to get category ancestors: %w", err)
+  }
+
+  categories, err := scanCategories(rows)
+  if err != nil {
+	return nil, err
+  }
+
+  if len(categories) == 0 {
+	return nil, ErrCategoryNotFound
+  }
+
+  return categories, nil
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, id string, updates map[string]interface{}) (*model.Category, error) {
+  setClauses := []string{}
+  args := []interface{}{}
+  argID := 1
+
+  for field, value := range updates {
+	setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field, argID))
+	args = append(args, value)
+	argID++
+  }
+
+  setClauses = append(setClauses, fmt.Sprintf("updated_at = $%d", argID))
+  args = append(args, time.Now())
+  argID++
+
+  args = append(args, id)
+
+  query := fmt.Sprintf(
+	"UPDATE categories c SET %s WHERE c.id = $%d AND c.deleted_at IS NULL RETURNING %s",
+	strings.Join(setClauses, ", "),
+	argID,
+	categoryColumns,
+  )
+
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if parentID, ok := updates["parent_id"].(string); ok {
+	if err := checkReparent(ctx, tx, id, parentID); err != nil {
+	  return nil, err
+	}
+  }
+
+  category, err := scanCategory(tx.QueryRow(ctx, query, args...))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrCategoryNotFound
+	}
+
+	return nil, r.translateError(err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, fmt.Errorf("failed to commit category update: %w", err)
+  }
+
+  return category, nil
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id string) (time.Time, error) {
+  var deletedAt time.Time
+  err := r.db.QueryRow(ctx, "UPDATE categories SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING deleted_at", id).Scan(&deletedAt)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return time.Time{}, ErrCategoryNotFound
+	}
+
+	return time.Time{}, fmt.Errorf("failed to delete category: %w", err)
+  }
+
+  return deletedAt, nil
+}
+
+func (r *CategoryRepository) HasChildren(ctx context.Context, id string) (bool, error) {
+  var exists bool
+  err := r.db.QueryRow(ctx,
+	"SELECT EXISTS(SELECT 1 FROM categories WHERE parent_id = $1 AND deleted_at IS NULL)",
+	id,
+  ).Scan(&exists)
+
+  if err != nil {
+	return false, fmt.Errorf("failed to check category children: %w", err)
+  }
+
+  return exists, nil
+}
+
+func (r *CategoryRepository) HasProducts(ctx context.Context, id string) (bool, error) {
+  var exists bool
+  err := r.db.QueryRow(ctx,
+	"SELECT EXISTS(SELECT 1 FROM products WHERE category_id = $1 AND deleted_at IS NULL)",
+	id,
+  ).Scan(&exists)
+
+  if err != nil {
+	return false, fmt.Errorf("failed to check category products: %w", err)
+  }
+
+  return exists, nil
+}
+
+// categoryAncestorsCTE walks up from the category $2 through its live
+// ancestors, including itself.
+const categoryAncestorsCTE = `WITH RECURSIVE ancestors AS (
+	  SELECT id, parent_id FROM categories WHERE id = $2 AND deleted_at IS NULL
+	  UNION
+	  SELECT c.id, c.parent_id FROM categories c
+	  JOIN ancestors a ON c.id = a.parent_id
+	  WHERE c.deleted_at IS NULL
+	)`
+
+// checkReparent rejects moving categoryID under parentID when the parent
+// doesn't exist or the move would close a cycle. The moved category and the
+// parent's ancestors are locked first, in id order, so two concurrent moves
+// touching the same branch run one after the other and the second one checks
+// the tree the first one left.
+func checkReparent(ctx context.Context, q querier, categoryID, parentID string) error {
+  rows, err := q.Query(ctx,
+	`SELECT id FROM categories
+	WHERE id = $1 OR id IN (`+categoryAncestorsCTE+` SELECT id FROM ancestors)
+	ORDER BY id
+	FOR UPDATE`,
+	categoryID, parentID,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to lock categories: %w", err)
+  }
+  rows.Close()
+  if err := rows.Err(); err != nil {
+	return fmt.Errorf("failed to lock categories: %w", err)
+  }
+
+  var parentExists, cycle bool
+  err = q.QueryRow(ctx,
+	categoryAncestorsCTE+`
+	SELECT COUNT(*) > 0, COALESCE(bool_or(id = $1), false) FROM ancestors`,
+	categoryID, parentID,
+  ).Scan(&parentExists, &cycle)
+  if err != nil {
+	return fmt.Errorf("failed to check category ancestors: %w", err)
+  }
+
+  if !parentExists {
+	return ErrParentCategoryNotFound
+  }
+  if cycle {
+	return ErrCategoryCycle
+  }
+
+  return nil
+}
+
+func (r *CategoryRepository) translateError(err error) error {
+  if err == nil {
+	return nil
+  }
+
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) {
+	if pgErr.Code == "23505" && strings.Contains(pgErr.Detail, "slug") { // unique_violation
+	  return ErrDuplicateSlug
+	}
+  }
+
+  return err
+}
+
+func scanCategory(row pgx.Row) (*model.Category, error) {
+  var c model.Category
+  err := row.Scan(
+	&c.ID, &c.Name, &c.Slug, &c.Description, &c.ParentID, &c.ImageURL, &c.SortOrder,
+	&c.IsActive, &c.CreatedAt, &c.UpdatedAt, &c.DeletedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &c, nil
+}
+
+func scanCategories(rows pgx.Rows) ([]*model.Category, error) {
+  defer rows.Close()
+
+  categories := []*model.Category{}
+  for rows.Next() {
+	category, err := scanCategory(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan category: %w", err)
+	}
+	categories = append(categories, category)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate categories: %w", err)
+  }
+
+  return categories, nil
+}
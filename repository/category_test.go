@@ -0,0 +1,78 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "sync"
+  "testing"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/google/uuid"
+)
+
+func testCategory(t *testing.T, repo *CategoryRepository, parentID *string) string {
+  t.Helper()
+
+  id := uuid.New().String()
+  category := &model.Category{ID: id, Name: "Test " + id, Slug: "test-" + id, ParentID: parentID, IsActive: true}
+  if err := repo.Create(context.Background(), category); err != nil {
+	t.Fatal(err)
+  }
+  // Cleanups run last-in first-out, so children go before their parents.
+  t.Cleanup(func() { repo.db.Exec(context.Background(), "DELETE FROM categories WHERE id = $1", id) })
+
+  return id
+}
+
+func TestCategoryRepositoryRejectsReparentCycle(t *testing.T) {
+  ctx := context.Background()
+  repo := NewCategoryRepository(testDB(t))
+  root := testCategory(t, repo, nil)
+  child := testCategory(t, repo, &root)
+  grandchild := testCategory(t, repo, &child)
+
+  _, err := repo.Update(ctx, root, map[string]interface{}{"parent_id": grandchild})
+  if !errors.Is(err, ErrCategoryCycle) {
+	t.Errorf("moving a category under its grandchild: got %v, want %v", err, ErrCategoryCycle)
+  }
+
+  _, err = repo.Update(ctx, child, map[string]interface{}{"parent_id": uuid.New().String()})
+  if !errors.Is(err, ErrParentCategoryNotFound) {
+	t.Errorf("moving a category under a missing one: got %v, want %v", err, ErrParentCategoryNotFound)
+  }
+}
+
+func TestCategoryRepositoryConcurrentReparentsKeepTree(t *testing.T) {
+  ctx := context.Background()
+  repo := NewCategoryRepository(testDB(t))
+  a := testCategory(t, repo, nil)
+  b := testCategory(t, repo, nil)
+  t.Cleanup(func() { repo.db.Exec(context.Background(), "UPDATE categories SET parent_id = NULL WHERE id = ANY($1)", []string{a, b}) })
+
+  // Each move is fine alone; together they would make a and b each other's
+  // parent.
+  var wg sync.WaitGroup
+  errs := make([]error, 2)
+  for i, move := range [][2]string{{a, b}, {b, a}} {
+	wg.Add(1)
+	go func() {
+	  defer wg.Done()
+	  _, errs[i] = repo.Update(ctx, move[0], map[string]interface{}{"parent_id": move[1]})
+	}()
+  }
+  wg.Wait()
+
+  failed := 0
+  for _, err := range errs {
+	if err != nil {
+	  if !errors.Is(err, ErrCategoryCycle) {
+		t.Fatal(err)
+	  }
+	  failed++
+	}
+  }
+  if failed != 1 {
+	t.Errorf("%d moves were rejected, want exactly 1", failed)
+  }
+}
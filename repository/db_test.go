@@ -0,0 +1,28 @@
+package repository
+
+import (
+  "context"
+  "os"
+  "testing"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testDB connects to the migrated database at DATABASE_URL, skipping the test
+// when it isn't set.
+func testDB(t *testing.T) *pgxpool.Pool {
+  t.Helper()
+
+  dsn := os.Getenv("DATABASE_URL")
+  if dsn == "" {
+	t.Skip("DATABASE_URL is not set")
+  }
+
+  db, err := pgxpool.New(context.Background(), dsn)
+  if err != nil {
+	t.Fatalf("failed to connect to database: %v", err)
+  }
+  t.Cleanup(db.Close)
+
+  return db
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrVerificationTokenNotFound = errors.New("email verification token not found")
+)
+
+type EmailVerificationRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewEmailVerificationRepository(db *pgxpool.Pool) *EmailVerificationRepository {
+  return &EmailVerificationRepository{
+	db: db,
+  }
+}
+
+func (r *EmailVerificationRepository) Create(ctx context.Context, id, userID, email, tokenHash string, expiresAt time.Time) error {
+  _, err := r.db.Exec(ctx,
+	`INSERT INTO email_verification_tokens (id, user_id, email, token_hash, expires_at)
+	VALUES ($1, $2, $3, $4, $5)`,
+	id, userID, email, tokenHash, expiresAt,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to create email verification token: %w", err)
+  }
+
+  return nil
+}
+
+// CountSince returns how many verification tokens were issued to a user since
+// the given time, used or not.
+func (r *EmailVerificationRepository) CountSince(ctx context.Context, userID string, since time.Time) (int, error) {
+  var count int
+  err := r.db.QueryRow(ctx,
+	"SELECT COUNT(*) FROM email_verification_tokens WHERE user_id = $1 AND created_at > $2",
+	userID, since,
+  ).Scan(&count)
+  if err != nil {
+	return 0, fmt.Errorf("failed to count email verification tokens: %w", err)
+  }
+
+  return count, nil
+}
+
+// Consume uses up a token and marks the address it was sent to as verified,
+// returning the user. A token sent to an address the user has since changed
+// is used up without verifying anything and reported as not found.
+func (r *EmailVerificationRepository) Consume(ctx context.Context, tokenHash string) (string, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return "", fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  var userID, email string
+  err = tx.QueryRow(ctx,
+	`UPDATE email_verification_tokens SET used_at = NOW()
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+	RETURNING user_id, email`,
+	tokenHash,
+  ).Scan(&userID, &email)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return "", ErrVerificationTokenNotFound
+	}
+	return "", fmt.Errorf("failed to use email verification token: %w", err)
+  }
+
+  tag, err := tx.Exec(ctx,
+	`UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+	WHERE id = $1 AND email = $2 AND deleted_at IS NULL`,
+	userID, email,
+  )
+  if err != nil {
+	return "", fmt.Errorf("failed to verify email: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	if err := tx.Commit(ctx); err != nil {
+	  return "", fmt.Errorf("failed to commit email verification: %w", err)
+	}
+	return "", ErrVerificationTokenNotFound
+  }
+
+  _, err = tx.Exec(ctx,
+	"UPDATE email_verification_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL",
+	userID,
+  )
+  if err != nil {
+	return "", fmt.Errorf("failed to use email verification tokens: %w", err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return "", fmt.Errorf("failed to commit email verification: %w", err)
+  }
+
+  return userID, nil
+}
+
+// DeleteExpired drops tokens that expired before the given time.
+func (r *EmailVerificationRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+  tag, err := r.db.Exec(ctx,
+	"DELETE FROM email_verification_tokens WHERE expires_at < $1",
+	before,
+  )
+  if err != nil {
+	return 0, fmt.Errorf("failed to delete expired email verification tokens: %w", err)
+  }
+
+  return tag.RowsAffected(), nil
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrImageNotFound = errors.New("image not found")
+  ErrImageLimitReached = errors.New("product image limit reached")
+  ErrImageOrderMismatch = errors.New("image order must list every product image exactly once")
+)
+
+const imageColumns = `i.id, i.product_id, i.storage_key, i.url, i.content_type, i.size, i.width, i.height,
+  i.position, i.created_at`
+
+type ImageRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewImageRepository(db *pgxpool.Pool) *ImageRepository {
+  return &ImageRepository{
+	db: db,
+  }
+}
+
+// Create appends an image to the end of the product gallery. The product row
+// is locked so concurrent uploads can't exceed maxImages or share a position.
+func (r *ImageRepository) Create(ctx context.Context, image *model.ProductImage, maxImages int) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if err := lockProduct(ctx, tx, image.ProductID); err != nil {
+	return err
+  }
+
+  var count, nextPosition int
+  err = tx.QueryRow(ctx,
+	"SELECT COUNT(*), COALESCE(MAX(position) + 1, 0) FROM product_images WHERE product_id = $1",
+	image.ProductID,
+  ).Scan(&count, &nextPosition)
+  if err != nil {
+	return fmt.Errorf("failed to count product images: %w", err)
+  }
+
+  if count >= maxImages {
+	return ErrImageLimitReached
+  }
+
+  image.Position = nextPosition
+  err = tx.QueryRow(ctx,
+	`INSERT INTO product_images (id, product_id, storage_key, url, content_type, size, width, height, position)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING created_at`,
+	image.ID, image.ProductID, image.StorageKey, image.URL, image.ContentType, image.Size,
+	image.Width, image.Height, image.Position,
+  ).Scan(&image.CreatedAt)
+  if err != nil {
+	return fmt.Errorf("failed to create image: %w", err)
+  }
+
+  if err := syncProductImages(ctx, tx, image.ProductID, nil); err != nil {
+	return err
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit image: %w", err)
+  }
+
+  return nil
+}
+
+func (r *ImageRepository) GetByID(ctx context.Context, productID, imageID string) (*model.ProductImage, error) {
+  image, err := scanImage(r.db.QueryRow(ctx,
+	"SELECT "+imageColumns+" FROM product_images i WHERE i.id = $1 AND i.product_id = $2",
+	imageID, productID,
+  ))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrImageNotFound
+	}
+
+	return nil, fmt.Errorf("failed to get image by id: %w", err)
+  }
+
+  return image, nil
+}
+
+func (r *ImageRepository) ListByProduct(ctx context.Context, productID string) ([]*model.ProductImage, error) {
+  rows, err := r.db.Query(ctx,
+	"SELECT "+imageColumns+" FROM product_images i WHERE i.product_id = $1 ORDER BY i.position, i.created_at",
+	productID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list images: %w", err)
+  }
+  defer rows.Close()
+
+  return scanImages(rows)
+}
+
+// Reorder assigns positions following imageIDs, which must contain every image
+// of the product exactly once.
+func (r *ImageRepository) Reorder(ctx context.Context, productID string, imageIDs []string) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if err := lockProduct(ctx, tx, productID); err != nil {
+	return err
+  }
+
+  tag, err := tx.Exec(ctx,
+	`UPDATE product_images i SET position = o.ord - 1
+	FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, ord)
+	WHERE i.id = o.id AND i.product_id = $1`,
+	productID, imageIDs,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to reorder images: %w", err)
+  }
+
+  var total int
+  if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM product_images WHERE product_id = $1", productID).Scan(&total); err != nil {
+	return fmt.Errorf("failed to count product images: %w", err)
+  }
+
+  if int(tag.RowsAffected()) != len(imageIDs) || total != len(imageIDs) {
+	return ErrImageOrderMismatch
+  }
+
+  if err := syncProductImages(ctx, tx, productID, nil); err != nil {
+	return err
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit image order: %w", err)
+  }
+
+  return nil
+}
+
+// Delete removes the image row and drops its URL from the product. Removing
+// the stored files is left to the caller.
+func (r *ImageRepository) Delete(ctx context.Context, productID, imageID string) (*model.ProductImage, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  image, err := scanImage(tx.QueryRow(ctx,
+	"DELETE FROM product_images i WHERE i.id = $1 AND i.product_id = $2 RETURNING "+imageColumns,
+	imageID, productID,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrImageNotFound
+	}
+
+	return nil, fmt.Errorf("failed to delete image: %w", err)
+  }
+
+  if err := syncProductImages(ctx, tx, productID, []string{image.URL}); err != nil {
+	return nil, err
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, fmt.Errorf("failed to commit image deletion: %w", err)
+  }
+
+  return image, nil
+}
+
+// ListOrphaned returns images whose product has been purged. Images of
+// products sitting in the trash are kept so a restore brings them back.
+func (r *ImageRepository) ListOrphaned(ctx context.Context, limit int) ([]*model.ProductImage, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT `+imageColumns+`
+	FROM product_images i
+	LEFT JOIN products p ON p.id = i.product_id
+	WHERE p.id IS NULL
+	ORDER BY i.product_id, i.position
+	LIMIT $1`,
+	limit,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list orphaned images: %w", err)
+  }
+  defer rows.Close()
+
+  return scanImages(rows)
+}
+
+func (r *ImageRepository) DeleteByIDs(ctx context.Context, imageIDs []string) error {
+  _, err := r.db.Exec(ctx, "DELETE FROM product_images WHERE id = ANY($1)", imageIDs)
+  if err != nil {
+	return fmt.Errorf("failed to delete images: %w", err)
+  }
+
+  return nil
+}
+
+func lockProduct(ctx context.Context, q querier, productID string) error {
+  var id string
+  err := q.QueryRow(ctx,
+	"SELECT id FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE",
+	productID,
+  ).Scan(&id)
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return ErrNotFound
+	}
+
+	return fmt.Errorf("failed to lock product: %w", err)
+  }
+
+  return nil
+}
+
+// syncProductImages keeps products.images in step with the uploaded gallery:
+// uploaded images come first in gallery order, followed by any pre-hosted URLs
+// the product already had. URLs listed in removed are dropped.
+func syncProductImages(ctx context.Context, q querier, productID string, removed []string) error {
+  if removed == nil {
+	removed = []string{}
+  }
+
+  _, err := q.Exec(ctx,
+	`UPDATE products p SET
+	  images = ARRAY(SELECT i.url FROM product_images i WHERE i.product_id = p.id ORDER BY i.position, i.created_at)
+	    || ARRAY(
+	      SELECT u FROM unnest(p.images) AS u
+	      WHERE u <> ALL(ARRAY(SELECT i.url FROM product_images i WHERE i.product_id = p.id))
+	        AND u <> ALL($2::text[])
+	    ),
+	  updated_at = NOW()
+	WHERE p.id = $1`,
+	productID, removed,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to sync product images: %w", err)
+  }
+
+  return nil
+}
+
+func scanImage(row pgx.Row) (*model.ProductImage, error) {
+  var i model.ProductImage
+  err := row.Scan(
+	&i.ID, &i.ProductID, &i.StorageKey, &i.URL, &i.ContentType, &i.Size, &i.Width, &i.Height,
+	&i.Position, &i.CreatedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &i, nil
+}
+
+func scanImages(rows pgx.Rows) ([]*model.ProductImage, error) {
+  images := []*model.ProductImage{}
+  for rows.Next() {
+	image, err := scanImage(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan image: %w", err)
+	}
+	images = append(images, image)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate images: %w", err)
+  }
+
+  return images, nil
+}
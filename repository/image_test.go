@@ -0,0 +1,51 @@
+package repository
+
+import (
+  "context"
+  "testing"
+
+  "github.com/google/uuid"
+)
+
+func TestImageRepositoryListsImagesOfPurgedProducts(t *testing.T) {
+  ctx := context.Background()
+  repo := NewImageRepository(testDB(t))
+  imageID := uuid.New().String()
+  t.Cleanup(func() { repo.db.Exec(context.Background(), "DELETE FROM product_images WHERE id = $1", imageID) })
+
+  // The product was purged, which leaves its image rows behind.
+  _, err := repo.db.Exec(ctx,
+	`INSERT INTO product_images (id, product_id, storage_key, url, content_type, size, width, height)
+	VALUES ($1, $2, 'original.jpg', '/uploads/original.jpg', 'image/jpeg', 4, 1, 1)`,
+	imageID, uuid.New().String(),
+  )
+  if err != nil {
+	t.Fatal(err)
+  }
+
+  if !listsOrphan(t, repo, imageID) {
+	t.Fatal("image of a purged product isn't listed")
+  }
+
+  if err := repo.DeleteByIDs(ctx, []string{imageID}); err != nil {
+	t.Fatal(err)
+  }
+  if listsOrphan(t, repo, imageID) {
+	t.Error("deleted image is still listed")
+  }
+}
+
+func listsOrphan(t *testing.T, repo *ImageRepository, imageID string) bool {
+  t.Helper()
+
+  images, err := repo.ListOrphaned(context.Background(), 10000)
+  if err != nil {
+	t.Fatal(err)
+  }
+  for _, img := range images {
+	if img.ID == imageID {
+	  return true
+	}
+  }
+  return false
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrImportJobNotFound = errors.New("import job not found")
+)
+
+const importJobColumns = `j.id, j.created_by, j.status, j.dry_run, j.source_key, j.total_rows, j.processed_rows,
+  j.created_count, j.updated_count, j.failed_count, j.errors, j.failure_reason, j.created_at, j.updated_at,
+  j.started_at, j.finished_at`
+
+type ImportJobRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewImportJobRepository(db *pgxpool.Pool) *ImportJobRepository {
+  return &ImportJobRepository{
+	db: db,
+  }
+}
+
+func (r *ImportJobRepository) Create(ctx context.Context, job *model.ImportJob) error {
+  err := r.db.QueryRow(ctx,
+	`INSERT INTO product_import_jobs (id, created_by, status, dry_run, source_key)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING created_at, updated_at`,
+	job.ID, job.CreatedBy, job.Status, job.DryRun, job.SourceKey,
+  ).Scan(&job.CreatedAt, &job.UpdatedAt)
+
+  if err != nil {
+	return fmt.Errorf("failed to create import job: %w", err)
+  }
+
+  return nil
+}
+
+func (r *ImportJobRepository) GetByID(ctx context.Context, id string) (*model.ImportJob, error) {
+  job, err := scanImportJob(r.db.QueryRow(ctx,
+	"SELECT "+importJobColumns+" FROM product_import_jobs j WHERE j.id = $1", id))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrImportJobNotFound
+	}
+
+	return nil, fmt.Errorf("failed to get import job: %w", err)
+  }
+
+  return job, nil
+}
+
+// ClaimNext marks the oldest pending job as running and returns it. Jobs left
+// running without progress for longer than staleAfter are assumed to belong to
+// a worker that died and are claimed again. Returns nil when there is nothing
+// to do.
+func (r *ImportJobRepository) ClaimNext(ctx context.Context, staleAfter time.Duration) (*model.ImportJob, error) {
+  job, err := scanImportJob(r.db.QueryRow(ctx,
+	`UPDATE product_import_jobs j SET
+	  status = 'running', started_at = COALESCE(j.started_at, NOW()), updated_at = NOW()
+	WHERE j.id = (
+	  SELECT id FROM product_import_jobs
+	  WHERE status = 'pending' OR (status = 'running' AND updated_at < $1)
+	  ORDER BY created_at
+	  FOR UPDATE SKIP LOCKED
+	  LIMIT 1
+	)
+	RETURNING `+importJobColumns,
+	time.Now().Add(-staleAfter),
+  ))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, nil
+	}
+
+	return nil, fmt.Errorf("failed to claim import job: %w", err)
+  }
+
+  return job, nil
+}
+
+// SaveProgress stores counters and collected row errors. It also serves as the
+// heartbeat ClaimNext relies on.
+func (r *ImportJobRepository) SaveProgress(ctx context.Context, job *model.ImportJob) error {
+  _, err := r.db.Exec(ctx,
+	`UPDATE product_import_jobs SET
+	  total_rows = $2, processed_rows = $3, created_count = $4, updated_count = $5, failed_count = $6,
+	  errors = $7, updated_at = NOW()
+	WHERE id = $1`,
+	job.ID, job.TotalRows, job.ProcessedRows, job.CreatedCount, job.UpdatedCount, job.FailedCount, job.Errors,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to save import progress: %w", err)
+  }
+
+  return nil
+}
+
+func (r *ImportJobRepository) Finish(ctx context.Context, job *model.ImportJob) error {
+  err := r.db.QueryRow(ctx,
+	`UPDATE product_import_jobs SET
+	  status = $2, total_rows = $3, processed_rows = $4, created_count = $5, updated_count = $6,
+	  failed_count = $7, errors = $8, failure_reason = $9, updated_at = NOW(), finished_at = NOW()
+	WHERE id = $1
+	RETURNING finished_at`,
+	job.ID, job.Status, job.TotalRows, job.ProcessedRows, job.CreatedCount, job.UpdatedCount,
+	job.FailedCount, job.Errors, job.FailureReason,
+  ).Scan(&job.FinishedAt)
+
+  if err != nil {
+	return fmt.Errorf("failed to finish import job: %w", err)
+  }
+
+  return nil
+}
+
+func scanImportJob(row pgx.Row) (*model.ImportJob, error) {
+  var j model.ImportJob
+  err := row.Scan(
+	&j.ID, &j.CreatedBy, &j.Status, &j.DryRun, &j.SourceKey, &j.TotalRows, &j.ProcessedRows,
+	&j.CreatedCount, &j.UpdatedCount, &j.FailedCount, &j.Errors, &j.FailureReason, &j.CreatedAt, &j.UpdatedAt,
+	&j.StartedAt, &j.FinishedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &j, nil
+}
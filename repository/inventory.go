@@ -0,0 +1,488 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrInsufficientStock = errors.New("insufficient stock")
+)
+
+const stockMovementColumns = `m.id, m.product_id, m.variant_id, m.warehouse_id, m.type, m.quantity, m.reserved_quantity,
+  m.stock_before, m.stock_after, m.reserved_before, m.reserved_after, m.reason, m.actor_id, m.order_id,
+  m.created_at`
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx, so stock helpers can
+// run standalone or as part of a larger transaction.
+type querier interface {
+  Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+  Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+  QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type InventoryRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewInventoryRepository(db *pgxpool.Pool) *InventoryRepository {
+  return &InventoryRepository{
+	db: db,
+  }
+}
+
+// ListMovements returns the ledger of a product, newest first. When variantID
+// is set only that variant's movements are returned.
+func (r *InventoryRepository) ListMovements(ctx context.Context, productID string, variantID *string, limit, offset int) ([]*model.StockMovement, int, error) {
+  var total int
+  err := r.db.QueryRow(ctx,
+	"SELECT COUNT(*) FROM stock_movements m WHERE m.product_id = $1 AND ($2::uuid IS NULL OR m.variant_id = $2)",
+	productID, variantID,
+  ).Scan(&total)
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to count stock movements: %w", err)
+  }
+
+  rows, err := r.db.Query(ctx,
+	`SELECT `+stockMovementColumns+`
+	FROM stock_movements m
+	WHERE m.product_id = $1 AND ($2::uuid IS NULL OR m.variant_id = $2)
+	ORDER BY m.created_at DESC, m.id
+	LIMIT $3 OFFSET $4`,
+	productID, variantID, limit, offset,
+  )
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to list stock movements: %w", err)
+  }
+  defer rows.Close()
+
+  movements := []*model.StockMovement{}
+  for rows.Next() {
+	m, err := scanStockMovement(rows)
+	if err != nil {
+	  return nil, 0, fmt.Errorf("failed to scan stock movement: %w", err)
+	}
+	movements = append(movements, m)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, 0, fmt.Errorf("failed to iterate stock movements: %w", err)
+  }
+
+  return movements, total, nil
+}
+
+// Reconcile compares the stock and reserved stock of every product and variant
+// with the sum of its ledger and the sum of its per-warehouse stock, and
+// returns the ones that disagree.
+func (r *InventoryRepository) Reconcile(ctx context.Context) ([]*model.StockDiscrepancy, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT p.id, NULL::uuid, p.sku, p.stock, l.stock, w.stock, p.reserved_stock, l.reserved, w.reserved
+	FROM products p
+	CROSS JOIN LATERAL (
+	  SELECT COALESCE(SUM(quantity), 0) AS stock, COALESCE(SUM(reserved_quantity), 0) AS reserved
+	  FROM stock_movements WHERE product_id = p.id AND variant_id IS NULL
+	) l
+	CROSS JOIN LATERAL (
+	  SELECT COALESCE(SUM(stock), 0) AS stock, COALESCE(SUM(reserved_stock), 0) AS reserved
+	  FROM warehouse_stock WHERE product_id = p.id AND variant_id IS NULL
+	) w
+	WHERE p.stock <> l.stock OR p.stock <> w.stock
+	  OR p.reserved_stock <> l.reserved OR p.reserved_stock <> w.reserved
+	UNION ALL
+	SELECT v.product_id, v.id, v.sku, v.stock, l.stock, w.stock, v.reserved_stock, l.reserved, w.reserved
+	FROM product_variants v
+	CROSS JOIN LATERAL (
+	  SELECT COALESCE(SUM(quantity), 0) AS stock, COALESCE(SUM(reserved_quantity), 0) AS reserved
+	  FROM stock_movements WHERE variant_id = v.id
+	) l
+	CROSS JOIN LATERAL (
+	  SELECT COALESCE(SUM(stock), 0) AS stock, COALESCE(SUM(reserved_stock), 0) AS reserved
+	  FROM warehouse_stock WHERE variant_id = v.id
+	) w
+	WHERE v.stock <> l.stock OR v.stock <> w.stock
+	  OR v.reserved_stock <> l.reserved OR v.reserved_stock <> w.reserved
+	ORDER BY 3`,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to reconcile stock: %w", err)
+  }
+  defer rows.Close()
+
+  discrepancies := []*model.StockDiscrepancy{}
+  for rows.Next() {
+	var d model.StockDiscrepancy
+	err := rows.Scan(
+	  &d.ProductID, &d.VariantID, &d.SKU, &d.Stock, &d.LedgerStock, &d.WarehouseStock,
+	  &d.ReservedStock, &d.LedgerReserved, &d.WarehouseReserved,
+	)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan stock discrepancy: %w", err)
+	}
+	discrepancies = append(discrepancies, &d)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate stock discrepancies: %w", err)
+  }
+
+  return discrepancies, nil
+}
+
+// ListLowStock returns the products and variants whose available stock is at
+// or below their product's reorder threshold, least available first, along
+// with how many there are in total.
+func (r *InventoryRepository) ListLowStock(ctx context.Context, limit, offset int) ([]*model.LowStockItem, int, error) {
+  const lowStock = `
+	SELECT p.id AS product_id, NULL::uuid AS variant_id, p.sku, p.name, p.status, p.stock, p.reserved_stock,
+	  p.reorder_threshold AS threshold
+	FROM products p
+	WHERE p.deleted_at IS NULL AND p.reorder_threshold IS NOT NULL
+	  AND p.stock - p.reserved_stock <= p.reorder_threshold
+	  AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id AND v.deleted_at IS NULL)
+	UNION ALL
+	SELECT p.id, v.id, v.sku, p.name || ' - ' || v.name, p.status, v.stock, v.reserved_stock, p.reorder_threshold
+	FROM product_variants v
+	JOIN products p ON p.id = v.product_id
+	WHERE p.deleted_at IS NULL AND v.deleted_at IS NULL AND p.reorder_threshold IS NOT NULL
+	  AND v.stock - v.reserved_stock <= p.reorder_threshold`
+
+  var total int
+  if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM ("+lowStock+") l").Scan(&total); err != nil {
+	return nil, 0, fmt.Errorf("failed to count low stock items: %w", err)
+  }
+
+  rows, err := r.db.Query(ctx,
+	"SELECT * FROM ("+lowStock+") l ORDER BY l.stock - l.reserved_stock, l.sku LIMIT $1 OFFSET $2",
+	limit, offset,
+  )
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to list low stock items: %w", err)
+  }
+  defer rows.Close()
+
+  items := []*model.LowStockItem{}
+  for rows.Next() {
+	var item model.LowStockItem
+	err := rows.Scan(
+	  &item.ProductID, &item.VariantID, &item.SKU, &item.Name, &item.Status, &item.Stock, &item.ReservedStock,
+	  &item.Threshold,
+	)
+	if err != nil {
+	  return nil, 0, fmt.Errorf("failed to scan low stock item: %w", err)
+	}
+	items = append(items, &item)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, 0, fmt.Errorf("failed to iterate low stock items: %w", err)
+  }
+
+  return items, total, nil
+}
+
+// DeliverPendingAlerts hands up to limit undelivered low-stock alerts, oldest
+// first, to deliver and marks the ones it accepted as notified. Alerts are
+// locked while being delivered so concurrent runs skip them. Failed
+// deliveries stay pending for the next run; their errors are joined.
+func (r *InventoryRepository) DeliverPendingAlerts(ctx context.Context, limit int, deliver func(*model.LowStockAlert) error) (int, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return 0, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  rows, err := tx.Query(ctx,
+	`SELECT id, product_id, variant_id, sku, name, available, threshold, created_at, notified_at
+	FROM low_stock_alerts
+	WHERE notified_at IS NULL
+	ORDER BY created_at
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED`,
+	limit,
+  )
+  if err != nil {
+	return 0, fmt.Errorf("failed to get pending alerts: %w", err)
+  }
+
+  alerts, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.LowStockAlert])
+  if err != nil {
+	return 0, fmt.Errorf("failed to scan pending alerts: %w", err)
+  }
+
+  delivered := []string{}
+  var failures []error
+  for _, a := range alerts {
+	if err := deliver(a); err != nil {
+	  failures = append(failures, fmt.Errorf("alert %s: %w", a.ID, err))
+	  continue
+	}
+	delivered = append(delivered, a.ID)
+  }
+
+  if len(delivered) > 0 {
+	_, err := tx.Exec(ctx, "UPDATE low_stock_alerts SET notified_at = NOW() WHERE id = ANY($1)", delivered)
+	if err != nil {
+	  return 0, fmt.Errorf("failed to mark alerts notified: %w", err)
+	}
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return 0, fmt.Errorf("failed to commit alerts: %w", err)
+  }
+
+  return len(delivered), errors.Join(failures...)
+}
+
+// applyStockMovement changes the stock and reserved stock a warehouse holds
+// of a product, or of one of its variants when m.VariantID is set, keeps the
+// product or variant totals in step, and appends m to the ledger with the
+// resulting before/after totals. Movements without a warehouse go to the
+// default one. The change is refused with ErrInsufficientStock when it would
+// leave the warehouse with less stock than it has reserved, or a negative
+// reservation. The check and the update are a single statement so concurrent
+// movements can't oversell.
+func applyStockMovement(ctx context.Context, q querier, m *model.StockMovement) error {
+  if m.WarehouseID == nil {
+	warehouseID, err := defaultWarehouseID(ctx, q)
+	if err != nil {
+	  return err
+	}
+	m.WarehouseID = &warehouseID
+  }
+
+  _, err := q.Exec(ctx,
+	`INSERT INTO warehouse_stock (warehouse_id, product_id, variant_id)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'), warehouse_id) DO NOTHING`,
+	*m.WarehouseID, m.ProductID, m.VariantID,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to create warehouse stock: %w", err)
+  }
+
+  tag, err := q.Exec(ctx,
+	`UPDATE warehouse_stock SET stock = stock + $4, reserved_stock = reserved_stock + $5, updated_at = NOW()
+	WHERE warehouse_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3
+	  AND stock + $4 >= reserved_stock + $5 AND reserved_stock + $5 >= 0`,
+	*m.WarehouseID, m.ProductID, m.VariantID, m.Quantity, m.ReservedQuantity,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to apply warehouse stock movement: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrInsufficientStock
+  }
+
+  if m.VariantID != nil {
+	err = q.QueryRow(ctx,
+	  `UPDATE product_variants SET stock = stock + $3, reserved_stock = reserved_stock + $4, updated_at = NOW()
+	  WHERE id = $1 AND product_id = $2
+	    AND stock + $3 >= reserved_stock + $4 AND reserved_stock + $4 >= 0
+	  RETURNING stock, reserved_stock`,
+	  *m.VariantID, m.ProductID, m.Quantity, m.ReservedQuantity,
+	).Scan(&m.StockAfter, &m.ReservedAfter)
+  } else {
+	err = q.QueryRow(ctx,
+	  `UPDATE products SET stock = stock + $2, reserved_stock = reserved_stock + $3, updated_at = NOW()
+	  WHERE id = $1
+	    AND stock + $2 >= reserved_stock + $3 AND reserved_stock + $3 >= 0
+	  RETURNING stock, reserved_stock`,
+	  m.ProductID, m.Quantity, m.ReservedQuantity,
+	).Scan(&m.StockAfter, &m.ReservedAfter)
+  }
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return ErrInsufficientStock
+	}
+	return fmt.Errorf("failed to apply stock movement: %w", err)
+  }
+
+  m.StockBefore = m.StockAfter - m.Quantity
+  m.ReservedBefore = m.ReservedAfter - m.ReservedQuantity
+
+  if err := recordStockMovement(ctx, q, m); err != nil {
+	return err
+  }
+
+  if err := checkLowStock(ctx, q, m); err != nil {
+	return err
+  }
+
+  if m.VariantID != nil && m.StockBefore - m.ReservedBefore <= 0 && m.StockAfter - m.ReservedAfter > 0 {
+	if err := triggerBackInStock(ctx, q, m.ProductID, m.VariantID); err != nil {
+	  return err
+	}
+  }
+
+  return refreshProductStatus(ctx, q, m.ProductID)
+}
+
+// refreshProductStatus switches a product between active and out_of_stock to
+// match its available stock. A product with variants is available while any
+// of its live variants is. Products an admin made inactive or discontinued
+// are left alone. Coming back to active triggers back-in-stock subscriptions.
+func refreshProductStatus(ctx context.Context, q querier, productID string) error {
+  var status model.ProductStatus
+  err := q.QueryRow(ctx,
+	`UPDATE products p SET status = a.status, updated_at = NOW()
+	FROM (
+	  SELECT CASE WHEN (
+	    CASE
+	      WHEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = $1 AND v.deleted_at IS NULL)
+	      THEN (SELECT SUM(v.stock - v.reserved_stock) FROM product_variants v WHERE v.product_id = $1 AND v.deleted_at IS NULL)
+	      ELSE (SELECT stock - reserved_stock FROM products WHERE id = $1)
+	    END
+	  ) > 0 THEN 'active' ELSE 'out_of_stock' END AS status
+	) a
+	WHERE p.id = $1 AND p.status IN ('active', 'out_of_stock') AND p.status <> a.status
+	RETURNING p.status`,
+	productID,
+  ).Scan(&status)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil
+	}
+	return fmt.Errorf("failed to refresh product status: %w", err)
+  }
+
+  if status == model.ProductStatusActive {
+	return triggerBackInStock(ctx, q, productID, nil)
+  }
+
+  return nil
+}
+
+// checkLowStock raises a low-stock alert when m takes the available stock of
+// a product or variant from above the product's reorder threshold to at or
+// below it, and withdraws an undelivered alert once stock is back above it.
+func checkLowStock(ctx context.Context, q querier, m *model.StockMovement) error {
+  var threshold *int
+  var sku, name string
+  err := q.QueryRow(ctx,
+	`SELECT p.reorder_threshold, COALESCE(v.sku, p.sku), COALESCE(p.name || ' - ' || v.name, p.name)
+	FROM products p
+	LEFT JOIN product_variants v ON v.id = $2
+	WHERE p.id = $1`,
+	m.ProductID, m.VariantID,
+  ).Scan(&threshold, &sku, &name)
+  if err != nil {
+	return fmt.Errorf("failed to get reorder threshold: %w", err)
+  }
+
+  if threshold == nil {
+	return nil
+  }
+
+  before := m.StockBefore - m.ReservedBefore
+  after := m.StockAfter - m.ReservedAfter
+
+  switch {
+  case before > *threshold && after <= *threshold:
+	_, err = q.Exec(ctx,
+	  `INSERT INTO low_stock_alerts (id, product_id, variant_id, sku, name, available, threshold)
+	  VALUES ($1, $2, $3, $4, $5, $6, $7)
+	  ON CONFLICT (product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000')) WHERE notified_at IS NULL
+	  DO UPDATE SET available = EXCLUDED.available, threshold = EXCLUDED.threshold`,
+	  uuid.New().String(), m.ProductID, m.VariantID, sku, name, after, *threshold,
+	)
+	if err != nil {
+	  return fmt.Errorf("failed to raise low stock alert: %w", err)
+	}
+  case after > *threshold:
+	_, err = q.Exec(ctx,
+	  "DELETE FROM low_stock_alerts WHERE product_id = $1 AND variant_id IS NOT DISTINCT FROM $2 AND notified_at IS NULL",
+	  m.ProductID, m.VariantID,
+	)
+	if err != nil {
+	  return fmt.Errorf("failed to withdraw low stock alert: %w", err)
+	}
+  }
+
+  return nil
+}
+
+// recordStockMovement appends m to the ledger as is. Callers that changed the
+// stock columns themselves must fill in the before/after quantities.
+func recordStockMovement(ctx context.Context, q querier, m *model.StockMovement) error {
+  if m.ID == "" {
+	m.ID = uuid.New().String()
+  }
+
+  err := q.QueryRow(ctx,
+	`INSERT INTO stock_movements (id, product_id, variant_id, warehouse_id, type, quantity, reserved_quantity,
+	  stock_before, stock_after, reserved_before, reserved_after, reason, actor_id, order_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	RETURNING created_at`,
+	m.ID, m.ProductID, m.VariantID, m.WarehouseID, m.Type, m.Quantity, m.ReservedQuantity,
+	m.StockBefore, m.StockAfter, m.ReservedBefore, m.ReservedAfter, m.Reason, m.ActorID, m.OrderID,
+  ).Scan(&m.CreatedAt)
+  if err != nil {
+	return fmt.Errorf("failed to record stock movement: %w", err)
+  }
+
+  return nil
+}
+
+// openingStockMovement is the first ledger entry of a newly created product or
+// variant, which is inserted with no stock. Applying it puts the initial stock
+// in the default warehouse.
+func openingStockMovement(productID string, variantID *string, stock int, reason string) *model.StockMovement {
+  return &model.StockMovement{
+	ProductID: productID,
+	VariantID: variantID,
+	Type: model.StockMovementReceiving,
+	Quantity: stock,
+	Reason: reason,
+  }
+}
+
+// reserveStock holds the allocated units at their warehouse on behalf of the
+// order.
+func reserveStock(ctx context.Context, q querier, a *model.StockAllocation, actorID *string) error {
+  return applyStockMovement(ctx, q, &model.StockMovement{
+	ProductID: a.ProductID,
+	VariantID: a.VariantID,
+	WarehouseID: &a.WarehouseID,
+	Type: model.StockMovementReservation,
+	ReservedQuantity: a.Quantity,
+	ActorID: actorID,
+	OrderID: &a.OrderID,
+  })
+}
+
+// releaseStock gives back units previously held by reserveStock.
+func releaseStock(ctx context.Context, q querier, a *model.StockAllocation, actorID *string) error {
+  return applyStockMovement(ctx, q, &model.StockMovement{
+	ProductID: a.ProductID,
+	VariantID: a.VariantID,
+	WarehouseID: &a.WarehouseID,
+	Type: model.StockMovementRelease,
+	ReservedQuantity: -a.Quantity,
+	ActorID: actorID,
+	OrderID: &a.OrderID,
+  })
+}
+
+func scanStockMovement(row pgx.Row) (*model.StockMovement, error) {
+  var m model.StockMovement
+  err := row.Scan(
+	&m.ID, &m.ProductID, &m.VariantID, &m.WarehouseID, &m.Type, &m.Quantity, &m.ReservedQuantity,
+	&m.StockBefore, &m.StockAfter, &m.ReservedBefore, &m.ReservedAfter, &m.Reason, &m.ActorID, &m.OrderID,
+	&m.CreatedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &m, nil
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrTOTPNotFound = errors.New("totp not found")
+  ErrTOTPAlreadyConfirmed = errors.New("totp already confirmed")
+  ErrTOTPStepUsed = errors.New("totp code already used")
+  ErrRecoveryCodeNotFound = errors.New("recovery code not found")
+  ErrChallengeNotFound = errors.New("mfa challenge not found")
+)
+
+type MFARepository struct {
+  db *pgxpool.Pool
+}
+
+func NewMFARepository(db *pgxpool.Pool) *MFARepository {
+  return &MFARepository{
+	db: db,
+  }
+}
+
+func (r *MFARepository) GetTOTP(ctx context.Context, userID string) (*model.TOTP, error) {
+  var totp model.TOTP
+  err := r.db.QueryRow(ctx,
+	`SELECT user_id, secret, confirmed_at, last_used_step, created_at
+	FROM user_totp WHERE user_id = $1`,
+	userID,
+  ).Scan(&totp.UserID, &totp.Secret, &totp.ConfirmedAt, &totp.LastUsedStep, &totp.CreatedAt)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrTOTPNotFound
+	}
+	return nil, fmt.Errorf("failed to get totp: %w", err)
+  }
+
+  return &totp, nil
+}
+
+// SavePendingTOTP stores a new secret waiting for confirmation, replacing an
+// unconfirmed one. A confirmed secret is never replaced.
+func (r *MFARepository) SavePendingTOTP(ctx context.Context, userID, secret string) error {
+  tag, err := r.db.Exec(ctx,
+	`INSERT INTO user_totp (user_id, secret)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE
+	SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+	WHERE user_totp.confirmed_at IS NULL`,
+	userID, secret,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to save totp: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrTOTPAlreadyConfirmed
+  }
+
+  return nil
+}
+
+// ConfirmTOTP turns 2FA on with the step of the code that confirmed it and
+// stores the first set of recovery codes.
+func (r *MFARepository) ConfirmTOTP(ctx context.Context, userID string, step int64, codeHashes []string) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  tag, err := tx.Exec(ctx,
+	`UPDATE user_totp SET confirmed_at = NOW(), last_used_step = $2
+	WHERE user_id = $1 AND confirmed_at IS NULL`,
+	userID, step,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to confirm totp: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrTOTPAlreadyConfirmed
+  }
+
+  if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+	return err
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit totp confirmation: %w", err)
+  }
+
+  return nil
+}
+
+// UseTOTPStep records that the code of a step was used. It fails with
+// ErrTOTPStepUsed if that step, or a later one, already was.
+func (r *MFARepository) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+  tag, err := r.db.Exec(ctx,
+	`UPDATE user_totp SET last_used_step = $2
+	WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2`,
+	userID, step,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to use totp code: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrTOTPStepUsed
+  }
+
+  return nil
+}
+
+// DeleteTOTP turns 2FA off, dropping the secret and the recovery codes.
+func (r *MFARepository) DeleteTOTP(ctx context.Context, userID string) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  tag, err := tx.Exec(ctx, "DELETE FROM user_totp WHERE user_id = $1", userID)
+  if err != nil {
+	return fmt.Errorf("failed to delete totp: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrTOTPNotFound
+  }
+
+  if _, err := tx.Exec(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+	return fmt.Errorf("failed to delete recovery codes: %w", err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit totp removal: %w", err)
+  }
+
+  return nil
+}
+
+// ReplaceRecoveryCodes swaps every recovery code of a user, used or not, for
+// a new set.
+func (r *MFARepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+	return err
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit recovery codes: %w", err)
+  }
+
+  return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string, codeHashes []string) error {
+  if _, err := tx.Exec(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+	return fmt.Errorf("failed to delete recovery codes: %w", err)
+  }
+
+  for _, hash := range codeHashes {
+	_, err := tx.Exec(ctx,
+	  "INSERT INTO mfa_recovery_codes (id, user_id, code_hash) VALUES ($1, $2, $3)",
+	  uuid.New().String(), userID, hash,
+	)
+	if err != nil {
+	  return fmt.Errorf("failed to create recovery code: %w", err)
+	}
+  }
+
+  return nil
+}
+
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) error {
+  tag, err := r.db.Exec(ctx,
+	`UPDATE mfa_recovery_codes SET used_at = NOW()
+	WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+	userID, codeHash,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to use recovery code: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrRecoveryCodeNotFound
+  }
+
+  return nil
+}
+
+func (r *MFARepository) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+  var count int
+  err := r.db.QueryRow(ctx,
+	"SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL",
+	userID,
+  ).Scan(&count)
+  if err != nil {
+	return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+  }
+
+  return count, nil
+}
+
+func (r *MFARepository) CreateChallenge(ctx context.Context, id, userID, tokenHash string, expiresAt time.Time) error {
+  _, err := r.db.Exec(ctx,
+	`INSERT INTO mfa_challenges (id, user_id, token_hash, expires_at)
+	VALUES ($1, $2, $3, $4)`,
+	id, userID, tokenHash, expiresAt,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to create mfa challenge: %w", err)
+  }
+
+  return nil
+}
+
+// GetChallengeUserID returns the user of a challenge that can still be
+// answered.
+func (r *MFARepository) GetChallengeUserID(ctx context.Context, tokenHash string, maxAttempts int) (string, error) {
+  var userID string
+  err := r.db.QueryRow(ctx,
+	`SELECT user_id FROM mfa_challenges
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW() AND attempts < $2`,
+	tokenHash, maxAttempts,
+  ).Scan(&userID)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return "", ErrChallengeNotFound
+	}
+	return "", fmt.Errorf("failed to get mfa challenge: %w", err)
+  }
+
+  return userID, nil
+}
+
+// RecordChallengeAttempt counts one code tried against a challenge, before it
+// is checked, and returns the challenge's user. Challenges that ran out of
+// attempts are reported as not found.
+func (r *MFARepository) RecordChallengeAttempt(ctx context.Context, tokenHash string, maxAttempts int) (string, error) {
+  var userID string
+  err := r.db.QueryRow(ctx,
+	`UPDATE mfa_challenges SET attempts = attempts + 1
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW() AND attempts < $2
+	RETURNING user_id`,
+	tokenHash, maxAttempts,
+  ).Scan(&userID)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return "", ErrChallengeNotFound
+	}
+	return "", fmt.Errorf("failed to update mfa challenge: %w", err)
+  }
+
+  return userID, nil
+}
+
+// CountChallengeAttempts adds up the codes tried against the user's
+// challenges created since the given time.
+func (r *MFARepository) CountChallengeAttempts(ctx context.Context, userID string, since time.Time) (int, error) {
+  var attempts int
+  err := r.db.QueryRow(ctx,
+	`SELECT COALESCE(SUM(attempts), 0) FROM mfa_challenges
+	WHERE user_id = $1 AND created_at > $2`,
+	userID, since,
+  ).Scan(&attempts)
+  if err != nil {
+	return 0, fmt.Errorf("failed to count mfa challenge attempts: %w", err)
+  }
+
+  return attempts, nil
+}
+
+func (r *MFARepository) ConsumeChallenge(ctx context.Context, tokenHash string) error {
+  tag, err := r.db.Exec(ctx,
+	"UPDATE mfa_challenges SET used_at = NOW() WHERE token_hash = $1 AND used_at IS NULL",
+	tokenHash,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to use mfa challenge: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrChallengeNotFound
+  }
+
+  return nil
+}
+
+// DeleteExpiredChallenges drops challenges that expired before the given
+// time.
+func (r *MFARepository) DeleteExpiredChallenges(ctx context.Context, before time.Time) (int64, error) {
+  tag, err := r.db.Exec(ctx,
+	"DELETE FROM mfa_challenges WHERE expires_at < $1",
+	before,
+  )
+  if err != nil {
+	return 0, fmt.Errorf("failed to delete expired mfa challenges: %w", err)
+  }
+
+  return tag.RowsAffected(), nil
+}
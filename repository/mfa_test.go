@@ -0,0 +1,101 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "testing"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/google/uuid"
+)
+
+// testUser creates a user for rows that reference one, removed with
+// everything hanging off it when the test ends.
+func testUser(t *testing.T, users *UserRepository) string {
+  t.Helper()
+
+  id := uuid.New().String()
+  user := &model.User{
+	ID: id,
+	Email: id + "@example.com",
+	Username: "test-" + id[:8],
+	Password: "x",
+  }
+  if err := users.CreateUserAtomic(context.Background(), user); err != nil {
+	t.Fatal(err)
+  }
+  t.Cleanup(func() { users.db.Exec(context.Background(), "DELETE FROM users WHERE id = $1", id) })
+
+  return id
+}
+
+func TestMFARepositoryRecoveryCodeIsSingleUse(t *testing.T) {
+  ctx := context.Background()
+  db := testDB(t)
+  repo := NewMFARepository(db)
+  userID := testUser(t, NewUserRepository(db))
+
+  if err := repo.ReplaceRecoveryCodes(ctx, userID, []string{"hash-1", "hash-2"}); err != nil {
+	t.Fatal(err)
+  }
+
+  if err := repo.UseRecoveryCode(ctx, userID, "hash-1"); err != nil {
+	t.Fatalf("first use: %v", err)
+  }
+  if err := repo.UseRecoveryCode(ctx, userID, "hash-1"); !errors.Is(err, ErrRecoveryCodeNotFound) {
+	t.Errorf("second use: got %v, want %v", err, ErrRecoveryCodeNotFound)
+  }
+
+  remaining, err := repo.CountRecoveryCodes(ctx, userID)
+  if err != nil {
+	t.Fatal(err)
+  }
+  if remaining != 1 {
+	t.Errorf("got %d codes left, want 1", remaining)
+  }
+}
+
+func TestMFARepositoryCountChallengeAttempts(t *testing.T) {
+  ctx := context.Background()
+  db := testDB(t)
+  repo := NewMFARepository(db)
+  users := NewUserRepository(db)
+  userID := testUser(t, users)
+  otherID := testUser(t, users)
+  since := time.Now().Add(-time.Minute)
+
+  challenge := func(userID string, attempts int) {
+	t.Helper()
+	tokenHash := uuid.New().String()
+	if err := repo.CreateChallenge(ctx, uuid.New().String(), userID, tokenHash, time.Now().Add(time.Minute)); err != nil {
+	  t.Fatal(err)
+	}
+	for i := 0; i < attempts; i++ {
+	  if _, err := repo.RecordChallengeAttempt(ctx, tokenHash, 5); err != nil {
+		t.Fatal(err)
+	  }
+	}
+  }
+  challenge(userID, 3)
+  challenge(userID, 2)
+  challenge(userID, 0)
+  challenge(otherID, 4)
+
+  attempts, err := repo.CountChallengeAttempts(ctx, userID, since)
+  if err != nil {
+	t.Fatal(err)
+  }
+  if attempts != 5 {
+	t.Errorf("got %d attempts, want 5", attempts)
+  }
+
+  attempts, err = repo.CountChallengeAttempts(ctx, userID, time.Now().Add(time.Minute))
+  if err != nil {
+	t.Fatal(err)
+  }
+  if attempts != 0 {
+	t.Errorf("got %d attempts after the window, want 0", attempts)
+  }
+}
@@ -0,0 +1,325 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrOrderNotFound = errors.New("order not found")
+  ErrOrderStatusChanged = errors.New("order status changed")
+)
+
+const orderColumns = `o.id, o.order_number, o.user_id, o.status, o.subtotal_amount, o.tax_amount, o.shipping_amount,
+  o.total_amount, o.payment_method, o.payment_id, o.paid_at, o.shipping_method, o.shipping_address, o.shipping_city,
+  o.shipping_country, o.tracking_number, o.tracking_url, o.estimated_delivery, o.delivered_at, o.created_at,
+  o.updated_at, o.cancelled_at`
+
+// OrderFilter narrows an order listing. Amounts are in cents.
+type OrderFilter struct {
+  UserID      *string
+  Status      *model.OrderStatus
+  DateFrom    *time.Time
+  DateTo      *time.Time
+  MinAmount   *int64
+  MaxAmount   *int64
+  OrderNumber *string
+}
+
+func (f OrderFilter) where() (string, []interface{}) {
+  args := []interface{}{}
+  bind := binder(&args)
+
+  conditions := []string{"TRUE"}
+  if f.UserID != nil {
+	conditions = append(conditions, "o.user_id = "+bind(*f.UserID))
+  }
+  if f.Status != nil {
+	conditions = append(conditions, "o.status = "+bind(*f.Status))
+  }
+  if f.DateFrom != nil {
+	conditions = append(conditions, "o.created_at >= "+bind(*f.DateFrom))
+  }
+  if f.DateTo != nil {
+	conditions = append(conditions, "o.created_at <= "+bind(*f.DateTo))
+  }
+  if f.MinAmount != nil {
+	conditions = append(conditions, "o.total_amount >= "+bind(*f.MinAmount))
+  }
+  if f.MaxAmount != nil {
+	conditions = append(conditions, "o.total_amount <= "+bind(*f.MaxAmount))
+  }
+  if f.OrderNumber != nil {
+	conditions = append(conditions, "o.order_number = "+bind(*f.OrderNumber))
+  }
+
+  return strings.Join(conditions, " AND "), args
+}
+
+type orderSort struct {
+  column string
+  typ    string
+  value  func(o *model.Order) string
+}
+
+var orderSorts = map[string]orderSort{
+  "created_at": {"o.created_at", "timestamptz", func(o *model.Order) string { return timeKey(o.CreatedAt) }},
+  "total_amount": {"o.total_amount", "bigint", func(o *model.Order) string { return intKey(o.TotalAmount) }},
+  "status": {"o.status::text", "text", func(o *model.Order) string { return string(o.Status) }},
+}
+
+type OrderRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewOrderRepository(db *pgxpool.Pool) *OrderRepository {
+  return &OrderRepository{
+	db: db,
+  }
+}
+
+// AllocateFunc decides which warehouses fulfil an order, given the stock that
+// active warehouses hold of the ordered products.
+type AllocateFunc func(stock []*model.WarehouseStock) ([]*model.StockAllocation, error)
+
+// CreateWithReservation stores the order and its items, then locks the stock
+// of the ordered products, asks allocate where to take it from and reserves it
+// at those warehouses, all in the same transaction. If any line can't be
+// reserved the whole order is rolled back and ErrInsufficientStock is
+// returned.
+func (r *OrderRepository) CreateWithReservation(ctx context.Context, order *model.Order, items []*model.OrderItem, allocate AllocateFunc) ([]*model.StockAllocation, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  err = tx.QueryRow(ctx,
+	`INSERT INTO orders (id, order_number, user_id, status, subtotal_amount, tax_amount, shipping_amount,
+	  total_amount, payment_method, shipping_method, shipping_address, shipping_city, shipping_country)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	RETURNING created_at, updated_at`,
+	order.ID, order.OrderNumber, order.UserID, order.Status, order.SubtotalAmount, order.TaxAmount,
+	order.ShippingAmount, order.TotalAmount, order.PaymentMethod, order.ShippingMethod,
+	order.ShippingAddress, order.ShippingCity, order.ShippingCountry,
+  ).Scan(&order.CreatedAt, &order.UpdatedAt)
+  if err != nil {
+	return nil, fmt.Errorf("failed to insert order: %w", err)
+  }
+
+  productIDs := make([]string, len(items))
+  for i, item := range items {
+	err := tx.QueryRow(ctx,
+	  `INSERT INTO order_items (id, order_id, product_id, variant_id, product_sku, product_name, product_image,
+	    unit_price, quantity, subtotal_amount, total_amount)
+	  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	  RETURNING created_at, updated_at`,
+	  item.ID, order.ID, item.ProductID, item.VariantID, item.ProductSKU, item.ProductName, item.ProductImage,
+	  item.UnitPrice, item.Quantity, item.SubtotalAmount, item.TotalAmount,
+	).Scan(&item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to insert order item: %w", err)
+	}
+	productIDs[i] = *item.ProductID
+  }
+
+  stock, err := lockWarehouseStock(ctx, tx, productIDs)
+  if err != nil {
+	return nil, err
+  }
+
+  allocations, err := allocate(stock)
+  if err != nil {
+	return nil, err
+  }
+
+  for _, a := range allocations {
+	if a.ID == "" {
+	  a.ID = uuid.New().String()
+	}
+	a.OrderID = order.ID
+
+	if err := reserveStock(ctx, tx, a, &order.UserID); err != nil {
+	  return nil, err
+	}
+
+	err := tx.QueryRow(ctx,
+	  `INSERT INTO order_allocations (id, order_id, order_item_id, warehouse_id, product_id, variant_id, quantity)
+	  VALUES ($1, $2, $3, $4, $5, $6, $7)
+	  RETURNING created_at`,
+	  a.ID, a.OrderID, a.OrderItemID, a.WarehouseID, a.ProductID, a.VariantID, a.Quantity,
+	).Scan(&a.CreatedAt)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to insert order allocation: %w", err)
+	}
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, fmt.Errorf("failed to commit order: %w", err)
+  }
+
+  return allocations, nil
+}
+
+func (r *OrderRepository) GetByID(ctx context.Context, id string) (*model.Order, error) {
+  order, err := scanOrder(r.db.QueryRow(ctx, "SELECT "+orderColumns+" FROM orders o WHERE o.id = $1", id))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrOrderNotFound
+	}
+	return nil, fmt.Errorf("failed to get order: %w", err)
+  }
+
+  return order, nil
+}
+
+func (r *OrderRepository) ListItems(ctx context.Context, orderID string) ([]*model.OrderItem, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT id, order_id, product_id, variant_id, product_sku, product_name, product_image, unit_price, quantity,
+	  subtotal_amount, total_amount, created_at, updated_at
+	FROM order_items WHERE order_id = $1
+	ORDER BY created_at, id`,
+	orderID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list order items: %w", err)
+  }
+  defer rows.Close()
+
+  items := []*model.OrderItem{}
+  for rows.Next() {
+	var i model.OrderItem
+	err := rows.Scan(
+	  &i.ID, &i.OrderID, &i.ProductID, &i.VariantID, &i.ProductSKU, &i.ProductName, &i.ProductImage, &i.UnitPrice,
+	  &i.Quantity, &i.SubtotalAmount, &i.TotalAmount, &i.CreatedAt, &i.UpdatedAt,
+	)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan order item: %w", err)
+	}
+	items = append(items, &i)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate order items: %w", err)
+  }
+
+  return items, nil
+}
+
+// OrderStockFunc turns one warehouse allocation of an order into the stock
+// movement a status change applies to it, or nil to leave it alone.
+type OrderStockFunc func(a *model.StockAllocation) *model.StockMovement
+
+// UpdateStatus moves an order from one status to another and applies the
+// stock movements stock returns for its allocations, in one transaction.
+// Allocations of purged products are skipped. If the order is no longer in
+// the from status nothing changes and ErrOrderStatusChanged is returned.
+func (r *OrderRepository) UpdateStatus(ctx context.Context, id string, from, to model.OrderStatus, stock OrderStockFunc) (*model.Order, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  order, err := scanOrder(tx.QueryRow(ctx,
+	`UPDATE orders o SET status = $3, updated_at = NOW(),
+	  paid_at = CASE WHEN $3 = 'paid' THEN NOW() ELSE o.paid_at END,
+	  delivered_at = CASE WHEN $3 = 'delivered' THEN NOW() ELSE o.delivered_at END,
+	  cancelled_at = CASE WHEN $3 = 'cancelled' THEN NOW() ELSE o.cancelled_at END
+	WHERE o.id = $1 AND o.status = $2
+	RETURNING `+orderColumns,
+	id, from, to,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrOrderStatusChanged
+	}
+	return nil, fmt.Errorf("failed to update order status: %w", err)
+  }
+
+  if stock != nil {
+	rows, err := tx.Query(ctx,
+	  `SELECT id, order_id, order_item_id, warehouse_id, product_id, variant_id, quantity, created_at
+	  FROM order_allocations
+	  WHERE order_id = $1 AND product_id IS NOT NULL
+	  ORDER BY product_id, variant_id, warehouse_id`,
+	  id,
+	)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to list order allocations: %w", err)
+	}
+
+	allocations, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.StockAllocation])
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan order allocation: %w", err)
+	}
+
+	for _, a := range allocations {
+	  m := stock(a)
+	  if m == nil {
+		continue
+	  }
+	  if err := applyStockMovement(ctx, tx, m); err != nil {
+		return nil, err
+	  }
+	}
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, fmt.Errorf("failed to commit order status: %w", err)
+  }
+
+  return order, nil
+}
+
+// List returns a page of the orders matching filter, ordered by sortBy in
+// sortOrder ("asc" or "desc"). Unknown sort options fall back to the creation
+// date.
+func (r *OrderRepository) List(ctx context.Context, filter OrderFilter, sortBy, sortOrder string, page Page) ([]*model.Order, PageInfo, error) {
+  sort, ok := orderSorts[sortBy]
+  if !ok {
+	sortBy = "created_at"
+	sort = orderSorts[sortBy]
+  }
+
+  where, args := filter.where()
+
+  return fetchPage(ctx, r.db, listQuery{
+	columns: orderColumns,
+	from: "FROM orders o",
+	where: where,
+	args: args,
+	order: keyset{
+	  name: sortBy,
+	  columns: []string{sort.column, "o.id"},
+	  types: []string{sort.typ, "uuid"},
+	  desc: sortOrder == "desc",
+	},
+  }, page, scanOrder, func(o *model.Order) []string {
+	return []string{sort.value(o), o.ID}
+  })
+}
+
+func scanOrder(row pgx.Row) (*model.Order, error) {
+  var o model.Order
+  err := row.Scan(
+	&o.ID, &o.OrderNumber, &o.UserID, &o.Status, &o.SubtotalAmount, &o.TaxAmount, &o.ShippingAmount,
+	&o.TotalAmount, &o.PaymentMethod, &o.PaymentID, &o.PaidAt, &o.ShippingMethod, &o.ShippingAddress, &o.ShippingCity,
+	&o.ShippingCountry, &o.TrackingNumber, &o.TrackingURL, &o.EstimatedDelivery, &o.DeliveredAt, &o.CreatedAt,
+	&o.UpdatedAt, &o.CancelledAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &o, nil
+}
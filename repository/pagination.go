@@ -0,0 +1,233 @@
+package repository
+
+import (
+  "context"
+  "encoding/base64"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "strconv"
+  "strings"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+)
+
+var (
+  ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// TotalMode selects how a list counts its matching rows. Counting is skipped
+// unless asked for; estimates come from the query planner and stay cheap on
+// large tables.
+type TotalMode string
+
+const (
+  TotalNone      TotalMode = ""
+  TotalExact     TotalMode = "exact"
+  TotalEstimated TotalMode = "estimated"
+)
+
+// Page asks for one page of a list. When Cursor is set the page starts right
+// after the row it points at and Offset is ignored.
+type Page struct {
+  Limit  int
+  Offset int
+  Cursor string
+  Total  TotalMode
+}
+
+// PageInfo describes the page a list returned. NextCursor is empty on the
+// last page. Total is only set when the Page asked for it.
+type PageInfo struct {
+  NextCursor     string
+  HasMore        bool
+  Total          *int
+  TotalEstimated bool
+}
+
+// keyset is one ordering of a list that can be paginated by cursor. Rows are
+// compared as a whole against the cursor, so every column sorts in the same
+// direction; the last column must be unique, usually the ID.
+type keyset struct {
+  name    string
+  columns []string
+  types   []string
+  desc    bool
+}
+
+type cursor struct {
+  Sort   string   `json:"s"`
+  Desc   bool     `json:"d,omitempty"`
+  Values []string `json:"v"`
+}
+
+func (k keyset) orderBy() string {
+  direction := " ASC"
+  if k.desc {
+	direction = " DESC"
+  }
+
+  order := make([]string, len(k.columns))
+  for i, column := range k.columns {
+	order[i] = column + direction
+  }
+  return strings.Join(order, ", ")
+}
+
+// after returns the condition selecting the rows that come after the cursor,
+// binding its values with bind.
+func (k keyset) after(token string, bind func(value interface{}) string) (string, error) {
+  raw, err := base64.RawURLEncoding.DecodeString(token)
+  if err != nil {
+	return "", ErrInvalidCursor
+  }
+
+  var c cursor
+  if err := json.Unmarshal(raw, &c); err != nil {
+	return "", ErrInvalidCursor
+  }
+  if c.Sort != k.name || c.Desc != k.desc || len(c.Values) != len(k.columns) {
+	return "", ErrInvalidCursor
+  }
+
+  params := make([]string, len(c.Values))
+  for i, value := range c.Values {
+	params[i] = bind(value) + "::" + k.types[i]
+  }
+
+  operator := ">"
+  if k.desc {
+	operator = "<"
+  }
+  return fmt.Sprintf("(%s) %s (%s)", strings.Join(k.columns, ", "), operator, strings.Join(params, ", ")), nil
+}
+
+// cursor encodes the values of the last row of a page into an opaque token.
+func (k keyset) cursor(values ...string) string {
+  raw, _ := json.Marshal(cursor{Sort: k.name, Desc: k.desc, Values: values})
+  return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// binder returns a function that appends a value to args and returns its
+// placeholder.
+func binder(args *[]interface{}) func(value interface{}) string {
+  return func(value interface{}) string {
+	*args = append(*args, value)
+	return fmt.Sprintf("$%d", len(*args))
+  }
+}
+
+// countRows counts the rows of from, a FROM clause with its WHERE, either
+// exactly or from the planner's estimate, as mode asks.
+func countRows(ctx context.Context, q querier, mode TotalMode, from string, args []interface{}) (*int, bool, error) {
+  switch mode {
+  case TotalExact:
+	var total int
+	if err := q.QueryRow(ctx, "SELECT COUNT(*) "+from, args...).Scan(&total); err != nil {
+	  return nil, false, fmt.Errorf("failed to count rows: %w", err)
+	}
+	return &total, false, nil
+  case TotalEstimated:
+	var plan []struct {
+	  Plan struct {
+		Rows float64 `json:"Plan Rows"`
+	  } `json:"Plan"`
+	}
+	if err := q.QueryRow(ctx, "EXPLAIN (FORMAT JSON) SELECT 1 "+from, args...).Scan(&plan); err != nil {
+	  return nil, false, fmt.Errorf("failed to estimate rows: %w", err)
+	}
+	total := 0
+	if len(plan) > 0 {
+	  total = int(plan[0].Plan.Rows)
+	}
+	return &total, true, nil
+  }
+
+  return nil, false, nil
+}
+
+// listQuery is a paginated SELECT: its columns, FROM clause, WHERE condition
+// with the arguments it binds, and the order rows are listed in.
+type listQuery struct {
+  columns string
+  from    string
+  where   string
+  args    []interface{}
+  order   keyset
+}
+
+// fetchPage runs a listQuery for one page. One row more than the limit is
+// read to know whether another page follows; values returns the sort key of
+// a row, ID last, to build the next cursor from.
+func fetchPage[T any](ctx context.Context, q querier, lq listQuery, page Page, scan func(pgx.Row) (*T, error), values func(*T) []string) ([]*T, PageInfo, error) {
+  var info PageInfo
+  var err error
+  info.Total, info.TotalEstimated, err = countRows(ctx, q, page.Total, lq.from+" WHERE "+lq.where, lq.args)
+  if err != nil {
+	return nil, PageInfo{}, err
+  }
+
+  args := append([]interface{}{}, lq.args...)
+  bind := binder(&args)
+
+  where := lq.where
+  offset := page.Offset
+  if page.Cursor != "" {
+	condition, err := lq.order.after(page.Cursor, bind)
+	if err != nil {
+	  return nil, PageInfo{}, err
+	}
+	where += " AND " + condition
+	offset = 0
+  }
+
+  limitParam := bind(page.Limit + 1)
+  offsetParam := bind(offset)
+
+  rows, err := q.Query(ctx,
+	fmt.Sprintf("SELECT %s %s WHERE %s ORDER BY %s LIMIT %s OFFSET %s",
+	  lq.columns, lq.from, where, lq.order.orderBy(), limitParam, offsetParam),
+	args...,
+  )
+  if err != nil {
+	return nil, PageInfo{}, fmt.Errorf("failed to list rows: %w", err)
+  }
+  defer rows.Close()
+
+  items := []*T{}
+  for rows.Next() {
+	item, err := scan(rows)
+	if err != nil {
+	  return nil, PageInfo{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+	items = append(items, item)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, PageInfo{}, fmt.Errorf("failed to iterate rows: %w", err)
+  }
+
+  if len(items) > page.Limit {
+	items = items[:page.Limit]
+	info.HasMore = true
+	info.NextCursor = lq.order.cursor(values(items[len(items)-1])...)
+  }
+
+  return items, info, nil
+}
+
+// Cursor values are stored as text and cast back by the keyset, so they must
+// round-trip exactly.
+
+func floatKey(v float64) string {
+  return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func intKey(v int64) string {
+  return strconv.FormatInt(v, 10)
+}
+
+func timeKey(t time.Time) string {
+  return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "math"
+  "strconv"
+  "testing"
+  "time"
+)
+
+var testKeyset = keyset{
+  name: "price",
+  columns: []string{"p.price", "p.id"},
+  types: []string{"numeric", "uuid"},
+  desc: true,
+}
+
+func TestKeysetCursorRoundTrip(t *testing.T) {
+  token := testKeyset.cursor("19.99", "8a0a4f6e-3c3b-4e0b-9a57-3c1f4b2b2f10")
+
+  var args []interface{}
+  condition, err := testKeyset.after(token, binder(&args))
+  if err != nil {
+	t.Fatal(err)
+  }
+
+  if want := "(p.price, p.id) < ($1::numeric, $2::uuid)"; condition != want {
+	t.Errorf("got condition %q, want %q", condition, want)
+  }
+  if len(args) != 2 || args[0] != "19.99" || args[1] != "8a0a4f6e-3c3b-4e0b-9a57-3c1f4b2b2f10" {
+	t.Errorf("got args %v", args)
+  }
+  if want := "p.price DESC, p.id DESC"; testKeyset.orderBy() != want {
+	t.Errorf("got order %q, want %q", testKeyset.orderBy(), want)
+  }
+}
+
+func TestKeysetRejectsOtherCursors(t *testing.T) {
+  ascending := testKeyset
+  ascending.desc = false
+  byName := testKeyset
+  byName.name = "name"
+
+  tests := []struct {
+	name  string
+	token string
+  }{
+	{"not base64", "not a cursor!"},
+	{"not json", "bm90IGpzb24"},
+	{"other direction", ascending.cursor("19.99", "id")},
+	{"other sort", byName.cursor("19.99", "id")},
+	{"missing value", testKeyset.cursor("19.99")},
+  }
+
+  for _, tt := range tests {
+	t.Run(tt.name, func(t *testing.T) {
+	  var args []interface{}
+	  if _, err := testKeyset.after(tt.token, binder(&args)); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("got %v, want %v", err, ErrInvalidCursor)
+	  }
+	})
+  }
+}
+
+func TestFloatKeyRoundTrip(t *testing.T) {
+  for _, v := range []float64{0, 0.1, 19.99, 1234567890.12, 1.0 / 3, 1e-7, 4.5e15, math.MaxFloat64, -2.5} {
+	parsed, err := strconv.ParseFloat(floatKey(v), 64)
+	if err != nil {
+	  t.Fatal(err)
+	}
+	if parsed != v {
+	  t.Errorf("floatKey(%v) = %s, read back as %v", v, floatKey(v), parsed)
+	}
+  }
+
+  // The shortest form, so NUMERIC(12,2) prices come out as stored.
+  if got := floatKey(19.99); got != "19.99" {
+	t.Errorf("got %s, want 19.99", got)
+  }
+}
+
+func TestTimeKeyRoundTrip(t *testing.T) {
+  zone := time.FixedZone("UTC-3", -3*60*60)
+  for _, v := range []time.Time{
+	time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC),
+	time.Date(2025, 3, 1, 9, 30, 0, 123456000, zone),
+	time.Date(2025, 3, 1, 9, 30, 0, 1, zone),
+  } {
+	parsed, err := time.Parse(time.RFC3339Nano, timeKey(v))
+	if err != nil {
+	  t.Fatal(err)
+	}
+	if !parsed.Equal(v) {
+	  t.Errorf("timeKey(%s) = %s, read back as %s", v, timeKey(v), parsed)
+	}
+  }
+}
+
+// TestCursorValuesMatchColumns checks that keys built from values Postgres
+// returned compare equal to the column once cast back by the keyset.
+func TestCursorValuesMatchColumns(t *testing.T) {
+  ctx := context.Background()
+  db := testDB(t)
+
+  for _, literal := range []string{"19.99", "0.10", "1234567890.12", "4.50"} {
+	var price float64
+	if err := db.QueryRow(ctx, "SELECT $1::numeric(12,2)", literal).Scan(&price); err != nil {
+	  t.Fatal(err)
+	}
+
+	var equal bool
+	if err := db.QueryRow(ctx, "SELECT $1::numeric = $2::numeric(12,2)", floatKey(price), literal).Scan(&equal); err != nil {
+	  t.Fatal(err)
+	}
+	if !equal {
+	  t.Errorf("numeric %s: key %s doesn't match", literal, floatKey(price))
+	}
+  }
+
+  var score float64
+  if err := db.QueryRow(ctx, "SELECT 1::float8 / 3").Scan(&score); err != nil {
+	t.Fatal(err)
+  }
+  var equal bool
+  if err := db.QueryRow(ctx, "SELECT $1::float8 = 1::float8 / 3", floatKey(score)).Scan(&equal); err != nil {
+	t.Fatal(err)
+  }
+  if !equal {
+	t.Errorf("float8: key %s doesn't match", floatKey(score))
+  }
+
+  var createdAt time.Time
+  if err := db.QueryRow(ctx, "SELECT '2025-03-01 09:30:00.123456-03'::timestamptz").Scan(&createdAt); err != nil {
+	t.Fatal(err)
+  }
+  if err := db.QueryRow(ctx, "SELECT $1::timestamptz = '2025-03-01 09:30:00.123456-03'::timestamptz", timeKey(createdAt)).Scan(&equal); err != nil {
+	t.Fatal(err)
+  }
+  if !equal {
+	t.Errorf("timestamptz: key %s doesn't match", timeKey(createdAt))
+  }
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrResetTokenNotFound = errors.New("password reset token not found")
+)
+
+type PasswordResetRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewPasswordResetRepository(db *pgxpool.Pool) *PasswordResetRepository {
+  return &PasswordResetRepository{
+	db: db,
+  }
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, id, userID, tokenHash string, expiresAt time.Time) error {
+  _, err := r.db.Exec(ctx,
+	`INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at)
+	VALUES ($1, $2, $3, $4)`,
+	id, userID, tokenHash, expiresAt,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to create password reset token: %w", err)
+  }
+
+  return nil
+}
+
+// CountSince returns how many reset tokens were issued to a user since the
+// given time, used or not.
+func (r *PasswordResetRepository) CountSince(ctx context.Context, userID string, since time.Time) (int, error) {
+  var count int
+  err := r.db.QueryRow(ctx,
+	"SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = $1 AND created_at > $2",
+	userID, since,
+  ).Scan(&count)
+  if err != nil {
+	return 0, fmt.Errorf("failed to count password reset tokens: %w", err)
+  }
+
+  return count, nil
+}
+
+// GetUserID returns the user an unused, unexpired token was issued to.
+func (r *PasswordResetRepository) GetUserID(ctx context.Context, tokenHash string) (string, error) {
+  var userID string
+  err := r.db.QueryRow(ctx,
+	`SELECT user_id FROM password_reset_tokens
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()`,
+	tokenHash,
+  ).Scan(&userID)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return "", ErrResetTokenNotFound
+	}
+	return "", fmt.Errorf("failed to get password reset token: %w", err)
+  }
+
+  return userID, nil
+}
+
+// Consume uses up a token and sets the password of its user in one
+// transaction, so a token can't reset the password twice even when presented
+// twice at once. Every other outstanding token of the user is used up too.
+func (r *PasswordResetRepository) Consume(ctx context.Context, tokenHash, passwordHash string) (string, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return "", fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  var userID string
+  err = tx.QueryRow(ctx,
+	`UPDATE password_reset_tokens SET used_at = NOW()
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+	RETURNING user_id`,
+	tokenHash,
+  ).Scan(&userID)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return "", ErrResetTokenNotFound
+	}
+	return "", fmt.Errorf("failed to use password reset token: %w", err)
+  }
+
+  tag, err := tx.Exec(ctx,
+	"UPDATE users SET password = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL",
+	userID, passwordHash,
+  )
+  if err != nil {
+	return "", fmt.Errorf("failed to update password: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return "", ErrUserNotFound
+  }
+
+  _, err = tx.Exec(ctx,
+	"UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL",
+	userID,
+  )
+  if err != nil {
+	return "", fmt.Errorf("failed to use password reset tokens: %w", err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return "", fmt.Errorf("failed to commit password reset: %w", err)
+  }
+
+  return userID, nil
+}
+
+// DeleteExpired drops tokens that can no longer be used.
+func (r *PasswordResetRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+  tag, err := r.db.Exec(ctx,
+	"DELETE FROM password_reset_tokens WHERE expires_at < $1",
+	before,
+  )
+  if err != nil {
+	return 0, fmt.Errorf("failed to delete expired password reset tokens: %w", err)
+  }
+
+  return tag.RowsAffected(), nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+  "context"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PopularityParams controls how product popularity is scored. Every order
+// unit, wishlist add and page view adds its weight to the score, halved for
+// each HalfLife elapsed since it happened. Signals older than Since are
+// ignored and stored views older than Since are dropped.
+type PopularityParams struct {
+  OrderWeight    float64
+  WishlistWeight float64
+  ViewWeight     float64
+  HalfLife       time.Duration
+  Since          time.Time
+}
+
+type PopularityRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewPopularityRepository(db *pgxpool.Pool) *PopularityRepository {
+  return &PopularityRepository{
+	db: db,
+  }
+}
+
+// RecordView counts a view of a product page for today.
+func (r *PopularityRepository) RecordView(ctx context.Context, productID string) error {
+  _, err := r.db.Exec(ctx,
+	`INSERT INTO product_daily_views (product_id, day, views)
+	VALUES ($1, CURRENT_DATE, 1)
+	ON CONFLICT (product_id, day) DO UPDATE SET views = product_daily_views.views + 1`,
+	productID,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to record product view: %w", err)
+  }
+
+  return nil
+}
+
+// RefreshPopularity recomputes the popularity score of every live product and
+// returns how many scores changed. Products without recent signals drop to 0.
+func (r *PopularityRepository) RefreshPopularity(ctx context.Context, params PopularityParams) (int64, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return 0, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  tag, err := tx.Exec(ctx,
+	`WITH signals AS (
+	  SELECT oi.product_id, $1::float8 * oi.quantity AS weight, o.created_at AS happened_at
+	  FROM order_items oi
+	  JOIN orders o ON o.id = oi.order_id
+	  WHERE o.status IN ('paid', 'processing', 'shipped', 'delivered') AND o.created_at >= $5::timestamptz
+	  UNION ALL
+	  SELECT wi.product_id, $2::float8, wi.created_at
+	  FROM wishlist_items wi
+	  WHERE wi.created_at >= $5::timestamptz
+	  UNION ALL
+	  SELECT v.product_id, $3::float8 * v.views, v.day::timestamptz + INTERVAL '12 hours'
+	  FROM product_daily_views v
+	  WHERE v.day >= $5::timestamptz::date
+	),
+	scores AS (
+	  SELECT product_id,
+	    SUM(weight * EXP(-LN(2) * GREATEST(EXTRACT(EPOCH FROM NOW() - happened_at), 0) / $4::float8)) AS score
+	  FROM signals
+	  WHERE product_id IS NOT NULL
+	  GROUP BY product_id
+	)
+	UPDATE products p
+	SET popularity_score = COALESCE(s.score, 0)
+	FROM products x
+	LEFT JOIN scores s ON s.product_id = x.id
+	WHERE p.id = x.id AND p.deleted_at IS NULL
+	  AND p.popularity_score IS DISTINCT FROM COALESCE(s.score, 0)`,
+	params.OrderWeight, params.WishlistWeight, params.ViewWeight, params.HalfLife.Seconds(), params.Since,
+  )
+  if err != nil {
+	return 0, fmt.Errorf("failed to compute popularity: %w", err)
+  }
+
+  if _, err := tx.Exec(ctx, "DELETE FROM product_daily_views WHERE day < $1::timestamptz::date", params.Since); err != nil {
+	return 0, fmt.Errorf("failed to prune product views: %w", err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return 0, fmt.Errorf("failed to commit popularity: %w", err)
+  }
+
+  return tag.RowsAffected(), nil
+}
@@ -0,0 +1,413 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrScheduledPriceNotFound = errors.New("scheduled price not found")
+  ErrScheduledPriceOverlap = errors.New("scheduled price overlaps another schedule")
+  ErrScheduledPriceNotCancellable = errors.New("scheduled price already finished")
+)
+
+const priceChangeColumns = `c.id, c.product_id, c.old_price, c.new_price, c.old_cost_price, c.new_cost_price,
+  c.source, c.changed_by, c.scheduled_price_id, c.changed_at`
+
+const scheduledPriceColumns = `s.id, s.product_id, s.price, s.previous_price, s.starts_at, s.ends_at, s.status,
+  s.created_by, s.created_at, s.updated_at, s.applied_at, s.ended_at`
+
+const scheduledPriceBatch = 100
+
+type PriceRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewPriceRepository(db *pgxpool.Pool) *PriceRepository {
+  return &PriceRepository{
+	db: db,
+  }
+}
+
+func (r *PriceRepository) ListChanges(ctx context.Context, productID string, limit, offset int) ([]*model.PriceChange, int, error) {
+  var total int
+  if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM product_price_changes WHERE product_id = $1", productID).Scan(&total); err != nil {
+	return nil, 0, fmt.Errorf("failed to count price changes: %w", err)
+  }
+
+  rows, err := r.db.Query(ctx,
+	`SELECT `+priceChangeColumns+`
+	FROM product_price_changes c
+	WHERE c.product_id = $1
+	ORDER BY c.changed_at DESC, c.id
+	LIMIT $2 OFFSET $3`,
+	productID, limit, offset,
+  )
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to list price changes: %w", err)
+  }
+  defer rows.Close()
+
+  changes := []*model.PriceChange{}
+  for rows.Next() {
+	change, err := scanPriceChange(rows)
+	if err != nil {
+	  return nil, 0, fmt.Errorf("failed to scan price change: %w", err)
+	}
+	changes = append(changes, change)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, 0, fmt.Errorf("failed to iterate price changes: %w", err)
+  }
+
+  return changes, total, nil
+}
+
+// LowestPricesSince returns, for each product, the lowest price it had at any
+// point since the given time, including its current price. Every price that
+// was in effect during the window is either the current price or the old or
+// new side of a change recorded inside it.
+func (r *PriceRepository) LowestPricesSince(ctx context.Context, productIDs []string, since time.Time) (map[string]float64, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT p.id, LEAST(p.price, MIN(c.old_price), MIN(c.new_price))
+	FROM products p
+	LEFT JOIN product_price_changes c ON c.product_id = p.id AND c.changed_at >= $2
+	WHERE p.id = ANY($1)
+	GROUP BY p.id, p.price`,
+	productIDs, since,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to get lowest prices: %w", err)
+  }
+  defer rows.Close()
+
+  lowest := make(map[string]float64, len(productIDs))
+  for rows.Next() {
+	var id string
+	var price float64
+	if err := rows.Scan(&id, &price); err != nil {
+	  return nil, fmt.Errorf("failed to scan lowest price: %w", err)
+	}
+	lowest[id] = price
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate lowest prices: %w", err)
+  }
+
+  return lowest, nil
+}
+
+// CreateSchedule stores a scheduled price change, refusing schedules whose
+// time window overlaps another pending or running one for the same product.
+func (r *PriceRepository) CreateSchedule(ctx context.Context, schedule *model.ScheduledPrice) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if err := lockProduct(ctx, tx, schedule.ProductID); err != nil {
+	return err
+  }
+
+  var overlaps bool
+  err = tx.QueryRow(ctx,
+	`SELECT EXISTS (
+	  SELECT 1 FROM scheduled_prices
+	  WHERE product_id = $1 AND status IN ('scheduled', 'active')
+	    AND tstzrange(starts_at, ends_at) && tstzrange($2, $3)
+	)`,
+	schedule.ProductID, schedule.StartsAt, schedule.EndsAt,
+  ).Scan(&overlaps)
+  if err != nil {
+	return fmt.Errorf("failed to check schedule overlap: %w", err)
+  }
+
+  if overlaps {
+	return ErrScheduledPriceOverlap
+  }
+
+  err = tx.QueryRow(ctx,
+	`INSERT INTO scheduled_prices (id, product_id, price, starts_at, ends_at, status, created_by)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING created_at, updated_at`,
+	schedule.ID, schedule.ProductID, schedule.Price, schedule.StartsAt, schedule.EndsAt, schedule.Status,
+	schedule.CreatedBy,
+  ).Scan(&schedule.CreatedAt, &schedule.UpdatedAt)
+  if err != nil {
+	return fmt.Errorf("failed to create scheduled price: %w", err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit scheduled price: %w", err)
+  }
+
+  return nil
+}
+
+func (r *PriceRepository) ListSchedules(ctx context.Context, productID string) ([]*model.ScheduledPrice, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT `+scheduledPriceColumns+`
+	FROM scheduled_prices s
+	WHERE s.product_id = $1
+	ORDER BY s.starts_at DESC`,
+	productID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list scheduled prices: %w", err)
+  }
+
+  return collectScheduledPrices(rows)
+}
+
+// CancelSchedule cancels a schedule that hasn't started yet. A running one is
+// cut short instead, so the next scheduler run reverts the price.
+func (r *PriceRepository) CancelSchedule(ctx context.Context, productID, scheduleID string) (*model.ScheduledPrice, error) {
+  schedule, err := scanScheduledPrice(r.db.QueryRow(ctx,
+	`UPDATE scheduled_prices s SET
+	  status = CASE WHEN s.status = 'scheduled' THEN 'cancelled' ELSE s.status END,
+	  ends_at = CASE WHEN s.status = 'active' THEN NOW() ELSE s.ends_at END,
+	  updated_at = NOW()
+	WHERE s.id = $1 AND s.product_id = $2
+	  AND (s.status = 'scheduled' OR (s.status = 'active' AND s.ends_at IS NOT NULL))
+	RETURNING `+scheduledPriceColumns,
+	scheduleID, productID,
+  ))
+
+  if err != nil {
+	if !errors.Is(err, pgx.ErrNoRows) {
+	  return nil, fmt.Errorf("failed to cancel scheduled price: %w", err)
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(ctx,
+	  "SELECT EXISTS (SELECT 1 FROM scheduled_prices WHERE id = $1 AND product_id = $2)",
+	  scheduleID, productID,
+	).Scan(&exists); err != nil {
+	  return nil, fmt.Errorf("failed to get scheduled price: %w", err)
+	}
+
+	if exists {
+	  return nil, ErrScheduledPriceNotCancellable
+	}
+	return nil, ErrScheduledPriceNotFound
+  }
+
+  return schedule, nil
+}
+
+// StartDueSchedules applies every schedule whose start time has passed.
+// Schedules without an end are one-off price changes and complete right away.
+func (r *PriceRepository) StartDueSchedules(ctx context.Context, now time.Time) (int, error) {
+  return r.processDue(ctx,
+	`SELECT `+scheduledPriceColumns+`
+	FROM scheduled_prices s
+	WHERE s.status = 'scheduled' AND s.starts_at <= $1
+	ORDER BY s.starts_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`,
+	now,
+	func(tx pgx.Tx, s *model.ScheduledPrice, price, costPrice float64) error {
+	  if err := setScheduledPrice(ctx, tx, s, price, s.Price, costPrice); err != nil {
+		return err
+	  }
+
+	  status := model.ScheduledPriceStatusActive
+	  if s.EndsAt == nil {
+		status = model.ScheduledPriceStatusCompleted
+	  }
+
+	  _, err := tx.Exec(ctx,
+		`UPDATE scheduled_prices SET status = $2, previous_price = $3, applied_at = NOW(), updated_at = NOW(),
+		  ended_at = CASE WHEN $2 = 'completed' THEN NOW() END
+		WHERE id = $1`,
+		s.ID, status, price,
+	  )
+	  return err
+	},
+  )
+}
+
+// EndDueSchedules reverts products whose schedule has ended to the price they
+// had before it started. If the price was changed by hand in the meantime the
+// manual price wins and is left alone.
+func (r *PriceRepository) EndDueSchedules(ctx context.Context, now time.Time) (int, error) {
+  return r.processDue(ctx,
+	`SELECT `+scheduledPriceColumns+`
+	FROM scheduled_prices s
+	WHERE s.status = 'active' AND s.ends_at <= $1
+	ORDER BY s.ends_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`,
+	now,
+	func(tx pgx.Tx, s *model.ScheduledPrice, price, costPrice float64) error {
+	  if s.PreviousPrice != nil && price == s.Price {
+		if err := setScheduledPrice(ctx, tx, s, price, *s.PreviousPrice, costPrice); err != nil {
+		  return err
+		}
+	  }
+
+	  _, err := tx.Exec(ctx,
+		"UPDATE scheduled_prices SET status = 'completed', ended_at = NOW(), updated_at = NOW() WHERE id = $1",
+		s.ID,
+	  )
+	  return err
+	},
+  )
+}
+
+// processDue locks a batch of due schedules and their products, and hands each
+// to apply with the product's current price and cost. Schedules of products
+// that are gone are cancelled.
+func (r *PriceRepository) processDue(ctx context.Context, query string, now time.Time, apply func(tx pgx.Tx, s *model.ScheduledPrice, price, costPrice float64) error) (int, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return 0, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  rows, err := tx.Query(ctx, query, now, scheduledPriceBatch)
+  if err != nil {
+	return 0, fmt.Errorf("failed to select due schedules: %w", err)
+  }
+
+  schedules, err := collectScheduledPrices(rows)
+  if err != nil {
+	return 0, err
+  }
+
+  for _, s := range schedules {
+	var price, costPrice float64
+	err := tx.QueryRow(ctx,
+	  "SELECT price, cost_price FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE",
+	  s.ProductID,
+	).Scan(&price, &costPrice)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+	  if _, err := tx.Exec(ctx,
+		"UPDATE scheduled_prices SET status = 'cancelled', updated_at = NOW() WHERE id = $1", s.ID,
+	  ); err != nil {
+		return 0, fmt.Errorf("failed to cancel scheduled price: %w", err)
+	  }
+	  continue
+	}
+	if err != nil {
+	  return 0, fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	if err := apply(tx, s, price, costPrice); err != nil {
+	  return 0, fmt.Errorf("failed to apply scheduled price %s: %w", s.ID, err)
+	}
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return 0, fmt.Errorf("failed to commit scheduled prices: %w", err)
+  }
+
+  return len(schedules), nil
+}
+
+func setScheduledPrice(ctx context.Context, tx pgx.Tx, s *model.ScheduledPrice, oldPrice, newPrice, costPrice float64) error {
+  if _, err := tx.Exec(ctx,
+	"UPDATE products SET price = $2, updated_at = NOW() WHERE id = $1",
+	s.ProductID, newPrice,
+  ); err != nil {
+	return err
+  }
+
+  return recordPriceChange(ctx, tx, &model.PriceChange{
+	ProductID: s.ProductID,
+	OldPrice: oldPrice,
+	NewPrice: newPrice,
+	OldCostPrice: costPrice,
+	NewCostPrice: costPrice,
+	Source: model.PriceChangeSourceSchedule,
+	ChangedBy: &s.CreatedBy,
+	ScheduledPriceID: &s.ID,
+  })
+}
+
+// recordPriceChange appends to the price history. It is a no-op when neither
+// the price nor the cost actually changed.
+func recordPriceChange(ctx context.Context, q querier, change *model.PriceChange) error {
+  if change.OldPrice == change.NewPrice && change.OldCostPrice == change.NewCostPrice {
+	return nil
+  }
+
+  if change.ID == "" {
+	change.ID = uuid.New().String()
+  }
+
+  err := q.QueryRow(ctx,
+	`INSERT INTO product_price_changes (id, product_id, old_price, new_price, old_cost_price, new_cost_price,
+	  source, changed_by, scheduled_price_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING changed_at`,
+	change.ID, change.ProductID, change.OldPrice, change.NewPrice, change.OldCostPrice, change.NewCostPrice,
+	change.Source, change.ChangedBy, change.ScheduledPriceID,
+  ).Scan(&change.ChangedAt)
+  if err != nil {
+	return fmt.Errorf("failed to record price change: %w", err)
+  }
+
+  if change.NewPrice < change.OldPrice {
+	return triggerPriceBelow(ctx, q, change.ProductID, change.NewPrice)
+  }
+
+  return nil
+}
+
+func scanPriceChange(row pgx.Row) (*model.PriceChange, error) {
+  var c model.PriceChange
+  err := row.Scan(
+	&c.ID, &c.ProductID, &c.OldPrice, &c.NewPrice, &c.OldCostPrice, &c.NewCostPrice,
+	&c.Source, &c.ChangedBy, &c.ScheduledPriceID, &c.ChangedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &c, nil
+}
+
+func scanScheduledPrice(row pgx.Row) (*model.ScheduledPrice, error) {
+  var s model.ScheduledPrice
+  err := row.Scan(
+	&s.ID, &s.ProductID, &s.Price, &s.PreviousPrice, &s.StartsAt, &s.EndsAt, &s.Status,
+	&s.CreatedBy, &s.CreatedAt, &s.UpdatedAt, &s.AppliedAt, &s.EndedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &s, nil
+}
+
+func collectScheduledPrices(rows pgx.Rows) ([]*model.ScheduledPrice, error) {
+  defer rows.Close()
+
+  schedules := []*model.ScheduledPrice{}
+  for rows.Next() {
+	s, err := scanScheduledPrice(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan scheduled price: %w", err)
+	}
+	schedules = append(schedules, s)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate scheduled prices: %w", err)
+  }
+
+  return schedules, nil
+}
@@ -0,0 +1,806 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrNotFound = errors.New("record not found")
+)
+
+const productColumns = `p.id, p.sku, p.name, p.description, p.price, p.cost_price, p.stock, p.reserved_stock,
+  p.category_id, p.brand_id, p.weight, p.status, p.images, p.tags, p.reorder_threshold, p.rating_average, p.rating_count, p.popularity_score, p.created_at, p.updated_at,
+  p.deleted_at`
+
+type ProductRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewProductRepository(db *pgxpool.Pool) *ProductRepository {
+  return &ProductRepository{
+	db: db,
+  }
+}
+
+func (r *ProductRepository) GetProductByID(ctx context.Context, id string) (*model.Product, error) {
+  product, err := scanProduct(r.db.QueryRow(ctx,
+	"SELECT "+productColumns+" FROM products p WHERE p.id = $1 AND p.deleted_at IS NULL", id))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrNotFound
+	}
+
+	return nil, fmt.Errorf("failed to get product by id: %w", err)
+  }
+
+  return product, nil
+}
+
+func (r *ProductRepository) GetBySKU(ctx context.Context, sku string) (*model.Product, error) {
+  product, err := scanProduct(r.db.QueryRow(ctx,
+	"SELECT "+productColumns+" FROM products p WHERE p.sku = $1 AND p.deleted_at IS NULL", sku))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrNotFound
+	}
+
+	return nil, fmt.Errorf("failed to get product by sku: %w", err)
+  }
+
+  return product, nil
+}
+
+func scanProduct(row pgx.Row) (*model.Product, error) {
+  var p model.Product
+  err := row.Scan(
+	&p.ID, &p.SKU, &p.Name, &p.Description, &p.Price, &p.CostPrice, &p.Stock, &p.ReservedStock,
+	&p.CategoryID, &p.BrandID, &p.Weight, &p.Status, &p.Images, &p.Tags, &p.ReorderThreshold, &p.RatingAverage, &p.RatingCount, &p.PopularityScore, &p.CreatedAt, &p.UpdatedAt,
+	&p.DeletedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &p, nil
+}
+
+func scanProducts(rows pgx.Rows) ([]*model.Product, error) {
+  defer rows.Close()
+
+  products := []*model.Product{}
+  for rows.Next() {
+	product, err := scanProduct(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan product: %w", err)
+	}
+	products = append(products, product)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate products: %w", err)
+  }
+
+  return products, nil
+}
+
+// GetProductsByCategory lists the products of a category. When
+// includeSubcategories is set, products of every descendant category are
+// returned as well.
+func (r *ProductRepository) GetProductsByCategory(ctx context.Context, categoryID string, includeSubcategories bool, limit, offset int) ([]*model.Product, error) {
+  rows, err := r.db.Query(ctx,
+	`WITH RECURSIVE category_tree AS (
+	  SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL
+	  UNION
+	  SELECT c.id FROM categories c
+	  JOIN category_tree t ON c.parent_id = t.id
+	  WHERE $2 AND c.deleted_at IS NULL AND c.is_active
+	)
+	SELECT `+productColumns+`
+	FROM products p
+	JOIN category_tree t ON t.id = p.category_id
+	WHERE p.deleted_at IS NULL
+	ORDER BY p.created_at DESC, p.id
+	LIMIT $3 OFFSET $4`,
+	categoryID, includeSubcategories, limit, offset,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to get products by category: %w", err)
+  }
+
+  return scanProducts(rows)
+}
+
+func (r *ProductRepository) GetProductsByBrand(ctx context.Context, brandID string, limit, offset int) ([]*model.Product, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT `+productColumns+`
+	FROM products p
+	WHERE p.brand_id = $1 AND p.deleted_at IS NULL
+	ORDER BY p.created_at DESC, p.id
+	LIMIT $2 OFFSET $3`,
+	brandID, limit, offset,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to get products by brand: %w", err)
+  }
+
+  return scanProducts(rows)
+}
+
+// ProductFilter narrows catalog-wide queries such as exports. Nil fields are
+// ignored.
+type ProductFilter struct {
+  CategoryID *string
+  BrandID    *string
+  MinPrice   *float64
+  MaxPrice   *float64
+  InStock    *bool
+  Status     *model.ProductStatus
+  Tags       []string // tag slugs; products need any of them
+  MatchAllTags bool   // products need every tag instead
+}
+
+func (f ProductFilter) where() (string, []interface{}) {
+  conditions := []string{"p.deleted_at IS NULL"}
+  args := []interface{}{}
+
+  add := func(condition string, value interface{}) {
+	args = append(args, value)
+	conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+  }
+
+  if f.CategoryID != nil {
+	add("p.category_id = $%d", *f.CategoryID)
+  }
+  if f.BrandID != nil {
+	add("p.brand_id = $%d", *f.BrandID)
+  }
+  if f.MinPrice != nil {
+	add("p.price >= $%d", *f.MinPrice)
+  }
+  if f.MaxPrice != nil {
+	add("p.price <= $%d", *f.MaxPrice)
+  }
+  if f.InStock != nil {
+	if *f.InStock {
+	  conditions = append(conditions, "p.stock > p.reserved_stock")
+	} else {
+	  conditions = append(conditions, "p.stock <= p.reserved_stock")
+	}
+  }
+  if f.Status != nil {
+	add("p.status = $%d", string(*f.Status))
+  }
+  if len(f.Tags) > 0 {
+	if f.MatchAllTags {
+	  add("p.tags @> $%d", f.Tags)
+	} else {
+	  add("p.tags && $%d", f.Tags)
+	}
+  }
+
+  return strings.Join(conditions, " AND "), args
+}
+
+// productSort is one way the catalog can be ordered: the columns it sorts by,
+// their SQL types and how to read them back from a product for the cursor.
+type productSort struct {
+  columns []string
+  types   []string
+  values  func(p *model.Product) []string
+}
+
+// productSorts maps the accepted catalog sort options to their orderings.
+// The product ID is always appended so pages are stable.
+var productSorts = map[string]productSort{
+  "price": {
+	[]string{"p.price"}, []string{"numeric"},
+	func(p *model.Product) []string { return []string{floatKey(p.Price)} },
+  },
+  "name": {
+	[]string{"p.name"}, []string{"text"},
+	func(p *model.Product) []string { return []string{p.Name} },
+  },
+  "created_at": {
+	[]string{"p.created_at"}, []string{"timestamptz"},
+	func(p *model.Product) []string { return []string{timeKey(p.CreatedAt)} },
+  },
+  "stock": {
+	[]string{"p.stock - p.reserved_stock"}, []string{"integer"},
+	func(p *model.Product) []string { return []string{intKey(int64(p.Stock - p.ReservedStock))} },
+  },
+  "rating": {
+	[]string{"p.rating_average", "p.rating_count"}, []string{"numeric", "integer"},
+	func(p *model.Product) []string { return []string{floatKey(p.RatingAverage), intKey(int64(p.RatingCount))} },
+  },
+  "popularity": {
+	[]string{"p.popularity_score"}, []string{"float8"},
+	func(p *model.Product) []string { return []string{floatKey(p.PopularityScore)} },
+  },
+}
+
+// ListProducts returns a page of the products matching filter, ordered by
+// sortBy in sortOrder ("asc" or "desc"). Unknown sort options fall back to
+// the creation date.
+func (r *ProductRepository) ListProducts(ctx context.Context, filter ProductFilter, sortBy, sortOrder string, page Page) ([]*model.Product, PageInfo, error) {
+  sort, ok := productSorts[sortBy]
+  if !ok {
+	sortBy = "created_at"
+	sort = productSorts[sortBy]
+  }
+
+  where, args := filter.where()
+
+  return fetchPage(ctx, r.db, listQuery{
+	columns: productColumns,
+	from: "FROM products p",
+	where: where,
+	args: args,
+	order: keyset{
+	  name: sortBy,
+	  columns: append(append([]string{}, sort.columns...), "p.id"),
+	  types: append(append([]string{}, sort.types...), "uuid"),
+	  desc: sortOrder == "desc",
+	},
+  }, page, scanProduct, func(p *model.Product) []string {
+	return append(sort.values(p), p.ID)
+  })
+}
+
+// SearchProducts returns a page of the products whose SKU, name, description
+// or tags contain query, most relevant first: exact SKU matches, then names
+// starting with query, then names containing it, then everything else.
+func (r *ProductRepository) SearchProducts(ctx context.Context, query string, categoryID *string, page Page) ([]*model.Product, PageInfo, error) {
+  args := []interface{}{}
+  bind := binder(&args)
+
+  exact := bind(strings.ToLower(query))
+  prefix := bind(escapeLike(query) + "%")
+  contains := bind("%" + escapeLike(query) + "%")
+
+  conditions := []string{
+	"p.deleted_at IS NULL",
+	// The exact and prefix patterns are implied by contains; they're listed
+	// so every parameter shows up in the WHERE the total is counted with.
+	fmt.Sprintf(`(lower(p.sku) = %s OR p.name ILIKE %s OR p.sku ILIKE %[3]s OR p.name ILIKE %[3]s
+	  OR p.description ILIKE %[3]s OR EXISTS (SELECT 1 FROM unnest(p.tags) t WHERE t ILIKE %[3]s))`,
+	  exact, prefix, contains),
+  }
+  if categoryID != nil {
+	conditions = append(conditions, "p.category_id = "+bind(*categoryID))
+  }
+
+  relevance := fmt.Sprintf(`(CASE WHEN lower(p.sku) = %s THEN 3 WHEN p.name ILIKE %s THEN 2 WHEN p.name ILIKE %s THEN 1 ELSE 0 END)`,
+	exact, prefix, contains)
+
+  results, info, err := fetchPage(ctx, r.db, listQuery{
+	columns: productColumns + ", " + relevance,
+	from: "FROM products p",
+	where: strings.Join(conditions, " AND "),
+	args: args,
+	order: keyset{
+	  name: "relevance",
+	  columns: []string{relevance, "p.id"},
+	  types: []string{"integer", "uuid"},
+	  desc: true,
+	},
+  }, page, scanSearchResult, func(s *searchResult) []string {
+	return []string{intKey(int64(s.relevance)), s.ID}
+  })
+  if err != nil {
+	return nil, PageInfo{}, err
+  }
+
+  products := make([]*model.Product, len(results))
+  for i, result := range results {
+	products[i] = &result.Product
+  }
+
+  return products, info, nil
+}
+
+// searchResult is a product with the relevance it was ranked by, which the
+// next page's cursor needs.
+type searchResult struct {
+  model.Product
+  relevance int
+}
+
+func scanSearchResult(row pgx.Row) (*searchResult, error) {
+  var s searchResult
+  p := &s.Product
+  err := row.Scan(
+	&p.ID, &p.SKU, &p.Name, &p.Description, &p.Price, &p.CostPrice, &p.Stock, &p.ReservedStock,
+	&p.CategoryID, &p.BrandID, &p.Weight, &p.Status, &p.Images, &p.Tags, &p.ReorderThreshold,
+	&p.RatingAverage, &p.RatingCount, &p.PopularityScore, &p.CreatedAt, &p.UpdatedAt,
+	&p.DeletedAt, &s.relevance,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &s, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+  return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// StreamProducts calls fn for every product matching filter, in SKU order,
+// without loading the whole result into memory.
+func (r *ProductRepository) StreamProducts(ctx context.Context, filter ProductFilter, fn func(*model.Product) error) error {
+  where, args := filter.where()
+
+  rows, err := r.db.Query(ctx,
+	"SELECT "+productColumns+" FROM products p WHERE "+where+" ORDER BY p.sku",
+	args...,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to stream products: %w", err)
+  }
+  defer rows.Close()
+
+  for rows.Next() {
+	product, err := scanProduct(rows)
+	if err != nil {
+	  return fmt.Errorf("failed to scan product: %w", err)
+	}
+
+	if err := fn(product); err != nil {
+	  return err
+	}
+  }
+
+  if err := rows.Err(); err != nil {
+	return fmt.Errorf("failed to iterate products: %w", err)
+  }
+
+  return nil
+}
+
+// ReservedStockBySKU returns the reserved stock of every live product whose
+// SKU is in skus. SKUs missing from the result don't exist yet.
+func (r *ProductRepository) ReservedStockBySKU(ctx context.Context, skus []string) (map[string]int, error) {
+  rows, err := r.db.Query(ctx,
+	"SELECT sku, reserved_stock FROM products WHERE sku = ANY($1) AND deleted_at IS NULL",
+	skus,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to look up skus: %w", err)
+  }
+  defer rows.Close()
+
+  reserved := make(map[string]int, len(skus))
+  for rows.Next() {
+	var sku string
+	var qty int
+	if err := rows.Scan(&sku, &qty); err != nil {
+	  return nil, fmt.Errorf("failed to scan sku: %w", err)
+	}
+	reserved[sku] = qty
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate skus: %w", err)
+  }
+
+  return reserved, nil
+}
+
+type UpsertOutcome int
+
+const (
+  UpsertCreated UpsertOutcome = iota
+  UpsertUpdated
+  // UpsertRejected means the product exists but the new stock would drop
+  // below what is already reserved, so the row was left untouched.
+  UpsertRejected
+)
+
+// UpsertBySKU creates or updates products matched by SKU in a single
+// transaction and reports what happened to each of them, in input order.
+// Products with an empty Status keep their current status, or become active
+// when created. Stock changes are applied to the default warehouse. Price and
+// stock changes of updated products are recorded under actorID.
+func (r *ProductRepository) UpsertBySKU(ctx context.Context, products []*model.Product, actorID string) ([]UpsertOutcome, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  warehouseID, err := defaultWarehouseID(ctx, tx)
+  if err != nil {
+	return nil, err
+  }
+
+  outcomes := make([]UpsertOutcome, len(products))
+  for i, p := range products {
+	var status *string
+	if p.Status != "" {
+	  s := string(p.Status)
+	  status = &s
+	}
+
+	var oldPrice, oldCostPrice float64
+	var oldStock int
+	var exists bool
+	err := tx.QueryRow(ctx,
+	  "SELECT price, cost_price, stock FROM products WHERE sku = $1 AND deleted_at IS NULL FOR UPDATE",
+	  p.SKU,
+	).Scan(&oldPrice, &oldCostPrice, &oldStock)
+	switch {
+	case err == nil:
+	  exists = true
+	case !errors.Is(err, pgx.ErrNoRows):
+	  return nil, fmt.Errorf("failed to lock product %s: %w", p.SKU, err)
+	}
+
+	// The import sets the total stock, but only the default warehouse's share
+	// can change. Reject products whose new total would leave that warehouse
+	// with less than it has reserved.
+	if exists {
+	  var held, reserved int
+	  err := tx.QueryRow(ctx,
+		`SELECT ws.stock, ws.reserved_stock FROM warehouse_stock ws JOIN products p ON p.id = ws.product_id
+		WHERE p.sku = $1 AND p.deleted_at IS NULL AND ws.variant_id IS NULL AND ws.warehouse_id = $2
+		FOR UPDATE OF ws`,
+		p.SKU, warehouseID,
+	  ).Scan(&held, &reserved)
+	  if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to lock warehouse stock of %s: %w", p.SKU, err)
+	  }
+
+	  if held + p.Stock - oldStock < reserved {
+		outcomes[i] = UpsertRejected
+		continue
+	  }
+	}
+
+	if err := registerTags(ctx, tx, p.Tags); err != nil {
+	  return nil, err
+	}
+
+	var inserted bool
+	err = tx.QueryRow(ctx,
+	  `INSERT INTO products (id, sku, name, description, price, cost_price, stock, category_id, brand_id,
+	    weight, status, images, tags)
+	  VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, $9, COALESCE($10, 'active'), $11, $12)
+	  ON CONFLICT (sku) WHERE deleted_at IS NULL DO UPDATE SET
+	    name = EXCLUDED.name,
+	    description = EXCLUDED.description,
+	    price = EXCLUDED.price,
+	    cost_price = EXCLUDED.cost_price,
+	    category_id = EXCLUDED.category_id,
+	    brand_id = EXCLUDED.brand_id,
+	    weight = EXCLUDED.weight,
+	    status = COALESCE($10, products.status),
+	    images = EXCLUDED.images,
+	    tags = EXCLUDED.tags,
+	    updated_at = NOW()
+	  RETURNING id, (xmax = 0)`,
+	  p.ID, p.SKU, p.Name, p.Description, p.Price, p.CostPrice, p.CategoryID, p.BrandID,
+	  p.Weight, status, p.Images, p.Tags,
+	).Scan(&p.ID, &inserted)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to upsert product %s: %w", p.SKU, err)
+	}
+
+	movement := &model.StockMovement{
+	  ProductID: p.ID,
+	  WarehouseID: &warehouseID,
+	  Type: model.StockMovementImport,
+	  Quantity: p.Stock - oldStock,
+	  Reason: "Updated by product import",
+	  ActorID: &actorID,
+	}
+
+	if inserted {
+	  outcomes[i] = UpsertCreated
+	  movement.Reason = "Created by product import"
+	  if err := applyStockMovement(ctx, tx, movement); err != nil {
+		return nil, err
+	  }
+	  continue
+	}
+
+	outcomes[i] = UpsertUpdated
+	// The import replaced products.images, put uploaded images back.
+	if err := syncProductImages(ctx, tx, p.ID, nil); err != nil {
+	  return nil, err
+	}
+
+	err = recordPriceChange(ctx, tx, &model.PriceChange{
+	  ProductID: p.ID,
+	  OldPrice: oldPrice,
+	  NewPrice: p.Price,
+	  OldCostPrice: oldCostPrice,
+	  NewCostPrice: p.CostPrice,
+	  Source: model.PriceChangeSourceImport,
+	  ChangedBy: &actorID,
+	})
+	if err != nil {
+	  return nil, err
+	}
+
+	if movement.Quantity != 0 {
+	  if err := applyStockMovement(ctx, tx, movement); err != nil {
+		return nil, err
+	  }
+	}
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, fmt.Errorf("failed to commit products: %w", err)
+  }
+
+  return outcomes, nil
+}
+
+// DeleteProduct moves a product to the trash. It stays there, restorable,
+// until PurgeDeleted removes it for good.
+func (r *ProductRepository) DeleteProduct(ctx context.Context, id string) error {
+  tag, err := r.db.Exec(ctx,
+	"UPDATE products SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL",
+	id,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to delete product: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrNotFound
+  }
+
+  return nil
+}
+
+// ListDeleted returns soft-deleted products, most recently deleted first,
+// along with the total number of products in the trash.
+func (r *ProductRepository) ListDeleted(ctx context.Context, limit, offset int) ([]*model.Product, int, error) {
+  var total int
+  if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM products WHERE deleted_at IS NOT NULL").Scan(&total); err != nil {
+	return nil, 0, fmt.Errorf("failed to count deleted products: %w", err)
+  }
+
+  rows, err := r.db.Query(ctx,
+	`SELECT `+productColumns+`
+	FROM products p
+	WHERE p.deleted_at IS NOT NULL
+	ORDER BY p.deleted_at DESC, p.id
+	LIMIT $1 OFFSET $2`,
+	limit, offset,
+  )
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to list deleted products: %w", err)
+  }
+
+  products, err := scanProducts(rows)
+  if err != nil {
+	return nil, 0, err
+  }
+
+  return products, total, nil
+}
+
+// Restore takes a product out of the trash. Returns ErrDuplicateSKU when a
+// live product took its SKU in the meantime.
+func (r *ProductRepository) Restore(ctx context.Context, id string) (*model.Product, error) {
+  product, err := scanProduct(r.db.QueryRow(ctx,
+	`UPDATE products p SET deleted_at = NULL, updated_at = NOW()
+	WHERE p.id = $1 AND p.deleted_at IS NOT NULL
+	RETURNING `+productColumns,
+	id,
+  ))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+	  return nil, ErrDuplicateSKU
+	}
+
+	return nil, fmt.Errorf("failed to restore product: %w", err)
+  }
+
+  return product, nil
+}
+
+// PurgeDeleted permanently removes up to limit products deleted before
+// cutoff. Order items keep their snapshot but are detached from the product
+// and its variants; carts and variants go away with it.
+func (r *ProductRepository) PurgeDeleted(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return 0, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  rows, err := tx.Query(ctx,
+	`SELECT id FROM products
+	WHERE deleted_at IS NOT NULL AND deleted_at < $1
+	ORDER BY deleted_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`,
+	cutoff, limit,
+  )
+  if err != nil {
+	return 0, fmt.Errorf("failed to select products to purge: %w", err)
+  }
+
+  ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+  if err != nil {
+	return 0, fmt.Errorf("failed to scan products to purge: %w", err)
+  }
+
+  if len(ids) == 0 {
+	return 0, nil
+  }
+
+  statements := []string{
+	"UPDATE order_items SET product_id = NULL, variant_id = NULL WHERE product_id = ANY($1)",
+	"DELETE FROM cart_items WHERE product_id = ANY($1)",
+	"DELETE FROM product_variants WHERE product_id = ANY($1)",
+	"DELETE FROM products WHERE id = ANY($1)",
+  }
+  for _, stmt := range statements {
+	if _, err := tx.Exec(ctx, stmt, ids); err != nil {
+	  return 0, fmt.Errorf("failed to purge products: %w", err)
+	}
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return 0, fmt.Errorf("failed to commit purge: %w", err)
+  }
+
+  return len(ids), nil
+}
+
+// Update applies a partial update. When the price or cost changes, the change
+// is added to the price history in the same transaction, attributed to
+// actorID.
+func (r *ProductRepository) Update(ctx context.Context, id string, updates map[string]interface{}, actorID *string) (*model.Product, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  var oldPrice, oldCostPrice float64
+  err = tx.QueryRow(ctx,
+	"SELECT price, cost_price FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE",
+	id,
+  ).Scan(&oldPrice, &oldCostPrice)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrNotFound
+	}
+
+	return nil, fmt.Errorf("failed to lock product: %w", err)
+  }
+
+  if tags, ok := updates["tags"].([]string); ok {
+	if err := registerTags(ctx, tx, tags); err != nil {
+	  return nil, err
+	}
+  }
+
+  setClauses := []string{}
+  args := []interface{}{}
+  argID := 1
+
+  for field, value := range updates {
+	setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field, argID))
+	args = append(args, value)
+	argID++
+  }
+
+  setClauses = append(setClauses, fmt.Sprintf("updated_at = $%d", argID))
+  args = append(args, time.Now())
+  argID++
+
+  args = append(args, id)
+
+  query := fmt.Sprintf(
+	"UPDATE products p SET %s WHERE p.id = $%d RETURNING %s",
+	strings.Join(setClauses, ", "),
+	argID,
+	productColumns,
+  )
+
+  product, err := scanProduct(tx.QueryRow(ctx, query, args...))
+  if err != nil {
+	return nil, fmt.Errorf("failed to update product: %w", err)
+  }
+
+  err = recordPriceChange(ctx, tx, &model.PriceChange{
+	ProductID: id,
+	OldPrice: oldPrice,
+	NewPrice: product.Price,
+	OldCostPrice: oldCostPrice,
+	NewCostPrice: product.CostPrice,
+	Source: model.PriceChangeSourceManual,
+	ChangedBy: actorID,
+  })
+  if err != nil {
+	return nil, err
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, fmt.Errorf("failed to commit product update: %w", err)
+  }
+
+  return product, nil
+}
+
+// CreateProduct inserts a product and opens its stock ledger with the initial
+// stock, which goes to the default warehouse.
+func (r *ProductRepository) CreateProduct(ctx context.Context, p *model.Product) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if err := registerTags(ctx, tx, p.Tags); err != nil {
+	return err
+  }
+
+  err = tx.QueryRow(ctx,
+	`INSERT INTO products (id, sku, name, description, price, cost_price, stock, category_id, brand_id,
+	  weight, images, tags, reorder_threshold)
+	VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, $9, $10, $11, $12)
+	RETURNING status, created_at, updated_at`,
+	p.ID, p.SKU, p.Name, p.Description, p.Price, p.CostPrice, p.CategoryID, p.BrandID,
+	p.Weight, p.Images, p.Tags, p.ReorderThreshold,
+  ).Scan(&p.Status, &p.CreatedAt, &p.UpdatedAt)
+  if err != nil {
+	return fmt.Errorf("failed to insert product: %w", err)
+  }
+
+  opening := openingStockMovement(p.ID, nil, p.Stock, "Initial stock")
+  if err := applyStockMovement(ctx, tx, opening); err != nil {
+	return err
+  }
+  p.Stock = opening.StockAfter
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit product: %w", err)
+  }
+
+  return nil
+}
+
+// UpdateStock applies m to the product's stock and records it in the ledger.
+// On success m holds the before/after quantities.
+func (r *ProductRepository) UpdateStock(ctx context.Context, m *model.StockMovement) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if err := applyStockMovement(ctx, tx, m); err != nil {
+	return err
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit stock update: %w", err)
+  }
+
+  return nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+  "context"
+  "fmt"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RecommendationParams controls how the precomputed related products are
+// scored. The content weights are applied to each similarity signal and the
+// result is blended with the normalized co-purchase score.
+type RecommendationParams struct {
+  CategoryWeight   float64
+  BrandWeight      float64
+  TagWeight        float64
+  PriceWeight      float64
+  ContentWeight    float64
+  CoPurchaseWeight float64
+  CoPurchaseSince  time.Time
+  MaxPerProduct    int
+}
+
+type RecommendationRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewRecommendationRepository(db *pgxpool.Pool) *RecommendationRepository {
+  return &RecommendationRepository{
+	db: db,
+  }
+}
+
+func (r *RecommendationRepository) GetRelatedProducts(ctx context.Context, productID string, limit int) ([]*model.Product, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT `+productColumns+`
+	FROM product_recommendations pr
+	JOIN products p ON p.id = pr.related_product_id
+	WHERE pr.product_id = $1 AND p.deleted_at IS NULL AND p.status = 'active'
+	ORDER BY pr.score DESC, p.id
+	LIMIT $2`,
+	productID, limit,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to get related products: %w", err)
+  }
+
+  return scanProducts(rows)
+}
+
+// RebuildRecommendations replaces the whole product_recommendations table in
+// a single transaction so readers never see a half-computed set.
+func (r *RecommendationRepository) RebuildRecommendations(ctx context.Context, params RecommendationParams) (int64, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return 0, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if _, err := tx.Exec(ctx, "DELETE FROM product_recommendations"); err != nil {
+	return 0, fmt.Errorf("failed to clear recommendations: %w", err)
+  }
+
+  tag, err := tx.Exec(ctx,
+	`WITH candidates AS (
+	  SELECT id, category_id, brand_id, price, COALESCE(tags, '{}') AS tags
+	  FROM products
+	  WHERE deleted_at IS NULL AND status = 'active'
+	),
+	content AS (
+	  SELECT a.id AS product_id, b.id AS related_product_id,
+	    $1::numeric * (a.category_id = b.category_id)::int
+	    + $2::numeric * (a.brand_id IS NOT NULL AND a.brand_id = b.brand_id)::int
+	    + $3::numeric * COALESCE(
+	        cardinality(ARRAY(SELECT unnest(a.tags) INTERSECT SELECT unnest(b.tags)))::numeric
+	        / NULLIF(cardinality(ARRAY(SELECT unnest(a.tags) UNION SELECT unnest(b.tags))), 0), 0)
+	    + $4::numeric * GREATEST(0, 1 - ABS(a.price - b.price) / NULLIF(GREATEST(a.price, b.price), 0))
+	    AS content_score
+	  FROM candidates a
+	  JOIN candidates b ON a.id <> b.id
+	    AND (a.category_id = b.category_id OR a.brand_id = b.brand_id OR a.tags && b.tags)
+	),
+	co_purchase AS (
+	  SELECT a.product_id, b.product_id AS related_product_id, COUNT(DISTINCT a.order_id) AS co_count
+	  FROM order_items a
+	  JOIN order_items b ON b.order_id = a.order_id AND b.product_id <> a.product_id
+	  JOIN orders o ON o.id = a.order_id
+	  WHERE o.status IN ('paid', 'processing', 'shipped', 'delivered') AND o.created_at >= $5
+	  GROUP BY a.product_id, b.product_id
+	),
+	blended AS (
+	  SELECT COALESCE(c.product_id, cp.product_id) AS product_id,
+	    COALESCE(c.related_product_id, cp.related_product_id) AS related_product_id,
+	    COALESCE(c.content_score, 0) AS content_score,
+	    COALESCE(cp.co_count::numeric / MAX(cp.co_count) OVER (PARTITION BY cp.product_id), 0) AS co_purchase_score,
+	    COALESCE(cp.co_count, 0) AS co_purchase_count
+	  FROM content c
+	  FULL OUTER JOIN co_purchase cp
+	    ON cp.product_id = c.product_id AND cp.related_product_id = c.related_product_id
+	),
+	ranked AS (
+	  SELECT b.*,
+	    $6::numeric * b.content_score + $7::numeric * b.co_purchase_score AS score,
+	    ROW_NUMBER() OVER (
+	      PARTITION BY b.product_id
+	      ORDER BY $6::numeric * b.content_score + $7::numeric * b.co_purchase_score DESC, b.related_product_id
+	    ) AS position
+	  FROM blended b
+	  JOIN candidates x ON x.id = b.product_id
+	  JOIN candidates y ON y.id = b.related_product_id
+	)
+	INSERT INTO product_recommendations
+	  (product_id, related_product_id, score, content_score, co_purchase_score, co_purchase_count, computed_at)
+	SELECT product_id, related_product_id, score, content_score, co_purchase_score, co_purchase_count, NOW()
+	FROM ranked
+	WHERE position <= $8`,
+	params.CategoryWeight, params.BrandWeight, params.TagWeight, params.PriceWeight,
+	params.CoPurchaseSince, params.ContentWeight, params.CoPurchaseWeight, params.MaxPerProduct,
+  )
+  if err != nil {
+	return 0, fmt.Errorf("failed to compute recommendations: %w", err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return 0, fmt.Errorf("failed to commit recommendations: %w", err)
+  }
+
+  return tag.RowsAffected(), nil
+}
@@ -0,0 +1,285 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrReviewNotFound  = errors.New("review not found")
+  ErrDuplicateReview = errors.New("product already reviewed by user")
+)
+
+const reviewColumns = `r.id, r.product_id, r.user_id, r.rating, r.title, r.body, r.status, r.moderation_note,
+  r.moderated_by, r.moderated_at, r.helpful_count, r.unhelpful_count, r.created_at, r.updated_at`
+
+// reviewOrders maps the accepted review sort options to their ORDER BY
+// clauses. Ties fall back to the newest review.
+var reviewOrders = map[string]string{
+  "recent":      "r.created_at DESC, r.id",
+  "helpful":     "r.helpful_count - r.unhelpful_count DESC, r.created_at DESC, r.id",
+  "rating_high": "r.rating DESC, r.created_at DESC, r.id",
+  "rating_low":  "r.rating ASC, r.created_at DESC, r.id",
+}
+
+type ReviewRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewReviewRepository(db *pgxpool.Pool) *ReviewRepository {
+  return &ReviewRepository{
+	db: db,
+  }
+}
+
+// HasDeliveredPurchase reports whether the user has an order containing the
+// product that was delivered.
+func (r *ReviewRepository) HasDeliveredPurchase(ctx context.Context, userID, productID string) (bool, error) {
+  var exists bool
+  err := r.db.QueryRow(ctx,
+	`SELECT EXISTS (
+	  SELECT 1 FROM order_items oi
+	  JOIN orders o ON o.id = oi.order_id
+	  WHERE o.user_id = $1 AND oi.product_id = $2 AND o.status = 'delivered'
+	)`,
+	userID, productID,
+  ).Scan(&exists)
+  if err != nil {
+	return false, fmt.Errorf("failed to check purchase: %w", err)
+  }
+
+  return exists, nil
+}
+
+func (r *ReviewRepository) Create(ctx context.Context, review *model.Review) error {
+  err := r.db.QueryRow(ctx,
+	`INSERT INTO product_reviews (id, product_id, user_id, rating, title, body, status)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING created_at, updated_at`,
+	review.ID, review.ProductID, review.UserID, review.Rating, review.Title, review.Body, review.Status,
+  ).Scan(&review.CreatedAt, &review.UpdatedAt)
+  if err != nil {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+	  return ErrDuplicateReview
+	}
+	return fmt.Errorf("failed to create review: %w", err)
+  }
+
+  return nil
+}
+
+func (r *ReviewRepository) GetByID(ctx context.Context, id string) (*model.Review, error) {
+  review, err := scanReview(r.db.QueryRow(ctx, "SELECT "+reviewColumns+" FROM product_reviews r WHERE r.id = $1", id))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrReviewNotFound
+	}
+	return nil, fmt.Errorf("failed to get review: %w", err)
+  }
+
+  return review, nil
+}
+
+// ListPublished returns the approved reviews of a product in the given sort
+// order, along with their total count.
+func (r *ReviewRepository) ListPublished(ctx context.Context, productID, sortBy string, limit, offset int) ([]*model.Review, int, error) {
+  order, ok := reviewOrders[sortBy]
+  if !ok {
+	order = reviewOrders["recent"]
+  }
+
+  var total int
+  err := r.db.QueryRow(ctx,
+	"SELECT COUNT(*) FROM product_reviews r WHERE r.product_id = $1 AND r.status = 'approved'",
+	productID,
+  ).Scan(&total)
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to count reviews: %w", err)
+  }
+
+  rows, err := r.db.Query(ctx,
+	`SELECT `+reviewColumns+`
+	FROM product_reviews r
+	WHERE r.product_id = $1 AND r.status = 'approved'
+	ORDER BY `+order+`
+	LIMIT $2 OFFSET $3`,
+	productID, limit, offset,
+  )
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to list reviews: %w", err)
+  }
+
+  reviews, err := collectReviews(rows)
+  if err != nil {
+	return nil, 0, err
+  }
+
+  return reviews, total, nil
+}
+
+// ListByStatus returns the reviews in a moderation status, oldest first, so
+// the queue is worked through in submission order.
+func (r *ReviewRepository) ListByStatus(ctx context.Context, status model.ReviewStatus, limit, offset int) ([]*model.Review, int, error) {
+  var total int
+  err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM product_reviews r WHERE r.status = $1", status).Scan(&total)
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to count reviews: %w", err)
+  }
+
+  rows, err := r.db.Query(ctx,
+	`SELECT `+reviewColumns+`
+	FROM product_reviews r
+	WHERE r.status = $1
+	ORDER BY r.created_at, r.id
+	LIMIT $2 OFFSET $3`,
+	status, limit, offset,
+  )
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to list reviews: %w", err)
+  }
+
+  reviews, err := collectReviews(rows)
+  if err != nil {
+	return nil, 0, err
+  }
+
+  return reviews, total, nil
+}
+
+// Moderate sets the status of a review and refreshes the rating of its
+// product in the same transaction.
+func (r *ReviewRepository) Moderate(ctx context.Context, id string, status model.ReviewStatus, note *string, moderatorID string) (*model.Review, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  review, err := scanReview(tx.QueryRow(ctx,
+	`UPDATE product_reviews r
+	SET status = $2, moderation_note = $3, moderated_by = $4, moderated_at = NOW(), updated_at = NOW()
+	WHERE r.id = $1
+	RETURNING `+reviewColumns,
+	id, status, note, moderatorID,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrReviewNotFound
+	}
+	return nil, fmt.Errorf("failed to moderate review: %w", err)
+  }
+
+  if err := refreshProductRating(ctx, tx, review.ProductID); err != nil {
+	return nil, err
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, fmt.Errorf("failed to commit review: %w", err)
+  }
+
+  return review, nil
+}
+
+// Vote records whether the user found a review helpful, replacing any earlier
+// vote of theirs, and returns the review with its recounted votes.
+func (r *ReviewRepository) Vote(ctx context.Context, reviewID, userID string, helpful bool) (*model.Review, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  _, err = tx.Exec(ctx,
+	`INSERT INTO review_votes (review_id, user_id, helpful)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (review_id, user_id) DO UPDATE SET helpful = EXCLUDED.helpful, created_at = NOW()`,
+	reviewID, userID, helpful,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to record vote: %w", err)
+  }
+
+  review, err := scanReview(tx.QueryRow(ctx,
+	`UPDATE product_reviews r
+	SET helpful_count = v.helpful, unhelpful_count = v.unhelpful
+	FROM (
+	  SELECT COUNT(*) FILTER (WHERE helpful) AS helpful, COUNT(*) FILTER (WHERE NOT helpful) AS unhelpful
+	  FROM review_votes WHERE review_id = $1
+	) v
+	WHERE r.id = $1
+	RETURNING `+reviewColumns,
+	reviewID,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrReviewNotFound
+	}
+	return nil, fmt.Errorf("failed to count votes: %w", err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, fmt.Errorf("failed to commit vote: %w", err)
+  }
+
+  return review, nil
+}
+
+// refreshProductRating recomputes the average rating and review count of a
+// product from its approved reviews.
+func refreshProductRating(ctx context.Context, q querier, productID string) error {
+  _, err := q.Exec(ctx,
+	`UPDATE products p
+	SET rating_average = COALESCE(s.average, 0), rating_count = s.count
+	FROM (
+	  SELECT ROUND(AVG(rating), 2) AS average, COUNT(*) AS count
+	  FROM product_reviews WHERE product_id = $1 AND status = 'approved'
+	) s
+	WHERE p.id = $1`,
+	productID,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to refresh product rating: %w", err)
+  }
+
+  return nil
+}
+
+func collectReviews(rows pgx.Rows) ([]*model.Review, error) {
+  defer rows.Close()
+
+  reviews := []*model.Review{}
+  for rows.Next() {
+	review, err := scanReview(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan review: %w", err)
+	}
+	reviews = append(reviews, review)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate reviews: %w", err)
+  }
+
+  return reviews, nil
+}
+
+func scanReview(row pgx.Row) (*model.Review, error) {
+  var r model.Review
+  err := row.Scan(
+	&r.ID, &r.ProductID, &r.UserID, &r.Rating, &r.Title, &r.Body, &r.Status, &r.ModerationNote,
+	&r.ModeratedBy, &r.ModeratedAt, &r.HelpfulCount, &r.UnhelpfulCount, &r.CreatedAt, &r.UpdatedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &r, nil
+}
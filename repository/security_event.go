@@ -0,0 +1,34 @@
+package repository
+
+import (
+  "context"
+  "fmt"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SecurityEventRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewSecurityEventRepository(db *pgxpool.Pool) *SecurityEventRepository {
+  return &SecurityEventRepository{
+	db: db,
+  }
+}
+
+func (r *SecurityEventRepository) Create(ctx context.Context, e *model.SecurityEvent) error {
+  err := r.db.QueryRow(ctx,
+	`INSERT INTO security_events (id, user_id, session_id, kind, ip_address, user_agent)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING created_at`,
+	e.ID, e.UserID, e.SessionID, e.Kind, e.IPAddress, e.UserAgent,
+  ).Scan(&e.CreatedAt)
+  if err != nil {
+	return fmt.Errorf("failed to record security event: %w", err)
+  }
+
+  return nil
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrSessionNotFound = errors.New("session not found")
+)
+
+const sessionColumns = `s.id, s.user_id, s.refresh_token, s.access_token_jti, s.ip_address, s.user_agent,
+  s.expires_at, s.last_activity_at, s.is_valid, s.created_at`
+
+type SessionRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
+  return &SessionRepository{
+	db: db,
+  }
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *model.Session) error {
+  err := r.db.QueryRow(ctx,
+	`INSERT INTO sessions (id, user_id, refresh_token, access_token_jti, ip_address, user_agent, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING last_activity_at, is_valid, created_at`,
+	session.ID, session.UserID, session.RefreshToken, session.AccessTokenJTI,
+	session.IPAddress, session.UserAgent, session.ExpiresAt,
+  ).Scan(&session.LastActivityAt, &session.IsValid, &session.CreatedAt)
+  if err != nil {
+	return fmt.Errorf("failed to create session: %w", err)
+  }
+
+  return nil
+}
+
+// GetByRefreshToken returns the valid session holding the refresh token with
+// the given hash. Expired sessions are returned too, callers check ExpiresAt.
+func (r *SessionRepository) GetByRefreshToken(ctx context.Context, tokenHash string) (*model.Session, error) {
+  session, err := scanSession(r.db.QueryRow(ctx,
+	"SELECT "+sessionColumns+" FROM sessions s WHERE s.refresh_token = $1 AND s.is_valid",
+	tokenHash,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrSessionNotFound
+	}
+	return nil, fmt.Errorf("failed to get session: %w", err)
+  }
+
+  return session, nil
+}
+
+// ListActiveByUser returns the valid, unexpired sessions of a user, most
+// recently used first.
+func (r *SessionRepository) ListActiveByUser(ctx context.Context, userID string) ([]*model.Session, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT `+sessionColumns+` FROM sessions s
+	WHERE s.user_id = $1 AND s.is_valid AND s.expires_at > NOW()
+	ORDER BY s.last_activity_at DESC, s.id`,
+	userID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list sessions: %w", err)
+  }
+  defer rows.Close()
+
+  sessions := []*model.Session{}
+  for rows.Next() {
+	session, err := scanSession(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan session: %w", err)
+	}
+	sessions = append(sessions, session)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+  }
+
+  return sessions, nil
+}
+
+func (r *SessionRepository) InvalidateByID(ctx context.Context, id string) error {
+  if _, err := r.db.Exec(ctx, "UPDATE sessions SET is_valid = FALSE WHERE id = $1", id); err != nil {
+	return fmt.Errorf("failed to invalidate session: %w", err)
+  }
+
+  return nil
+}
+
+// InvalidateForUser signs out one session of a user and returns the jti of the
+// last access token issued to it. Sessions of other users and ones already
+// signed out look like they don't exist.
+func (r *SessionRepository) InvalidateForUser(ctx context.Context, userID, id string) (string, error) {
+  var jti string
+  err := r.db.QueryRow(ctx,
+	`UPDATE sessions SET is_valid = FALSE WHERE id = $1 AND user_id = $2 AND is_valid
+	RETURNING access_token_jti`,
+	id, userID,
+  ).Scan(&jti)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return "", ErrSessionNotFound
+	}
+	return "", fmt.Errorf("failed to invalidate session: %w", err)
+  }
+
+  return jti, nil
+}
+
+func (r *SessionRepository) InvalidateByUserID(ctx context.Context, userID string) error {
+  if _, err := r.db.Exec(ctx, "UPDATE sessions SET is_valid = FALSE WHERE user_id = $1 AND is_valid", userID); err != nil {
+	return fmt.Errorf("failed to invalidate sessions: %w", err)
+  }
+
+  return nil
+}
+
+// InvalidateOthers signs out every session of a user except keepID and
+// returns the jti of the last access token issued to each of them.
+func (r *SessionRepository) InvalidateOthers(ctx context.Context, userID, keepID string) ([]string, error) {
+  rows, err := r.db.Query(ctx,
+	`UPDATE sessions SET is_valid = FALSE WHERE user_id = $1 AND id <> $2 AND is_valid
+	RETURNING access_token_jti`,
+	userID, keepID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to invalidate sessions: %w", err)
+  }
+  defer rows.Close()
+
+  jtis := []string{}
+  for rows.Next() {
+	var jti string
+	if err := rows.Scan(&jti); err != nil {
+	  return nil, fmt.Errorf("failed to scan session: %w", err)
+	}
+	jtis = append(jtis, jti)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to invalidate sessions: %w", err)
+  }
+
+  return jtis, nil
+}
+
+// GetByRotatedToken returns the session that once held the refresh token with
+// the given hash and has since rotated it, whether or not it's still valid.
+func (r *SessionRepository) GetByRotatedToken(ctx context.Context, tokenHash string) (*model.Session, error) {
+  session, err := scanSession(r.db.QueryRow(ctx,
+	`SELECT `+sessionColumns+` FROM sessions s
+	JOIN session_rotated_tokens t ON t.session_id = s.id
+	WHERE t.token_hash = $1`,
+	tokenHash,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrSessionNotFound
+	}
+	return nil, fmt.Errorf("failed to get session: %w", err)
+  }
+
+  return session, nil
+}
+
+// Rotate replaces the refresh token of a session, records the jti of the
+// access token issued with the new one, and remembers the old refresh token
+// so presenting it again can be detected. It fails with ErrSessionNotFound
+// when oldHash is no longer the session's current token, which happens when
+// the same token is refreshed twice at once.
+func (r *SessionRepository) Rotate(ctx context.Context, id, oldHash, newHash, accessJTI string) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  tag, err := tx.Exec(ctx,
+	`UPDATE sessions SET refresh_token = $3, access_token_jti = $4, last_activity_at = NOW()
+	WHERE id = $1 AND refresh_token = $2 AND is_valid`,
+	id, oldHash, newHash, accessJTI,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to rotate refresh token: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrSessionNotFound
+  }
+
+  _, err = tx.Exec(ctx,
+	"INSERT INTO session_rotated_tokens (token_hash, session_id) VALUES ($1, $2)",
+	oldHash, id,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to record rotated refresh token: %w", err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit refresh token rotation: %w", err)
+  }
+
+  return nil
+}
+
+// SetAccessTokenJTI records the jti of an access token issued to a valid
+// session without rotating its refresh token.
+func (r *SessionRepository) SetAccessTokenJTI(ctx context.Context, id, jti string) error {
+  tag, err := r.db.Exec(ctx,
+	"UPDATE sessions SET access_token_jti = $2, last_activity_at = NOW() WHERE id = $1 AND is_valid",
+	id, jti,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to update session: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrSessionNotFound
+  }
+
+  return nil
+}
+
+func (r *SessionRepository) UpdateLastActivity(ctx context.Context, id string) error {
+  if _, err := r.db.Exec(ctx, "UPDATE sessions SET last_activity_at = NOW() WHERE id = $1", id); err != nil {
+	return fmt.Errorf("failed to update session activity: %w", err)
+  }
+
+  return nil
+}
+
+func scanSession(row pgx.Row) (*model.Session, error) {
+  var s model.Session
+  err := row.Scan(
+	&s.ID, &s.UserID, &s.RefreshToken, &s.AccessTokenJTI, &s.IPAddress, &s.UserAgent,
+	&s.ExpiresAt, &s.LastActivityAt, &s.IsValid, &s.CreatedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &s, nil
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrSubscriptionNotFound = errors.New("subscription not found")
+)
+
+const subscriptionColumns = `s.id, s.user_id, s.product_id, s.variant_id, s.type, s.target_price, s.triggered_at, s.created_at`
+
+type SubscriptionRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewSubscriptionRepository(db *pgxpool.Pool) *SubscriptionRepository {
+  return &SubscriptionRepository{
+	db: db,
+  }
+}
+
+// Upsert creates a subscription, or re-arms the user's existing one for the
+// same product, variant and type with the new target price.
+func (r *SubscriptionRepository) Upsert(ctx context.Context, sub *model.ProductSubscription) error {
+  err := r.db.QueryRow(ctx,
+	`INSERT INTO product_subscriptions (id, user_id, product_id, variant_id, type, target_price)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (user_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'), type)
+	DO UPDATE SET target_price = EXCLUDED.target_price, triggered_at = NULL
+	RETURNING id, created_at`,
+	sub.ID, sub.UserID, sub.ProductID, sub.VariantID, sub.Type, sub.TargetPrice,
+  ).Scan(&sub.ID, &sub.CreatedAt)
+  if err != nil {
+	return fmt.Errorf("failed to save subscription: %w", err)
+  }
+
+  sub.TriggeredAt = nil
+  return nil
+}
+
+func (r *SubscriptionRepository) ListByUser(ctx context.Context, userID string) ([]*model.ProductSubscription, error) {
+  rows, err := r.db.Query(ctx,
+	"SELECT "+subscriptionColumns+" FROM product_subscriptions s WHERE s.user_id = $1 ORDER BY s.created_at DESC",
+	userID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+  }
+  defer rows.Close()
+
+  subs := []*model.ProductSubscription{}
+  for rows.Next() {
+	sub, err := scanSubscription(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan subscription: %w", err)
+	}
+	subs = append(subs, sub)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate subscriptions: %w", err)
+  }
+
+  return subs, nil
+}
+
+func (r *SubscriptionRepository) Delete(ctx context.Context, userID, id string) error {
+  tag, err := r.db.Exec(ctx, "DELETE FROM product_subscriptions WHERE id = $1 AND user_id = $2", id, userID)
+  if err != nil {
+	return fmt.Errorf("failed to delete subscription: %w", err)
+  }
+
+  if tag.RowsAffected() == 0 {
+	return ErrSubscriptionNotFound
+  }
+
+  return nil
+}
+
+// DeliverTriggered hands up to limit triggered subscriptions, oldest first, to
+// deliver, grouped by user so each user gets a single notification per batch.
+// Subscriptions of users whose delivery succeeded are deleted, the others stay
+// for the next run; their errors are joined. Subscriptions are locked while
+// being delivered so concurrent runs skip them.
+func (r *SubscriptionRepository) DeliverTriggered(ctx context.Context, limit int, deliver func(email string, subs []*model.TriggeredSubscription) error) (int, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return 0, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  rows, err := tx.Query(ctx,
+	`SELECT `+subscriptionColumns+`, u.email, p.name, COALESCE(v.sku, p.sku), COALESCE(v.price, p.price)
+	FROM product_subscriptions s
+	JOIN users u ON u.id = s.user_id
+	JOIN products p ON p.id = s.product_id
+	LEFT JOIN product_variants v ON v.id = s.variant_id
+	WHERE s.triggered_at IS NOT NULL
+	ORDER BY s.triggered_at
+	LIMIT $1
+	FOR UPDATE OF s SKIP LOCKED`,
+	limit,
+  )
+  if err != nil {
+	return 0, fmt.Errorf("failed to get triggered subscriptions: %w", err)
+  }
+  defer rows.Close()
+
+  byUser := map[string][]*model.TriggeredSubscription{}
+  emails := []string{}
+  for rows.Next() {
+	var t model.TriggeredSubscription
+	err := rows.Scan(
+	  &t.ID, &t.UserID, &t.ProductID, &t.VariantID, &t.Type, &t.TargetPrice, &t.TriggeredAt, &t.CreatedAt,
+	  &t.Email, &t.ProductName, &t.SKU, &t.Price,
+	)
+	if err != nil {
+	  return 0, fmt.Errorf("failed to scan triggered subscription: %w", err)
+	}
+	if _, ok := byUser[t.Email]; !ok {
+	  emails = append(emails, t.Email)
+	}
+	byUser[t.Email] = append(byUser[t.Email], &t)
+  }
+
+  if err := rows.Err(); err != nil {
+	return 0, fmt.Errorf("failed to iterate triggered subscriptions: %w", err)
+  }
+
+  delivered := []string{}
+  var failures []error
+  for _, email := range emails {
+	subs := byUser[email]
+	if err := deliver(email, subs); err != nil {
+	  failures = append(failures, fmt.Errorf("user %s: %w", subs[0].UserID, err))
+	  continue
+	}
+	for _, s := range subs {
+	  delivered = append(delivered, s.ID)
+	}
+  }
+
+  if len(delivered) > 0 {
+	if _, err := tx.Exec(ctx, "DELETE FROM product_subscriptions WHERE id = ANY($1)", delivered); err != nil {
+	  return 0, fmt.Errorf("failed to remove delivered subscriptions: %w", err)
+	}
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return 0, fmt.Errorf("failed to commit subscriptions: %w", err)
+  }
+
+  return len(delivered), errors.Join(failures...)
+}
+
+// triggerBackInStock marks the waiting back-in-stock subscriptions of a
+// variant, or of the product itself when variantID is nil, as triggered.
+func triggerBackInStock(ctx context.Context, q querier, productID string, variantID *string) error {
+  _, err := q.Exec(ctx,
+	`UPDATE product_subscriptions SET triggered_at = NOW()
+	WHERE product_id = $1 AND variant_id IS NOT DISTINCT FROM $2 AND type = 'back_in_stock' AND triggered_at IS NULL`,
+	productID, variantID,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to trigger back in stock subscriptions: %w", err)
+  }
+
+  return nil
+}
+
+// triggerPriceBelow marks the waiting price subscriptions of a product whose
+// target the new price has reached as triggered.
+func triggerPriceBelow(ctx context.Context, q querier, productID string, price float64) error {
+  _, err := q.Exec(ctx,
+	`UPDATE product_subscriptions SET triggered_at = NOW()
+	WHERE product_id = $1 AND type = 'price_below' AND triggered_at IS NULL AND target_price >= $2`,
+	productID, price,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to trigger price subscriptions: %w", err)
+  }
+
+  return nil
+}
+
+func scanSubscription(row pgx.Row) (*model.ProductSubscription, error) {
+  var s model.ProductSubscription
+  err := row.Scan(&s.ID, &s.UserID, &s.ProductID, &s.VariantID, &s.Type, &s.TargetPrice, &s.TriggeredAt, &s.CreatedAt)
+  if err != nil {
+	return nil, err
+  }
+
+  return &s, nil
+}
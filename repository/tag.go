@@ -0,0 +1,211 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrTagNotFound = errors.New("tag not found")
+  ErrDuplicateTag = errors.New("tag slug already exists")
+)
+
+const tagColumns = `t.id, t.name, t.slug, t.created_at, t.updated_at`
+
+type TagWithProductCount struct {
+  model.Tag
+  ProductCount int
+}
+
+type TagRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewTagRepository(db *pgxpool.Pool) *TagRepository {
+  return &TagRepository{
+	db: db,
+  }
+}
+
+// ListWithProductCounts returns every tag with the number of non-deleted
+// products carrying it, most used first.
+func (r *TagRepository) ListWithProductCounts(ctx context.Context) ([]*TagWithProductCount, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT `+tagColumns+`, COUNT(p.id)
+	FROM tags t
+	LEFT JOIN products p ON p.tags @> ARRAY[t.slug]::text[] AND p.deleted_at IS NULL
+	GROUP BY t.id
+	ORDER BY COUNT(p.id) DESC, t.name`,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list tags: %w", err)
+  }
+  defer rows.Close()
+
+  tags := []*TagWithProductCount{}
+  for rows.Next() {
+	var t TagWithProductCount
+	err := rows.Scan(&t.ID, &t.Name, &t.Slug, &t.CreatedAt, &t.UpdatedAt, &t.ProductCount)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan tag: %w", err)
+	}
+	tags = append(tags, &t)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate tags: %w", err)
+  }
+
+  return tags, nil
+}
+
+// Rename changes the name and slug of a tag. When the slug changes every
+// product carrying the tag is rewritten; the number of products is returned.
+func (r *TagRepository) Rename(ctx context.Context, slug, name, newSlug string) (*model.Tag, int64, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  tag, err := scanTag(tx.QueryRow(ctx,
+	`UPDATE tags t SET name = $2, slug = $3, updated_at = NOW()
+	WHERE t.slug = $1
+	RETURNING `+tagColumns,
+	slug, name, newSlug,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, 0, ErrTagNotFound
+	}
+	return nil, 0, r.translateError(err)
+  }
+
+  var rewritten int64
+  if newSlug != slug {
+	result, err := tx.Exec(ctx,
+	  `UPDATE products SET tags = array_replace(tags, $1, $2), updated_at = NOW()
+	  WHERE tags @> ARRAY[$1]::text[]`,
+	  slug, newSlug,
+	)
+	if err != nil {
+	  return nil, 0, fmt.Errorf("failed to rewrite product tags: %w", err)
+	}
+	rewritten = result.RowsAffected()
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, 0, fmt.Errorf("failed to commit tag rename: %w", err)
+  }
+
+  return tag, rewritten, nil
+}
+
+// Merge moves every product tagged source to target and deletes source.
+// Products that already had both keep a single copy of target, in source's
+// position if it came first. Returns target and the number of products
+// rewritten.
+func (r *TagRepository) Merge(ctx context.Context, source, target string) (*model.Tag, int64, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  tag, err := scanTag(tx.QueryRow(ctx, "SELECT "+tagColumns+" FROM tags t WHERE t.slug = $1 FOR UPDATE", target))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, 0, ErrTagNotFound
+	}
+	return nil, 0, fmt.Errorf("failed to lock tag: %w", err)
+  }
+
+  deleted, err := tx.Exec(ctx, "DELETE FROM tags WHERE slug = $1", source)
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to delete tag: %w", err)
+  }
+  if deleted.RowsAffected() == 0 {
+	return nil, 0, ErrTagNotFound
+  }
+
+  rewritten, err := tx.Exec(ctx,
+	`UPDATE products p SET tags = (
+	  SELECT array_agg(slug ORDER BY ord)
+	  FROM (
+	    SELECT slug, MIN(ord) AS ord
+	    FROM unnest(array_replace(p.tags, $1, $2)) WITH ORDINALITY AS u(slug, ord)
+	    GROUP BY slug
+	  ) deduped
+	), updated_at = NOW()
+	WHERE p.tags @> ARRAY[$1]::text[]`,
+	source, target,
+  )
+  if err != nil {
+	return nil, 0, fmt.Errorf("failed to rewrite product tags: %w", err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, 0, fmt.Errorf("failed to commit tag merge: %w", err)
+  }
+
+  return tag, rewritten.RowsAffected(), nil
+}
+
+// registerTags makes sure every slug has a tag, naming new ones after their
+// slug. Product writes call it in their own transaction.
+func registerTags(ctx context.Context, q querier, slugs []string) error {
+  if len(slugs) == 0 {
+	return nil
+  }
+
+  ids := make([]string, len(slugs))
+  for i := range slugs {
+	ids[i] = uuid.New().String()
+  }
+
+  _, err := q.Exec(ctx,
+	`INSERT INTO tags (id, name, slug)
+	SELECT id, slug, slug FROM unnest($1::uuid[], $2::text[]) AS u(id, slug)
+	ON CONFLICT (slug) DO NOTHING`,
+	ids, slugs,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to register tags: %w", err)
+  }
+
+  return nil
+}
+
+func (r *TagRepository) translateError(err error) error {
+  if err == nil {
+	return nil
+  }
+
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) {
+	if pgErr.Code == "23505" && strings.Contains(pgErr.Detail, "slug") { // unique_violation
+	  return ErrDuplicateTag
+	}
+  }
+
+  return err
+}
+
+func scanTag(row pgx.Row) (*model.Tag, error) {
+  var t model.Tag
+  err := row.Scan(&t.ID, &t.Name, &t.Slug, &t.CreatedAt, &t.UpdatedAt)
+  if err != nil {
+	return nil, err
+  }
+
+  return &t, nil
+}
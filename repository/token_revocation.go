@@ -0,0 +1,88 @@
+package repository
+
+import (
+  "context"
+  "fmt"
+  "time"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TokenRevocationRepository is the Postgres-backed store of revoked access
+// tokens, shared by every instance of the API.
+type TokenRevocationRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewTokenRevocationRepository(db *pgxpool.Pool) *TokenRevocationRepository {
+  return &TokenRevocationRepository{
+	db: db,
+  }
+}
+
+func (r *TokenRevocationRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+  _, err := r.db.Exec(ctx,
+	`INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2)
+	ON CONFLICT (jti) DO UPDATE SET expires_at = GREATEST(revoked_tokens.expires_at, EXCLUDED.expires_at)`,
+	jti, expiresAt,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to revoke token: %w", err)
+  }
+
+  return nil
+}
+
+// RevokeUser revokes every token of a user issued before issuedBefore, stored
+// to the microsecond like token issue times. A later revocation of the same
+// user replaces an earlier one.
+func (r *TokenRevocationRepository) RevokeUser(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error {
+  _, err := r.db.Exec(ctx,
+	`INSERT INTO user_token_revocations (user_id, revoked_before, expires_at) VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE SET
+	  revoked_before = GREATEST(user_token_revocations.revoked_before, EXCLUDED.revoked_before),
+	  expires_at = GREATEST(user_token_revocations.expires_at, EXCLUDED.expires_at)`,
+	userID, issuedBefore, expiresAt,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to revoke user tokens: %w", err)
+  }
+
+  return nil
+}
+
+// IsRevoked reports whether the token jti, issued to userID at issuedAt, was
+// revoked on its own or by a revocation of all the user's tokens issued before
+// the cutoff.
+func (r *TokenRevocationRepository) IsRevoked(ctx context.Context, userID, jti string, issuedAt time.Time) (bool, error) {
+  var revoked bool
+  err := r.db.QueryRow(ctx,
+	`SELECT EXISTS (
+	  SELECT 1 FROM revoked_tokens WHERE jti = $1 AND expires_at > NOW()
+	) OR EXISTS (
+	  SELECT 1 FROM user_token_revocations
+	  WHERE user_id = $2 AND expires_at > NOW() AND $3::timestamptz < revoked_before
+	)`,
+	jti, userID, issuedAt,
+  ).Scan(&revoked)
+  if err != nil {
+	return false, fmt.Errorf("failed to check token revocation: %w", err)
+  }
+
+  return revoked, nil
+}
+
+// DeleteExpired drops revocations of tokens that have expired on their own.
+func (r *TokenRevocationRepository) DeleteExpired(ctx context.Context) (int64, error) {
+  tokens, err := r.db.Exec(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= NOW()")
+  if err != nil {
+	return 0, fmt.Errorf("failed to delete expired token revocations: %w", err)
+  }
+
+  users, err := r.db.Exec(ctx, "DELETE FROM user_token_revocations WHERE expires_at <= NOW()")
+  if err != nil {
+	return 0, fmt.Errorf("failed to delete expired user revocations: %w", err)
+  }
+
+  return tokens.RowsAffected() + users.RowsAffected(), nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+  "context"
+  "testing"
+  "time"
+
+  "github.com/google/uuid"
+)
+
+func TestTokenRevocationRepositoryRevokesToken(t *testing.T) {
+  ctx := context.Background()
+  repo := NewTokenRevocationRepository(testDB(t))
+  jti := uuid.New().String()
+  userID := uuid.New().String()
+  t.Cleanup(func() { repo.db.Exec(context.Background(), "DELETE FROM revoked_tokens WHERE jti = $1", jti) })
+
+  if err := repo.RevokeToken(ctx, jti, time.Now().Add(time.Hour)); err != nil {
+	t.Fatal(err)
+  }
+
+  assertTokenRevoked(t, repo, userID, jti, time.Now(), true)
+  assertTokenRevoked(t, repo, userID, uuid.New().String(), time.Now(), false)
+}
+
+func TestTokenRevocationRepositoryUserCutoff(t *testing.T) {
+  ctx := context.Background()
+  repo := NewTokenRevocationRepository(testDB(t))
+  userID := uuid.New().String()
+  t.Cleanup(func() { repo.db.Exec(context.Background(), "DELETE FROM user_token_revocations WHERE user_id = $1", userID) })
+
+  cutoff := time.Now().Truncate(time.Second).Add(500 * time.Millisecond)
+  if err := repo.RevokeUser(ctx, userID, cutoff, time.Now().Add(time.Hour)); err != nil {
+	t.Fatal(err)
+  }
+
+  sameSecond := cutoff.Truncate(time.Second)
+  tests := []struct {
+	name     string
+	userID   string
+	issuedAt time.Time
+	revoked  bool
+  }{
+	{"issued earlier", userID, sameSecond.Add(-time.Second), true},
+	{"issued earlier in the same second", userID, sameSecond, true},
+	{"issued a microsecond before the cutoff", userID, cutoff.Add(-time.Microsecond), true},
+	{"issued at the cutoff", userID, cutoff, false},
+	{"issued in the same second after the cutoff", userID, cutoff.Add(100 * time.Millisecond), false},
+	{"issued in the next second", userID, sameSecond.Add(time.Second), false},
+	{"other user", uuid.New().String(), sameSecond, false},
+  }
+
+  for _, tt := range tests {
+	t.Run(tt.name, func(t *testing.T) {
+	  assertTokenRevoked(t, repo, tt.userID, uuid.New().String(), tt.issuedAt, tt.revoked)
+	})
+  }
+}
+
+func assertTokenRevoked(t *testing.T, repo *TokenRevocationRepository, userID, jti string, issuedAt time.Time, want bool) {
+  t.Helper()
+
+  revoked, err := repo.IsRevoked(context.Background(), userID, jti, issuedAt)
+  if err != nil {
+	t.Fatal(err)
+  }
+  if revoked != want {
+	t.Errorf("IsRevoked(%s, %s) = %v, want %v", userID, issuedAt.Format(time.RFC3339Nano), revoked, want)
+  }
+}
@@ -21,6 +21,24 @@ var (
   ErrNoRowsAffected = errors.New("no rows affected")
 )
 
+// UserFilter narrows a user listing. Query matches the username or email.
+type UserFilter struct {
+  Query *string
+  Role  *model.UserRole
+}
+
+type userSort struct {
+  column string
+  typ    string
+  value  func(u *model.User) string
+}
+
+var userSorts = map[string]userSort{
+  "created_at": {"u.created_at", "timestamptz", func(u *model.User) string { return timeKey(u.CreatedAt) }},
+  "username": {"u.username", "text", func(u *model.User) string { return u.Username }},
+  "email": {"u.email", "text", func(u *model.User) string { return u.Email }},
+}
+
 type UserRepository struct {
   db *pgxpool.Pool
 }
@@ -49,8 +67,8 @@ func (r *UserRepository) CreateUserAtomic(ctx context.Context, user *model.User)
 
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
   var user model.User
-  err := r.db.QueryRow(ctx, "SELECT id, email, password, username, address, city, country, created_at, updated_at FROM users WHERE id = $1 AND deleted_at IS NULL", id).Scan(
-  &user.ID, &user.Email, &user.Password, &user.Username, &user.Address, &user.City, &user.Country, &user.CreatedAt, &user.UpdatedAt)
+  err := r.db.QueryRow(ctx, "SELECT id, email, password, username, role, address, city, country, created_at, updated_at, email_verified_at FROM users WHERE id = $1 AND deleted_at IS NULL", id).Scan(
+  &user.ID, &user.Email, &user.Password, &user.Username, &user.Role, &user.Address, &user.City, &user.Country, &user.CreatedAt, &user.UpdatedAt, &user.EmailVerifiedAt)
 
   if err != nil {
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -65,23 +83,21 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*model.User, e
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
   var user model.User
-  err := r.db.QueryRow(ctx, "SELECT id, email, password, username, address, city, country, created_at, updated_at FROM users WHERE email = $1 AND deleted_at IS NULL", email).Scan(
-  &user.ID, &user.Email, &user.Username, &user.Password, &user.Address, &user.City, &user.Country, &user.CreatedAt, &user.UpdatedAt)
+  err := r.db.QueryRow(ctx, "SELECT id, email, password, username, role, address, city, country, created_at, updated_at, email_verified_at FROM users WHERE email = $1 AND deleted_at IS NULL", email).Scan(
+  &user.ID, &user.Email, &user.Password, &user.Username, &user.Role, &user.Address, &user.City, &user.Country, &user.CreatedAt, &user.UpdatedAt, &user.EmailVerifiedAt)
 
   if err != nil {
 	if errors.Is(err, pgx.ErrNoRows) {
 	  return nil, ErrUserNotFound
 	}
 
-	return nil, fmt.Errorf("failed to get user by id: %w", err)
+	return nil, fmt.Errorf("failed to get user by email: %w", err)
   }
 
   return &user, nil
 }
 
 func (r *UserRepository) Update(ctx context.Context, id string, updates map[string]interface{}) (*model.User, error) {
-  var user model.User
-
   setClauses := []string{}
   args := []interface{}{}
   argID := 1
@@ -99,12 +115,12 @@ func (r *UserRepository) Update(ctx context.Context, id string, updates map[stri
   args = append(args, id)
 
   query := fmt.Sprintf(
-	"UPDATE users SET %s WHERE id = $%d AND deleted_at IS NULL RETURNING id, email, username, address, city, country, created_at, updated_at",
+	"UPDATE users u SET %s WHERE u.id = $%d AND u.deleted_at IS NULL RETURNING u.id, u.email, u.username, u.role, u.address, u.city, u.country, u.created_at, u.updated_at, u.email_verified_at",
 	strings.Join(setClauses, ", "),
 	argID,
   )
 
-  err := r.db.QueryRow(ctx, query).Scan(&user.ID, &user.Email, &user.Username, &user.Address, &user.City, &user.Country, &user.CreatedAt, &user.UpdatedAt)
+  user, err := scanUserSummary(r.db.QueryRow(ctx, query, args...))
   if err != nil {
 	if errors.Is(err, pgx.ErrNoRows) {
 	  return nil, ErrUserNotFound
@@ -113,7 +129,40 @@ func (r *UserRepository) Update(ctx context.Context, id string, updates map[stri
 	return nil, fmt.Errorf("failed to update user: %w", err)
   }
 
-  return &user, nil
+  return user, nil
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+  tag, err := r.db.Exec(ctx,
+	"UPDATE users SET password = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL",
+	id, passwordHash,
+  )
+  if err != nil {
+	return fmt.Errorf("failed to update password: %w", err)
+  }
+  if tag.RowsAffected() == 0 {
+	return ErrUserNotFound
+  }
+
+  return nil
+}
+
+// UpdateRole changes the role of a user and returns the updated user.
+func (r *UserRepository) UpdateRole(ctx context.Context, id string, role model.UserRole) (*model.User, error) {
+  user, err := scanUserSummary(r.db.QueryRow(ctx,
+	`UPDATE users SET role = $2, updated_at = NOW()
+	WHERE id = $1 AND deleted_at IS NULL
+	RETURNING id, email, username, role, address, city, country, created_at, updated_at, email_verified_at`,
+	id, role,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrUserNotFound
+	}
+	return nil, fmt.Errorf("failed to update user role: %w", err)
+  }
+
+  return user, nil
 }
 
 func (r *UserRepository) Delete(ctx context.Context, id string) (time.Time, error) {
@@ -147,17 +196,64 @@ func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool,
 func (r *UserRepository) ExistsByUsername(ctx context.Context, username string) (bool, error) {
   var exists bool
   err := r.db.QueryRow(ctx, 
-	"SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND deleted_at IS NULL)", 
+	"SELECT EXISTS(SELECT 1 FROM users WHERE username = $1 AND deleted_at IS NULL)", 
 	username,
   ).Scan(&exists)
 
   if err != nil {
-	return false, fmt.Errorf("failed to check email existence: %w", err)
+	return false, fmt.Errorf("failed to check username existence: %w", err)
   }
 
   return exists, nil
 }
 
+// List returns a page of the users matching filter, ordered by sortBy in
+// sortOrder ("asc" or "desc"). Deleted users are left out.
+func (r *UserRepository) List(ctx context.Context, filter UserFilter, sortBy, sortOrder string, page Page) ([]*model.User, PageInfo, error) {
+  sort, ok := userSorts[sortBy]
+  if !ok {
+	sortBy = "created_at"
+	sort = userSorts[sortBy]
+  }
+
+  args := []interface{}{}
+  bind := binder(&args)
+
+  conditions := []string{"u.deleted_at IS NULL"}
+  if filter.Query != nil {
+	pattern := bind("%" + escapeLike(*filter.Query) + "%")
+	conditions = append(conditions, fmt.Sprintf("(u.username ILIKE %[1]s OR u.email ILIKE %[1]s)", pattern))
+  }
+  if filter.Role != nil {
+	conditions = append(conditions, "u.role = "+bind(*filter.Role))
+  }
+
+  return fetchPage(ctx, r.db, listQuery{
+	columns: "u.id, u.email, u.username, u.role, u.address, u.city, u.country, u.created_at, u.updated_at, u.email_verified_at",
+	from: "FROM users u",
+	where: strings.Join(conditions, " AND "),
+	args: args,
+	order: keyset{
+	  name: sortBy,
+	  columns: []string{sort.column, "u.id"},
+	  types: []string{sort.typ, "uuid"},
+	  desc: sortOrder == "desc",
+	},
+  }, page, scanUserSummary, func(u *model.User) []string {
+	return []string{sort.value(u), u.ID}
+  })
+}
+
+func scanUserSummary(row pgx.Row) (*model.User, error) {
+  var u model.User
+  err := row.Scan(&u.ID, &u.Email, &u.Username, &u.Role, &u.Address, &u.City, &u.Country, &u.CreatedAt, &u.UpdatedAt, &u.EmailVerifiedAt)
+  if err != nil {
+	return nil, err
+  }
+
+  return &u, nil
+}
+
 func (r *UserRepository) translateError(err error) error {
   if err == nil {
     return nil
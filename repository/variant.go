@@ -0,0 +1,320 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrVariantNotFound = errors.New("variant not found")
+  ErrDuplicateSKU = errors.New("sku already exists")
+  ErrDuplicateVariant = errors.New("variant with these attributes already exists")
+)
+
+const variantColumns = `v.id, v.product_id, v.sku, v.name, v.price, v.stock, v.reserved_stock, v.attributes,
+  v.created_at, v.updated_at, v.deleted_at`
+
+type VariantRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewVariantRepository(db *pgxpool.Pool) *VariantRepository {
+  return &VariantRepository{
+	db: db,
+  }
+}
+
+func (r *VariantRepository) Create(ctx context.Context, variant *model.ProductVariant) error {
+  return r.CreateMany(ctx, []*model.ProductVariant{variant})
+}
+
+// CreateMany inserts all variants or none of them.
+func (r *VariantRepository) CreateMany(ctx context.Context, variants []*model.ProductVariant) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  for _, v := range variants {
+	if err := r.create(ctx, tx, v); err != nil {
+	  return err
+	}
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit variants: %w", err)
+  }
+
+  return nil
+}
+
+// create inserts the variant with no stock and then receives its initial
+// stock into the default warehouse, so the ledger opens with it.
+func (r *VariantRepository) create(ctx context.Context, q querier, variant *model.ProductVariant) error {
+  err := q.QueryRow(ctx,
+	`INSERT INTO product_variants (id, product_id, sku, name, price, stock, attributes)
+	VALUES ($1, $2, $3, $4, $5, 0, $6)
+	RETURNING created_at, updated_at`,
+	variant.ID, variant.ProductID, variant.SKU, variant.Name, variant.Price, variant.Attributes,
+  ).Scan(&variant.CreatedAt, &variant.UpdatedAt)
+
+  if err != nil {
+	return r.translateError(err)
+  }
+
+  opening := openingStockMovement(variant.ProductID, &variant.ID, variant.Stock, "Initial stock")
+  if err := applyStockMovement(ctx, q, opening); err != nil {
+	return err
+  }
+  variant.Stock = opening.StockAfter
+
+  return nil
+}
+
+func (r *VariantRepository) GetByID(ctx context.Context, productID, variantID string) (*model.ProductVariant, error) {
+  variant, err := scanVariant(r.db.QueryRow(ctx,
+	"SELECT "+variantColumns+" FROM product_variants v WHERE v.id = $1 AND v.product_id = $2 AND v.deleted_at IS NULL",
+	variantID, productID,
+  ))
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrVariantNotFound
+	}
+
+	return nil, fmt.Errorf("failed to get variant by id: %w", err)
+  }
+
+  return variant, nil
+}
+
+func (r *VariantRepository) ListByProduct(ctx context.Context, productID string) ([]*model.ProductVariant, error) {
+  return r.GetByProductIDs(ctx, []string{productID})
+}
+
+func (r *VariantRepository) GetByProductIDs(ctx context.Context, productIDs []string) ([]*model.ProductVariant, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT `+variantColumns+`
+	FROM product_variants v
+	WHERE v.product_id = ANY($1) AND v.deleted_at IS NULL
+	ORDER BY v.product_id, v.created_at, v.sku`,
+	productIDs,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list variants: %w", err)
+  }
+  defer rows.Close()
+
+  variants := []*model.ProductVariant{}
+  for rows.Next() {
+	variant, err := scanVariant(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan variant: %w", err)
+	}
+	variants = append(variants, variant)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate variants: %w", err)
+  }
+
+  return variants, nil
+}
+
+func (r *VariantRepository) Update(ctx context.Context, productID, variantID string, updates map[string]interface{}) (*model.ProductVariant, error) {
+  setClauses := []string{}
+  args := []interface{}{}
+  argID := 1
+
+  for field, value := range updates {
+	setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field, argID))
+	args = append(args, value)
+	argID++
+  }
+
+  setClauses = append(setClauses, fmt.Sprintf("updated_at = $%d", argID))
+  args = append(args, time.Now())
+  argID++
+
+  args = append(args, variantID, productID)
+
+  query := fmt.Sprintf(
+	"UPDATE product_variants v SET %s WHERE v.id = $%d AND v.product_id = $%d AND v.deleted_at IS NULL RETURNING %s",
+	strings.Join(setClauses, ", "),
+	argID,
+	argID+1,
+	variantColumns,
+  )
+
+  variant, err := scanVariant(r.db.QueryRow(ctx, query, args...))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrVariantNotFound
+	}
+
+	return nil, r.translateError(err)
+  }
+
+  return variant, nil
+}
+
+// UpdateStock applies m to the variant's stock and records it in the ledger.
+// On success m holds the before/after quantities.
+func (r *VariantRepository) UpdateStock(ctx context.Context, m *model.StockMovement) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  var exists bool
+  err = tx.QueryRow(ctx,
+	"SELECT EXISTS (SELECT 1 FROM product_variants WHERE id = $1 AND product_id = $2 AND deleted_at IS NULL)",
+	*m.VariantID, m.ProductID,
+  ).Scan(&exists)
+  if err != nil {
+	return fmt.Errorf("failed to get variant: %w", err)
+  }
+  if !exists {
+	return ErrVariantNotFound
+  }
+
+  if err := applyStockMovement(ctx, tx, m); err != nil {
+	return err
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit stock update: %w", err)
+  }
+
+  return nil
+}
+
+func (r *VariantRepository) Delete(ctx context.Context, productID, variantID string) (time.Time, error) {
+  var deletedAt time.Time
+  err := r.db.QueryRow(ctx,
+	"UPDATE product_variants SET deleted_at = NOW() WHERE id = $1 AND product_id = $2 AND deleted_at IS NULL RETURNING deleted_at",
+	variantID, productID,
+  ).Scan(&deletedAt)
+
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return time.Time{}, ErrVariantNotFound
+	}
+
+	return time.Time{}, fmt.Errorf("failed to delete variant: %w", err)
+  }
+
+  // The product may have just lost its last variant in stock.
+  if err := refreshProductStatus(ctx, r.db, productID); err != nil {
+	return time.Time{}, err
+  }
+
+  return deletedAt, nil
+}
+
+func (r *VariantRepository) GetOptions(ctx context.Context, productID string) ([]*model.ProductOption, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT id, product_id, name, values, position, created_at, updated_at
+	FROM product_options WHERE product_id = $1 ORDER BY position, name`,
+	productID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to get product options: %w", err)
+  }
+  defer rows.Close()
+
+  options := []*model.ProductOption{}
+  for rows.Next() {
+	var o model.ProductOption
+	if err := rows.Scan(&o.ID, &o.ProductID, &o.Name, &o.Values, &o.Position, &o.CreatedAt, &o.UpdatedAt); err != nil {
+	  return nil, fmt.Errorf("failed to scan product option: %w", err)
+	}
+	options = append(options, &o)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate product options: %w", err)
+  }
+
+  return options, nil
+}
+
+// ReplaceOptions swaps the option definitions of a product. Existing variants
+// are left untouched; regenerating the matrix only adds missing combinations.
+func (r *VariantRepository) ReplaceOptions(ctx context.Context, productID string, options []*model.ProductOption) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if _, err := tx.Exec(ctx, "DELETE FROM product_options WHERE product_id = $1", productID); err != nil {
+	return fmt.Errorf("failed to clear product options: %w", err)
+  }
+
+  for i, o := range options {
+	o.ID = uuid.New().String()
+	o.ProductID = productID
+	o.Position = i
+	err := tx.QueryRow(ctx,
+	  `INSERT INTO product_options (id, product_id, name, values, position)
+	  VALUES ($1, $2, $3, $4, $5)
+	  RETURNING created_at, updated_at`,
+	  o.ID, o.ProductID, o.Name, o.Values, o.Position,
+	).Scan(&o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+	  return fmt.Errorf("failed to insert product option: %w", err)
+	}
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit product options: %w", err)
+  }
+
+  return nil
+}
+
+func (r *VariantRepository) translateError(err error) error {
+  if err == nil {
+	return nil
+  }
+
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) {
+	if pgErr.Code == "23505" { // unique_violation
+	  if strings.Contains(pgErr.Detail, "sku") {
+		return ErrDuplicateSKU
+	  }
+	  if strings.Contains(pgErr.Detail, "attributes") {
+		return ErrDuplicateVariant
+	  }
+	}
+  }
+
+  return err
+}
+
+func scanVariant(row pgx.Row) (*model.ProductVariant, error) {
+  var v model.ProductVariant
+  err := row.Scan(
+	&v.ID, &v.ProductID, &v.SKU, &v.Name, &v.Price, &v.Stock, &v.ReservedStock, &v.Attributes,
+	&v.CreatedAt, &v.UpdatedAt, &v.DeletedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &v, nil
+}
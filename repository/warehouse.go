@@ -0,0 +1,321 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrWarehouseNotFound = errors.New("warehouse not found")
+  ErrDuplicateWarehouseCode = errors.New("warehouse code already exists")
+  ErrNoDefaultWarehouse = errors.New("no default warehouse")
+)
+
+const warehouseColumns = `w.id, w.code, w.name, w.country, w.ships_to, w.priority, w.is_default, w.is_active,
+  w.created_at, w.updated_at`
+
+const warehouseStockColumns = `ws.warehouse_id, ws.product_id, ws.variant_id, ws.stock, ws.reserved_stock, ws.updated_at`
+
+type WarehouseRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewWarehouseRepository(db *pgxpool.Pool) *WarehouseRepository {
+  return &WarehouseRepository{
+	db: db,
+  }
+}
+
+// Create inserts a warehouse. A new default warehouse takes over from the
+// current one.
+func (r *WarehouseRepository) Create(ctx context.Context, w *model.Warehouse) error {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if w.IsDefault {
+	if _, err := tx.Exec(ctx, "UPDATE warehouses SET is_default = FALSE, updated_at = NOW() WHERE is_default"); err != nil {
+	  return fmt.Errorf("failed to unset default warehouse: %w", err)
+	}
+  }
+
+  err = tx.QueryRow(ctx,
+	`INSERT INTO warehouses (id, code, name, country, ships_to, priority, is_default, is_active)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING created_at, updated_at`,
+	w.ID, w.Code, w.Name, w.Country, w.ShipsTo, w.Priority, w.IsDefault, w.IsActive,
+  ).Scan(&w.CreatedAt, &w.UpdatedAt)
+  if err != nil {
+	return r.translateError(err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return fmt.Errorf("failed to commit warehouse: %w", err)
+  }
+
+  return nil
+}
+
+func (r *WarehouseRepository) GetByID(ctx context.Context, id string) (*model.Warehouse, error) {
+  w, err := scanWarehouse(r.db.QueryRow(ctx, "SELECT "+warehouseColumns+" FROM warehouses w WHERE w.id = $1", id))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrWarehouseNotFound
+	}
+
+	return nil, fmt.Errorf("failed to get warehouse: %w", err)
+  }
+
+  return w, nil
+}
+
+// List returns warehouses ordered by priority, lowest first.
+func (r *WarehouseRepository) List(ctx context.Context, activeOnly bool) ([]*model.Warehouse, error) {
+  rows, err := r.db.Query(ctx,
+	"SELECT "+warehouseColumns+" FROM warehouses w WHERE w.is_active OR NOT $1 ORDER BY w.priority, w.code",
+	activeOnly,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list warehouses: %w", err)
+  }
+  defer rows.Close()
+
+  warehouses := []*model.Warehouse{}
+  for rows.Next() {
+	w, err := scanWarehouse(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan warehouse: %w", err)
+	}
+	warehouses = append(warehouses, w)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate warehouses: %w", err)
+  }
+
+  return warehouses, nil
+}
+
+// Update applies a partial update. Making a warehouse the default unsets the
+// previous default in the same transaction.
+func (r *WarehouseRepository) Update(ctx context.Context, id string, updates map[string]interface{}) (*model.Warehouse, error) {
+  tx, err := r.db.Begin(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to begin transaction: %w", err)
+  }
+  defer tx.Rollback(ctx)
+
+  if isDefault, ok := updates["is_default"].(bool); ok && isDefault {
+	_, err := tx.Exec(ctx, "UPDATE warehouses SET is_default = FALSE, updated_at = NOW() WHERE is_default AND id <> $1", id)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to unset default warehouse: %w", err)
+	}
+  }
+
+  setClauses := []string{}
+  args := []interface{}{}
+  argID := 1
+
+  for field, value := range updates {
+	setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field, argID))
+	args = append(args, value)
+	argID++
+  }
+
+  setClauses = append(setClauses, fmt.Sprintf("updated_at = $%d", argID))
+  args = append(args, time.Now())
+  argID++
+
+  args = append(args, id)
+
+  query := fmt.Sprintf(
+	"UPDATE warehouses w SET %s WHERE w.id = $%d RETURNING %s",
+	strings.Join(setClauses, ", "),
+	argID,
+	warehouseColumns,
+  )
+
+  w, err := scanWarehouse(tx.QueryRow(ctx, query, args...))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrWarehouseNotFound
+	}
+
+	return nil, r.translateError(err)
+  }
+
+  if err := tx.Commit(ctx); err != nil {
+	return nil, fmt.Errorf("failed to commit warehouse: %w", err)
+  }
+
+  return w, nil
+}
+
+// HasStock reports whether the warehouse holds any units.
+func (r *WarehouseRepository) HasStock(ctx context.Context, id string) (bool, error) {
+  var exists bool
+  err := r.db.QueryRow(ctx,
+	"SELECT EXISTS(SELECT 1 FROM warehouse_stock WHERE warehouse_id = $1 AND stock > 0)",
+	id,
+  ).Scan(&exists)
+
+  if err != nil {
+	return false, fmt.Errorf("failed to check warehouse stock: %w", err)
+  }
+
+  return exists, nil
+}
+
+func (r *WarehouseRepository) GetDefault(ctx context.Context) (*model.Warehouse, error) {
+  w, err := scanWarehouse(r.db.QueryRow(ctx, "SELECT "+warehouseColumns+" FROM warehouses w WHERE w.is_default"))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrNoDefaultWarehouse
+	}
+
+	return nil, fmt.Errorf("failed to get default warehouse: %w", err)
+  }
+
+  return w, nil
+}
+
+// GetStock returns what a warehouse holds of a product, or of one of its
+// variants. A product the warehouse never stocked comes back with zero stock.
+func (r *WarehouseRepository) GetStock(ctx context.Context, warehouseID, productID string, variantID *string) (*model.WarehouseStock, error) {
+  ws, err := scanWarehouseStock(r.db.QueryRow(ctx,
+	`SELECT `+warehouseStockColumns+` FROM warehouse_stock ws
+	WHERE ws.warehouse_id = $1 AND ws.product_id = $2 AND ws.variant_id IS NOT DISTINCT FROM $3`,
+	warehouseID, productID, variantID,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return &model.WarehouseStock{
+		WarehouseID: warehouseID,
+		ProductID: productID,
+		VariantID: variantID,
+	  }, nil
+	}
+
+	return nil, fmt.Errorf("failed to get warehouse stock: %w", err)
+  }
+
+  return ws, nil
+}
+
+// ListStockByProduct returns the per-warehouse stock of a product and all of
+// its variants.
+func (r *WarehouseRepository) ListStockByProduct(ctx context.Context, productID string) ([]*model.WarehouseStock, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT `+warehouseStockColumns+` FROM warehouse_stock ws
+	JOIN warehouses w ON w.id = ws.warehouse_id
+	WHERE ws.product_id = $1
+	ORDER BY ws.variant_id NULLS FIRST, w.priority, w.code`,
+	productID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list warehouse stock: %w", err)
+  }
+
+  return collectWarehouseStock(rows)
+}
+
+func (r *WarehouseRepository) translateError(err error) error {
+  if err == nil {
+	return nil
+  }
+
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) {
+	if pgErr.Code == "23505" && strings.Contains(pgErr.Detail, "code") { // unique_violation
+	  return ErrDuplicateWarehouseCode
+	}
+  }
+
+  return err
+}
+
+// lockWarehouseStock locks the stock rows that active warehouses hold of the
+// given products, so an allocation computed from them stays valid until the
+// transaction ends.
+func lockWarehouseStock(ctx context.Context, q querier, productIDs []string) ([]*model.WarehouseStock, error) {
+  rows, err := q.Query(ctx,
+	`SELECT `+warehouseStockColumns+` FROM warehouse_stock ws
+	JOIN warehouses w ON w.id = ws.warehouse_id AND w.is_active
+	WHERE ws.product_id = ANY($1)
+	ORDER BY ws.product_id, ws.variant_id, ws.warehouse_id
+	FOR UPDATE OF ws`,
+	productIDs,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to lock warehouse stock: %w", err)
+  }
+
+  return collectWarehouseStock(rows)
+}
+
+func defaultWarehouseID(ctx context.Context, q querier) (string, error) {
+  var id string
+  err := q.QueryRow(ctx, "SELECT id FROM warehouses WHERE is_default").Scan(&id)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return "", ErrNoDefaultWarehouse
+	}
+
+	return "", fmt.Errorf("failed to get default warehouse: %w", err)
+  }
+
+  return id, nil
+}
+
+func collectWarehouseStock(rows pgx.Rows) ([]*model.WarehouseStock, error) {
+  defer rows.Close()
+
+  stock := []*model.WarehouseStock{}
+  for rows.Next() {
+	ws, err := scanWarehouseStock(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan warehouse stock: %w", err)
+	}
+	stock = append(stock, ws)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate warehouse stock: %w", err)
+  }
+
+  return stock, nil
+}
+
+func scanWarehouse(row pgx.Row) (*model.Warehouse, error) {
+  var w model.Warehouse
+  err := row.Scan(
+	&w.ID, &w.Code, &w.Name, &w.Country, &w.ShipsTo, &w.Priority, &w.IsDefault, &w.IsActive,
+	&w.CreatedAt, &w.UpdatedAt,
+  )
+  if err != nil {
+	return nil, err
+  }
+
+  return &w, nil
+}
+
+func scanWarehouseStock(row pgx.Row) (*model.WarehouseStock, error) {
+  var ws model.WarehouseStock
+  err := row.Scan(&ws.WarehouseID, &ws.ProductID, &ws.VariantID, &ws.Stock, &ws.ReservedStock, &ws.UpdatedAt)
+  if err != nil {
+	return nil, err
+  }
+
+  return &ws, nil
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+  ErrWishlistNotFound = errors.New("wishlist not found")
+  ErrWishlistItemNotFound = errors.New("wishlist item not found")
+  ErrDuplicateWishlistName = errors.New("wishlist name already exists")
+)
+
+const wishlistColumns = `w.id, w.user_id, w.name, w.share_token,
+  (SELECT COUNT(*) FROM wishlist_items i WHERE i.wishlist_id = w.id), w.created_at, w.updated_at`
+
+type WishlistRepository struct {
+  db *pgxpool.Pool
+}
+
+func NewWishlistRepository(db *pgxpool.Pool) *WishlistRepository {
+  return &WishlistRepository{
+	db: db,
+  }
+}
+
+func (r *WishlistRepository) Create(ctx context.Context, w *model.Wishlist) error {
+  err := r.db.QueryRow(ctx,
+	`INSERT INTO wishlists (id, user_id, name, share_token)
+	VALUES ($1, $2, $3, $4)
+	RETURNING created_at, updated_at`,
+	w.ID, w.UserID, w.Name, w.ShareToken,
+  ).Scan(&w.CreatedAt, &w.UpdatedAt)
+  if err != nil {
+	return r.translateError(err)
+  }
+
+  return nil
+}
+
+func (r *WishlistRepository) ListByUser(ctx context.Context, userID string) ([]*model.Wishlist, error) {
+  rows, err := r.db.Query(ctx,
+	"SELECT "+wishlistColumns+" FROM wishlists w WHERE w.user_id = $1 ORDER BY w.created_at, w.id",
+	userID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list wishlists: %w", err)
+  }
+  defer rows.Close()
+
+  wishlists := []*model.Wishlist{}
+  for rows.Next() {
+	w, err := scanWishlist(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan wishlist: %w", err)
+	}
+	wishlists = append(wishlists, w)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate wishlists: %w", err)
+  }
+
+  return wishlists, nil
+}
+
+// GetByID returns the wishlist only when it belongs to userID, so other
+// users' lists look like they don't exist.
+func (r *WishlistRepository) GetByID(ctx context.Context, userID, id string) (*model.Wishlist, error) {
+  w, err := scanWishlist(r.db.QueryRow(ctx,
+	"SELECT "+wishlistColumns+" FROM wishlists w WHERE w.id = $1 AND w.user_id = $2",
+	id, userID,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrWishlistNotFound
+	}
+	return nil, fmt.Errorf("failed to get wishlist: %w", err)
+  }
+
+  return w, nil
+}
+
+func (r *WishlistRepository) GetByShareToken(ctx context.Context, token string) (*model.Wishlist, error) {
+  w, err := scanWishlist(r.db.QueryRow(ctx,
+	"SELECT "+wishlistColumns+" FROM wishlists w WHERE w.share_token = $1",
+	token,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrWishlistNotFound
+	}
+	return nil, fmt.Errorf("failed to get wishlist: %w", err)
+  }
+
+  return w, nil
+}
+
+// Update saves the name and share token of a wishlist.
+func (r *WishlistRepository) Update(ctx context.Context, w *model.Wishlist) error {
+  err := r.db.QueryRow(ctx,
+	`UPDATE wishlists SET name = $3, share_token = $4, updated_at = NOW()
+	WHERE id = $1 AND user_id = $2
+	RETURNING updated_at`,
+	w.ID, w.UserID, w.Name, w.ShareToken,
+  ).Scan(&w.UpdatedAt)
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return ErrWishlistNotFound
+	}
+	return r.translateError(err)
+  }
+
+  return nil
+}
+
+func (r *WishlistRepository) Delete(ctx context.Context, userID, id string) error {
+  tag, err := r.db.Exec(ctx, "DELETE FROM wishlists WHERE id = $1 AND user_id = $2", id, userID)
+  if err != nil {
+	return fmt.Errorf("failed to delete wishlist: %w", err)
+  }
+
+  if tag.RowsAffected() == 0 {
+	return ErrWishlistNotFound
+  }
+
+  return nil
+}
+
+func (r *WishlistRepository) ListItems(ctx context.Context, wishlistID string) ([]*model.WishlistItem, error) {
+  rows, err := r.db.Query(ctx,
+	`SELECT id, wishlist_id, product_id, variant_id, created_at
+	FROM wishlist_items WHERE wishlist_id = $1 ORDER BY created_at, id`,
+	wishlistID,
+  )
+  if err != nil {
+	return nil, fmt.Errorf("failed to list wishlist items: %w", err)
+  }
+  defer rows.Close()
+
+  items := []*model.WishlistItem{}
+  for rows.Next() {
+	item, err := scanWishlistItem(rows)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to scan wishlist item: %w", err)
+	}
+	items = append(items, item)
+  }
+
+  if err := rows.Err(); err != nil {
+	return nil, fmt.Errorf("failed to iterate wishlist items: %w", err)
+  }
+
+  return items, nil
+}
+
+// AddItem saves a product or variant to a wishlist and returns the saved
+// line. Adding one that is already there returns the existing line.
+func (r *WishlistRepository) AddItem(ctx context.Context, item *model.WishlistItem) (*model.WishlistItem, error) {
+  saved, err := scanWishlistItem(r.db.QueryRow(ctx,
+	`INSERT INTO wishlist_items (id, wishlist_id, product_id, variant_id)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (wishlist_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'))
+	DO UPDATE SET created_at = wishlist_items.created_at
+	RETURNING id, wishlist_id, product_id, variant_id, created_at`,
+	item.ID, item.WishlistID, item.ProductID, item.VariantID,
+  ))
+  if err != nil {
+	return nil, fmt.Errorf("failed to add wishlist item: %w", err)
+  }
+
+  if _, err := r.db.Exec(ctx, "UPDATE wishlists SET updated_at = NOW() WHERE id = $1", item.WishlistID); err != nil {
+	return nil, fmt.Errorf("failed to touch wishlist: %w", err)
+  }
+
+  return saved, nil
+}
+
+func (r *WishlistRepository) GetItem(ctx context.Context, wishlistID, itemID string) (*model.WishlistItem, error) {
+  item, err := scanWishlistItem(r.db.QueryRow(ctx,
+	`SELECT id, wishlist_id, product_id, variant_id, created_at
+	FROM wishlist_items WHERE id = $1 AND wishlist_id = $2`,
+	itemID, wishlistID,
+  ))
+  if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+	  return nil, ErrWishlistItemNotFound
+	}
+	return nil, fmt.Errorf("failed to get wishlist item: %w", err)
+  }
+
+  return item, nil
+}
+
+func (r *WishlistRepository) RemoveItem(ctx context.Context, wishlistID, itemID string) error {
+  tag, err := r.db.Exec(ctx, "DELETE FROM wishlist_items WHERE id = $1 AND wishlist_id = $2", itemID, wishlistID)
+  if err != nil {
+	return fmt.Errorf("failed to remove wishlist item: %w", err)
+  }
+
+  if tag.RowsAffected() == 0 {
+	return ErrWishlistItemNotFound
+  }
+
+  return nil
+}
+
+func (r *WishlistRepository) translateError(err error) error {
+  if err == nil {
+	return nil
+  }
+
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) {
+	if pgErr.Code == "23505" && strings.Contains(pgErr.Detail, "name") { // unique_violation
+	  return ErrDuplicateWishlistName
+	}
+  }
+
+  return err
+}
+
+func scanWishlist(row pgx.Row) (*model.Wishlist, error) {
+  var w model.Wishlist
+  err := row.Scan(&w.ID, &w.UserID, &w.Name, &w.ShareToken, &w.ItemCount, &w.CreatedAt, &w.UpdatedAt)
+  if err != nil {
+	return nil, err
+  }
+
+  return &w, nil
+}
+
+func scanWishlistItem(row pgx.Row) (*model.WishlistItem, error) {
+  var i model.WishlistItem
+  err := row.Scan(&i.ID, &i.WishlistID, &i.ProductID, &i.VariantID, &i.CreatedAt)
+  if err != nil {
+	return nil, err
+  }
+
+  return &i, nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+  "math"
+  "sort"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+)
+
+// allocationRequest is one order line waiting to be taken from warehouse
+// stock.
+type allocationRequest struct {
+  itemID string
+  productID string
+  variantID *string
+  quantity int
+}
+
+func stockKey(warehouseID, productID string, variantID *string) string {
+  key := warehouseID + "/" + productID
+  if variantID != nil {
+	key += "/" + *variantID
+  }
+  return key
+}
+
+// rankWarehouses returns the warehouses that can ship to country, closest
+// first. A warehouse in the destination country is closest, then come the
+// ones listing the country in ShipsTo, in list order. Warehouses with an
+// empty ShipsTo ship anywhere and come last. Priority breaks ties.
+func rankWarehouses(warehouses []*model.Warehouse, country string) []*model.Warehouse {
+  distance := func(w *model.Warehouse) int {
+	if w.Country == country {
+	  return 0
+	}
+	for i, c := range w.ShipsTo {
+	  if c == country {
+		return i + 1
+	  }
+	}
+	if len(w.ShipsTo) == 0 {
+	  return math.MaxInt
+	}
+	return -1
+  }
+
+  ranked := make([]*model.Warehouse, 0, len(warehouses))
+  distances := make(map[string]int, len(warehouses))
+  for _, w := range warehouses {
+	if !w.IsActive {
+	  continue
+	}
+	d := distance(w)
+	if d < 0 {
+	  continue
+	}
+	distances[w.ID] = d
+	ranked = append(ranked, w)
+  }
+
+  sort.SliceStable(ranked, func(i, j int) bool {
+	if distances[ranked[i].ID] != distances[ranked[j].ID] {
+	  return distances[ranked[i].ID] < distances[ranked[j].ID]
+	}
+	return ranked[i].Priority < ranked[j].Priority
+  })
+
+  return ranked
+}
+
+// allocateStock splits the requested lines across warehouses. With
+// AllocationClosest every line is filled from the closest warehouses first.
+// With AllocationFewestSplits the warehouse that can ship the most of what is
+// still missing is picked repeatedly, so an order ships whole from a single
+// warehouse whenever one can fulfil it. ErrInsufficientStock is returned when
+// the ranked warehouses don't hold enough.
+func allocateStock(strategy model.AllocationStrategy, lines []allocationRequest, ranked []*model.Warehouse, stock []*model.WarehouseStock) ([]*model.StockAllocation, error) {
+  available := make(map[string]int, len(stock))
+  for _, ws := range stock {
+	available[stockKey(ws.WarehouseID, ws.ProductID, ws.VariantID)] = ws.Available()
+  }
+
+  remaining := make([]int, len(lines))
+  for i, line := range lines {
+	remaining[i] = line.quantity
+  }
+
+  allocations := []*model.StockAllocation{}
+  take := func(w *model.Warehouse, i int) {
+	line := lines[i]
+	key := stockKey(w.ID, line.productID, line.variantID)
+	n := min(available[key], remaining[i])
+	if n <= 0 {
+	  return
+	}
+
+	available[key] -= n
+	remaining[i] -= n
+	allocations = append(allocations, &model.StockAllocation{
+	  OrderItemID: line.itemID,
+	  WarehouseID: w.ID,
+	  ProductID: line.productID,
+	  VariantID: line.variantID,
+	  Quantity: n,
+	})
+  }
+
+  switch strategy {
+  case model.AllocationFewestSplits:
+	for {
+	  var best *model.Warehouse
+	  bestUnits := 0
+	  for _, w := range ranked {
+		units := 0
+		for i, line := range lines {
+		  units += min(available[stockKey(w.ID, line.productID, line.variantID)], remaining[i])
+		}
+		if units > bestUnits {
+		  best, bestUnits = w, units
+		}
+	  }
+	  if best == nil {
+		break
+	  }
+	  for i := range lines {
+		take(best, i)
+	  }
+	}
+  default:
+	for i := range lines {
+	  for _, w := range ranked {
+		if remaining[i] == 0 {
+		  break
+		}
+		take(w, i)
+	  }
+	}
+  }
+
+  for _, n := range remaining {
+	if n > 0 {
+	  return nil, ErrInsufficientStock
+	}
+  }
+
+  return allocations, nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+  "errors"
+  "fmt"
+  "reflect"
+  "testing"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+)
+
+func TestRankWarehouses(t *testing.T) {
+  warehouses := []*model.Warehouse{
+	{ID: "madrid", Country: "ES", ShipsTo: []string{"ES", "PT", "FR"}, Priority: 2, IsActive: true},
+	{ID: "barcelona", Country: "ES", ShipsTo: []string{"ES", "FR"}, Priority: 1, IsActive: true},
+	{ID: "lyon", Country: "FR", ShipsTo: []string{"FR", "ES"}, Priority: 0, IsActive: true},
+	{ID: "anywhere", Country: "DE", Priority: 3, IsActive: true},
+	{ID: "anywhere-first", Country: "NL", Priority: 1, IsActive: true},
+	{ID: "closed", Country: "ES", ShipsTo: []string{"ES"}, Priority: 0, IsActive: false},
+  }
+
+  tests := []struct {
+	country string
+	want []string
+  }{
+	// Same country for both Spanish warehouses, Priority decides.
+	{"ES", []string{"barcelona", "madrid", "lyon", "anywhere-first", "anywhere"}},
+	// The position in ShipsTo is the distance.
+	{"FR", []string{"lyon", "barcelona", "madrid", "anywhere-first", "anywhere"}},
+	// Warehouses not listing the country don't ship there.
+	{"PT", []string{"madrid", "anywhere-first", "anywhere"}},
+	{"JP", []string{"anywhere-first", "anywhere"}},
+  }
+
+  for _, tt := range tests {
+	t.Run(tt.country, func(t *testing.T) {
+	  got := []string{}
+	  for _, w := range rankWarehouses(warehouses, tt.country) {
+		got = append(got, w.ID)
+	  }
+	  if !reflect.DeepEqual(got, tt.want) {
+		t.Errorf("got %v, want %v", got, tt.want)
+	  }
+	})
+  }
+}
+
+func TestAllocateStock(t *testing.T) {
+  variant := "blue"
+  ranked := []*model.Warehouse{{ID: "near"}, {ID: "mid"}, {ID: "far"}}
+  line := func(productID string, quantity int) allocationRequest {
+	return allocationRequest{itemID: "item-" + productID, productID: productID, quantity: quantity}
+  }
+  stock := func(warehouseID, productID string, available int) *model.WarehouseStock {
+	return &model.WarehouseStock{WarehouseID: warehouseID, ProductID: productID, Stock: available}
+  }
+
+  tests := []struct {
+	name string
+	strategy model.AllocationStrategy
+	lines []allocationRequest
+	stock []*model.WarehouseStock
+	want []string
+	wantErr error
+  }{
+	{
+	  name: "closest splits a line across warehouses",
+	  strategy: model.AllocationClosest,
+	  lines: []allocationRequest{line("a", 5)},
+	  stock: []*model.WarehouseStock{stock("near", "a", 3), stock("mid", "a", 10)},
+	  want: []string{"item-a@near:3", "item-a@mid:2"},
+	},
+	{
+	  name: "fewest splits ships a line from one warehouse",
+	  strategy: model.AllocationFewestSplits,
+	  lines: []allocationRequest{line("a", 5)},
+	  stock: []*model.WarehouseStock{stock("near", "a", 3), stock("mid", "a", 10)},
+	  want: []string{"item-a@mid:5"},
+	},
+	{
+	  name: "closest fills each line of an order on its own",
+	  strategy: model.AllocationClosest,
+	  lines: []allocationRequest{line("a", 2), line("b", 2)},
+	  stock: []*model.WarehouseStock{stock("near", "a", 2), stock("mid", "b", 1), stock("far", "a", 2), stock("far", "b", 2)},
+	  want: []string{"item-a@near:2", "item-b@mid:1", "item-b@far:1"},
+	},
+	{
+	  name: "fewest splits ships a whole order from one warehouse",
+	  strategy: model.AllocationFewestSplits,
+	  lines: []allocationRequest{line("a", 2), line("b", 2)},
+	  stock: []*model.WarehouseStock{stock("near", "a", 2), stock("mid", "b", 1), stock("far", "a", 2), stock("far", "b", 2)},
+	  want: []string{"item-a@far:2", "item-b@far:2"},
+	},
+	{
+	  name: "fewest splits splits an order no warehouse holds whole",
+	  strategy: model.AllocationFewestSplits,
+	  lines: []allocationRequest{line("a", 3), line("b", 2)},
+	  stock: []*model.WarehouseStock{stock("near", "a", 3), stock("mid", "b", 2), stock("far", "a", 1), stock("far", "b", 1)},
+	  want: []string{"item-a@near:3", "item-b@mid:2"},
+	},
+	{
+	  name: "fewest splits prefers the closest of equal warehouses",
+	  strategy: model.AllocationFewestSplits,
+	  lines: []allocationRequest{line("a", 2)},
+	  stock: []*model.WarehouseStock{stock("far", "a", 2), stock("near", "a", 2)},
+	  want: []string{"item-a@near:2"},
+	},
+	{
+	  name: "reserved stock isn't available",
+	  strategy: model.AllocationClosest,
+	  lines: []allocationRequest{line("a", 2)},
+	  stock: []*model.WarehouseStock{{WarehouseID: "near", ProductID: "a", Stock: 5, ReservedStock: 4}, stock("mid", "a", 5)},
+	  want: []string{"item-a@near:1", "item-a@mid:1"},
+	},
+	{
+	  name: "variants are stocked apart from their product",
+	  strategy: model.AllocationClosest,
+	  lines: []allocationRequest{{itemID: "item-a", productID: "a", variantID: &variant, quantity: 1}},
+	  stock: []*model.WarehouseStock{stock("near", "a", 5), {WarehouseID: "mid", ProductID: "a", VariantID: &variant, Stock: 1}},
+	  want: []string{"item-a@mid:1"},
+	},
+	{
+	  name: "closest with insufficient stock",
+	  strategy: model.AllocationClosest,
+	  lines: []allocationRequest{line("a", 5)},
+	  stock: []*model.WarehouseStock{stock("near", "a", 2), stock("far", "a", 2)},
+	  wantErr: ErrInsufficientStock,
+	},
+	{
+	  name: "fewest splits with insufficient stock",
+	  strategy: model.AllocationFewestSplits,
+	  lines: []allocationRequest{line("a", 1), line("b", 1)},
+	  stock: []*model.WarehouseStock{stock("near", "a", 2)},
+	  wantErr: ErrInsufficientStock,
+	},
+  }
+
+  for _, tt := range tests {
+	t.Run(tt.name, func(t *testing.T) {
+	  allocations, err := allocateStock(tt.strategy, tt.lines, ranked, tt.stock)
+	  if !errors.Is(err, tt.wantErr) {
+		t.Fatalf("got error %v, want %v", err, tt.wantErr)
+	  }
+
+	  got := []string{}
+	  for _, a := range allocations {
+		got = append(got, fmt.Sprintf("%s@%s:%d", a.OrderItemID, a.WarehouseID, a.Quantity))
+	  }
+	  if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+		t.Errorf("got %v, want %v", got, tt.want)
+	  }
+	})
+  }
+}
@@ -6,8 +6,15 @@ import (
     "crypto/sha256"
     "encoding/hex"
     "errors"
+    "fmt"
+    "log"
     "time"
-    
+
+    "github.com/F-Dupraz/ecommerce-with-go/dto"
+    "github.com/F-Dupraz/ecommerce-with-go/auth"
+    "github.com/F-Dupraz/ecommerce-with-go/model"
+    "github.com/F-Dupraz/ecommerce-with-go/repository"
+
     "github.com/google/uuid"
     "golang.org/x/crypto/bcrypt"
 )
@@ -16,56 +23,131 @@ var (
     ErrInvalidCredentials = errors.New("invalid credentials")
     ErrInvalidToken = errors.New("invalid token")
     ErrSessionExpired = errors.New("session expired")
+    ErrSessionNotFound = errors.New("session not found")
+    ErrTokenReused = errors.New("refresh token reused")
+    ErrIncorrectPassword = errors.New("current password is incorrect")
+    ErrPasswordUnchanged = errors.New("new password matches the current one")
+)
+
+const (
+    sessionTTL = 7 * 24 * time.Hour
+    maxUserAgentLength = 500
 )
 
+type AuthUserRepository interface {
+    GetByID(ctx context.Context, id string) (*model.User, error)
+    GetByEmail(ctx context.Context, email string) (*model.User, error)
+    UpdatePassword(ctx context.Context, id, passwordHash string) error
+}
+
+type SessionRepository interface {
+    Create(ctx context.Context, session *model.Session) error
+    GetByRefreshToken(ctx context.Context, tokenHash string) (*model.Session, error)
+    GetByRotatedToken(ctx context.Context, tokenHash string) (*model.Session, error)
+    ListActiveByUser(ctx context.Context, userID string) ([]*model.Session, error)
+    InvalidateByID(ctx context.Context, id string) error
+    InvalidateForUser(ctx context.Context, userID, id string) (string, error)
+    InvalidateOthers(ctx context.Context, userID, keepID string) ([]string, error)
+    Rotate(ctx context.Context, id, oldHash, newHash, accessJTI string) error
+    SetAccessTokenJTI(ctx context.Context, id, jti string) error
+}
+
+type SecurityEventRepository interface {
+    Create(ctx context.Context, e *model.SecurityEvent) error
+}
+
 type AuthService struct {
-    userRepo    *repository.UserRepository
-    sessionRepo *repository.SessionRepository
+    userRepo    AuthUserRepository
+    sessionRepo SessionRepository
+    eventRepo   SecurityEventRepository
+    mfa         *MFAService
+    revocations auth.RevocationStore
     jwtManager  *auth.JWTManager
 }
 
-func NewAuthService(userRepo *repository.UserRepository, sessionRepo *repository.SessionRepository, jwtManager *auth.JWTManager) *AuthService {
+func NewAuthService(userRepo AuthUserRepository, sessionRepo SessionRepository, eventRepo SecurityEventRepository, mfa *MFAService, revocations auth.RevocationStore, jwtManager *auth.JWTManager) *AuthService {
     return &AuthService{
         userRepo:    userRepo,
         sessionRepo: sessionRepo,
+        eventRepo:   eventRepo,
+        mfa:         mfa,
+        revocations: revocations,
         jwtManager:  jwtManager,
     }
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (*dto.LoginResponse, error) {
+// Login opens a new session for the device described by client. Sessions on
+// other devices stay signed in. Accounts with 2FA, or that the policy requires
+// it of, get a challenge instead, to complete with LoginMFA.
+func (s *AuthService) Login(ctx context.Context, email, password string, client dto.ClientInfo) (*dto.LoginResponse, *dto.MFAChallengeResponse, error) {
     user, err := s.userRepo.GetByEmail(ctx, email)
     if err != nil {
-        return nil, ErrInvalidCredentials
+        return nil, nil, ErrInvalidCredentials
     }
-    
+
     if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-        return nil, ErrInvalidCredentials
-    }
-    
-    s.sessionRepo.InvalidateByUserID(ctx, user.ID)
-    
-    accessToken, refreshToken, err := s.jwtManager.GenerateTokenPair(
-        user.ID, 
-        user.Email, 
+        return nil, nil, ErrInvalidCredentials
+    }
+
+    challenge, err := s.mfa.Challenge(ctx, user)
+    if err != nil || challenge != nil {
+        return nil, challenge, err
+    }
+
+    response, err := s.startSession(ctx, user, client)
+    return response, nil, err
+}
+
+// LoginMFA is the second step of a login that was answered with a challenge.
+func (s *AuthService) LoginMFA(ctx context.Context, req dto.MFALoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+    userID, recoveryCodes, err := s.mfa.CompleteChallenge(ctx, req)
+    if err != nil {
+        return nil, err
+    }
+
+    user, err := s.userRepo.GetByID(ctx, userID)
+    if err != nil {
+        if errors.Is(err, repository.ErrUserNotFound) {
+            return nil, ErrInvalidMFAChallenge
+        }
+        return nil, err
+    }
+
+    response, err := s.startSession(ctx, user, client)
+    if err != nil {
+        return nil, err
+    }
+
+    response.RecoveryCodes = recoveryCodes
+    return response, nil
+}
+
+func (s *AuthService) startSession(ctx context.Context, user *model.User, client dto.ClientInfo) (*dto.LoginResponse, error) {
+    sessionID := uuid.New().String()
+    accessToken, refreshToken, accessJTI, err := s.jwtManager.GenerateTokenPair(
+        user.ID,
+        user.Email,
         string(user.Role),
+        sessionID,
     )
     if err != nil {
         return nil, err
     }
-    
+
     session := &model.Session{
-        ID:           uuid.New().String(),
+        ID:           sessionID,
         UserID:       user.ID,
-        RefreshToken: s.hashToken(refreshToken),
-        IPAddress:    getIPFromContext(ctx),
-        UserAgent:    getUserAgentFromContext(ctx),
-        ExpiresAt:    time.Now().Add(7 * 24 * time.Hour),
+        RefreshToken: hashToken(refreshToken),
+        AccessTokenJTI: accessJTI,
+        IPAddress:    client.IPAddress,
+        UserAgent:    truncateUserAgent(client.UserAgent),
+        ExpiresAt:    time.Now().Add(sessionTTL),
     }
-    
+
     if err := s.sessionRepo.Create(ctx, session); err != nil {
         return nil, err
     }
-    
+
     return &dto.LoginResponse{
         AccessToken: accessToken,
         RefreshToken: refreshToken,
@@ -76,50 +158,254 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*dto.L
             Email: user.Email,
             Name:  user.Username,
             Role:  string(user.Role),
+            EmailVerified: user.EmailVerifiedAt != nil,
         },
     }, nil
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*dto.RefreshResponse, error) {
-    tokenHash := s.hashToken(refreshToken)
+// RefreshToken trades a refresh token for a new access token and a new
+// refresh token; the old refresh token stops working. Presenting a token that
+// was already rotated means two parties hold it, so the session is revoked for
+// both and the event is recorded.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, client dto.ClientInfo) (*dto.RefreshResponse, error) {
+    tokenHash := hashToken(refreshToken)
     session, err := s.sessionRepo.GetByRefreshToken(ctx, tokenHash)
     if err != nil {
-        return nil, ErrInvalidToken
+        if errors.Is(err, repository.ErrSessionNotFound) {
+            return nil, s.detectTokenReuse(ctx, tokenHash, client)
+        }
+        return nil, err
     }
-    
+
     if time.Now().After(session.ExpiresAt) {
         s.sessionRepo.InvalidateByID(ctx, session.ID)
         return nil, ErrSessionExpired
     }
-    
+
     user, err := s.userRepo.GetByID(ctx, session.UserID)
     if err != nil {
         return nil, err
     }
-    
-    accessToken, _, err := s.jwtManager.GenerateTokenPair(
+
+    accessToken, newRefreshToken, accessJTI, err := s.jwtManager.GenerateTokenPair(
         user.ID,
         user.Email,
         string(user.Role),
+        session.ID,
     )
     if err != nil {
         return nil, err
     }
-    
-    s.sessionRepo.UpdateLastActivity(ctx, session.ID)
-    
+
+    if err := s.sessionRepo.Rotate(ctx, session.ID, tokenHash, hashToken(newRefreshToken), accessJTI); err != nil {
+        // Lost a race with another refresh of the same token.
+        if errors.Is(err, repository.ErrSessionNotFound) {
+            return nil, s.detectTokenReuse(ctx, tokenHash, client)
+        }
+        return nil, err
+    }
+
     return &dto.RefreshResponse{
-        AccessToken: accessToken,
-        TokenType:   "Bearer",
-        ExpiresIn:   900,
+        AccessToken:  accessToken,
+        RefreshToken: newRefreshToken,
+        TokenType:    "Bearer",
+        ExpiresIn:    900,
+    }, nil
+}
+
+// detectTokenReuse handles a refresh token that matches no valid session. If
+// it was rotated away from, its session is revoked and ErrTokenReused
+// returned; otherwise it's just an invalid token.
+func (s *AuthService) detectTokenReuse(ctx context.Context, tokenHash string, client dto.ClientInfo) error {
+    session, err := s.sessionRepo.GetByRotatedToken(ctx, tokenHash)
+    if err != nil {
+        if errors.Is(err, repository.ErrSessionNotFound) {
+            return ErrInvalidToken
+        }
+        return err
+    }
+
+    if err := s.sessionRepo.InvalidateByID(ctx, session.ID); err != nil {
+        return err
+    }
+    if err := revokeAccessTokens(ctx, s.revocations, session.AccessTokenJTI); err != nil {
+        return err
+    }
+
+    event := &model.SecurityEvent{
+        ID:        uuid.New().String(),
+        UserID:    session.UserID,
+        SessionID: &session.ID,
+        Kind:      model.SecurityEventRefreshTokenReuse,
+        IPAddress: client.IPAddress,
+        UserAgent: truncateUserAgent(client.UserAgent),
+    }
+    if err := s.eventRepo.Create(ctx, event); err != nil {
+        log.Printf("session %s: %v", session.ID, err)
+    }
+    log.Printf("refresh token reuse detected for session %s of user %s, session revoked", session.ID, session.UserID)
+
+    return ErrTokenReused
+}
+
+// Logout signs out the session the request was made with and revokes the
+// access token it was made with.
+func (s *AuthService) Logout(ctx context.Context, userID, sessionID, tokenID string) error {
+    var sessionJTI string
+    if sessionID != "" {
+        jti, err := s.sessionRepo.InvalidateForUser(ctx, userID, sessionID)
+        if err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+            return err
+        }
+        sessionJTI = jti
+    }
+    return revokeAccessTokens(ctx, s.revocations, tokenID, sessionJTI)
+}
+
+// ListSessions returns the devices the user is signed in on, flagging the one
+// the request came from.
+func (s *AuthService) ListSessions(ctx context.Context, userID, currentSessionID string) (*dto.ListSessionsResponse, error) {
+    sessions, err := s.sessionRepo.ListActiveByUser(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list sessions: %w", err)
+    }
+
+    infos := make([]dto.SessionInfo, len(sessions))
+    for i, session := range sessions {
+        infos[i] = dto.SessionInfo{
+            ID:         session.ID,
+            IPAddress:  session.IPAddress,
+            UserAgent:  session.UserAgent,
+            LastUsedAt: session.LastActivityAt,
+            CreatedAt:  session.CreatedAt,
+            IsCurrent:  session.ID == currentSessionID,
+        }
+    }
+
+    return &dto.ListSessionsResponse{
+        Sessions: infos,
+        Total:    len(infos),
     }, nil
 }
 
-func (s *AuthService) Logout(ctx context.Context, userID string) error {
-    return s.sessionRepo.InvalidateByUserID(ctx, userID)
+// RevokeSession signs out one of the user's sessions, which may be the
+// current one.
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+    if _, err := uuid.Parse(sessionID); err != nil {
+        return ErrInvalidID
+    }
+
+    jti, err := s.sessionRepo.InvalidateForUser(ctx, userID, sessionID)
+    if err != nil {
+        if errors.Is(err, repository.ErrSessionNotFound) {
+            return ErrSessionNotFound
+        }
+        return err
+    }
+
+    return revokeAccessTokens(ctx, s.revocations, jti)
+}
+
+// RevokeOtherSessions signs out every session of the user except the current
+// one. Tokens that don't name their session can't tell which one to keep, so
+// they are rejected rather than signing the caller out too.
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) (*dto.RevokeSessionsResponse, error) {
+    if currentSessionID == "" {
+        return nil, ErrInvalidToken
+    }
+
+    jtis, err := s.sessionRepo.InvalidateOthers(ctx, userID, currentSessionID)
+    if err != nil {
+        return nil, err
+    }
+    if err := revokeAccessTokens(ctx, s.revocations, jtis...); err != nil {
+        return nil, err
+    }
+
+    return &dto.RevokeSessionsResponse{
+        Revoked: int64(len(jtis)),
+        Message: "Signed out of all other sessions",
+    }, nil
+}
+
+// ChangePassword replaces the password of a signed-in user who knows the
+// current one. Every other session is signed out and every access token
+// revoked; the current session keeps its refresh token and gets a new access
+// token in the response.
+func (s *AuthService) ChangePassword(ctx context.Context, userID, sessionID, tokenID string, req dto.ChangePasswordRequest) (*dto.ChangePasswordResponse, error) {
+    if sessionID == "" {
+        return nil, ErrInvalidToken
+    }
+
+    user, err := s.userRepo.GetByID(ctx, userID)
+    if err != nil {
+        if errors.Is(err, repository.ErrUserNotFound) {
+            return nil, ErrUserNotFound
+        }
+        return nil, err
+    }
+
+    if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+        return nil, ErrIncorrectPassword
+    }
+    if req.NewPassword == req.CurrentPassword {
+        return nil, ErrPasswordUnchanged
+    }
+
+    hashedPass, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), 12)
+    if err != nil {
+        return nil, fmt.Errorf("failed to hash password: %w", err)
+    }
+
+    if err := s.userRepo.UpdatePassword(ctx, userID, string(hashedPass)); err != nil {
+        return nil, err
+    }
+
+    jtis, err := s.sessionRepo.InvalidateOthers(ctx, userID, sessionID)
+    if err != nil {
+        return nil, err
+    }
+    // The sessions' tokens are revoked one by one and every token issued
+    // before now by the user-wide cutoff, which catches tokens of sessions
+    // refreshed meanwhile. The token issued below comes after the cutoff.
+    if err := revokeAccessTokens(ctx, s.revocations, append(jtis, tokenID)...); err != nil {
+        return nil, err
+    }
+    if err := revokeUserTokens(ctx, s.revocations, userID); err != nil {
+        return nil, err
+    }
+
+    accessToken, accessJTI, err := s.jwtManager.GenerateAccessToken(user.ID, user.Email, string(user.Role), sessionID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.sessionRepo.SetAccessTokenJTI(ctx, sessionID, accessJTI); err != nil {
+        if errors.Is(err, repository.ErrSessionNotFound) {
+            return nil, ErrSessionNotFound
+        }
+        return nil, err
+    }
+
+    return &dto.ChangePasswordResponse{
+        Message:         "Password changed, other sessions have been signed out",
+        AccessToken:     accessToken,
+        TokenType:       "Bearer",
+        ExpiresIn:       900,
+        SessionsRevoked: int64(len(jtis)),
+    }, nil
+}
+
+func truncateUserAgent(userAgent string) string {
+    if len(userAgent) > maxUserAgentLength {
+        return userAgent[:maxUserAgentLength]
+    }
+    return userAgent
 }
 
-func (s *AuthService) hashToken(token string) string {
+// hashToken is how refresh, password reset, email verification and MFA
+// challenge tokens and recovery codes are stored: they are random and
+// unguessable, so a fast unsalted hash is enough.
+func hashToken(token string) string {
     hash := sha256.Sum256([]byte(token))
     return hex.EncodeToString(hash[:])
 }
@@ -0,0 +1,279 @@
+package service
+
+import (
+  "context"
+  "errors"
+  "net/http"
+  "net/http/httptest"
+  "testing"
+
+  "golang.org/x/crypto/bcrypt"
+
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/middleware"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+)
+
+type fakeAuthUserRepository map[string]*model.User
+
+func (r fakeAuthUserRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
+  user, ok := r[id]
+  if !ok {
+	return nil, repository.ErrUserNotFound
+  }
+  copied := *user
+  return &copied, nil
+}
+
+func (r fakeAuthUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+  for _, user := range r {
+	if user.Email == email {
+	  copied := *user
+	  return &copied, nil
+	}
+  }
+  return nil, repository.ErrUserNotFound
+}
+
+func (r fakeAuthUserRepository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+  user, ok := r[id]
+  if !ok {
+	return repository.ErrUserNotFound
+  }
+  user.Password = passwordHash
+  return nil
+}
+
+// fakeSessionRepository keeps sessions in memory, with the same rules as the
+// SQL in repository.SessionRepository.
+type fakeSessionRepository struct {
+  sessions map[string]*model.Session
+  // rotated maps refresh token hashes that were rotated away from to their
+  // session.
+  rotated map[string]string
+}
+
+func newFakeSessionRepository() *fakeSessionRepository {
+  return &fakeSessionRepository{
+	sessions: map[string]*model.Session{},
+	rotated: map[string]string{},
+  }
+}
+
+func (r *fakeSessionRepository) Create(ctx context.Context, session *model.Session) error {
+  copied := *session
+  copied.IsValid = true
+  r.sessions[session.ID] = &copied
+  return nil
+}
+
+func (r *fakeSessionRepository) GetByRefreshToken(ctx context.Context, tokenHash string) (*model.Session, error) {
+  for _, session := range r.sessions {
+	if session.IsValid && session.RefreshToken == tokenHash {
+	  copied := *session
+	  return &copied, nil
+	}
+  }
+  return nil, repository.ErrSessionNotFound
+}
+
+func (r *fakeSessionRepository) GetByRotatedToken(ctx context.Context, tokenHash string) (*model.Session, error) {
+  id, ok := r.rotated[tokenHash]
+  if !ok {
+	return nil, repository.ErrSessionNotFound
+  }
+  copied := *r.sessions[id]
+  return &copied, nil
+}
+
+func (r *fakeSessionRepository) ListActiveByUser(ctx context.Context, userID string) ([]*model.Session, error) {
+  var sessions []*model.Session
+  for _, session := range r.sessions {
+	if session.IsValid && session.UserID == userID {
+	  copied := *session
+	  sessions = append(sessions, &copied)
+	}
+  }
+  return sessions, nil
+}
+
+func (r *fakeSessionRepository) InvalidateByID(ctx context.Context, id string) error {
+  if session, ok := r.sessions[id]; ok {
+	session.IsValid = false
+  }
+  return nil
+}
+
+func (r *fakeSessionRepository) InvalidateForUser(ctx context.Context, userID, id string) (string, error) {
+  session, ok := r.sessions[id]
+  if !ok || !session.IsValid || session.UserID != userID {
+	return "", repository.ErrSessionNotFound
+  }
+  session.IsValid = false
+  return session.AccessTokenJTI, nil
+}
+
+func (r *fakeSessionRepository) InvalidateOthers(ctx context.Context, userID, keepID string) ([]string, error) {
+  var jtis []string
+  for _, session := range r.sessions {
+	if session.IsValid && session.UserID == userID && session.ID != keepID {
+	  session.IsValid = false
+	  jtis = append(jtis, session.AccessTokenJTI)
+	}
+  }
+  return jtis, nil
+}
+
+func (r *fakeSessionRepository) Rotate(ctx context.Context, id, oldHash, newHash, accessJTI string) error {
+  session, ok := r.sessions[id]
+  if !ok || !session.IsValid || session.RefreshToken != oldHash {
+	return repository.ErrSessionNotFound
+  }
+  session.RefreshToken = newHash
+  session.AccessTokenJTI = accessJTI
+  r.rotated[oldHash] = id
+  return nil
+}
+
+func (r *fakeSessionRepository) SetAccessTokenJTI(ctx context.Context, id, jti string) error {
+  session, ok := r.sessions[id]
+  if !ok || !session.IsValid {
+	return repository.ErrSessionNotFound
+  }
+  session.AccessTokenJTI = jti
+  return nil
+}
+
+type fakeSecurityEventRepository struct {
+  events []*model.SecurityEvent
+}
+
+func (r *fakeSecurityEventRepository) Create(ctx context.Context, e *model.SecurityEvent) error {
+  r.events = append(r.events, e)
+  return nil
+}
+
+type testAuth struct {
+  service *AuthService
+  sessions *fakeSessionRepository
+  events *fakeSecurityEventRepository
+  jwt *auth.JWTManager
+  revocations auth.RevocationStore
+}
+
+const testPassword = "correct horse battery"
+
+// newTestAuthService signs in with a customer whose password is testPassword.
+func newTestAuthService(t *testing.T) *testAuth {
+  t.Helper()
+
+  hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+  if err != nil {
+	t.Fatal(err)
+  }
+  user := *testCustomer
+  user.Password = string(hash)
+
+  a := &testAuth{
+	sessions: newFakeSessionRepository(),
+	events: &fakeSecurityEventRepository{},
+	jwt: auth.NewJWTManager("test-secret"),
+	revocations: auth.NewMemoryRevocationStore(),
+  }
+  users := fakeAuthUserRepository{user.ID: &user}
+  a.service = NewAuthService(users, a.sessions, a.events, nil, a.revocations, a.jwt)
+  return a
+}
+
+func (a *testAuth) startSession(t *testing.T) *dto.LoginResponse {
+  t.Helper()
+  user := *testCustomer
+  login, err := a.service.startSession(context.Background(), &user, dto.ClientInfo{IPAddress: "192.0.2.1"})
+  if err != nil {
+	t.Fatal(err)
+  }
+  return login
+}
+
+// authenticate reports the status the auth middleware answers a request
+// carrying the access token with.
+func (a *testAuth) authenticate(accessToken string) int {
+  handler := middleware.NewAuthMiddleware(a.jwt, a.revocations).Authenticate(
+	http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	  w.WriteHeader(http.StatusOK)
+	}),
+  )
+  req := httptest.NewRequest(http.MethodGet, "/", nil)
+  req.Header.Set("Authorization", "Bearer "+accessToken)
+  rec := httptest.NewRecorder()
+  handler.ServeHTTP(rec, req)
+  return rec.Code
+}
+
+func TestChangePasswordReturnsUsableToken(t *testing.T) {
+  a := newTestAuthService(t)
+  current := a.startSession(t)
+  other := a.startSession(t)
+
+  claims, err := a.jwt.ValidateAccessToken(current.AccessToken)
+  if err != nil {
+	t.Fatal(err)
+  }
+  resp, err := a.service.ChangePassword(context.Background(), testCustomer.ID, claims.SessionID, claims.ID, dto.ChangePasswordRequest{
+	CurrentPassword: testPassword,
+	NewPassword: "a new password 1",
+	ConfirmPassword: "a new password 1",
+  })
+  if err != nil {
+	t.Fatal(err)
+  }
+  if resp.SessionsRevoked != 1 {
+	t.Errorf("revoked %d sessions, want 1", resp.SessionsRevoked)
+  }
+
+  if code := a.authenticate(resp.AccessToken); code != http.StatusOK {
+	t.Errorf("returned token: got status %d, want %d", code, http.StatusOK)
+  }
+  if code := a.authenticate(current.AccessToken); code != http.StatusUnauthorized {
+	t.Errorf("token the change was made with: got status %d, want %d", code, http.StatusUnauthorized)
+  }
+  if code := a.authenticate(other.AccessToken); code != http.StatusUnauthorized {
+	t.Errorf("token of the other session: got status %d, want %d", code, http.StatusUnauthorized)
+  }
+}
+
+func TestRefreshTokenReplayRevokesSession(t *testing.T) {
+  ctx := context.Background()
+  a := newTestAuthService(t)
+  login := a.startSession(t)
+  client := dto.ClientInfo{IPAddress: "192.0.2.1"}
+
+  refreshed, err := a.service.RefreshToken(ctx, login.RefreshToken, client)
+  if err != nil {
+	t.Fatal(err)
+  }
+
+  _, err = a.service.RefreshToken(ctx, login.RefreshToken, client)
+  if !errors.Is(err, ErrTokenReused) {
+	t.Fatalf("replaying a rotated token: got %v, want %v", err, ErrTokenReused)
+  }
+
+  sessions, err := a.sessions.ListActiveByUser(ctx, testCustomer.ID)
+  if err != nil {
+	t.Fatal(err)
+  }
+  if len(sessions) != 0 {
+	t.Errorf("%d sessions still active, want none", len(sessions))
+  }
+  if code := a.authenticate(refreshed.AccessToken); code != http.StatusUnauthorized {
+	t.Errorf("access token of the session: got status %d, want %d", code, http.StatusUnauthorized)
+  }
+  if _, err := a.service.RefreshToken(ctx, refreshed.RefreshToken, client); err == nil {
+	t.Error("the current refresh token of the revoked session still works")
+  }
+  if len(a.events.events) == 0 || a.events.events[0].Kind != model.SecurityEventRefreshTokenReuse {
+	t.Errorf("got events %+v, want a refresh token reuse", a.events.events)
+  }
+}
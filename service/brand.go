@@ -0,0 +1,196 @@
+package service
+
+import (
+  "fmt"
+  "time"
+  "errors"
+  "context"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+var (
+  ErrBrandNotFound = errors.New("brand not found")
+  ErrDuplicateBrandSlug = errors.New("brand slug already exists")
+  ErrBrandInUse = errors.New("brand has products")
+)
+
+type BrandRepository interface {
+  Create(ctx context.Context, brand *model.Brand) error
+  GetByID(ctx context.Context, id string) (*model.Brand, error)
+  GetBySlug(ctx context.Context, slug string) (*model.Brand, error)
+  GetByIDs(ctx context.Context, ids []string) ([]*model.Brand, error)
+  ListWithProductCounts(ctx context.Context) ([]*repository.BrandWithProductCount, error)
+  Update(ctx context.Context, id string, updates map[string]interface{}) (*model.Brand, error)
+  Delete(ctx context.Context, id string) (time.Time, error)
+  HasProducts(ctx context.Context, id string) (bool, error)
+}
+
+type BrandService struct {
+  repo BrandRepository
+}
+
+func NewBrandService(repo BrandRepository) *BrandService {
+  return &BrandService{
+	repo: repo,
+  }
+}
+
+func (s *BrandService) CreateBrand(ctx context.Context, req *dto.CreateBrandRequest) (*dto.CreateBrandResponse, error) {
+  slug := req.Slug
+  if slug == "" {
+	slug = slugify(req.Name)
+  }
+  if slug == "" {
+	return nil, ErrInvalidSlug
+  }
+
+  brand := model.Brand{
+	ID: uuid.New().String(),
+	Name: req.Name,
+	Slug: slug,
+	Description: req.Description,
+	LogoURL: req.LogoURL,
+	WebsiteURL: req.WebsiteURL,
+  }
+
+  if err := s.repo.Create(ctx, &brand); err != nil {
+	if errors.Is(err, repository.ErrDuplicateSlug) {
+	  return nil, ErrDuplicateBrandSlug
+	}
+	return nil, fmt.Errorf("failed to create brand: %w", err)
+  }
+
+  response := toBrandResponse(&brand)
+  return &dto.CreateBrandResponse{
+	ID: brand.ID,
+	Brand: &response,
+	Message: "Brand created successfully",
+  }, nil
+}
+
+func (s *BrandService) ListBrands(ctx context.Context) (*dto.ListBrandsResponse, error) {
+  brands, err := s.repo.ListWithProductCounts(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list brands: %w", err)
+  }
+
+  responses := make([]dto.BrandResponse, len(brands))
+  for i, b := range brands {
+	count := b.ProductCount
+	responses[i] = toBrandResponse(&b.Brand)
+	responses[i].ProductCount = &count
+  }
+
+  return &dto.ListBrandsResponse{
+	Brands: responses,
+	Total: len(responses),
+  }, nil
+}
+
+func (s *BrandService) GetBrandBySlug(ctx context.Context, slug string) (*dto.BrandResponse, error) {
+  brand, err := s.getBrandBySlug(ctx, slug)
+  if err != nil {
+	return nil, err
+  }
+
+  response := toBrandResponse(brand)
+  return &response, nil
+}
+
+func (s *BrandService) UpdateBrand(ctx context.Context, slug string, req *dto.UpdateBrandRequest) (*dto.UpdateBrandResponse, error) {
+  brand, err := s.getBrandBySlug(ctx, slug)
+  if err != nil {
+	return nil, err
+  }
+
+  updates := make(map[string]interface{})
+  if req.Name != nil {
+	updates["name"] = *req.Name
+  }
+  if req.Slug != nil {
+	updates["slug"] = *req.Slug
+  }
+  if req.Description != nil {
+	updates["description"] = *req.Description
+  }
+  if req.LogoURL != nil {
+	updates["logo_url"] = *req.LogoURL
+  }
+  if req.WebsiteURL != nil {
+	updates["website_url"] = *req.WebsiteURL
+  }
+
+  updated, err := s.repo.Update(ctx, brand.ID, updates)
+  if err != nil {
+	switch {
+	case errors.Is(err, repository.ErrBrandNotFound):
+	  return nil, ErrBrandNotFound
+	case errors.Is(err, repository.ErrDuplicateSlug):
+	  return nil, ErrDuplicateBrandSlug
+	}
+	return nil, fmt.Errorf("failed to update brand: %w", err)
+  }
+
+  response := toBrandResponse(updated)
+  return &dto.UpdateBrandResponse{
+	Brand: &response,
+	Message: "Brand updated successfully",
+  }, nil
+}
+
+func (s *BrandService) DeleteBrand(ctx context.Context, slug string) (*dto.DeleteBrandResponse, error) {
+  brand, err := s.getBrandBySlug(ctx, slug)
+  if err != nil {
+	return nil, err
+  }
+
+  hasProducts, err := s.repo.HasProducts(ctx, brand.ID)
+  if err != nil {
+	return nil, err
+  }
+  if hasProducts {
+	return nil, ErrBrandInUse
+  }
+
+  deletedAt, err := s.repo.Delete(ctx, brand.ID)
+  if err != nil {
+	if errors.Is(err, repository.ErrBrandNotFound) {
+	  return nil, ErrBrandNotFound
+	}
+	return nil, fmt.Errorf("failed to delete brand: %w", err)
+  }
+
+  return &dto.DeleteBrandResponse{
+	ID: brand.ID,
+	Message: "Brand deleted successfully",
+	DeletedAt: deletedAt,
+  }, nil
+}
+
+func (s *BrandService) getBrandBySlug(ctx context.Context, slug string) (*model.Brand, error) {
+  brand, err := s.repo.GetBySlug(ctx, slug)
+  if err != nil {
+	if errors.Is(err, repository.ErrBrandNotFound) {
+	  return nil, ErrBrandNotFound
+	}
+	return nil, fmt.Errorf("failed to get brand: %w", err)
+  }
+
+  return brand, nil
+}
+
+func toBrandResponse(b *model.Brand) dto.BrandResponse {
+  return dto.BrandResponse{
+	ID: b.ID,
+	Name: b.Name,
+	Slug: b.Slug,
+	Description: b.Description,
+	LogoURL: b.LogoURL,
+	WebsiteURL: b.WebsiteURL,
+  }
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+  "fmt"
+  "time"
+  "errors"
+  "context"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+var (
+  ErrCartItemNotFound = errors.New("cart item not found")
+)
+
+const cartTTL = 7 * 24 * time.Hour
+
+type CartRepository interface {
+  GetOrCreate(ctx context.Context, userID string, cartID string, expiresAt time.Time) (*model.ShoppingCart, error)
+  Touch(ctx context.Context, cartID string, expiresAt time.Time) error
+  ListItems(ctx context.Context, cartID string) ([]*model.CartItem, error)
+  AddItem(ctx context.Context, item *model.CartItem) (*model.CartItem, error)
+  GetItem(ctx context.Context, cartID, itemID string) (*model.CartItem, error)
+  UpdateItemQuantity(ctx context.Context, cartID, itemID string, quantity int) error
+  RemoveItem(ctx context.Context, cartID, itemID string) error
+}
+
+type CartService struct {
+  repo CartRepository
+  products ProductGetter
+  variants VariantRepository
+}
+
+func NewCartService(repo CartRepository, products ProductGetter, variants VariantRepository) *CartService {
+  return &CartService{
+	repo: repo,
+	products: products,
+	variants: variants,
+  }
+}
+
+func (s *CartService) GetCart(ctx context.Context, userID string) (*dto.CartResponse, error) {
+  cart, err := s.getCart(ctx, userID)
+  if err != nil {
+	return nil, err
+  }
+
+  return s.buildCartResponse(ctx, cart)
+}
+
+// AddToCart checks availability of the exact product or variant before adding
+// it. Stock is only reserved when the order is placed.
+func (s *CartService) AddToCart(ctx context.Context, userID string, req *dto.AddToCartRequest) (*dto.AddToCartResponse, error) {
+  cart, err := s.getCart(ctx, userID)
+  if err != nil {
+	return nil, err
+  }
+
+  line, err := resolveSaleLine(ctx, s.products, s.variants, req.ProductID, req.VariantID)
+  if err != nil {
+	return nil, err
+  }
+
+  inCart, err := s.quantityInCart(ctx, cart.ID, req.ProductID, req.VariantID)
+  if err != nil {
+	return nil, err
+  }
+
+  if line.available() < inCart+req.Quantity {
+	return nil, ErrInsufficientStock
+  }
+
+  _, err = s.repo.AddItem(ctx, &model.CartItem{
+	ID: uuid.New().String(),
+	CartID: cart.ID,
+	ProductID: req.ProductID,
+	VariantID: req.VariantID,
+	Quantity: req.Quantity,
+  })
+  if err != nil {
+	return nil, fmt.Errorf("failed to add to cart: %w", err)
+  }
+
+  if err := s.repo.Touch(ctx, cart.ID, time.Now().Add(cartTTL)); err != nil {
+	return nil, err
+  }
+
+  response, err := s.buildCartResponse(ctx, cart)
+  if err != nil {
+	return nil, err
+  }
+
+  return &dto.AddToCartResponse{
+	Cart: response,
+	Message: "Item added to cart",
+  }, nil
+}
+
+func (s *CartService) UpdateCartItem(ctx context.Context, userID, itemID string, req *dto.UpdateCartItemRequest) (*dto.CartResponse, error) {
+  cart, err := s.getCart(ctx, userID)
+  if err != nil {
+	return nil, err
+  }
+
+  item, err := s.getItem(ctx, cart.ID, itemID)
+  if err != nil {
+	return nil, err
+  }
+
+  line, err := resolveSaleLine(ctx, s.products, s.variants, item.ProductID, item.VariantID)
+  if err != nil {
+	return nil, err
+  }
+
+  if line.available() < req.Quantity {
+	return nil, ErrInsufficientStock
+  }
+
+  if err := s.repo.UpdateItemQuantity(ctx, cart.ID, itemID, req.Quantity); err != nil {
+	if errors.Is(err, repository.ErrCartItemNotFound) {
+	  return nil, ErrCartItemNotFound
+	}
+	return nil, fmt.Errorf("failed to update cart item: %w", err)
+  }
+
+  return s.buildCartResponse(ctx, cart)
+}
+
+func (s *CartService) RemoveFromCart(ctx context.Context, userID, itemID string) (*dto.CartResponse, error) {
+  cart, err := s.getCart(ctx, userID)
+  if err != nil {
+	return nil, err
+  }
+
+  if _, err := uuid.Parse(itemID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  if err := s.repo.RemoveItem(ctx, cart.ID, itemID); err != nil {
+	if errors.Is(err, repository.ErrCartItemNotFound) {
+	  return nil, ErrCartItemNotFound
+	}
+	return nil, fmt.Errorf("failed to remove cart item: %w", err)
+  }
+
+  return s.buildCartResponse(ctx, cart)
+}
+
+func (s *CartService) getCart(ctx context.Context, userID string) (*model.ShoppingCart, error) {
+  cart, err := s.repo.GetOrCreate(ctx, userID, uuid.New().String(), time.Now().Add(cartTTL))
+  if err != nil {
+	return nil, fmt.Errorf("failed to get cart: %w", err)
+  }
+
+  return cart, nil
+}
+
+func (s *CartService) getItem(ctx context.Context, cartID, itemID string) (*model.CartItem, error) {
+  if _, err := uuid.Parse(itemID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  item, err := s.repo.GetItem(ctx, cartID, itemID)
+  if err != nil {
+	if errors.Is(err, repository.ErrCartItemNotFound) {
+	  return nil, ErrCartItemNotFound
+	}
+	return nil, fmt.Errorf("failed to get cart item: %w", err)
+  }
+
+  return item, nil
+}
+
+func (s *CartService) quantityInCart(ctx context.Context, cartID, productID string, variantID *string) (int, error) {
+  items, err := s.repo.ListItems(ctx, cartID)
+  if err != nil {
+	return 0, fmt.Errorf("failed to list cart items: %w", err)
+  }
+
+  for _, i := range items {
+	if i.ProductID == productID && sameVariant(i.VariantID, variantID) {
+	  return i.Quantity, nil
+	}
+  }
+
+  return 0, nil
+}
+
+// buildCartResponse prices every line at the current product or variant
+// price. Lines whose product disappeared or ran out stay in the cart but are
+// flagged unavailable and excluded from the total.
+func (s *CartService) buildCartResponse(ctx context.Context, cart *model.ShoppingCart) (*dto.CartResponse, error) {
+  items, err := s.repo.ListItems(ctx, cart.ID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list cart items: %w", err)
+  }
+
+  response := &dto.CartResponse{
+	ID: cart.ID,
+	UserID: cart.UserID,
+	Items: make([]dto.CartItemResponse, 0, len(items)),
+	ExpiresAt: cart.ExpiresAt,
+	UpdatedAt: cart.UpdatedAt,
+  }
+
+  for _, i := range items {
+	itemResponse := dto.CartItemResponse{
+	  ID: i.ID,
+	  ProductID: i.ProductID,
+	  VariantID: i.VariantID,
+	  Quantity: i.Quantity,
+	}
+
+	line, err := resolveSaleLine(ctx, s.products, s.variants, i.ProductID, i.VariantID)
+	switch {
+	case err == nil:
+	  itemResponse.SKU = line.sku()
+	  itemResponse.ProductName = line.name()
+	  itemResponse.ProductImage = line.image()
+	  itemResponse.UnitPrice = line.unitPrice()
+	  itemResponse.Subtotal = line.unitPrice() * float64(i.Quantity)
+	  itemResponse.Available = line.available() >= i.Quantity
+	case errors.Is(err, ErrProductNotFound), errors.Is(err, ErrVariantNotFound),
+	  errors.Is(err, ErrProductUnavailable), errors.Is(err, ErrVariantRequired):
+	  itemResponse.Available = false
+	default:
+	  return nil, err
+	}
+
+	if itemResponse.Available {
+	  response.TotalItems += i.Quantity
+	  response.TotalAmount += itemResponse.Subtotal
+	}
+	response.Items = append(response.Items, itemResponse)
+  }
+
+  return response, nil
+}
+
+func sameVariant(a, b *string) bool {
+  if a == nil || b == nil {
+	return a == nil && b == nil
+  }
+  return *a == *b
+}
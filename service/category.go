@@ -0,0 +1,332 @@
+package service
+
+import (
+  "fmt"
+  "time"
+  "errors"
+  "context"
+  "sort"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+var (
+  ErrCategoryCycle = errors.New("category cannot be moved under itself or one of its descendants")
+  ErrParentCategoryNotFound = errors.New("parent category not found")
+  ErrDuplicateCategorySlug = errors.New("category slug already exists")
+  ErrCategoryHasChildren = errors.New("category has subcategories")
+  ErrCategoryInUse = errors.New("category has products")
+  ErrInvalidSlug = errors.New("slug cannot be generated from name")
+)
+
+type CategoryRepository interface {
+  Create(ctx context.Context, category *model.Category) error
+  GetByID(ctx context.Context, id string) (*model.Category, error)
+  GetByIDs(ctx context.Context, ids []string) ([]*model.Category, error)
+  List(ctx context.Context, includeInactive bool) ([]*model.Category, error)
+  GetAncestors(ctx context.Context, id string) ([]*model.Category, error)
+  Update(ctx context.Context, id string, updates map[string]interface{}) (*model.Category, error)
+  Delete(ctx context.Context, id string) (time.Time, error)
+  HasChildren(ctx context.Context, id string) (bool, error)
+  HasProducts(ctx context.Context, id string) (bool, error)
+}
+
+type CategoryService struct {
+  repo CategoryRepository
+}
+
+func NewCategoryService(repo CategoryRepository) *CategoryService {
+  return &CategoryService{
+	repo: repo,
+  }
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, req *dto.CreateCategoryRequest) (*dto.CreateCategoryResponse, error) {
+  slug := req.Slug
+  if slug == "" {
+	slug = slugify(req.Name)
+  }
+  if slug == "" {
+	return nil, ErrInvalidSlug
+  }
+
+  if req.ParentID != nil {
+	if _, err := s.getCategory(ctx, *req.ParentID); err != nil {
+	  if errors.Is(err, ErrCategoryNotFound) {
+		return nil, ErrParentCategoryNotFound
+	  }
+	  return nil, err
+	}
+  }
+
+  isActive := true
+  if req.IsActive != nil {
+	isActive = *req.IsActive
+  }
+
+  category := model.Category{
+	ID: uuid.New().String(),
+	Name: req.Name,
+	Slug: slug,
+	Description: req.Description,
+	ParentID: req.ParentID,
+	ImageURL: req.ImageURL,
+	SortOrder: req.SortOrder,
+	IsActive: isActive,
+  }
+
+  if err := s.repo.Create(ctx, &category); err != nil {
+	if errors.Is(err, repository.ErrDuplicateSlug) {
+	  return nil, ErrDuplicateCategorySlug
+	}
+	return nil, fmt.Errorf("failed to create category: %w", err)
+  }
+
+  response := toCategoryResponse(&category)
+  return &dto.CreateCategoryResponse{
+	ID: category.ID,
+	Category: &response,
+	Message: "Category created successfully",
+  }, nil
+}
+
+func (s *CategoryService) ListCategories(ctx context.Context, includeInactive bool) (*dto.ListCategoriesResponse, error) {
+  categories, err := s.repo.List(ctx, includeInactive)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list categories: %w", err)
+  }
+
+  responses := make([]dto.CategoryResponse, len(categories))
+  for i, c := range categories {
+	responses[i] = toCategoryResponse(c)
+  }
+
+  return &dto.ListCategoriesResponse{
+	Categories: responses,
+	Total: len(responses),
+  }, nil
+}
+
+func (s *CategoryService) GetCategoryByID(ctx context.Context, categoryID string) (*dto.CategoryResponse, error) {
+  category, err := s.getCategory(ctx, categoryID)
+  if err != nil {
+	return nil, err
+  }
+
+  response := toCategoryResponse(category)
+  return &response, nil
+}
+
+// GetCategoryTree builds the nested category tree in memory from the flat
+// list. Inactive categories hide their whole subtree unless includeInactive
+// is set.
+func (s *CategoryService) GetCategoryTree(ctx context.Context, includeInactive bool) (*dto.CategoryTreeResponse, error) {
+  categories, err := s.repo.List(ctx, includeInactive)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list categories: %w", err)
+  }
+
+  nodes := make(map[string]*dto.CategoryTreeNode, len(categories))
+  for _, c := range categories {
+	nodes[c.ID] = &dto.CategoryTreeNode{
+	  CategoryResponse: toCategoryResponse(c),
+	  Children: []*dto.CategoryTreeNode{},
+	}
+  }
+
+  roots := []*dto.CategoryTreeNode{}
+  for _, c := range categories {
+	node := nodes[c.ID]
+	if c.ParentID == nil {
+	  roots = append(roots, node)
+	  continue
+	}
+	if parent, ok := nodes[*c.ParentID]; ok {
+	  parent.Children = append(parent.Children, node)
+	}
+  }
+
+  sortCategoryNodes(roots)
+
+  return &dto.CategoryTreeResponse{
+	Categories: roots,
+  }, nil
+}
+
+func (s *CategoryService) GetBreadcrumbs(ctx context.Context, categoryID string) (*dto.CategoryBreadcrumbsResponse, error) {
+  if _, err := uuid.Parse(categoryID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  ancestors, err := s.repo.GetAncestors(ctx, categoryID)
+  if err != nil {
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+	  return nil, ErrCategoryNotFound
+	}
+	return nil, fmt.Errorf("failed to get breadcrumbs: %w", err)
+  }
+
+  path := make([]dto.CategoryResponse, len(ancestors))
+  for i, c := range ancestors {
+	path[i] = toCategoryResponse(c)
+  }
+
+  return &dto.CategoryBreadcrumbsResponse{
+	CategoryID: categoryID,
+	Path: path,
+  }, nil
+}
+
+func (s *CategoryService) UpdateCategory(ctx context.Context, categoryID string, req *dto.UpdateCategoryRequest) (*dto.UpdateCategoryResponse, error) {
+  if _, err := s.getCategory(ctx, categoryID); err != nil {
+	return nil, err
+  }
+
+  updates := make(map[string]interface{})
+  if req.Name != nil {
+	updates["name"] = *req.Name
+  }
+  if req.Slug != nil {
+	updates["slug"] = *req.Slug
+  }
+  if req.Description != nil {
+	updates["description"] = *req.Description
+  }
+  if req.ImageURL != nil {
+	updates["image_url"] = *req.ImageURL
+  }
+  if req.SortOrder != nil {
+	updates["sort_order"] = *req.SortOrder
+  }
+  if req.IsActive != nil {
+	updates["is_active"] = *req.IsActive
+  }
+
+  switch {
+  case req.MoveToRoot:
+	updates["parent_id"] = nil
+  case req.ParentID != nil:
+	if err := s.checkReparent(categoryID, *req.ParentID); err != nil {
+	  return nil, err
+	}
+	updates["parent_id"] = *req.ParentID
+  }
+
+  updated, err := s.repo.Update(ctx, categoryID, updates)
+  if err != nil {
+	switch {
+	case errors.Is(err, repository.ErrCategoryNotFound):
+	  return nil, ErrCategoryNotFound
+	case errors.Is(err, repository.ErrDuplicateSlug):
+	  return nil, ErrDuplicateCategorySlug
+	case errors.Is(err, repository.ErrParentCategoryNotFound):
+	  return nil, ErrParentCategoryNotFound
+	case errors.Is(err, repository.ErrCategoryCycle):
+	  return nil, ErrCategoryCycle
+	}
+	return nil, fmt.Errorf("failed to update category: %w", err)
+  }
+
+  response := toCategoryResponse(updated)
+  return &dto.UpdateCategoryResponse{
+	Category: &response,
+	Message: "Category updated successfully",
+  }, nil
+}
+
+func (s *CategoryService) DeleteCategory(ctx context.Context, categoryID string) (*dto.DeleteCategoryResponse, error) {
+  if _, err := s.getCategory(ctx, categoryID); err != nil {
+	return nil, err
+  }
+
+  hasChildren, err := s.repo.HasChildren(ctx, categoryID)
+  if err != nil {
+	return nil, err
+  }
+  if hasChildren {
+	return nil, ErrCategoryHasChildren
+  }
+
+  hasProducts, err := s.repo.HasProducts(ctx, categoryID)
+  if err != nil {
+	return nil, err
+  }
+  if hasProducts {
+	return nil, ErrCategoryInUse
+  }
+
+  deletedAt, err := s.repo.Delete(ctx, categoryID)
+  if err != nil {
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+	  return nil, ErrCategoryNotFound
+	}
+	return nil, fmt.Errorf("failed to delete category: %w", err)
+  }
+
+  return &dto.DeleteCategoryResponse{
+	ID: categoryID,
+	Message: "Category deleted successfully",
+	DeletedAt: deletedAt,
+  }, nil
+}
+
+// checkReparent rejects moves that can be ruled out without the database:
+// a category can't become its own parent. Whether the parent exists and
+// isn't one of the category's descendants is checked by the repository,
+// under lock, in the same transaction as the move.
+func (s *CategoryService) checkReparent(categoryID, parentID string) error {
+  if categoryID == parentID {
+	return ErrCategoryCycle
+  }
+  if _, err := uuid.Parse(parentID); err != nil {
+	return ErrInvalidID
+  }
+
+  return nil
+}
+
+func (s *CategoryService) getCategory(ctx context.Context, categoryID string) (*model.Category, error) {
+  if _, err := uuid.Parse(categoryID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  category, err := s.repo.GetByID(ctx, categoryID)
+  if err != nil {
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+	  return nil, ErrCategoryNotFound
+	}
+	return nil, fmt.Errorf("failed to get category: %w", err)
+  }
+
+  return category, nil
+}
+
+func sortCategoryNodes(nodes []*dto.CategoryTreeNode) {
+  sort.SliceStable(nodes, func(i, j int) bool {
+	if nodes[i].SortOrder != nodes[j].SortOrder {
+	  return nodes[i].SortOrder < nodes[j].SortOrder
+	}
+	return nodes[i].Name < nodes[j].Name
+  })
+
+  for _, n := range nodes {
+	sortCategoryNodes(n.Children)
+  }
+}
+
+func toCategoryResponse(c *model.Category) dto.CategoryResponse {
+  return dto.CategoryResponse{
+	ID: c.ID,
+	Name: c.Name,
+	Slug: c.Slug,
+	Description: c.Description,
+	ParentID: c.ParentID,
+	ImageURL: c.ImageURL,
+	SortOrder: c.SortOrder,
+	IsActive: c.IsActive,
+  }
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+  "io"
+  "fmt"
+  "log"
+  "path"
+  "bytes"
+  "errors"
+  "context"
+  "image"
+  "image/png"
+  "image/jpeg"
+  _ "image/gif"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/storage"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/gabriel-vasile/mimetype"
+  "github.com/google/uuid"
+)
+
+var (
+  ErrImageNotFound = errors.New("image not found")
+  ErrImageTooLarge = errors.New("image is too large")
+  ErrUnsupportedImageType = errors.New("unsupported image type")
+  ErrInvalidImage = errors.New("image could not be decoded")
+  ErrImageLimitReached = errors.New("product image limit reached")
+  ErrImageOrderMismatch = errors.New("image order must list every product image exactly once")
+)
+
+const (
+  MaxImageSize = 10 << 20
+  maxImagePixels = 40_000_000
+  maxImagesPerProduct = 10
+  orphanedImageBatch = 100
+)
+
+// imageExtensions lists the accepted upload types, keyed by sniffed MIME type.
+var imageExtensions = map[string]string{
+  "image/jpeg": ".jpg",
+  "image/png":  ".png",
+  "image/gif":  ".gif",
+}
+
+var thumbnailSizes = []struct {
+  name  string
+  bound int
+}{
+  {"small", 150},
+  {"medium", 400},
+  {"large", 800},
+}
+
+type ImageRepository interface {
+  Create(ctx context.Context, image *model.ProductImage, maxImages int) error
+  GetByID(ctx context.Context, productID, imageID string) (*model.ProductImage, error)
+  ListByProduct(ctx context.Context, productID string) ([]*model.ProductImage, error)
+  Reorder(ctx context.Context, productID string, imageIDs []string) error
+  Delete(ctx context.Context, productID, imageID string) (*model.ProductImage, error)
+  ListOrphaned(ctx context.Context, limit int) ([]*model.ProductImage, error)
+  DeleteByIDs(ctx context.Context, imageIDs []string) error
+}
+
+type ImageService struct {
+  repo ImageRepository
+  products ProductGetter
+  blobs storage.BlobStore
+}
+
+func NewImageService(repo ImageRepository, products ProductGetter, blobs storage.BlobStore) *ImageService {
+  return &ImageService{
+	repo: repo,
+	products: products,
+	blobs: blobs,
+  }
+}
+
+func (s *ImageService) UploadImage(ctx context.Context, productID string, r io.Reader) (*dto.UploadProductImageResponse, error) {
+  if _, err := findProduct(ctx, s.products, productID); err != nil {
+	return nil, err
+  }
+
+  data, err := io.ReadAll(io.LimitReader(r, MaxImageSize+1))
+  if err != nil {
+	return nil, fmt.Errorf("failed to read image: %w", err)
+  }
+
+  if len(data) > MaxImageSize {
+	return nil, ErrImageTooLarge
+  }
+
+  // Trust the bytes, not the client supplied Content-Type.
+  contentType := mimetype.Detect(data).String()
+  ext, ok := imageExtensions[contentType]
+  if !ok {
+	return nil, ErrUnsupportedImageType
+  }
+
+  // Check the dimensions before decoding so a tiny file can't expand into a
+  // huge bitmap.
+  cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+  if err != nil {
+	return nil, ErrInvalidImage
+  }
+
+  if cfg.Width*cfg.Height > maxImagePixels {
+	return nil, ErrImageTooLarge
+  }
+
+  decoded, _, err := image.Decode(bytes.NewReader(data))
+  if err != nil {
+	return nil, ErrInvalidImage
+  }
+
+  img := &model.ProductImage{
+	ID: uuid.New().String(),
+	ProductID: productID,
+	ContentType: contentType,
+	Size: int64(len(data)),
+	Width: cfg.Width,
+	Height: cfg.Height,
+  }
+  img.StorageKey = imagePrefix(productID, img.ID) + "original" + ext
+  img.URL = s.blobs.URL(img.StorageKey)
+
+  if err := s.storeImage(ctx, img, data, decoded); err != nil {
+	s.removeBlobs(ctx, img)
+	return nil, err
+  }
+
+  if err := s.repo.Create(ctx, img, maxImagesPerProduct); err != nil {
+	s.removeBlobs(ctx, img)
+
+	if errors.Is(err, repository.ErrNotFound) {
+	  return nil, ErrProductNotFound
+	}
+	if errors.Is(err, repository.ErrImageLimitReached) {
+	  return nil, ErrImageLimitReached
+	}
+	return nil, fmt.Errorf("failed to create image: %w", err)
+  }
+
+  response := s.toImageResponse(img)
+  return &dto.UploadProductImageResponse{
+	ID: img.ID,
+	Image: &response,
+	Message: "Image uploaded successfully",
+  }, nil
+}
+
+func (s *ImageService) ListImages(ctx context.Context, productID string) (*dto.ListProductImagesResponse, error) {
+  if _, err := findProduct(ctx, s.products, productID); err != nil {
+	return nil, err
+  }
+
+  images, err := s.repo.ListByProduct(ctx, productID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list images: %w", err)
+  }
+
+  return &dto.ListProductImagesResponse{
+	ProductID: productID,
+	Images: s.toImageResponses(images),
+  }, nil
+}
+
+func (s *ImageService) ReorderImages(ctx context.Context, productID string, req *dto.ReorderProductImagesRequest) (*dto.ListProductImagesResponse, error) {
+  if _, err := uuid.Parse(productID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  if err := s.repo.Reorder(ctx, productID, req.ImageIDs); err != nil {
+	if errors.Is(err, repository.ErrNotFound) {
+	  return nil, ErrProductNotFound
+	}
+	if errors.Is(err, repository.ErrImageOrderMismatch) {
+	  return nil, ErrImageOrderMismatch
+	}
+	return nil, fmt.Errorf("failed to reorder images: %w", err)
+  }
+
+  return s.ListImages(ctx, productID)
+}
+
+func (s *ImageService) DeleteImage(ctx context.Context, productID, imageID string) (*dto.DeleteProductImageResponse, error) {
+  if _, err := uuid.Parse(productID); err != nil {
+	return nil, ErrInvalidID
+  }
+  if _, err := uuid.Parse(imageID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  img, err := s.repo.Delete(ctx, productID, imageID)
+  if err != nil {
+	if errors.Is(err, repository.ErrImageNotFound) {
+	  return nil, ErrImageNotFound
+	}
+	return nil, fmt.Errorf("failed to delete image: %w", err)
+  }
+
+  // The row is gone, so a failure here only leaves unreachable files behind.
+  s.removeBlobs(ctx, img)
+
+  return &dto.DeleteProductImageResponse{
+	ID: imageID,
+	Message: "Image deleted successfully",
+  }, nil
+}
+
+// RemoveOrphanedImages deletes the stored files and rows of images that belong
+// to purged products. Rows are only dropped once their files are gone, so a
+// storage failure is retried on the next run.
+func (s *ImageService) RemoveOrphanedImages(ctx context.Context) error {
+  for {
+	images, err := s.repo.ListOrphaned(ctx, orphanedImageBatch)
+	if err != nil {
+	  return fmt.Errorf("failed to list orphaned images: %w", err)
+	}
+
+	removed := make([]string, 0, len(images))
+	for _, img := range images {
+	  if err := s.blobs.DeletePrefix(ctx, imagePrefix(img.ProductID, img.ID)); err != nil {
+		log.Printf("failed to remove files of image %s: %v", img.ID, err)
+		continue
+	  }
+	  removed = append(removed, img.ID)
+	}
+
+	if len(removed) > 0 {
+	  if err := s.repo.DeleteByIDs(ctx, removed); err != nil {
+		return err
+	  }
+	}
+
+	if len(images) < orphanedImageBatch || len(removed) == 0 {
+	  return nil
+	}
+  }
+}
+
+// storeImage writes the original upload and its thumbnails.
+func (s *ImageService) storeImage(ctx context.Context, img *model.ProductImage, data []byte, decoded image.Image) error {
+  if err := s.blobs.Put(ctx, img.StorageKey, bytes.NewReader(data), img.ContentType); err != nil {
+	return fmt.Errorf("failed to store image: %w", err)
+  }
+
+  src := toRGBA(decoded)
+  thumbType, thumbExt := thumbnailFormat(img.ContentType)
+  for _, size := range thumbnailSizes {
+	var buf bytes.Buffer
+	thumb := resizeToFit(src, size.bound)
+
+	var err error
+	if thumbType == "image/png" {
+	  err = png.Encode(&buf, thumb)
+	} else {
+	  err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+	  return fmt.Errorf("failed to encode %s thumbnail: %w", size.name, err)
+	}
+
+	key := imagePrefix(img.ProductID, img.ID) + size.name + thumbExt
+	if err := s.blobs.Put(ctx, key, &buf, thumbType); err != nil {
+	  return fmt.Errorf("failed to store %s thumbnail: %w", size.name, err)
+	}
+  }
+
+  return nil
+}
+
+func (s *ImageService) removeBlobs(ctx context.Context, img *model.ProductImage) {
+  if err := s.blobs.DeletePrefix(ctx, imagePrefix(img.ProductID, img.ID)); err != nil {
+	log.Printf("failed to remove files of image %s: %v", img.ID, err)
+  }
+}
+
+func (s *ImageService) toImageResponse(img *model.ProductImage) dto.ProductImageResponse {
+  _, thumbExt := thumbnailFormat(img.ContentType)
+  thumbnails := make(map[string]string, len(thumbnailSizes))
+  for _, size := range thumbnailSizes {
+	thumbnails[size.name] = s.blobs.URL(path.Join(path.Dir(img.StorageKey), size.name + thumbExt))
+  }
+
+  return dto.ProductImageResponse{
+	ID: img.ID,
+	URL: img.URL,
+	ContentType: img.ContentType,
+	Size: img.Size,
+	Width: img.Width,
+	Height: img.Height,
+	Position: img.Position,
+	Thumbnails: thumbnails,
+	CreatedAt: img.CreatedAt,
+  }
+}
+
+func (s *ImageService) toImageResponses(images []*model.ProductImage) []dto.ProductImageResponse {
+  responses := make([]dto.ProductImageResponse, len(images))
+  for i, img := range images {
+	responses[i] = s.toImageResponse(img)
+  }
+  return responses
+}
+
+// imagePrefix is the storage folder holding an image and all its thumbnails.
+func imagePrefix(productID, imageID string) string {
+  return "products/" + productID + "/images/" + imageID + "/"
+}
+
+// thumbnailFormat keeps PNG and GIF thumbnails lossless so transparency
+// survives, everything else becomes JPEG.
+func thumbnailFormat(contentType string) (string, string) {
+  if contentType == "image/png" || contentType == "image/gif" {
+	return "image/png", ".png"
+  }
+  return "image/jpeg", ".jpg"
+}
+
+func findProduct(ctx context.Context, products ProductGetter, productID string) (*model.Product, error) {
+  if _, err := uuid.Parse(productID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  product, err := products.GetProductByID(ctx, productID)
+  if err != nil {
+	if errors.Is(err, repository.ErrNotFound) {
+	  return nil, ErrProductNotFound
+	}
+	return nil, fmt.Errorf("failed to get product: %w", err)
+  }
+
+  return product, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+  "testing"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/storage"
+)
+
+// fakeImageRepository holds image rows next to the IDs of the products still
+// in the products table, deleted or not. Only the methods the cleanup uses
+// are implemented.
+type fakeImageRepository struct {
+  ImageRepository
+  images []*model.ProductImage
+  products map[string]bool
+}
+
+func (r *fakeImageRepository) ListOrphaned(ctx context.Context, limit int) ([]*model.ProductImage, error) {
+  var orphaned []*model.ProductImage
+  for _, img := range r.images {
+	if !r.products[img.ProductID] && len(orphaned) < limit {
+	  orphaned = append(orphaned, img)
+	}
+  }
+  return orphaned, nil
+}
+
+func (r *fakeImageRepository) DeleteByIDs(ctx context.Context, imageIDs []string) error {
+  deleted := map[string]bool{}
+  for _, id := range imageIDs {
+	deleted[id] = true
+  }
+
+  kept := r.images[:0]
+  for _, img := range r.images {
+	if !deleted[img.ID] {
+	  kept = append(kept, img)
+	}
+  }
+  r.images = kept
+  return nil
+}
+
+func (r *fakeImageRepository) has(imageID string) bool {
+  for _, img := range r.images {
+	if img.ID == imageID {
+	  return true
+	}
+  }
+  return false
+}
+
+// failingStore refuses to delete the files under one prefix.
+type failingStore struct {
+  storage.BlobStore
+  prefix string
+}
+
+func (s *failingStore) DeletePrefix(ctx context.Context, prefix string) error {
+  if prefix == s.prefix {
+	return errors.New("storage unavailable")
+  }
+  return s.BlobStore.DeletePrefix(ctx, prefix)
+}
+
+// addImage stores the files of an image and adds its row.
+func addImage(t *testing.T, repo *fakeImageRepository, blobs storage.BlobStore, productID, imageID string) {
+  t.Helper()
+
+  img := &model.ProductImage{ID: imageID, ProductID: productID, StorageKey: imagePrefix(productID, imageID) + "original.jpg"}
+  for _, key := range []string{img.StorageKey, imagePrefix(productID, imageID) + "thumb_200.jpg"} {
+	if err := blobs.Put(context.Background(), key, strings.NewReader("jpeg"), "image/jpeg"); err != nil {
+	  t.Fatal(err)
+	}
+  }
+  repo.images = append(repo.images, img)
+}
+
+func assertImageFiles(t *testing.T, blobs storage.BlobStore, productID, imageID string, want bool) {
+  t.Helper()
+
+  for _, name := range []string{"original.jpg", "thumb_200.jpg"} {
+	f, err := blobs.Get(context.Background(), imagePrefix(productID, imageID)+name)
+	if err == nil {
+	  f.Close()
+	}
+	if exists := err == nil; exists != want {
+	  t.Errorf("image %s file %s exists = %v, want %v", imageID, name, exists, want)
+	}
+  }
+}
+
+func TestRemoveOrphanedImages(t *testing.T) {
+  ctx := context.Background()
+  blobs, err := storage.NewLocalStore(t.TempDir(), "/uploads")
+  if err != nil {
+	t.Fatal(err)
+  }
+
+  // The trashed product is still in the table; the purged one is gone.
+  repo := &fakeImageRepository{products: map[string]bool{"live": true, "trashed": true}}
+  addImage(t, repo, blobs, "live", "live-1")
+  addImage(t, repo, blobs, "trashed", "trashed-1")
+  // More than one batch of images of the purged product.
+  var purged []string
+  for i := 0; i <= orphanedImageBatch; i++ {
+	id := fmt.Sprintf("purged-%d", i)
+	addImage(t, repo, blobs, "purged", id)
+	purged = append(purged, id)
+  }
+
+  s := NewImageService(repo, nil, blobs)
+  if err := s.RemoveOrphanedImages(ctx); err != nil {
+	t.Fatal(err)
+  }
+
+  for _, id := range purged {
+	if repo.has(id) {
+	  t.Errorf("row of image %s of the purged product was kept", id)
+	}
+	assertImageFiles(t, blobs, "purged", id, false)
+  }
+  for _, img := range []struct{ productID, imageID string }{{"live", "live-1"}, {"trashed", "trashed-1"}} {
+	if !repo.has(img.imageID) {
+	  t.Errorf("row of image %s was removed", img.imageID)
+	}
+	assertImageFiles(t, blobs, img.productID, img.imageID, true)
+  }
+}
+
+func TestRemoveOrphanedImagesKeepsRowsOfFilesNotRemoved(t *testing.T) {
+  ctx := context.Background()
+  local, err := storage.NewLocalStore(t.TempDir(), "/uploads")
+  if err != nil {
+	t.Fatal(err)
+  }
+  blobs := &failingStore{BlobStore: local, prefix: imagePrefix("purged", "stuck")}
+
+  repo := &fakeImageRepository{products: map[string]bool{}}
+  addImage(t, repo, blobs, "purged", "stuck")
+  addImage(t, repo, blobs, "purged", "removed")
+
+  s := NewImageService(repo, nil, blobs)
+  if err := s.RemoveOrphanedImages(ctx); err != nil {
+	t.Fatal(err)
+  }
+
+  if !repo.has("stuck") {
+	t.Error("row of an image whose files couldn't be removed was dropped")
+  }
+  assertImageFiles(t, blobs, "purged", "stuck", true)
+  if repo.has("removed") {
+	t.Error("row of a removed image was kept")
+  }
+
+  // The next run picks it up once storage works again.
+  blobs.prefix = ""
+  if err := s.RemoveOrphanedImages(ctx); err != nil {
+	t.Fatal(err)
+  }
+  if repo.has("stuck") {
+	t.Error("row was kept after its files were removed")
+  }
+  assertImageFiles(t, blobs, "purged", "stuck", false)
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+  "fmt"
+  "log"
+  "errors"
+  "context"
+  "strconv"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/notify"
+
+  "github.com/google/uuid"
+)
+
+// lowStockAlertBatch is how many pending alerts a single run delivers.
+const lowStockAlertBatch = 100
+
+type InventoryRepository interface {
+  ListMovements(ctx context.Context, productID string, variantID *string, limit, offset int) ([]*model.StockMovement, int, error)
+  Reconcile(ctx context.Context) ([]*model.StockDiscrepancy, error)
+  ListLowStock(ctx context.Context, limit, offset int) ([]*model.LowStockItem, int, error)
+  DeliverPendingAlerts(ctx context.Context, limit int, deliver func(*model.LowStockAlert) error) (int, error)
+}
+
+type InventoryService struct {
+  repo InventoryRepository
+  products ProductGetter
+  notifier notify.Notifier
+  alertRecipients []string
+}
+
+// NewInventoryService sends low-stock alerts through notifier to every
+// address in alertRecipients.
+func NewInventoryService(repo InventoryRepository, products ProductGetter, notifier notify.Notifier, alertRecipients []string) *InventoryService {
+  return &InventoryService{
+	repo: repo,
+	products: products,
+	notifier: notifier,
+	alertRecipients: alertRecipients,
+  }
+}
+
+func (s *InventoryService) ListStockMovements(ctx context.Context, productID string, req dto.GetStockMovementsRequest) (*dto.ListStockMovementsResponse, error) {
+  if _, err := findProduct(ctx, s.products, productID); err != nil {
+	return nil, err
+  }
+
+  if req.VariantID != nil {
+	if _, err := uuid.Parse(*req.VariantID); err != nil {
+	  return nil, ErrInvalidID
+	}
+  }
+
+  movements, total, err := s.repo.ListMovements(ctx, productID, req.VariantID, req.Limit, req.Offset)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list stock movements: %w", err)
+  }
+
+  responses := make([]dto.StockMovementResponse, len(movements))
+  for i, m := range movements {
+	responses[i] = dto.StockMovementResponse{
+	  ID: m.ID,
+	  VariantID: m.VariantID,
+	  WarehouseID: m.WarehouseID,
+	  Type: m.Type,
+	  Quantity: m.Quantity,
+	  ReservedQuantity: m.ReservedQuantity,
+	  StockBefore: m.StockBefore,
+	  StockAfter: m.StockAfter,
+	  ReservedBefore: m.ReservedBefore,
+	  ReservedAfter: m.ReservedAfter,
+	  Reason: m.Reason,
+	  ActorID: m.ActorID,
+	  OrderID: m.OrderID,
+	  CreatedAt: m.CreatedAt,
+	}
+  }
+
+  return &dto.ListStockMovementsResponse{
+	ProductID: productID,
+	Movements: responses,
+	Total: total,
+	Limit: req.Limit,
+	Offset: req.Offset,
+  }, nil
+}
+
+// Reconcile returns every product and variant whose stock doesn't match the
+// sum of its ledger. An empty result means the ledger is consistent.
+func (s *InventoryService) Reconcile(ctx context.Context) ([]*model.StockDiscrepancy, error) {
+  discrepancies, err := s.repo.Reconcile(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to reconcile stock: %w", err)
+  }
+
+  return discrepancies, nil
+}
+
+func (s *InventoryService) GetLowStockReport(ctx context.Context, req dto.GetLowStockRequest) (*dto.LowStockReportResponse, error) {
+  items, total, err := s.repo.ListLowStock(ctx, req.Limit, req.Offset)
+  if err != nil {
+	return nil, fmt.Errorf("failed to get low stock report: %w", err)
+  }
+
+  responses := make([]dto.LowStockItemResponse, len(items))
+  for i, item := range items {
+	responses[i] = dto.LowStockItemResponse{
+	  ProductID: item.ProductID,
+	  VariantID: item.VariantID,
+	  SKU: item.SKU,
+	  Name: item.Name,
+	  Status: item.Status,
+	  Stock: item.Stock,
+	  ReservedStock: item.ReservedStock,
+	  Available: item.Stock - item.ReservedStock,
+	  ReorderThreshold: item.Threshold,
+	}
+  }
+
+  return &dto.LowStockReportResponse{
+	Items: responses,
+	Total: total,
+	Limit: req.Limit,
+	Offset: req.Offset,
+  }, nil
+}
+
+// SendLowStockAlerts delivers pending low-stock alerts to every recipient.
+// An alert that can't be delivered to all of them stays pending and is
+// retried on the next run.
+func (s *InventoryService) SendLowStockAlerts(ctx context.Context) error {
+  if len(s.alertRecipients) == 0 {
+	return nil
+  }
+
+  sent, err := s.repo.DeliverPendingAlerts(ctx, lowStockAlertBatch, func(a *model.LowStockAlert) error {
+	var failures []error
+	for _, to := range s.alertRecipients {
+	  if err := s.notifier.Notify(ctx, lowStockMessage(to, a)); err != nil {
+		failures = append(failures, err)
+	  }
+	}
+	return errors.Join(failures...)
+  })
+  if sent > 0 {
+	log.Printf("low stock alerts: %d sent", sent)
+  }
+  if err != nil {
+	return fmt.Errorf("failed to send low stock alerts: %w", err)
+  }
+
+  return nil
+}
+
+func lowStockMessage(to string, a *model.LowStockAlert) notify.Message {
+  data := map[string]string{
+	"product_id": a.ProductID,
+	"sku": a.SKU,
+	"available": strconv.Itoa(a.Available),
+	"reorder_threshold": strconv.Itoa(a.Threshold),
+  }
+  if a.VariantID != nil {
+	data["variant_id"] = *a.VariantID
+  }
+
+  return notify.Message{
+	To: to,
+	Kind: "low_stock",
+	Subject: fmt.Sprintf("Low stock: %s (%s)", a.Name, a.SKU),
+	Body: fmt.Sprintf(
+	  "%s (%s) is down to %d available units, at or below its reorder threshold of %d.",
+	  a.Name, a.SKU, a.Available, a.Threshold,
+	),
+	Data: data,
+  }
+}
+
+// newStockMovement builds a manual stock change. Movements without a type are
+// adjustments.
+func newStockMovement(productID string, variantID *string, warehouseID string, delta int, movementType model.StockMovementType, reason, actorID string) *model.StockMovement {
+  if movementType == "" {
+	movementType = model.StockMovementAdjustment
+  }
+
+  m := &model.StockMovement{
+	ProductID: productID,
+	VariantID: variantID,
+	WarehouseID: &warehouseID,
+	Type: movementType,
+	Quantity: delta,
+	Reason: reason,
+  }
+  if actorID != "" {
+	m.ActorID = &actorID
+  }
+
+  return m
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+  "log"
+  "time"
+  "errors"
+  "context"
+  "strings"
+  "encoding/base64"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+  "github.com/skip2/go-qrcode"
+)
+
+var (
+  ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+  ErrMFANotEnabled = errors.New("two-factor authentication not enabled")
+  ErrMFANotEnrolled = errors.New("two-factor authentication enrollment not started")
+  ErrMFARequired = errors.New("two-factor authentication required")
+  ErrInvalidMFACode = errors.New("invalid two-factor authentication code")
+  ErrInvalidMFAChallenge = errors.New("invalid or expired two-factor authentication challenge")
+  ErrTooManyMFAAttempts = errors.New("too many two-factor authentication attempts")
+)
+
+const (
+  mfaChallengeTTL = 5 * time.Minute
+  mfaChallengeAttempts = 5
+  // Every challenge gets a few attempts, so the codes tried are also capped
+  // per account, or new logins would hand out unlimited guesses.
+  mfaAccountAttempts = 20
+  mfaAttemptWindow = 15 * time.Minute
+  recoveryCodeCount = 10
+  mfaQRScale = 6
+)
+
+type MFARepository interface {
+  GetTOTP(ctx context.Context, userID string) (*model.TOTP, error)
+  SavePendingTOTP(ctx context.Context, userID, secret string) error
+  ConfirmTOTP(ctx context.Context, userID string, step int64, codeHashes []string) error
+  UseTOTPStep(ctx context.Context, userID string, step int64) error
+  DeleteTOTP(ctx context.Context, userID string) error
+  ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+  UseRecoveryCode(ctx context.Context, userID, codeHash string) error
+  CountRecoveryCodes(ctx context.Context, userID string) (int, error)
+  CreateChallenge(ctx context.Context, id, userID, tokenHash string, expiresAt time.Time) error
+  CountChallengeAttempts(ctx context.Context, userID string, since time.Time) (int, error)
+  GetChallengeUserID(ctx context.Context, tokenHash string, maxAttempts int) (string, error)
+  RecordChallengeAttempt(ctx context.Context, tokenHash string, maxAttempts int) (string, error)
+  ConsumeChallenge(ctx context.Context, tokenHash string) error
+  DeleteExpiredChallenges(ctx context.Context, before time.Time) (int64, error)
+}
+
+type MFAUserRepository interface {
+  GetByID(ctx context.Context, id string) (*model.User, error)
+}
+
+type MFAService struct {
+  repo MFARepository
+  users MFAUserRepository
+  policy model.MFAPolicy
+  issuer string
+}
+
+// NewMFAService labels the accounts it enrolls in authenticator apps with
+// issuer, the name users will see next to their codes.
+func NewMFAService(repo MFARepository, users MFAUserRepository, policy model.MFAPolicy, issuer string) *MFAService {
+  return &MFAService{
+	repo: repo,
+	users: users,
+	policy: policy,
+	issuer: issuer,
+  }
+}
+
+// Required reports whether the policy makes user sign in with a second
+// factor.
+func (s *MFAService) Required(user *model.User) bool {
+  return s.policy == model.MFARequiredForAdmins && user.Role == model.Admin
+}
+
+// enabledTOTP returns the user's confirmed secret, or nil if 2FA is off.
+func (s *MFAService) enabledTOTP(ctx context.Context, userID string) (*model.TOTP, error) {
+  totp, err := s.repo.GetTOTP(ctx, userID)
+  if err != nil {
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+	  return nil, nil
+	}
+	return nil, err
+  }
+
+  if totp.ConfirmedAt == nil {
+	return nil, nil
+  }
+  return totp, nil
+}
+
+func (s *MFAService) Status(ctx context.Context, userID string) (*dto.MFAStatusResponse, error) {
+  user, err := s.getUser(ctx, userID)
+  if err != nil {
+	return nil, err
+  }
+
+  totp, err := s.enabledTOTP(ctx, userID)
+  if err != nil {
+	return nil, err
+  }
+
+  status := &dto.MFAStatusResponse{
+	Required: s.Required(user),
+  }
+  if totp == nil {
+	return status, nil
+  }
+
+  remaining, err := s.repo.CountRecoveryCodes(ctx, userID)
+  if err != nil {
+	return nil, err
+  }
+
+  status.Enabled = true
+  status.EnabledAt = totp.ConfirmedAt
+  status.RecoveryCodesRemaining = remaining
+  return status, nil
+}
+
+// BeginEnrollment creates a new secret for the user's authenticator app. 2FA
+// isn't on until ConfirmEnrollment gets a code generated from it; starting
+// over replaces a secret that was never confirmed.
+func (s *MFAService) BeginEnrollment(ctx context.Context, userID string) (*dto.MFAEnrollmentResponse, error) {
+  user, err := s.getUser(ctx, userID)
+  if err != nil {
+	return nil, err
+  }
+
+  secret, err := auth.NewTOTPSecret()
+  if err != nil {
+	return nil, err
+  }
+
+  if err := s.repo.SavePendingTOTP(ctx, userID, secret); err != nil {
+	if errors.Is(err, repository.ErrTOTPAlreadyConfirmed) {
+	  return nil, ErrMFAAlreadyEnabled
+	}
+	return nil, err
+  }
+
+  uri := auth.TOTPURI(s.issuer, user.Email, secret)
+  // A negative size sets the pixels per module instead of the image width.
+  png, err := qrcode.Encode(uri, qrcode.Medium, -mfaQRScale)
+  if err != nil {
+	return nil, err
+  }
+
+  return &dto.MFAEnrollmentResponse{
+	Secret: secret,
+	OTPAuthURI: uri,
+	QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+  }, nil
+}
+
+// ConfirmEnrollment turns 2FA on once the user shows their app produces the
+// right codes, and returns the recovery codes. They are only stored hashed,
+// so this is the one time they can be shown.
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID string, req dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, error) {
+  totp, err := s.repo.GetTOTP(ctx, userID)
+  if err != nil {
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+	  return nil, ErrMFANotEnrolled
+	}
+	return nil, err
+  }
+  if totp.ConfirmedAt != nil {
+	return nil, ErrMFAAlreadyEnabled
+  }
+
+  step, ok := auth.ValidateTOTP(totp.Secret, strings.TrimSpace(req.Code), time.Now())
+  if !ok {
+	return nil, ErrInvalidMFACode
+  }
+
+  codes, hashes, err := newRecoveryCodes()
+  if err != nil {
+	return nil, err
+  }
+
+  if err := s.repo.ConfirmTOTP(ctx, userID, step, hashes); err != nil {
+	if errors.Is(err, repository.ErrTOTPAlreadyConfirmed) {
+	  return nil, ErrMFAAlreadyEnabled
+	}
+	return nil, err
+  }
+
+  return &dto.MFARecoveryCodesResponse{
+	Message: "Two-factor authentication enabled, keep these recovery codes somewhere safe",
+	RecoveryCodes: codes,
+  }, nil
+}
+
+// Disable turns 2FA off, given a current code. Accounts the policy requires
+// 2FA of can't turn it off.
+func (s *MFAService) Disable(ctx context.Context, userID string, req dto.MFACodeRequest) error {
+  user, err := s.getUser(ctx, userID)
+  if err != nil {
+	return err
+  }
+  if s.Required(user) {
+	return ErrMFARequired
+  }
+
+  if err := s.verifyCode(ctx, userID, req.Code); err != nil {
+	return err
+  }
+
+  if err := s.repo.DeleteTOTP(ctx, userID); err != nil {
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+	  return ErrMFANotEnabled
+	}
+	return err
+  }
+
+  return nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, used or not, given a
+// current code.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID string, req dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, error) {
+  if err := s.verifyCode(ctx, userID, req.Code); err != nil {
+	return nil, err
+  }
+
+  codes, hashes, err := newRecoveryCodes()
+  if err != nil {
+	return nil, err
+  }
+
+  if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+	return nil, err
+  }
+
+  return &dto.MFARecoveryCodesResponse{
+	Message: "New recovery codes generated, the old ones no longer work",
+	RecoveryCodes: codes,
+  }, nil
+}
+
+// Challenge decides whether a login that got the password right needs a
+// second step. If so it returns a challenge to answer with a code, otherwise
+// nil.
+func (s *MFAService) Challenge(ctx context.Context, user *model.User) (*dto.MFAChallengeResponse, error) {
+  totp, err := s.enabledTOTP(ctx, user.ID)
+  if err != nil {
+	return nil, err
+  }
+  if totp == nil && !s.Required(user) {
+	return nil, nil
+  }
+
+  attempts, err := s.repo.CountChallengeAttempts(ctx, user.ID, time.Now().Add(-mfaAttemptWindow))
+  if err != nil {
+	return nil, err
+  }
+  if attempts >= mfaAccountAttempts {
+	return nil, ErrTooManyMFAAttempts
+  }
+
+  token, err := auth.RandomToken(32)
+  if err != nil {
+	return nil, err
+  }
+
+  if err := s.repo.CreateChallenge(ctx, uuid.New().String(), user.ID, hashToken(token), time.Now().Add(mfaChallengeTTL)); err != nil {
+	return nil, err
+  }
+
+  return &dto.MFAChallengeResponse{
+	MFARequired: true,
+	MFAToken: token,
+	ExpiresIn: int(mfaChallengeTTL.Seconds()),
+	EnrollmentRequired: totp == nil,
+  }, nil
+}
+
+// BeginChallengeEnrollment lets an account that must use 2FA but hasn't set
+// it up enroll halfway through logging in, having proved the password.
+func (s *MFAService) BeginChallengeEnrollment(ctx context.Context, req dto.MFALoginEnrollmentRequest) (*dto.MFAEnrollmentResponse, error) {
+  userID, err := s.repo.GetChallengeUserID(ctx, hashToken(req.MFAToken), mfaChallengeAttempts)
+  if err != nil {
+	if errors.Is(err, repository.ErrChallengeNotFound) {
+	  return nil, ErrInvalidMFAChallenge
+	}
+	return nil, err
+  }
+
+  return s.BeginEnrollment(ctx, userID)
+}
+
+// CompleteChallenge checks the code sent for a challenge and uses the
+// challenge up, returning its user. For an account enrolling during login the
+// code confirms the enrollment, and the new recovery codes are returned too.
+// Each challenge only takes a few attempts, and the account a few more over
+// all its recent challenges.
+func (s *MFAService) CompleteChallenge(ctx context.Context, req dto.MFALoginRequest) (string, []string, error) {
+  tokenHash := hashToken(req.MFAToken)
+  userID, err := s.repo.RecordChallengeAttempt(ctx, tokenHash, mfaChallengeAttempts)
+  if err != nil {
+	if errors.Is(err, repository.ErrChallengeNotFound) {
+	  return "", nil, ErrInvalidMFAChallenge
+	}
+	return "", nil, err
+  }
+
+  // The attempt just recorded is part of the count.
+  attempts, err := s.repo.CountChallengeAttempts(ctx, userID, time.Now().Add(-mfaAttemptWindow))
+  if err != nil {
+	return "", nil, err
+  }
+  if attempts > mfaAccountAttempts {
+	return "", nil, ErrTooManyMFAAttempts
+  }
+
+  totp, err := s.enabledTOTP(ctx, userID)
+  if err != nil {
+	return "", nil, err
+  }
+
+  var recoveryCodes []string
+  if totp != nil {
+	err = s.verifyCode(ctx, userID, req.Code)
+  } else {
+	var confirmed *dto.MFARecoveryCodesResponse
+	confirmed, err = s.ConfirmEnrollment(ctx, userID, dto.MFACodeRequest{Code: req.Code})
+	if confirmed != nil {
+	  recoveryCodes = confirmed.RecoveryCodes
+	}
+  }
+  if err != nil {
+	return "", nil, err
+  }
+
+  if err := s.repo.ConsumeChallenge(ctx, tokenHash); err != nil {
+	if errors.Is(err, repository.ErrChallengeNotFound) {
+	  return "", nil, ErrInvalidMFAChallenge
+	}
+	return "", nil, err
+  }
+
+  return userID, recoveryCodes, nil
+}
+
+func (s *MFAService) PurgeExpired(ctx context.Context) error {
+  // Expired challenges still count towards the account's attempts for a
+  // while.
+  deleted, err := s.repo.DeleteExpiredChallenges(ctx, time.Now().Add(-mfaAttemptWindow))
+  if err != nil {
+	return err
+  }
+
+  if deleted > 0 {
+	log.Printf("purged %d expired mfa challenges", deleted)
+  }
+
+  return nil
+}
+
+// verifyCode accepts a code from the authenticator app, each at most once, or
+// failing that an unused recovery code, which is then spent.
+func (s *MFAService) verifyCode(ctx context.Context, userID, code string) error {
+  totp, err := s.enabledTOTP(ctx, userID)
+  if err != nil {
+	return err
+  }
+  if totp == nil {
+	return ErrMFANotEnabled
+  }
+
+  code = strings.TrimSpace(code)
+  if step, ok := auth.ValidateTOTP(totp.Secret, code, time.Now()); ok {
+	if err := s.repo.UseTOTPStep(ctx, userID, step); err != nil {
+	  if errors.Is(err, repository.ErrTOTPStepUsed) {
+		return ErrInvalidMFACode
+	  }
+	  return err
+	}
+	return nil
+  }
+
+  if err := s.repo.UseRecoveryCode(ctx, userID, hashToken(auth.NormalizeRecoveryCode(code))); err != nil {
+	if errors.Is(err, repository.ErrRecoveryCodeNotFound) {
+	  return ErrInvalidMFACode
+	}
+	return err
+  }
+  return nil
+}
+
+func (s *MFAService) getUser(ctx context.Context, userID string) (*model.User, error) {
+  user, err := s.users.GetByID(ctx, userID)
+  if err != nil {
+	if errors.Is(err, repository.ErrUserNotFound) {
+	  return nil, ErrUserNotFound
+	}
+	return nil, err
+  }
+  return user, nil
+}
+
+// newRecoveryCodes returns a fresh set of recovery codes and the hashes they
+// are stored as.
+func newRecoveryCodes() ([]string, []string, error) {
+  codes := make([]string, recoveryCodeCount)
+  hashes := make([]string, recoveryCodeCount)
+  for i := range codes {
+	code, err := auth.NewRecoveryCode()
+	if err != nil {
+	  return nil, nil, err
+	}
+	codes[i] = code
+	hashes[i] = hashToken(auth.NormalizeRecoveryCode(code))
+  }
+  return codes, hashes, nil
+}
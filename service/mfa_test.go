@@ -0,0 +1,382 @@
+package service
+
+import (
+  "bytes"
+  "context"
+  "encoding/base64"
+  "errors"
+  "image/png"
+  "strings"
+  "testing"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+)
+
+// fakeMFARepository keeps 2FA state in memory, with the same rules as the
+// SQL in repository.MFARepository.
+type fakeMFARepository struct {
+  totp map[string]*model.TOTP
+  recoveryCodes map[string]map[string]bool
+  challenges map[string]*fakeChallenge
+}
+
+type fakeChallenge struct {
+  userID string
+  attempts int
+  expiresAt time.Time
+  used bool
+  createdAt time.Time
+}
+
+func newFakeMFARepository() *fakeMFARepository {
+  return &fakeMFARepository{
+	totp: map[string]*model.TOTP{},
+	recoveryCodes: map[string]map[string]bool{},
+	challenges: map[string]*fakeChallenge{},
+  }
+}
+
+func (r *fakeMFARepository) GetTOTP(ctx context.Context, userID string) (*model.TOTP, error) {
+  totp, ok := r.totp[userID]
+  if !ok {
+	return nil, repository.ErrTOTPNotFound
+  }
+  copied := *totp
+  return &copied, nil
+}
+
+func (r *fakeMFARepository) SavePendingTOTP(ctx context.Context, userID, secret string) error {
+  if totp, ok := r.totp[userID]; ok && totp.ConfirmedAt != nil {
+	return repository.ErrTOTPAlreadyConfirmed
+  }
+  r.totp[userID] = &model.TOTP{UserID: userID, Secret: secret, CreatedAt: time.Now()}
+  return nil
+}
+
+func (r *fakeMFARepository) ConfirmTOTP(ctx context.Context, userID string, step int64, codeHashes []string) error {
+  totp, ok := r.totp[userID]
+  if !ok || totp.ConfirmedAt != nil {
+	return repository.ErrTOTPAlreadyConfirmed
+  }
+  now := time.Now()
+  totp.ConfirmedAt = &now
+  totp.LastUsedStep = step
+  return r.ReplaceRecoveryCodes(ctx, userID, codeHashes)
+}
+
+func (r *fakeMFARepository) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+  totp, ok := r.totp[userID]
+  if !ok || totp.ConfirmedAt == nil || totp.LastUsedStep >= step {
+	return repository.ErrTOTPStepUsed
+  }
+  totp.LastUsedStep = step
+  return nil
+}
+
+func (r *fakeMFARepository) DeleteTOTP(ctx context.Context, userID string) error {
+  if _, ok := r.totp[userID]; !ok {
+	return repository.ErrTOTPNotFound
+  }
+  delete(r.totp, userID)
+  delete(r.recoveryCodes, userID)
+  return nil
+}
+
+func (r *fakeMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+  codes := map[string]bool{}
+  for _, hash := range codeHashes {
+	codes[hash] = false
+  }
+  r.recoveryCodes[userID] = codes
+  return nil
+}
+
+func (r *fakeMFARepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) error {
+  used, ok := r.recoveryCodes[userID][codeHash]
+  if !ok || used {
+	return repository.ErrRecoveryCodeNotFound
+  }
+  r.recoveryCodes[userID][codeHash] = true
+  return nil
+}
+
+func (r *fakeMFARepository) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+  count := 0
+  for _, used := range r.recoveryCodes[userID] {
+	if !used {
+	  count++
+	}
+  }
+  return count, nil
+}
+
+func (r *fakeMFARepository) CreateChallenge(ctx context.Context, id, userID, tokenHash string, expiresAt time.Time) error {
+  r.challenges[tokenHash] = &fakeChallenge{userID: userID, expiresAt: expiresAt, createdAt: time.Now()}
+  return nil
+}
+
+func (r *fakeMFARepository) CountChallengeAttempts(ctx context.Context, userID string, since time.Time) (int, error) {
+  attempts := 0
+  for _, c := range r.challenges {
+	if c.userID == userID && c.createdAt.After(since) {
+	  attempts += c.attempts
+	}
+  }
+  return attempts, nil
+}
+
+func (r *fakeMFARepository) openChallenge(tokenHash string, maxAttempts int) (*fakeChallenge, error) {
+  c, ok := r.challenges[tokenHash]
+  if !ok || c.used || !c.expiresAt.After(time.Now()) || c.attempts >= maxAttempts {
+	return nil, repository.ErrChallengeNotFound
+  }
+  return c, nil
+}
+
+func (r *fakeMFARepository) GetChallengeUserID(ctx context.Context, tokenHash string, maxAttempts int) (string, error) {
+  c, err := r.openChallenge(tokenHash, maxAttempts)
+  if err != nil {
+	return "", err
+  }
+  return c.userID, nil
+}
+
+func (r *fakeMFARepository) RecordChallengeAttempt(ctx context.Context, tokenHash string, maxAttempts int) (string, error) {
+  c, err := r.openChallenge(tokenHash, maxAttempts)
+  if err != nil {
+	return "", err
+  }
+  c.attempts++
+  return c.userID, nil
+}
+
+func (r *fakeMFARepository) ConsumeChallenge(ctx context.Context, tokenHash string) error {
+  c, ok := r.challenges[tokenHash]
+  if !ok || c.used {
+	return repository.ErrChallengeNotFound
+  }
+  c.used = true
+  return nil
+}
+
+func (r *fakeMFARepository) DeleteExpiredChallenges(ctx context.Context, before time.Time) (int64, error) {
+  var deleted int64
+  for hash, c := range r.challenges {
+	if c.expiresAt.Before(before) {
+	  delete(r.challenges, hash)
+	  deleted++
+	}
+  }
+  return deleted, nil
+}
+
+type fakeMFAUserRepository map[string]*model.User
+
+func (r fakeMFAUserRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
+  user, ok := r[id]
+  if !ok {
+	return nil, repository.ErrUserNotFound
+  }
+  return user, nil
+}
+
+var (
+  testAdmin = &model.User{ID: "admin-1", Email: "admin@example.com", Role: model.Admin}
+  testCustomer = &model.User{ID: "customer-1", Email: "customer@example.com", Role: model.Customer}
+)
+
+func newTestMFAService(policy model.MFAPolicy) (*MFAService, *fakeMFARepository) {
+  repo := newFakeMFARepository()
+  users := fakeMFAUserRepository{testAdmin.ID: testAdmin, testCustomer.ID: testCustomer}
+  return NewMFAService(repo, users, policy, "Shop"), repo
+}
+
+// enableTOTP turns 2FA on for the user and returns the secret and its
+// recovery codes.
+func enableTOTP(t *testing.T, s *MFAService, userID string) (string, []string) {
+  t.Helper()
+  ctx := context.Background()
+
+  enrollment, err := s.BeginEnrollment(ctx, userID)
+  if err != nil {
+	t.Fatal(err)
+  }
+  code, err := auth.TOTPCode(enrollment.Secret, auth.TOTPStep(time.Now()))
+  if err != nil {
+	t.Fatal(err)
+  }
+  confirmed, err := s.ConfirmEnrollment(ctx, userID, dto.MFACodeRequest{Code: code})
+  if err != nil {
+	t.Fatal(err)
+  }
+  return enrollment.Secret, confirmed.RecoveryCodes
+}
+
+func TestMFAChallengeForAdminWithout2FA(t *testing.T) {
+  ctx := context.Background()
+  s, _ := newTestMFAService(model.MFARequiredForAdmins)
+
+  challenge, err := s.Challenge(ctx, testAdmin)
+  if err != nil {
+	t.Fatal(err)
+  }
+  // A challenge instead of a session: the password alone doesn't sign in.
+  if challenge == nil || !challenge.EnrollmentRequired {
+	t.Fatalf("got challenge %+v, want one requiring enrollment", challenge)
+  }
+
+  _, _, err = s.CompleteChallenge(ctx, dto.MFALoginRequest{MFAToken: challenge.MFAToken, Code: "123456"})
+  if !errors.Is(err, ErrMFANotEnrolled) {
+	t.Errorf("completing without enrolling: got %v, want %v", err, ErrMFANotEnrolled)
+  }
+
+  if err := s.Disable(ctx, testAdmin.ID, dto.MFACodeRequest{Code: "123456"}); !errors.Is(err, ErrMFARequired) {
+	t.Errorf("disabling 2FA: got %v, want %v", err, ErrMFARequired)
+  }
+
+  challenge, err = s.Challenge(ctx, testCustomer)
+  if err != nil {
+	t.Fatal(err)
+  }
+  if challenge != nil {
+	t.Errorf("customer without 2FA got challenge %+v", challenge)
+  }
+}
+
+func TestMFAAdminWithout2FASignsInUnderOptionalPolicy(t *testing.T) {
+  s, _ := newTestMFAService(model.MFAOptional)
+
+  challenge, err := s.Challenge(context.Background(), testAdmin)
+  if err != nil {
+	t.Fatal(err)
+  }
+  if challenge != nil {
+	t.Errorf("got challenge %+v, want none", challenge)
+  }
+}
+
+func TestMFAEnrollmentQRCodeIsPNG(t *testing.T) {
+  s, _ := newTestMFAService(model.MFAOptional)
+
+  enrollment, err := s.BeginEnrollment(context.Background(), testCustomer.ID)
+  if err != nil {
+	t.Fatal(err)
+  }
+
+  data, ok := strings.CutPrefix(enrollment.QRCode, "data:image/png;base64,")
+  if !ok {
+	t.Fatalf("QR code %.40q... isn't a PNG data URL", enrollment.QRCode)
+  }
+  raw, err := base64.StdEncoding.DecodeString(data)
+  if err != nil {
+	t.Fatal(err)
+  }
+  img, err := png.Decode(bytes.NewReader(raw))
+  if err != nil {
+	t.Fatal(err)
+  }
+  // At mfaQRScale pixels per module, even the smallest code is wider than this.
+  if width := img.Bounds().Dx(); width < 21*mfaQRScale {
+	t.Errorf("QR code is %d pixels wide, want at least %d", width, 21*mfaQRScale)
+  }
+}
+
+func TestMFARecoveryCodeIsSingleUse(t *testing.T) {
+  ctx := context.Background()
+  s, _ := newTestMFAService(model.MFAOptional)
+  _, recoveryCodes := enableTOTP(t, s, testCustomer.ID)
+
+  login := func() error {
+	challenge, err := s.Challenge(ctx, testCustomer)
+	if err != nil {
+	  t.Fatal(err)
+	}
+	_, _, err = s.CompleteChallenge(ctx, dto.MFALoginRequest{MFAToken: challenge.MFAToken, Code: recoveryCodes[0]})
+	return err
+  }
+
+  if err := login(); err != nil {
+	t.Fatalf("first use of the recovery code: %v", err)
+  }
+  if err := login(); !errors.Is(err, ErrInvalidMFACode) {
+	t.Errorf("second use of the recovery code: got %v, want %v", err, ErrInvalidMFACode)
+  }
+
+  status, err := s.Status(ctx, testCustomer.ID)
+  if err != nil {
+	t.Fatal(err)
+  }
+  if status.RecoveryCodesRemaining != recoveryCodeCount-1 {
+	t.Errorf("got %d recovery codes left, want %d", status.RecoveryCodesRemaining, recoveryCodeCount-1)
+  }
+}
+
+func TestMFAChallengeIsSingleUse(t *testing.T) {
+  ctx := context.Background()
+  s, _ := newTestMFAService(model.MFAOptional)
+  _, recoveryCodes := enableTOTP(t, s, testCustomer.ID)
+
+  challenge, err := s.Challenge(ctx, testCustomer)
+  if err != nil {
+	t.Fatal(err)
+  }
+
+  req := dto.MFALoginRequest{MFAToken: challenge.MFAToken, Code: recoveryCodes[0]}
+  if _, _, err := s.CompleteChallenge(ctx, req); err != nil {
+	t.Fatal(err)
+  }
+  req.Code = recoveryCodes[1]
+  if _, _, err := s.CompleteChallenge(ctx, req); !errors.Is(err, ErrInvalidMFAChallenge) {
+	t.Errorf("got %v, want %v", err, ErrInvalidMFAChallenge)
+  }
+}
+
+func TestMFAAccountAttemptCap(t *testing.T) {
+  ctx := context.Background()
+  s, _ := newTestMFAService(model.MFAOptional)
+  secret, _ := enableTOTP(t, s, testCustomer.ID)
+
+  // Handed out before the account ran out of attempts.
+  early, err := s.Challenge(ctx, testCustomer)
+  if err != nil {
+	t.Fatal(err)
+  }
+
+  for guessed := 0; guessed < mfaAccountAttempts; {
+	challenge, err := s.Challenge(ctx, testCustomer)
+	if err != nil {
+	  t.Fatalf("after %d wrong codes: %v", guessed, err)
+	}
+	for i := 0; i < mfaChallengeAttempts && guessed < mfaAccountAttempts; i++ {
+	  _, _, err := s.CompleteChallenge(ctx, dto.MFALoginRequest{MFAToken: challenge.MFAToken, Code: "000000"})
+	  if !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("wrong code %d: got %v, want %v", guessed+1, err, ErrInvalidMFACode)
+	  }
+	  guessed++
+	}
+  }
+
+  if _, err := s.Challenge(ctx, testCustomer); !errors.Is(err, ErrTooManyMFAAttempts) {
+	t.Errorf("new challenge: got %v, want %v", err, ErrTooManyMFAAttempts)
+  }
+
+  // Not even the right code gets through an earlier challenge.
+  code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now())+1)
+  if err != nil {
+	t.Fatal(err)
+  }
+  _, _, err = s.CompleteChallenge(ctx, dto.MFALoginRequest{MFAToken: early.MFAToken, Code: code})
+  if !errors.Is(err, ErrTooManyMFAAttempts) {
+	t.Errorf("earlier challenge: got %v, want %v", err, ErrTooManyMFAAttempts)
+  }
+
+  // Other accounts are unaffected.
+  enableTOTP(t, s, testAdmin.ID)
+  if _, err := s.Challenge(ctx, testAdmin); err != nil {
+	t.Errorf("other account: %v", err)
+  }
+}
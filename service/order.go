@@ -2,49 +2,430 @@ package service
 
 import (
   "fmt"
+  "math"
   "time"
   "context"
   "errors"
+  "strings"
 
   "github.com/F-Dupraz/ecommerce-with-go/dto"
   "github.com/F-Dupraz/ecommerce-with-go/model"
   "github.com/F-Dupraz/ecommerce-with-go/repository"
 
-  "golang.org/x/crypto/bcrypt"
   "github.com/google/uuid"
 )
 
-type OrderRepository interface {}
+var (
+  ErrOrderNotFound = errors.New("order not found")
+  ErrInvalidStatusTransition = errors.New("invalid order status transition")
+  ErrOrderNotCancellable = errors.New("order can no longer be cancelled")
+  ErrOrderStatusChanged = errors.New("order status changed, try again")
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+// Cancelled, refunded and failed orders are final.
+var orderTransitions = map[model.OrderStatus][]model.OrderStatus{
+  model.OrderStatusPending: {model.OrderStatusPaid, model.OrderStatusCancelled, model.OrderStatusFailed},
+  model.OrderStatusPaid: {model.OrderStatusProcessing, model.OrderStatusShipped, model.OrderStatusCancelled, model.OrderStatusRefunded},
+  model.OrderStatusProcessing: {model.OrderStatusShipped, model.OrderStatusCancelled, model.OrderStatusRefunded},
+  model.OrderStatusShipped: {model.OrderStatusDelivered, model.OrderStatusRefunded},
+  model.OrderStatusDelivered: {model.OrderStatusRefunded},
+}
+
+type OrderRepository interface {
+  CreateWithReservation(ctx context.Context, order *model.Order, items []*model.OrderItem, allocate repository.AllocateFunc) ([]*model.StockAllocation, error)
+  GetByID(ctx context.Context, id string) (*model.Order, error)
+  ListItems(ctx context.Context, orderID string) ([]*model.OrderItem, error)
+  UpdateStatus(ctx context.Context, id string, from, to model.OrderStatus, stock repository.OrderStockFunc) (*model.Order, error)
+  List(ctx context.Context, filter repository.OrderFilter, sortBy, sortOrder string, page repository.Page) ([]*model.Order, repository.PageInfo, error)
+}
+
+type WarehouseLister interface {
+  List(ctx context.Context, activeOnly bool) ([]*model.Warehouse, error)
+}
+
+type OrderUserGetter interface {
+  GetByID(ctx context.Context, id string) (*model.User, error)
+}
 
 type OrderService struct {
   repo OrderRepository
+  products ProductGetter
+  variants VariantRepository
+  warehouses WarehouseLister
+  users OrderUserGetter
+  allocation model.AllocationStrategy
+  verification model.EmailVerificationPolicy
 }
 
-func NewOrderService(repo OrderRepository) *OrderService {
+func NewOrderService(repo OrderRepository, products ProductGetter, variants VariantRepository, warehouses WarehouseLister, users OrderUserGetter, allocation model.AllocationStrategy, verification model.EmailVerificationPolicy) *OrderService {
   return &OrderService{
 	repo: repo,
+	products: products,
+	variants: variants,
+	warehouses: warehouses,
+	users: users,
+	allocation: allocation,
+	verification: verification,
   }
 }
 
+// CreateOrder prices every line at its variant price (or the product price
+// when there is no variant) and reserves the stock of the exact variant
+// bought, at the warehouses picked by the allocation strategy for the
+// shipping country. The reservation and the order are written atomically.
+// Under EmailVerificationForCheckout the buyer's email must be verified.
 func (s *OrderService) CreateOrder(ctx context.Context, req *dto.CreateOrderRequest) (*dto.CreateOrderResponse, error) {
-  
+  if s.verification == model.EmailVerificationForCheckout {
+	user, err := s.users.GetByID(ctx, req.UserID)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.EmailVerifiedAt == nil {
+	  return nil, ErrEmailNotVerified
+	}
+  }
+
+  orderID := uuid.New().String()
+
+  items := make([]*model.OrderItem, 0, len(req.Items))
+  var subtotal int64
+  for _, input := range req.Items {
+	line, err := resolveSaleLine(ctx, s.products, s.variants, input.ProductID, input.VariantID)
+	if err != nil {
+	  return nil, err
+	}
+
+	if line.available() < input.Quantity {
+	  return nil, ErrInsufficientStock
+	}
+
+	unitPrice := toCents(line.unitPrice())
+	lineTotal := unitPrice * int64(input.Quantity)
+	subtotal += lineTotal
+
+	items = append(items, &model.OrderItem{
+	  ID: uuid.New().String(),
+	  OrderID: orderID,
+	  ProductID: &input.ProductID,
+	  VariantID: input.VariantID,
+	  ProductSKU: line.sku(),
+	  ProductName: line.name(),
+	  ProductImage: line.image(),
+	  UnitPrice: unitPrice,
+	  Quantity: input.Quantity,
+	  SubtotalAmount: lineTotal,
+	  TotalAmount: lineTotal,
+	})
+  }
+
+  order := model.Order{
+	ID: orderID,
+	OrderNumber: newOrderNumber(),
+	UserID: req.UserID,
+	Status: model.OrderStatusPending,
+	SubtotalAmount: subtotal,
+	TotalAmount: subtotal,
+	PaymentMethod: req.PaymentMethod,
+	ShippingMethod: req.ShippingMethod,
+	ShippingAddress: req.ShippingAddress,
+	ShippingCity: req.ShippingCity,
+	ShippingCountry: req.ShippingCountry,
+  }
+
+  warehouses, err := s.warehouses.List(ctx, true)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list warehouses: %w", err)
+  }
+  ranked := rankWarehouses(warehouses, req.ShippingCountry)
+
+  lines := make([]allocationRequest, len(items))
+  for i, item := range items {
+	lines[i] = allocationRequest{
+	  itemID: item.ID,
+	  productID: *item.ProductID,
+	  variantID: item.VariantID,
+	  quantity: item.Quantity,
+	}
+  }
+
+  allocations, err := s.repo.CreateWithReservation(ctx, &order, items, func(stock []*model.WarehouseStock) ([]*model.StockAllocation, error) {
+	return allocateStock(s.allocation, lines, ranked, stock)
+  })
+  if err != nil {
+	if errors.Is(err, ErrInsufficientStock) || errors.Is(err, repository.ErrInsufficientStock) {
+	  return nil, ErrInsufficientStock
+	}
+	return nil, fmt.Errorf("failed to create order: %w", err)
+  }
+
+  allocationResponses := make([]dto.StockAllocationResponse, len(allocations))
+  for i, a := range allocations {
+	allocationResponses[i] = dto.StockAllocationResponse{
+	  OrderItemID: a.OrderItemID,
+	  WarehouseID: a.WarehouseID,
+	  ProductID: a.ProductID,
+	  VariantID: a.VariantID,
+	  Quantity: a.Quantity,
+	}
+  }
+
+  response := toOrderResponse(&order, items)
+  return &dto.CreateOrderResponse{
+	ID: order.ID,
+	OrderNumber: order.OrderNumber,
+	Order: response,
+	Allocations: allocationResponses,
+	Message: "Order created successfully",
+  }, nil
 }
 
+// ListOrders returns a page of orders. Callers scope customers to their own
+// orders through req.UserID.
 func (s *OrderService) ListOrders(ctx context.Context, req dto.ListOrdersRequest) (*dto.ListOrdersResponse, error) {
-  
+  filter := repository.OrderFilter{
+	UserID: req.UserID,
+	Status: req.Status,
+	DateFrom: req.DateFrom,
+	DateTo: req.DateTo,
+	MinAmount: req.MinAmount,
+	MaxAmount: req.MaxAmount,
+	OrderNumber: req.OrderNumber,
+  }
+
+  orders, info, err := s.repo.List(ctx, filter, req.SortBy, req.SortOrder, newPage(req.Limit, req.Offset, req.Cursor, req.Total))
+  if err != nil {
+	return nil, listError(err, "orders")
+  }
+
+  responses := make([]dto.OrderResponse, len(orders))
+  for i, o := range orders {
+	responses[i] = *toOrderResponse(o, nil)
+  }
+
+  return &dto.ListOrdersResponse{
+	Orders: responses,
+	Limit: req.Limit,
+	Offset: req.Offset,
+	PageInfo: toPageInfo(info),
+  }, nil
 }
 
 func (s *OrderService) GetOrderByID(ctx context.Context, orderID string, includeItems bool) (*dto.OrderResponse, error) {
-  
+  order, err := s.getOrder(ctx, orderID)
+  if err != nil {
+	return nil, err
+  }
+
+  var items []*model.OrderItem
+  if includeItems {
+	items, err = s.repo.ListItems(ctx, order.ID)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+  }
+
+  return toOrderResponse(order, items), nil
 }
 
+// UpdateOrderStatus moves an order along its lifecycle. Shipping turns the
+// units reserved for it into a sale, and cancelling, failing or refunding an
+// order before it ships releases them. Refunding a shipped order leaves stock
+// alone, since the goods may never come back; CancelOrder can restock them.
 func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID string, req *dto.UpdateOrderStatusRequest) (*dto.UpdateOrderStatusResponse, error) {
-  
+  order, err := s.getOrder(ctx, orderID)
+  if err != nil {
+	return nil, err
+  }
+
+  if !canTransition(order.Status, req.Status) {
+	return nil, ErrInvalidStatusTransition
+  }
+
+  reason := fmt.Sprintf("order %s %s", order.OrderNumber, req.Status)
+  if req.InternalNotes != "" {
+	reason += ": " + req.InternalNotes
+  }
+
+  updated, err := s.updateStatus(ctx, order, req.Status, orderStockMovement(order.Status, req.Status, false, reason, req.ActorID))
+  if err != nil {
+	return nil, err
+  }
+
+  return &dto.UpdateOrderStatusResponse{
+	Order: toOrderResponse(updated, nil),
+	OldStatus: order.Status,
+	NewStatus: updated.Status,
+	Message: "Order status updated successfully",
+  }, nil
 }
 
+// CancelOrder cancels an order that hasn't shipped yet, releasing the units
+// reserved for it. An order that already shipped is refunded instead, and its
+// units are put back in stock when req.RestockItems says the goods came back.
 func (s *OrderService) CancelOrder(ctx context.Context, orderID string, req *dto.CancelOrderRequest) (*dto.CancelOrderResponse, error) {
-  
+  order, err := s.getOrder(ctx, orderID)
+  if err != nil {
+	return nil, err
+  }
+
+  status := model.OrderStatusCancelled
+  if order.Status == model.OrderStatusShipped || order.Status == model.OrderStatusDelivered {
+	status = model.OrderStatusRefunded
+  }
+  if !canTransition(order.Status, status) {
+	return nil, ErrOrderNotCancellable
+  }
+
+  reason := fmt.Sprintf("order %s %s: %s", order.OrderNumber, status, req.Reason)
+  updated, err := s.updateStatus(ctx, order, status, orderStockMovement(order.Status, status, req.RestockItems, reason, req.ActorID))
+  if err != nil {
+	return nil, err
+  }
+
+  response := &dto.CancelOrderResponse{
+	OrderID: updated.ID,
+	Message: "Order cancelled successfully",
+	CancelledAt: updated.UpdatedAt,
+  }
+  if updated.CancelledAt != nil {
+	response.CancelledAt = *updated.CancelledAt
+  }
+  if status == model.OrderStatusRefunded {
+	response.RefundStatus = string(model.OrderStatusRefunded)
+	response.Message = "Order refunded successfully"
+  }
+
+  return response, nil
+}
+
+func (s *OrderService) getOrder(ctx context.Context, orderID string) (*model.Order, error) {
+  order, err := s.repo.GetByID(ctx, orderID)
+  if err != nil {
+	if errors.Is(err, repository.ErrOrderNotFound) {
+	  return nil, ErrOrderNotFound
+	}
+	return nil, fmt.Errorf("failed to get order: %w", err)
+  }
+
+  return order, nil
+}
+
+func (s *OrderService) updateStatus(ctx context.Context, order *model.Order, status model.OrderStatus, stock repository.OrderStockFunc) (*model.Order, error) {
+  updated, err := s.repo.UpdateStatus(ctx, order.ID, order.Status, status, stock)
+  if err != nil {
+	switch {
+	case errors.Is(err, repository.ErrOrderStatusChanged):
+	  return nil, ErrOrderStatusChanged
+	case errors.Is(err, repository.ErrInsufficientStock):
+	  return nil, ErrInsufficientStock
+	}
+	return nil, fmt.Errorf("failed to update order status: %w", err)
+  }
+
+  return updated, nil
 }
 
+// Helpers
 
+func newOrderNumber() string {
+  suffix := strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:8])
+  return fmt.Sprintf("ORD-%s-%s", time.Now().UTC().Format("20060102"), suffix)
+}
+
+func canTransition(from, to model.OrderStatus) bool {
+  for _, status := range orderTransitions[from] {
+	if status == to {
+	  return true
+	}
+  }
+  return false
+}
+
+func isShipped(status model.OrderStatus) bool {
+  return status == model.OrderStatusShipped || status == model.OrderStatusDelivered
+}
 
+// orderStockMovement returns what moving an order from one status to another
+// does to the stock allocated to it, or nil when it does nothing. Before an
+// order ships its units are only reserved; shipping takes them out of stock.
+func orderStockMovement(from, to model.OrderStatus, restock bool, reason, actorID string) repository.OrderStockFunc {
+  var typ model.StockMovementType
+  var stock, reserved int
+
+  switch {
+  case to == model.OrderStatusShipped && !isShipped(from):
+	typ, stock, reserved = model.StockMovementSale, -1, -1
+  case to == model.OrderStatusCancelled:
+	typ, reserved = model.StockMovementCancellation, -1
+  case to == model.OrderStatusFailed:
+	typ, reserved = model.StockMovementRelease, -1
+  case to == model.OrderStatusRefunded && !isShipped(from):
+	typ, reserved = model.StockMovementRefund, -1
+  case to == model.OrderStatusRefunded && restock:
+	typ, stock = model.StockMovementRefund, 1
+  default:
+	return nil
+  }
+
+  return func(a *model.StockAllocation) *model.StockMovement {
+	m := newStockMovement(a.ProductID, a.VariantID, a.WarehouseID, stock*a.Quantity, typ, reason, actorID)
+	m.ReservedQuantity = reserved * a.Quantity
+	m.OrderID = &a.OrderID
+	return m
+  }
+}
+
+// Order amounts are stored in cents while catalog prices are decimals.
+func toCents(amount float64) int64 {
+  return int64(math.Round(amount * 100))
+}
+
+func fromCents(amount int64) float64 {
+  return float64(amount) / 100
+}
+
+func toOrderResponse(o *model.Order, items []*model.OrderItem) *dto.OrderResponse {
+  response := &dto.OrderResponse{
+	ID: o.ID,
+	OrderNumber: o.OrderNumber,
+	UserID: o.UserID,
+	Status: o.Status,
+	SubtotalAmount: fromCents(o.SubtotalAmount),
+	TaxAmount: fromCents(o.TaxAmount),
+	ShippingAmount: fromCents(o.ShippingAmount),
+	TotalAmount: fromCents(o.TotalAmount),
+	PaymentMethod: o.PaymentMethod,
+	PaymentID: o.PaymentID,
+	PaidAt: o.PaidAt,
+	ShippingMethod: o.ShippingMethod,
+	ShippingAddress: o.ShippingAddress,
+	ShippingCity: o.ShippingCity,
+	ShippingCountry: o.ShippingCountry,
+	TrackingNumber: o.TrackingNumber,
+	TrackingURL: o.TrackingURL,
+	EstimatedDelivery: o.EstimatedDelivery,
+	DeliveredAt: o.DeliveredAt,
+	CreatedAt: o.CreatedAt,
+	UpdatedAt: o.UpdatedAt,
+	CancelledAt: o.CancelledAt,
+  }
+
+  for _, i := range items {
+	response.Items = append(response.Items, dto.OrderItemResponse{
+	  ID: i.ID,
+	  OrderID: i.OrderID,
+	  ProductID: i.ProductID,
+	  VariantID: i.VariantID,
+	  ProductSKU: i.ProductSKU,
+	  ProductName: i.ProductName,
+	  ProductImage: i.ProductImage,
+	  UnitPrice: fromCents(i.UnitPrice),
+	  Quantity: i.Quantity,
+	  SubtotalAmount: fromCents(i.SubtotalAmount),
+	  TotalAmount: fromCents(i.TotalAmount),
+	})
+  }
+
+  return response
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+  "testing"
+
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+)
+
+// stockEffect is what a status change does to each allocated unit.
+type stockEffect struct {
+  typ model.StockMovementType
+  stock int
+  reserved int
+}
+
+func TestOrderStockMovement(t *testing.T) {
+  var (
+	sale = &stockEffect{model.StockMovementSale, -1, -1}
+	cancellation = &stockEffect{model.StockMovementCancellation, 0, -1}
+	release = &stockEffect{model.StockMovementRelease, 0, -1}
+	unreserve = &stockEffect{model.StockMovementRefund, 0, -1}
+	restock = &stockEffect{model.StockMovementRefund, 1, 0}
+  )
+
+  // Every transition in orderTransitions, with the effect without and with
+  // restock.
+  tests := []struct {
+	from, to model.OrderStatus
+	want, wantRestock *stockEffect
+  }{
+	{model.OrderStatusPending, model.OrderStatusPaid, nil, nil},
+	{model.OrderStatusPending, model.OrderStatusCancelled, cancellation, cancellation},
+	{model.OrderStatusPending, model.OrderStatusFailed, release, release},
+	{model.OrderStatusPaid, model.OrderStatusProcessing, nil, nil},
+	{model.OrderStatusPaid, model.OrderStatusShipped, sale, sale},
+	{model.OrderStatusPaid, model.OrderStatusCancelled, cancellation, cancellation},
+	{model.OrderStatusPaid, model.OrderStatusRefunded, unreserve, unreserve},
+	{model.OrderStatusProcessing, model.OrderStatusShipped, sale, sale},
+	{model.OrderStatusProcessing, model.OrderStatusCancelled, cancellation, cancellation},
+	{model.OrderStatusProcessing, model.OrderStatusRefunded, unreserve, unreserve},
+	{model.OrderStatusShipped, model.OrderStatusDelivered, nil, nil},
+	{model.OrderStatusShipped, model.OrderStatusRefunded, nil, restock},
+	{model.OrderStatusDelivered, model.OrderStatusRefunded, nil, restock},
+  }
+
+  covered := map[[2]model.OrderStatus]bool{}
+  for _, tt := range tests {
+	covered[[2]model.OrderStatus{tt.from, tt.to}] = true
+  }
+  for from, tos := range orderTransitions {
+	for _, to := range tos {
+	  if !covered[[2]model.OrderStatus{from, to}] {
+		t.Errorf("transition %s -> %s isn't tested", from, to)
+	  }
+	}
+  }
+
+  variant := "blue"
+  allocation := &model.StockAllocation{OrderID: "order-1", WarehouseID: "madrid", ProductID: "product-1", VariantID: &variant, Quantity: 3}
+
+  for _, tt := range tests {
+	for _, withRestock := range []bool{false, true} {
+	  want := tt.want
+	  if withRestock {
+		want = tt.wantRestock
+	  }
+
+	  movement := orderStockMovement(tt.from, tt.to, withRestock, "status change", "admin-1")
+	  if want == nil {
+		if movement != nil {
+		  t.Errorf("%s -> %s, restock %v: got %+v, want no movement", tt.from, tt.to, withRestock, movement(allocation))
+		}
+		continue
+	  }
+	  if movement == nil {
+		t.Errorf("%s -> %s, restock %v: got no movement, want %+v", tt.from, tt.to, withRestock, *want)
+		continue
+	  }
+
+	  m := movement(allocation)
+	  got := stockEffect{m.Type, m.Quantity / allocation.Quantity, m.ReservedQuantity / allocation.Quantity}
+	  if got != *want || m.Quantity%allocation.Quantity != 0 || m.ReservedQuantity%allocation.Quantity != 0 {
+		t.Errorf("%s -> %s, restock %v: got %s %+d/%+d reserved, want %s %+d/%+d per unit",
+		  tt.from, tt.to, withRestock, m.Type, m.Quantity, m.ReservedQuantity, want.typ, want.stock, want.reserved)
+	  }
+	  if m.OrderID == nil || *m.OrderID != allocation.OrderID || m.WarehouseID == nil || *m.WarehouseID != allocation.WarehouseID {
+		t.Errorf("%s -> %s: movement %+v isn't tied to the order's allocation", tt.from, tt.to, m)
+	  }
+	}
+  }
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+  "fmt"
+  "errors"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+)
+
+var (
+  ErrInvalidCursor = errors.New("invalid or expired cursor")
+)
+
+func newPage(limit, offset int, cursor, total string) repository.Page {
+  return repository.Page{
+	Limit: limit,
+	Offset: offset,
+	Cursor: cursor,
+	Total: repository.TotalMode(total),
+  }
+}
+
+func toPageInfo(info repository.PageInfo) dto.PageInfo {
+  return dto.PageInfo{
+	NextCursor: info.NextCursor,
+	HasMore: info.HasMore,
+	Total: info.Total,
+	TotalEstimated: info.TotalEstimated,
+  }
+}
+
+// listError maps the errors a paginated listing can fail with.
+func listError(err error, what string) error {
+  if errors.Is(err, repository.ErrInvalidCursor) {
+	return ErrInvalidCursor
+  }
+  return fmt.Errorf("failed to list %s: %w", what, err)
+}
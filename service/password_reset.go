@@ -0,0 +1,191 @@
+package service
+
+import (
+  "fmt"
+  "log"
+  "time"
+  "errors"
+  "context"
+  "net/url"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/notify"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+  "golang.org/x/crypto/bcrypt"
+)
+
+var (
+  ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+)
+
+const (
+  passwordResetTTL = 30 * time.Minute
+  // At most this many reset emails are sent to one account per window,
+  // whatever the client's IP.
+  passwordResetLimit = 3
+  passwordResetWindow = time.Hour
+  // Spent tokens are kept a while so the per-account limit can count them.
+  passwordResetRetention = 24 * time.Hour
+  passwordResetTimeout = 30 * time.Second
+)
+
+type PasswordResetRepository interface {
+  Create(ctx context.Context, id, userID, tokenHash string, expiresAt time.Time) error
+  CountSince(ctx context.Context, userID string, since time.Time) (int, error)
+  GetUserID(ctx context.Context, tokenHash string) (string, error)
+  Consume(ctx context.Context, tokenHash, passwordHash string) (string, error)
+  DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PasswordResetUserRepository interface {
+  GetByEmail(ctx context.Context, email string) (*model.User, error)
+}
+
+type SessionInvalidator interface {
+  InvalidateByUserID(ctx context.Context, userID string) error
+}
+
+type PasswordResetService struct {
+  repo PasswordResetRepository
+  users PasswordResetUserRepository
+  sessions SessionInvalidator
+  revocations auth.RevocationStore
+  notifier notify.Notifier
+  resetURL string
+}
+
+// NewPasswordResetService sends reset links pointing at resetURL, the page of
+// the storefront that asks for the new password; the token is added as the
+// "token" query parameter.
+func NewPasswordResetService(repo PasswordResetRepository, users PasswordResetUserRepository, sessions SessionInvalidator, revocations auth.RevocationStore, notifier notify.Notifier, resetURL string) *PasswordResetService {
+  return &PasswordResetService{
+	repo: repo,
+	users: users,
+	sessions: sessions,
+	revocations: revocations,
+	notifier: notifier,
+	resetURL: resetURL,
+  }
+}
+
+// ForgotPassword sends a reset link if email belongs to an account. The work
+// happens after it returns, so neither the response nor its timing tells the
+// caller whether the account exists.
+func (s *PasswordResetService) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) *dto.ForgotPasswordResponse {
+  go func() {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), passwordResetTimeout)
+	defer cancel()
+
+	if err := s.sendResetLink(ctx, req.Email); err != nil {
+	  log.Printf("password reset: %v", err)
+	}
+  }()
+
+  return &dto.ForgotPasswordResponse{
+	Message: "If an account exists for this email, a link to reset the password has been sent",
+  }
+}
+
+func (s *PasswordResetService) sendResetLink(ctx context.Context, email string) error {
+  user, err := s.users.GetByEmail(ctx, email)
+  if err != nil {
+	if errors.Is(err, repository.ErrUserNotFound) {
+	  return nil
+	}
+	return err
+  }
+
+  sent, err := s.repo.CountSince(ctx, user.ID, time.Now().Add(-passwordResetWindow))
+  if err != nil {
+	return err
+  }
+  if sent >= passwordResetLimit {
+	log.Printf("password reset: limit reached for user %s, no email sent", user.ID)
+	return nil
+  }
+
+  token, err := auth.RandomToken(32)
+  if err != nil {
+	return err
+  }
+
+  if err := s.repo.Create(ctx, uuid.New().String(), user.ID, hashToken(token), time.Now().Add(passwordResetTTL)); err != nil {
+	return err
+  }
+
+  return s.notifier.Notify(ctx, s.resetMessage(user, token))
+}
+
+// ResetPassword sets a new password with a token from a reset link. Every
+// session of the user is signed out and every access token revoked, since
+// whoever knew the old password may be using them.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) (*dto.ResetPasswordResponse, error) {
+  tokenHash := hashToken(req.Token)
+
+  // Checked before hashing so invalid tokens don't cost a bcrypt round.
+  if _, err := s.repo.GetUserID(ctx, tokenHash); err != nil {
+	if errors.Is(err, repository.ErrResetTokenNotFound) {
+	  return nil, ErrInvalidResetToken
+	}
+	return nil, err
+  }
+
+  hashedPass, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), 12)
+  if err != nil {
+	return nil, fmt.Errorf("failed to hash password: %w", err)
+  }
+
+  userID, err := s.repo.Consume(ctx, tokenHash, string(hashedPass))
+  if err != nil {
+	if errors.Is(err, repository.ErrResetTokenNotFound) || errors.Is(err, repository.ErrUserNotFound) {
+	  return nil, ErrInvalidResetToken
+	}
+	return nil, err
+  }
+
+  if err := s.sessions.InvalidateByUserID(ctx, userID); err != nil {
+	return nil, err
+  }
+  if err := revokeUserTokens(ctx, s.revocations, userID); err != nil {
+	return nil, fmt.Errorf("failed to revoke tokens: %w", err)
+  }
+
+  return &dto.ResetPasswordResponse{
+	Message: "Password reset successfully, please log in with the new password",
+  }, nil
+}
+
+// PurgeExpired drops reset tokens that expired long enough ago that the
+// per-account limit no longer counts them.
+func (s *PasswordResetService) PurgeExpired(ctx context.Context) error {
+  deleted, err := s.repo.DeleteExpired(ctx, time.Now().Add(-passwordResetRetention))
+  if err != nil {
+	return err
+  }
+
+  if deleted > 0 {
+	log.Printf("purged %d expired password reset tokens", deleted)
+  }
+
+  return nil
+}
+
+func (s *PasswordResetService) resetMessage(user *model.User, token string) notify.Message {
+  link := s.resetURL + "?token=" + url.QueryEscape(token)
+
+  return notify.Message{
+	To: user.Email,
+	Kind: "password_reset",
+	Subject: "Reset your password",
+	Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. Use this link within %d minutes to choose a new one:\n\n%s\n\nIf you didn't ask for this, you can ignore this email, your password hasn't changed.",
+	  user.Username, int(passwordResetTTL.Minutes()), link),
+	Data: map[string]string{
+	  "user_id": user.ID,
+	  "link": link,
+	},
+  }
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+  "context"
+  "errors"
+  "testing"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+)
+
+// fakePasswordResetRepository keeps reset tokens in memory, with the same
+// rules as the SQL in repository.PasswordResetRepository.
+type fakePasswordResetRepository struct {
+  tokens map[string]*fakeResetToken
+  passwords map[string]string
+}
+
+type fakeResetToken struct {
+  userID string
+  expiresAt time.Time
+  used bool
+}
+
+func newFakePasswordResetRepository() *fakePasswordResetRepository {
+  return &fakePasswordResetRepository{
+	tokens: map[string]*fakeResetToken{},
+	passwords: map[string]string{},
+  }
+}
+
+func (r *fakePasswordResetRepository) Create(ctx context.Context, id, userID, tokenHash string, expiresAt time.Time) error {
+  r.tokens[tokenHash] = &fakeResetToken{userID: userID, expiresAt: expiresAt}
+  return nil
+}
+
+func (r *fakePasswordResetRepository) CountSince(ctx context.Context, userID string, since time.Time) (int, error) {
+  count := 0
+  for _, token := range r.tokens {
+	if token.userID == userID {
+	  count++
+	}
+  }
+  return count, nil
+}
+
+func (r *fakePasswordResetRepository) GetUserID(ctx context.Context, tokenHash string) (string, error) {
+  token, ok := r.tokens[tokenHash]
+  if !ok || token.used || !token.expiresAt.After(time.Now()) {
+	return "", repository.ErrResetTokenNotFound
+  }
+  return token.userID, nil
+}
+
+func (r *fakePasswordResetRepository) Consume(ctx context.Context, tokenHash, passwordHash string) (string, error) {
+  userID, err := r.GetUserID(ctx, tokenHash)
+  if err != nil {
+	return "", err
+  }
+  for _, token := range r.tokens {
+	if token.userID == userID {
+	  token.used = true
+	}
+  }
+  r.passwords[userID] = passwordHash
+  return userID, nil
+}
+
+func (r *fakePasswordResetRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+  var deleted int64
+  for hash, token := range r.tokens {
+	if token.expiresAt.Before(before) {
+	  delete(r.tokens, hash)
+	  deleted++
+	}
+  }
+  return deleted, nil
+}
+
+type fakeSessionInvalidator map[string]bool
+
+func (s fakeSessionInvalidator) InvalidateByUserID(ctx context.Context, userID string) error {
+  s[userID] = true
+  return nil
+}
+
+func newTestPasswordResetService() (*PasswordResetService, *fakePasswordResetRepository, fakeSessionInvalidator) {
+  repo := newFakePasswordResetRepository()
+  sessions := fakeSessionInvalidator{}
+  s := NewPasswordResetService(repo, nil, sessions, auth.NewMemoryRevocationStore(), nil, "https://shop.example.com/reset")
+  return s, repo, sessions
+}
+
+func resetRequest(token string) dto.ResetPasswordRequest {
+  return dto.ResetPasswordRequest{Token: token, NewPassword: "a new password 1", ConfirmPassword: "a new password 1"}
+}
+
+func TestResetPasswordTokenIsSingleUse(t *testing.T) {
+  ctx := context.Background()
+  s, repo, sessions := newTestPasswordResetService()
+  repo.Create(ctx, "reset-1", testCustomer.ID, hashToken("token"), time.Now().Add(passwordResetTTL))
+
+  if _, err := s.ResetPassword(ctx, resetRequest("token")); err != nil {
+	t.Fatal(err)
+  }
+  if repo.passwords[testCustomer.ID] == "" {
+	t.Error("password wasn't changed")
+  }
+  if !sessions[testCustomer.ID] {
+	t.Error("sessions weren't signed out")
+  }
+
+  repo.passwords = map[string]string{}
+  if _, err := s.ResetPassword(ctx, resetRequest("token")); !errors.Is(err, ErrInvalidResetToken) {
+	t.Errorf("reusing the token: got %v, want %v", err, ErrInvalidResetToken)
+  }
+  if repo.passwords[testCustomer.ID] != "" {
+	t.Error("reused token changed the password")
+  }
+}
+
+func TestResetPasswordRejectsExpiredToken(t *testing.T) {
+  ctx := context.Background()
+  s, repo, sessions := newTestPasswordResetService()
+  repo.Create(ctx, "reset-1", testCustomer.ID, hashToken("token"), time.Now().Add(-time.Minute))
+
+  if _, err := s.ResetPassword(ctx, resetRequest("token")); !errors.Is(err, ErrInvalidResetToken) {
+	t.Errorf("got %v, want %v", err, ErrInvalidResetToken)
+  }
+  if repo.passwords[testCustomer.ID] != "" {
+	t.Error("expired token changed the password")
+  }
+  if sessions[testCustomer.ID] {
+	t.Error("expired token signed the sessions out")
+  }
+}
+
+func TestResetPasswordRejectsUnknownToken(t *testing.T) {
+  s, _, _ := newTestPasswordResetService()
+
+  if _, err := s.ResetPassword(context.Background(), resetRequest("unknown")); !errors.Is(err, ErrInvalidResetToken) {
+	t.Errorf("got %v, want %v", err, ErrInvalidResetToken)
+  }
+}
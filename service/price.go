@@ -0,0 +1,243 @@
+package service
+
+import (
+  "fmt"
+  "log"
+  "time"
+  "errors"
+  "context"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+var (
+  ErrScheduledPriceNotFound = errors.New("scheduled price not found")
+  ErrScheduledPriceOverlap = errors.New("scheduled price overlaps another schedule")
+  ErrScheduledPriceNotCancellable = errors.New("scheduled price already finished")
+  ErrScheduleInPast = errors.New("scheduled price must end in the future")
+)
+
+// lowestPriceWindow is the look-back period for the lowest price shown next
+// to discounts, as required by the EU price indication rules.
+const lowestPriceWindow = 30 * 24 * time.Hour
+
+type PriceRepository interface {
+  ListChanges(ctx context.Context, productID string, limit, offset int) ([]*model.PriceChange, int, error)
+  LowestPricesSince(ctx context.Context, productIDs []string, since time.Time) (map[string]float64, error)
+  CreateSchedule(ctx context.Context, schedule *model.ScheduledPrice) error
+  ListSchedules(ctx context.Context, productID string) ([]*model.ScheduledPrice, error)
+  CancelSchedule(ctx context.Context, productID, scheduleID string) (*model.ScheduledPrice, error)
+  StartDueSchedules(ctx context.Context, now time.Time) (int, error)
+  EndDueSchedules(ctx context.Context, now time.Time) (int, error)
+}
+
+type PriceService struct {
+  repo PriceRepository
+  products ProductGetter
+}
+
+func NewPriceService(repo PriceRepository, products ProductGetter) *PriceService {
+  return &PriceService{
+	repo: repo,
+	products: products,
+  }
+}
+
+func (s *PriceService) GetPriceHistory(ctx context.Context, productID string, req dto.GetPriceHistoryRequest) (*dto.PriceHistoryResponse, error) {
+  product, err := findProduct(ctx, s.products, productID)
+  if err != nil {
+	return nil, err
+  }
+
+  changes, total, err := s.repo.ListChanges(ctx, productID, req.Limit, req.Offset)
+  if err != nil {
+	return nil, fmt.Errorf("failed to get price history: %w", err)
+  }
+
+  lowest, err := s.repo.LowestPricesSince(ctx, []string{productID}, time.Now().Add(-lowestPriceWindow))
+  if err != nil {
+	return nil, fmt.Errorf("failed to get lowest price: %w", err)
+  }
+
+  lowestPrice, ok := lowest[productID]
+  if !ok {
+	lowestPrice = product.Price
+  }
+
+  responses := make([]dto.PriceChangeResponse, len(changes))
+  for i, c := range changes {
+	responses[i] = dto.PriceChangeResponse{
+	  ID: c.ID,
+	  OldPrice: c.OldPrice,
+	  NewPrice: c.NewPrice,
+	  OldCostPrice: c.OldCostPrice,
+	  NewCostPrice: c.NewCostPrice,
+	  Source: c.Source,
+	  ChangedBy: c.ChangedBy,
+	  ScheduledPriceID: c.ScheduledPriceID,
+	  ChangedAt: c.ChangedAt,
+	}
+  }
+
+  return &dto.PriceHistoryResponse{
+	ProductID: productID,
+	CurrentPrice: product.Price,
+	LowestPrice30Days: lowestPrice,
+	Changes: responses,
+	Total: total,
+	Limit: req.Limit,
+	Offset: req.Offset,
+  }, nil
+}
+
+func (s *PriceService) ListScheduledPrices(ctx context.Context, productID string) (*dto.ListScheduledPricesResponse, error) {
+  if _, err := findProduct(ctx, s.products, productID); err != nil {
+	return nil, err
+  }
+
+  schedules, err := s.repo.ListSchedules(ctx, productID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list scheduled prices: %w", err)
+  }
+
+  responses := make([]dto.ScheduledPriceResponse, len(schedules))
+  for i, sp := range schedules {
+	responses[i] = toScheduledPriceResponse(sp)
+  }
+
+  return &dto.ListScheduledPricesResponse{
+	ProductID: productID,
+	Schedules: responses,
+  }, nil
+}
+
+func (s *PriceService) SchedulePrice(ctx context.Context, productID string, req *dto.CreateScheduledPriceRequest) (*dto.CreateScheduledPriceResponse, error) {
+  product, err := findProduct(ctx, s.products, productID)
+  if err != nil {
+	return nil, err
+  }
+
+  if req.Price <= product.CostPrice {
+	return nil, ErrInvalidPrice
+  }
+
+  if req.EndsAt != nil && !req.EndsAt.After(time.Now()) {
+	return nil, ErrScheduleInPast
+  }
+
+  schedule := model.ScheduledPrice{
+	ID: uuid.New().String(),
+	ProductID: productID,
+	Price: req.Price,
+	StartsAt: req.StartsAt,
+	EndsAt: req.EndsAt,
+	Status: model.ScheduledPriceStatusScheduled,
+	CreatedBy: req.CreatedBy,
+  }
+
+  if err := s.repo.CreateSchedule(ctx, &schedule); err != nil {
+	if errors.Is(err, repository.ErrNotFound) {
+	  return nil, ErrProductNotFound
+	}
+	if errors.Is(err, repository.ErrScheduledPriceOverlap) {
+	  return nil, ErrScheduledPriceOverlap
+	}
+	return nil, fmt.Errorf("failed to schedule price: %w", err)
+  }
+
+  response := toScheduledPriceResponse(&schedule)
+  return &dto.CreateScheduledPriceResponse{
+	ID: schedule.ID,
+	Schedule: &response,
+	Message: "Price change scheduled successfully",
+  }, nil
+}
+
+func (s *PriceService) CancelScheduledPrice(ctx context.Context, productID, scheduleID string) (*dto.CancelScheduledPriceResponse, error) {
+  if _, err := uuid.Parse(productID); err != nil {
+	return nil, ErrInvalidID
+  }
+  if _, err := uuid.Parse(scheduleID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  schedule, err := s.repo.CancelSchedule(ctx, productID, scheduleID)
+  if err != nil {
+	if errors.Is(err, repository.ErrScheduledPriceNotFound) {
+	  return nil, ErrScheduledPriceNotFound
+	}
+	if errors.Is(err, repository.ErrScheduledPriceNotCancellable) {
+	  return nil, ErrScheduledPriceNotCancellable
+	}
+	return nil, fmt.Errorf("failed to cancel scheduled price: %w", err)
+  }
+
+  message := "Scheduled price cancelled"
+  if schedule.Status == model.ScheduledPriceStatusActive {
+	message = "Scheduled price will be reverted shortly"
+  }
+
+  response := toScheduledPriceResponse(schedule)
+  return &dto.CancelScheduledPriceResponse{
+	Schedule: &response,
+	Message: message,
+  }, nil
+}
+
+// ApplyScheduledPrices ends finished schedules before starting new ones, so a
+// promotion that ends exactly when the next one begins hands over cleanly.
+// Meant to be run by the job scheduler.
+func (s *PriceService) ApplyScheduledPrices(ctx context.Context) error {
+  now := time.Now()
+
+  ended, err := drainBatches(func() (int, error) { return s.repo.EndDueSchedules(ctx, now) })
+  if err != nil {
+	return err
+  }
+
+  started, err := drainBatches(func() (int, error) { return s.repo.StartDueSchedules(ctx, now) })
+  if err != nil {
+	return err
+  }
+
+  if ended > 0 || started > 0 {
+	log.Printf("scheduled prices: %d started, %d ended", started, ended)
+  }
+
+  return nil
+}
+
+// drainBatches calls fn until it processes nothing and returns the total.
+func drainBatches(fn func() (int, error)) (int, error) {
+  total := 0
+  for {
+	n, err := fn()
+	if err != nil {
+	  return total, err
+	}
+	if n == 0 {
+	  return total, nil
+	}
+	total += n
+  }
+}
+
+func toScheduledPriceResponse(s *model.ScheduledPrice) dto.ScheduledPriceResponse {
+  return dto.ScheduledPriceResponse{
+	ID: s.ID,
+	ProductID: s.ProductID,
+	Price: s.Price,
+	PreviousPrice: s.PreviousPrice,
+	StartsAt: s.StartsAt,
+	EndsAt: s.EndsAt,
+	Status: s.Status,
+	CreatedBy: s.CreatedBy,
+	CreatedAt: s.CreatedAt,
+	AppliedAt: s.AppliedAt,
+	EndedAt: s.EndedAt,
+  }
+}
@@ -2,6 +2,8 @@ package service
 
 import (
   "fmt"
+  "log"
+  "time"
   "errors"
   "context"
 
@@ -23,13 +25,73 @@ var (
     ErrInvalidParams = errors.New("invalid parameters")
 )
 
+type ProductRepository interface {
+  CreateProduct(ctx context.Context, p *model.Product) error
+  GetProductByID(ctx context.Context, id string) (*model.Product, error)
+  GetBySKU(ctx context.Context, sku string) (*model.Product, error)
+  GetProductsByCategory(ctx context.Context, categoryID string, includeSubcategories bool, limit, offset int) ([]*model.Product, error)
+  GetProductsByBrand(ctx context.Context, brandID string, limit, offset int) ([]*model.Product, error)
+  ListProducts(ctx context.Context, filter repository.ProductFilter, sortBy, sortOrder string, page repository.Page) ([]*model.Product, repository.PageInfo, error)
+  SearchProducts(ctx context.Context, query string, categoryID *string, page repository.Page) ([]*model.Product, repository.PageInfo, error)
+  Update(ctx context.Context, id string, updates map[string]interface{}, actorID *string) (*model.Product, error)
+  UpdateStock(ctx context.Context, m *model.StockMovement) error
+  DeleteProduct(ctx context.Context, id string) error
+}
+
+type RecommendationRepository interface {
+  GetRelatedProducts(ctx context.Context, productID string, limit int) ([]*model.Product, error)
+  RebuildRecommendations(ctx context.Context, params repository.RecommendationParams) (int64, error)
+}
+
+// Weights used when precomputing related products. Content signals add up to
+// 1 and so do content vs co-purchase, so every score lands in [0, 1].
+const (
+  relatedCategoryWeight   = 0.40
+  relatedBrandWeight      = 0.20
+  relatedTagWeight        = 0.25
+  relatedPriceWeight      = 0.15
+  relatedContentWeight    = 0.45
+  relatedCoPurchaseWeight = 0.55
+  relatedCoPurchaseWindow = 180 * 24 * time.Hour
+  relatedMaxPerProduct    = 20
+)
+
+type PopularityRepository interface {
+  RecordView(ctx context.Context, productID string) error
+  RefreshPopularity(ctx context.Context, params repository.PopularityParams) (int64, error)
+}
+
+// Popularity scoring: a sold unit counts as much as two wishlist adds or 50
+// page views, and every signal loses half its weight each week.
+const (
+  popularityOrderWeight    = 1.0
+  popularityWishlistWeight = 0.5
+  popularityViewWeight     = 0.02
+  popularityHalfLife       = 7 * 24 * time.Hour
+  popularityWindow         = 90 * 24 * time.Hour
+)
+
 type ProductService struct {
-  repo repository.ProductRepository
+  repo ProductRepository
+  recommendations RecommendationRepository
+  categories CategoryRepository
+  brands BrandRepository
+  variants VariantRepository
+  prices PriceRepository
+  warehouses WarehouseStockReader
+  popularity PopularityRepository
 }
 
-func NewProductService(repo ProductRepository) *ProductService {
+func NewProductService(repo ProductRepository, recommendations RecommendationRepository, categories CategoryRepository, brands BrandRepository, variants VariantRepository, prices PriceRepository, warehouses WarehouseStockReader, popularity PopularityRepository) *ProductService {
   return &ProductService{
 	repo: repo,
+	recommendations: recommendations,
+	categories: categories,
+	brands: brands,
+	variants: variants,
+	prices: prices,
+	warehouses: warehouses,
+	popularity: popularity,
   }
 }
 
@@ -43,6 +105,10 @@ func (s *ProductService) CreateProduct(ctx context.Context, prod *dto.CreateProd
 	return nil, ErrDuplicateSKU
   }
 
+  if err := s.checkCatalogReferences(ctx, &prod.CategoryID, prod.BrandID); err != nil {
+	return nil, err
+  }
+
   productID := uuid.New().String()
 
   newProduct := model.Product{
@@ -57,16 +123,18 @@ func (s *ProductService) CreateProduct(ctx context.Context, prod *dto.CreateProd
 	BrandID: prod.BrandID,
 	Weight: prod.Weight,
 	Images: prod.Images,
-	Tags: prod.Tags,
+	Tags: normalizeTags(prod.Tags),
+	ReorderThreshold: prod.ReorderThreshold,
   }
 
   if err := s.repo.CreateProduct(ctx, &newProduct); err != nil {
 	  return nil, fmt.Errorf("Failed to create product: %w", err)
   }
 
+  response := s.toProductResponse(&newProduct)
   return &dto.CreateProductResponse{
 	ID: newProduct.ID,
-	Product: s.toProductResponse(product),
+	Product: &response,
 	Message: "Product created successfully",
   }, nil
 }
@@ -83,125 +151,208 @@ func (s *ProductService) ListProducts(ctx context.Context, prods dto.ListProduct
 	sort_order = "asc"
   }
 
-  params := make(map[string]interface{})
-  if prods.CategoryID != nil {
-	if err := uuid.Parse(prods.CategoryID); err != nil {
-	  return nil, ErrInvalidID
-	}
-	  params["category_id"] = *prods.CategoryID
-  }
-  if prods.BrandID != nil {
-	if err := uuid.Parse(prods.BrandID); err != nil {
-	  return nil, ErrInvalidID
-	}
-	  params["brand_id"] = *prods.BrandID
-  }
-  if prods.MinPrice != nil {
-	params["min_price"] = *prods.MinPrice
-  }
-  if prods.MaxPrice != nil {
-	params["max_price"] = *prods.MaxPrice
+  filter := repository.ProductFilter{
+	CategoryID: prods.CategoryID,
+	BrandID: prods.BrandID,
+	MinPrice: prods.MinPrice,
+	MaxPrice: prods.MaxPrice,
+	InStock: prods.InStock,
+	Status: prods.Status,
+	Tags: normalizeTags(prods.Tags),
+	MatchAllTags: prods.TagMatch == "all",
   }
-  if prods.InStock != nil {
-	params["in_stock"] = *prods.InStock
-  }
-  if prods.Status != nil {
-	params["status"] = *prods.Status
-  }
-  
-  products, err := s.repo.ListProducts(ctx, limit, offset, sort, sort_order, params)
+
+  products, info, err := s.repo.ListProducts(ctx, filter, sort, sort_order, newPage(limit, offset, prods.Cursor, prods.Total))
   if err != nil {
-    return nil, fmt.Errorf("failed to list products: %w", err)
+	return nil, listError(err, "products")
+  }
+
+  responses := s.toProductResponses(products)
+  if err := s.expandProductResponses(ctx, responses); err != nil {
+	return nil, err
   }
 
   return &dto.ListProductsResponse{
-	Products: s.toProductResponses(products),
+	Products: responses,
 	Limit: limit,
 	Offset: offset,
+	PageInfo: toPageInfo(info),
   }, nil
 }
 
 func (s *ProductService) GetProductByID(ctx context.Context, prodID string) (*dto.ProductResponse, error) {
-  if err := uuid.Parse(prodID); err != nil {
+  if _, err := uuid.Parse(prodID); err != nil {
 	return nil, ErrInvalidID
   }
 
   product, err := s.repo.GetProductByID(ctx, prodID)
   if err != nil {
-    return nil, fmt.Errorf("failed to get product: %w", err)
+	if errors.Is(err, repository.ErrNotFound) {
+	  return nil, ErrProductNotFound
+	}
+	return nil, fmt.Errorf("failed to get product: %w", err)
+  }
+
+  responses := []dto.ProductResponse{s.toProductResponse(product)}
+  if err := s.expandProductResponses(ctx, responses); err != nil {
+	return nil, err
   }
 
-  response := s.toProductResponse(product)
-  return &response, nil
+  return &responses[0], nil
 }
 
-func (s *ProductService) GetProductsByCategory(ctx context.Context, prod*dto.GetProductsByCategoryRequest) (*dto.ListProductsResponse, error) {
-  catID := prodCategoryID
-  includeSubcatefories := prodIncludeSubcategories
-  limit := prodLimit
-  offset := prodOffset
+// RecordProductView counts a visit to a product page towards its popularity.
+// Only the public product page calls it, so internal lookups don't inflate
+// the score. A lost view only nudges the score, so failures are just logged.
+func (s *ProductService) RecordProductView(ctx context.Context, prodID string) {
+  if err := s.popularity.RecordView(ctx, prodID); err != nil {
+	log.Printf("product %s: %v", prodID, err)
+  }
+}
+
+func (s *ProductService) GetProductsByCategory(ctx context.Context, prod *dto.GetProductsByCategoryRequest) (*dto.ListProductsResponse, error) {
+  catID := prod.CategoryID
+  includeSubcategories := prod.IncludeSubcategories
+  limit := prod.Limit
+  offset := prod.Offset
 
-  if err := uuid.Parse(catID); err != nil {
+  if _, err := uuid.Parse(catID); err != nil {
 	return nil, ErrInvalidID
   }
 
-  products, err := s.repo.GetProductsByCategory(ctx, catID, includeSubcatefories, limit, offset)
+  products, err := s.repo.GetProductsByCategory(ctx, catID, includeSubcategories, limit, offset)
   if err != nil {
     return nil, fmt.Errorf("failed to get products by category: %w", err)
   }
 
+  responses := s.toProductResponses(products)
+  if err := s.expandProductResponses(ctx, responses); err != nil {
+	return nil, err
+  }
+
   return &dto.ListProductsResponse{
-	Products: s.toProductResponses(products),
+	Products: responses,
 	Limit: limit,
 	Offset: offset,
   }, nil
 }
 
-// func (s *ProductService) GetRelatedProducts(ctx context.Context, prodID string, limit int) (*dto.ListProductsResponse, error) {
-//   TODO: I have no idea how to do this!
-// }
+func (s *ProductService) GetRelatedProducts(ctx context.Context, prodID string, limit int) (*dto.ListProductsResponse, error) {
+  if _, err := uuid.Parse(prodID); err != nil {
+	return nil, ErrInvalidID
+  }
 
-func (s *ProductService) SearchProducts(ctx context.Context, proddto.SearchProductsRequest) (*dto.SearchProductsResponse, error) {
-  query := prodQuery
-  limit := prodLimit
-  offset := prodOffset
-  catID := prodCategoryID
+  if limit <= 0 {
+	limit = 10
+  }
 
-  if catID != "" {
-	if err := uuid.Parse(catID); err != nil {
-	  return nil, ErrInvalidID
+  if _, err := s.repo.GetProductByID(ctx, prodID); err != nil {
+	if errors.Is(err, repository.ErrNotFound) {
+	  return nil, ErrProductNotFound
 	}
+	return nil, fmt.Errorf("failed to get product: %w", err)
   }
 
-  products, err := s.repo.SearchProducts(ctx, query, limit, offset, catID)
+  products, err := s.recommendations.GetRelatedProducts(ctx, prodID, limit)
   if err != nil {
-    return nil, fmt.Errorf("failed to search products: %w", err)
+	return nil, fmt.Errorf("failed to get related products: %w", err)
   }
 
-  return &dto.SearchProductsResponse{
-	Products: s.toProductResponses(products),
-	Query: query,
+  responses := s.toProductResponses(products)
+  if err := s.expandProductResponses(ctx, responses); err != nil {
+	return nil, err
+  }
+
+  return &dto.ListProductsResponse{
+	Products: responses,
 	Limit: limit,
-	Offset: offset,
+	Offset: 0,
+  }, nil
+}
+
+// RefreshRelatedProducts recomputes the related products table. It is meant
+// to be run periodically by the job scheduler, not on the request path.
+func (s *ProductService) RefreshRelatedProducts(ctx context.Context) error {
+  _, err := s.recommendations.RebuildRecommendations(ctx, repository.RecommendationParams{
+	CategoryWeight: relatedCategoryWeight,
+	BrandWeight: relatedBrandWeight,
+	TagWeight: relatedTagWeight,
+	PriceWeight: relatedPriceWeight,
+	ContentWeight: relatedContentWeight,
+	CoPurchaseWeight: relatedCoPurchaseWeight,
+	CoPurchaseSince: time.Now().Add(-relatedCoPurchaseWindow),
+	MaxPerProduct: relatedMaxPerProduct,
+  })
+  if err != nil {
+	return fmt.Errorf("failed to refresh related products: %w", err)
+  }
+
+  return nil
+}
+
+// RefreshPopularity recomputes the popularity score behind
+// sort_by=popularity. It is meant to be run periodically by the job scheduler.
+func (s *ProductService) RefreshPopularity(ctx context.Context) error {
+  _, err := s.popularity.RefreshPopularity(ctx, repository.PopularityParams{
+	OrderWeight: popularityOrderWeight,
+	WishlistWeight: popularityWishlistWeight,
+	ViewWeight: popularityViewWeight,
+	HalfLife: popularityHalfLife,
+	Since: time.Now().Add(-popularityWindow),
+  })
+  if err != nil {
+	return fmt.Errorf("failed to refresh popularity: %w", err)
+  }
+
+  return nil
+}
+
+func (s *ProductService) SearchProducts(ctx context.Context, req dto.SearchProductsRequest) (*dto.SearchProductsResponse, error) {
+  products, info, err := s.repo.SearchProducts(ctx, req.Query, req.CategoryID, newPage(req.Limit, req.Offset, req.Cursor, req.Total))
+  if err != nil {
+	return nil, listError(err, "products")
+  }
+
+  responses := s.toProductResponses(products)
+  if err := s.expandProductResponses(ctx, responses); err != nil {
+	return nil, err
+  }
+
+  return &dto.SearchProductsResponse{
+	Products: responses,
+	Query: req.Query,
+	Limit: req.Limit,
+	Offset: req.Offset,
+	PageInfo: toPageInfo(info),
   }, nil
 }
 
 func (s *ProductService) UpdateProduct(ctx context.Context, prodID string, prod *dto.UpdateProductRequest) (*dto.UpdateProductResponse, error) {
-  if err := uuid.Parse(prodID); err != nil {
+  if _, err := uuid.Parse(prodID); err != nil {
 	return nil, ErrInvalidID
   }
 
-  var currentProd model.Product
-
   currentProd, err := s.repo.GetProductByID(ctx, prodID)
   if err != nil {
-	return nil, ErrProductNotFound
+	if errors.Is(err, repository.ErrNotFound) {
+	  return nil, ErrProductNotFound
+	}
+	return nil, fmt.Errorf("failed to get product: %w", err)
   }
 
-  if req.Price != nil && req.CostPrice != nil {
-    if *req.Price <= *req.CostPrice {
-      return nil, ErrInvalidPrice
-    }
+  price, costPrice := currentProd.Price, currentProd.CostPrice
+  if prod.Price != nil {
+	price = *prod.Price
+  }
+  if prod.CostPrice != nil {
+	costPrice = *prod.CostPrice
+  }
+  if price <= costPrice {
+	return nil, ErrInvalidPrice
+  }
+
+  if err := s.checkCatalogReferences(ctx, prod.CategoryID, prod.BrandID); err != nil {
+	return nil, err
   }
 
   updates := make(map[string]interface{})
@@ -227,62 +378,67 @@ func (s *ProductService) UpdateProduct(ctx context.Context, prodID string, prod
 	updates["weight"] = *prod.Weight
   }
   if prod.Images != nil {
-	updates["images"] = *prod.Images
+	updates["images"] = prod.Images
   }
   if prod.Tags != nil {
-	updates["tags"] = *prod.Tags
+	updates["tags"] = normalizeTags(prod.Tags)
   }
   if prod.Status != nil {
 	updates["status"] = *prod.Status
   }
+  if prod.ReorderThreshold != nil {
+	updates["reorder_threshold"] = *prod.ReorderThreshold
+  }
+
+  var actorID *string
+  if prod.ActorID != "" {
+	actorID = &prod.ActorID
+  }
 
-  updatedProduct, err := s.repo.Update(ctx, prodID, updates)
+  updatedProduct, err := s.repo.Update(ctx, prodID, updates, actorID)
   if err != nil {
     return nil, fmt.Errorf("failed to update product: %w", err)
   }
 
-  response := s.toProductResponse(updatedProduct)
+  responses := []dto.ProductResponse{s.toProductResponse(updatedProduct)}
+  if err := s.expandProductResponses(ctx, responses); err != nil {
+	return nil, err
+  }
+
   return &dto.UpdateProductResponse{
-      Product: &response,
+      Product: &responses[0],
       Message: "Product updated successfully",
   }, nil
 }
 
-func (s *ProductService) UpdateProductStock(ctx context.Context, prodID string, prod*dto.UpdateProductStockRequest) (*dto.UpdateProductStockResponse, error) {
+func (s *ProductService) UpdateProductStock(ctx context.Context, prodID string, req *dto.UpdateProductStockRequest) (*dto.UpdateProductStockResponse, error) {
     if _, err := uuid.Parse(prodID); err != nil {
         return nil, ErrInvalidID
     }
     
-    product, err := s.repo.GetByID(ctx, prodID)
-    if err != nil {
+    if _, err := s.repo.GetProductByID(ctx, prodID); err != nil {
         if errors.Is(err, repository.ErrNotFound) {
             return nil, ErrProductNotFound
         }
         return nil, fmt.Errorf("failed to get product: %w", err)
     }
     
-    delta := req.Stock
-    if !req.Increment {
-        delta = req.Stock - product.Stock
-    }
-    
-    newStock := product.Stock + delta
-    
-    if newStock < product.ReservedStock {
-        return nil, ErrStockBelowReserved
-    }
-    
-    if newStock < 0 {
-        return nil, ErrInsufficientStock
+    warehouseID, delta, err := warehouseStockChange(ctx, s.warehouses, prodID, nil, req.WarehouseID, req.Stock, req.Increment)
+    if err != nil {
+        return nil, err
     }
 
-    if err := s.repo.UpdateStock(ctx, prodID, delta); err != nil {
+    movement := newStockMovement(prodID, nil, warehouseID, delta, req.Type, req.Reason, req.ActorID)
+    if err := s.repo.UpdateStock(ctx, movement); err != nil {
+        if errors.Is(err, repository.ErrInsufficientStock) {
+            return nil, ErrStockBelowReserved
+        }
         return nil, fmt.Errorf("failed to update stock: %w", err)
     }
     
     return &dto.UpdateProductStockResponse{
         ProductID: prodID,
-        NewStock:  newStock,
+        NewStock:  movement.StockAfter,
         Message:   "Stock updated successfully",
     }, nil
 }
@@ -304,6 +460,10 @@ func (s *ProductService) DeleteProduct(ctx context.Context, prodID string) error
 }
 
 func (s *ProductService) toProductResponse(p *model.Product) dto.ProductResponse {
+    return newProductResponse(p)
+}
+
+func newProductResponse(p *model.Product) dto.ProductResponse {
     return dto.ProductResponse{
         ID:          p.ID,
         SKU:         p.SKU,
@@ -314,6 +474,10 @@ func (s *ProductService) toProductResponse(p *model.Product) dto.ProductResponse
         Available:   p.Stock - p.ReservedStock,
         Images:      p.Images,
         Tags:        p.Tags,
+        CategoryID:  p.CategoryID,
+        BrandID:     p.BrandID,
+        RatingAverage: p.RatingAverage,
+        RatingCount: p.RatingCount,
         CreatedAt:   p.CreatedAt,
         UpdatedAt:   p.UpdatedAt,
     }
@@ -327,3 +491,130 @@ func (s *ProductService) toProductResponses(products []*model.Product) []dto.Pro
     return responses
 }
 
+// GetProductsByBrand lists the products of the brand identified by slug.
+func (s *ProductService) GetProductsByBrand(ctx context.Context, req *dto.GetProductsByBrandRequest) (*dto.ListProductsResponse, error) {
+  brand, err := s.brands.GetBySlug(ctx, req.Slug)
+  if err != nil {
+    if errors.Is(err, repository.ErrBrandNotFound) {
+      return nil, ErrBrandNotFound
+    }
+    return nil, fmt.Errorf("failed to get brand: %w", err)
+  }
+
+  products, err := s.repo.GetProductsByBrand(ctx, brand.ID, req.Limit, req.Offset)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get products by brand: %w", err)
+  }
+
+  responses := s.toProductResponses(products)
+  if err := s.expandProductResponses(ctx, responses); err != nil {
+    return nil, err
+  }
+
+  return &dto.ListProductsResponse{
+    Products: responses,
+    Limit: req.Limit,
+    Offset: req.Offset,
+  }, nil
+}
+
+// checkCatalogReferences makes sure the category and brand a product points
+// to exist before it is written. Nil IDs are skipped.
+func (s *ProductService) checkCatalogReferences(ctx context.Context, categoryID, brandID *string) error {
+  if categoryID != nil {
+    if _, err := s.categories.GetByID(ctx, *categoryID); err != nil {
+      if errors.Is(err, repository.ErrCategoryNotFound) {
+        return ErrCategoryNotFound
+      }
+      return fmt.Errorf("failed to get category: %w", err)
+    }
+  }
+
+  if brandID != nil {
+    if _, err := s.brands.GetByID(ctx, *brandID); err != nil {
+      if errors.Is(err, repository.ErrBrandNotFound) {
+        return ErrBrandNotFound
+      }
+      return fmt.Errorf("failed to get brand: %w", err)
+    }
+  }
+
+  return nil
+}
+
+// expandProductResponses embeds the category, brand, variants and lowest
+// recent price of each product, loading them with one query each regardless
+// of the number of products.
+func (s *ProductService) expandProductResponses(ctx context.Context, responses []dto.ProductResponse) error {
+  if len(responses) == 0 {
+    return nil
+  }
+
+  productIDs := make([]string, len(responses))
+  categoryIDs := []string{}
+  brandIDs := []string{}
+  seen := make(map[string]bool)
+
+  for i, r := range responses {
+    productIDs[i] = r.ID
+    if r.CategoryID != "" && !seen[r.CategoryID] {
+      seen[r.CategoryID] = true
+      categoryIDs = append(categoryIDs, r.CategoryID)
+    }
+    if r.BrandID != nil && !seen[*r.BrandID] {
+      seen[*r.BrandID] = true
+      brandIDs = append(brandIDs, *r.BrandID)
+    }
+  }
+
+  categories := make(map[string]*dto.CategoryResponse)
+  if len(categoryIDs) > 0 {
+    found, err := s.categories.GetByIDs(ctx, categoryIDs)
+    if err != nil {
+      return fmt.Errorf("failed to load product categories: %w", err)
+    }
+    for _, c := range found {
+      response := toCategoryResponse(c)
+      categories[c.ID] = &response
+    }
+  }
+
+  brands := make(map[string]*dto.BrandResponse)
+  if len(brandIDs) > 0 {
+    found, err := s.brands.GetByIDs(ctx, brandIDs)
+    if err != nil {
+      return fmt.Errorf("failed to load product brands: %w", err)
+    }
+    for _, b := range found {
+      response := toBrandResponse(b)
+      brands[b.ID] = &response
+    }
+  }
+
+  variants := make(map[string][]dto.VariantResponse)
+  found, err := s.variants.GetByProductIDs(ctx, productIDs)
+  if err != nil {
+    return fmt.Errorf("failed to load product variants: %w", err)
+  }
+  for _, v := range found {
+    variants[v.ProductID] = append(variants[v.ProductID], toVariantResponse(v))
+  }
+
+  lowest, err := s.prices.LowestPricesSince(ctx, productIDs, time.Now().Add(-lowestPriceWindow))
+  if err != nil {
+    return fmt.Errorf("failed to load lowest prices: %w", err)
+  }
+
+  for i := range responses {
+    if price, ok := lowest[responses[i].ID]; ok {
+      responses[i].LowestPrice30Days = &price
+    }
+    responses[i].Variants = variants[responses[i].ID]
+    responses[i].Category = categories[responses[i].CategoryID]
+    if responses[i].BrandID != nil {
+      responses[i].Brand = brands[*responses[i].BrandID]
+    }
+  }
+
+  return nil
+}
@@ -0,0 +1,663 @@
+package service
+
+import (
+  "io"
+  "fmt"
+  "log"
+  "time"
+  "bytes"
+  "errors"
+  "context"
+  "strconv"
+  "strings"
+  "encoding/csv"
+
+  "github.com/go-playground/validator/v10"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/storage"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+var (
+  ErrImportJobNotFound = errors.New("import job not found")
+  ErrImportTooLarge = errors.New("import file is too large")
+  ErrInvalidImportFile = errors.New("import file is not a valid product CSV")
+)
+
+const (
+  MaxImportSize = 50 << 20
+  importBatchSize = 500
+  maxImportRowErrors = 1000
+  importStaleAfter = 10 * time.Minute
+  importListSeparator = "|"
+)
+
+// productCSVColumns is the export layout and the set of headers imports
+// understand. Images and tags are joined with importListSeparator.
+var productCSVColumns = []string{
+  "sku", "name", "description", "price", "cost_price", "stock", "category_id", "brand_id",
+  "weight", "status", "images", "tags",
+}
+
+var requiredImportColumns = []string{
+  "sku", "name", "description", "price", "cost_price", "stock", "category_id", "weight", "images",
+}
+
+type ImportJobRepository interface {
+  Create(ctx context.Context, job *model.ImportJob) error
+  GetByID(ctx context.Context, id string) (*model.ImportJob, error)
+  ClaimNext(ctx context.Context, staleAfter time.Duration) (*model.ImportJob, error)
+  SaveProgress(ctx context.Context, job *model.ImportJob) error
+  Finish(ctx context.Context, job *model.ImportJob) error
+}
+
+type ProductCatalogRepository interface {
+  StreamProducts(ctx context.Context, filter repository.ProductFilter, fn func(*model.Product) error) error
+  ReservedStockBySKU(ctx context.Context, skus []string) (map[string]int, error)
+  UpsertBySKU(ctx context.Context, products []*model.Product, actorID string) ([]repository.UpsertOutcome, error)
+}
+
+type ProductImportService struct {
+  jobs ImportJobRepository
+  products ProductCatalogRepository
+  categories CategoryRepository
+  brands BrandRepository
+  blobs storage.BlobStore
+  validator *validator.Validate
+}
+
+func NewProductImportService(jobs ImportJobRepository, products ProductCatalogRepository, categories CategoryRepository, brands BrandRepository, blobs storage.BlobStore, validator *validator.Validate) *ProductImportService {
+  return &ProductImportService{
+	jobs: jobs,
+	products: products,
+	categories: categories,
+	brands: brands,
+	blobs: blobs,
+	validator: validator,
+  }
+}
+
+// StartImport stores the uploaded CSV and queues it. The header is checked
+// right away so obviously wrong files are rejected before a job exists.
+func (s *ProductImportService) StartImport(ctx context.Context, userID string, r io.Reader, dryRun bool) (*dto.StartImportResponse, error) {
+  data, err := io.ReadAll(io.LimitReader(r, MaxImportSize+1))
+  if err != nil {
+	return nil, fmt.Errorf("failed to read import file: %w", err)
+  }
+
+  if len(data) > MaxImportSize {
+	return nil, ErrImportTooLarge
+  }
+
+  header, err := csv.NewReader(bytes.NewReader(data)).Read()
+  if err != nil {
+	return nil, ErrInvalidImportFile
+  }
+  if _, err := importColumnIndex(header); err != nil {
+	return nil, err
+  }
+
+  job := model.ImportJob{
+	ID: uuid.New().String(),
+	CreatedBy: userID,
+	Status: model.ImportJobStatusPending,
+	DryRun: dryRun,
+  }
+  job.SourceKey = "imports/" + job.ID + ".csv"
+
+  if err := s.blobs.Put(ctx, job.SourceKey, bytes.NewReader(data), "text/csv"); err != nil {
+	return nil, fmt.Errorf("failed to store import file: %w", err)
+  }
+
+  if err := s.jobs.Create(ctx, &job); err != nil {
+	s.blobs.Delete(ctx, job.SourceKey)
+	return nil, fmt.Errorf("failed to create import job: %w", err)
+  }
+
+  message := "Import queued"
+  if dryRun {
+	message = "Dry run queued"
+  }
+
+  return &dto.StartImportResponse{
+	Job: toImportJobResponse(&job),
+	Message: message,
+  }, nil
+}
+
+func (s *ProductImportService) GetImportJob(ctx context.Context, jobID string) (*dto.ImportJobResponse, error) {
+  if _, err := uuid.Parse(jobID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  job, err := s.jobs.GetByID(ctx, jobID)
+  if err != nil {
+	if errors.Is(err, repository.ErrImportJobNotFound) {
+	  return nil, ErrImportJobNotFound
+	}
+	return nil, fmt.Errorf("failed to get import job: %w", err)
+  }
+
+  return toImportJobResponse(job), nil
+}
+
+// ProcessPendingImports runs queued imports one after another until the queue
+// is empty. It is meant to be run by the job scheduler.
+func (s *ProductImportService) ProcessPendingImports(ctx context.Context) error {
+  for {
+	job, err := s.jobs.ClaimNext(ctx, importStaleAfter)
+	if err != nil {
+	  return err
+	}
+	if job == nil {
+	  return nil
+	}
+
+	job.Status = model.ImportJobStatusCompleted
+	if err := s.runImport(ctx, job); err != nil {
+	  if ctx.Err() != nil {
+		// Shutting down, leave the job running so it is picked up again.
+		return err
+	  }
+
+	  reason := err.Error()
+	  job.Status = model.ImportJobStatusFailed
+	  job.FailureReason = &reason
+	  log.Printf("product import %s failed: %v", job.ID, err)
+	}
+
+	if err := s.jobs.Finish(ctx, job); err != nil {
+	  return err
+	}
+
+	s.blobs.Delete(ctx, job.SourceKey)
+  }
+}
+
+func (s *ProductImportService) runImport(ctx context.Context, job *model.ImportJob) error {
+  // Counters restart from zero when a stale job is claimed again, upserts are
+  // idempotent so replaying rows is harmless.
+  job.ProcessedRows, job.CreatedCount, job.UpdatedCount, job.FailedCount = 0, 0, 0, 0
+  job.Errors = []model.ImportRowError{}
+
+  total, err := s.countRows(ctx, job.SourceKey)
+  if err != nil {
+	return err
+  }
+  job.TotalRows = total
+
+  if err := s.jobs.SaveProgress(ctx, job); err != nil {
+	return err
+  }
+
+  src, err := s.blobs.Get(ctx, job.SourceKey)
+  if err != nil {
+	return fmt.Errorf("failed to open import file: %w", err)
+  }
+  defer src.Close()
+
+  reader := csv.NewReader(src)
+  reader.FieldsPerRecord = -1
+
+  header, err := reader.Read()
+  if err != nil {
+	return ErrInvalidImportFile
+  }
+  columns, err := importColumnIndex(header)
+  if err != nil {
+	return err
+  }
+
+  seen := make(map[string]int)
+  batch := make([]importRow, 0, importBatchSize)
+  line := 1
+
+  for {
+	record, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+	  break
+	}
+	line++
+
+	if err != nil {
+	  s.recordRowError(job, line, "", map[string]string{"row": err.Error()})
+	  job.ProcessedRows++
+	  continue
+	}
+
+	row := s.parseRow(line, record, columns)
+	if first, dup := seen[row.product.SKU]; dup && row.product.SKU != "" {
+	  row.addError("sku", fmt.Sprintf("duplicate of row %d", first))
+	} else if row.product.SKU != "" {
+	  seen[row.product.SKU] = line
+	}
+
+	batch = append(batch, row)
+	if len(batch) == importBatchSize {
+	  if err := s.processBatch(ctx, job, batch); err != nil {
+		return err
+	  }
+	  batch = batch[:0]
+	}
+  }
+
+  if len(batch) > 0 {
+	if err := s.processBatch(ctx, job, batch); err != nil {
+	  return err
+	}
+  }
+
+  return nil
+}
+
+func (s *ProductImportService) countRows(ctx context.Context, key string) (int, error) {
+  src, err := s.blobs.Get(ctx, key)
+  if err != nil {
+	return 0, fmt.Errorf("failed to open import file: %w", err)
+  }
+  defer src.Close()
+
+  reader := csv.NewReader(src)
+  reader.FieldsPerRecord = -1
+  reader.ReuseRecord = true
+
+  rows := -1
+  for {
+	_, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+	  break
+	}
+	var parseErr *csv.ParseError
+	if err != nil && !errors.As(err, &parseErr) {
+	  return 0, fmt.Errorf("failed to read import file: %w", err)
+	}
+	rows++
+  }
+
+  if rows < 0 {
+	return 0, ErrInvalidImportFile
+  }
+
+  return rows, nil
+}
+
+func (s *ProductImportService) processBatch(ctx context.Context, job *model.ImportJob, batch []importRow) error {
+  if err := s.checkBatchReferences(ctx, batch); err != nil {
+	return err
+  }
+
+  valid := []importRow{}
+  skus := []string{}
+  for _, row := range batch {
+	if len(row.errors) > 0 {
+	  s.recordRowError(job, row.line, row.product.SKU, row.errors)
+	  continue
+	}
+	valid = append(valid, row)
+	skus = append(skus, row.product.SKU)
+  }
+
+  if len(valid) > 0 {
+	if job.DryRun {
+	  if err := s.previewRows(ctx, job, valid, skus); err != nil {
+		return err
+	  }
+	} else {
+	  if err := s.upsertRows(ctx, job, valid); err != nil {
+		return err
+	  }
+	}
+  }
+
+  job.ProcessedRows += len(batch)
+  return s.jobs.SaveProgress(ctx, job)
+}
+
+// previewRows reports what an import would do without writing anything.
+func (s *ProductImportService) previewRows(ctx context.Context, job *model.ImportJob, rows []importRow, skus []string) error {
+  reserved, err := s.products.ReservedStockBySKU(ctx, skus)
+  if err != nil {
+	return err
+  }
+
+  for _, row := range rows {
+	qty, exists := reserved[row.product.SKU]
+	switch {
+	case !exists:
+	  job.CreatedCount++
+	case row.product.Stock < qty:
+	  s.recordRowError(job, row.line, row.product.SKU, stockBelowReservedError(qty))
+	default:
+	  job.UpdatedCount++
+	}
+  }
+
+  return nil
+}
+
+func (s *ProductImportService) upsertRows(ctx context.Context, job *model.ImportJob, rows []importRow) error {
+  products := make([]*model.Product, len(rows))
+  for i, row := range rows {
+	products[i] = row.product
+  }
+
+  outcomes, err := s.products.UpsertBySKU(ctx, products, job.CreatedBy)
+  if err != nil {
+	return err
+  }
+
+  for i, outcome := range outcomes {
+	switch outcome {
+	case repository.UpsertCreated:
+	  job.CreatedCount++
+	case repository.UpsertUpdated:
+	  job.UpdatedCount++
+	case repository.UpsertRejected:
+	  s.recordRowError(job, rows[i].line, rows[i].product.SKU, map[string]string{
+		"stock": "stock cannot be lower than the currently reserved stock",
+	  })
+	}
+  }
+
+  return nil
+}
+
+// checkBatchReferences flags rows pointing at categories or brands that don't
+// exist, with one lookup per batch.
+func (s *ProductImportService) checkBatchReferences(ctx context.Context, batch []importRow) error {
+  categoryIDs := []string{}
+  brandIDs := []string{}
+  for _, row := range batch {
+	if row.validCategory {
+	  categoryIDs = append(categoryIDs, row.product.CategoryID)
+	}
+	if row.validBrand {
+	  brandIDs = append(brandIDs, *row.product.BrandID)
+	}
+  }
+
+  categories := make(map[string]bool)
+  if len(categoryIDs) > 0 {
+	found, err := s.categories.GetByIDs(ctx, categoryIDs)
+	if err != nil {
+	  return fmt.Errorf("failed to get categories: %w", err)
+	}
+	for _, c := range found {
+	  categories[c.ID] = true
+	}
+  }
+
+  brands := make(map[string]bool)
+  if len(brandIDs) > 0 {
+	found, err := s.brands.GetByIDs(ctx, brandIDs)
+	if err != nil {
+	  return fmt.Errorf("failed to get brands: %w", err)
+	}
+	for _, b := range found {
+	  brands[b.ID] = true
+	}
+  }
+
+  for i := range batch {
+	row := &batch[i]
+	if row.validCategory && !categories[row.product.CategoryID] {
+	  row.addError("category_id", "category not found")
+	}
+	if row.validBrand && !brands[*row.product.BrandID] {
+	  row.addError("brand_id", "brand not found")
+	}
+  }
+
+  return nil
+}
+
+type importRow struct {
+  line int
+  product *model.Product
+  errors map[string]string
+  validCategory bool
+  validBrand bool
+}
+
+func (r *importRow) addError(field, message string) {
+  if r.errors == nil {
+	r.errors = make(map[string]string)
+  }
+  if _, exists := r.errors[field]; !exists {
+	r.errors[field] = message
+  }
+}
+
+// parseRow converts a CSV record into a product and validates it with the
+// same rules as POST /products.
+func (s *ProductImportService) parseRow(line int, record []string, columns map[string]int) importRow {
+  row := importRow{line: line}
+
+  get := func(name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+	  return ""
+	}
+	return strings.TrimSpace(record[i])
+  }
+
+  parseFloat := func(name string) float64 {
+	value := get(name)
+	if value == "" {
+	  return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+	  row.addError(name, name + " must be a number")
+	}
+	return f
+  }
+
+  parseInt := func(name string) int {
+	value := get(name)
+	if value == "" {
+	  return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+	  row.addError(name, name + " must be an integer")
+	}
+	return n
+  }
+
+  req := dto.CreateProductRequest{
+	SKU: get("sku"),
+	Name: get("name"),
+	Description: get("description"),
+	Price: parseFloat("price"),
+	CostPrice: parseFloat("cost_price"),
+	Stock: parseInt("stock"),
+	CategoryID: get("category_id"),
+	Weight: parseFloat("weight"),
+	Images: splitImportList(get("images")),
+	Tags: splitImportList(get("tags")),
+  }
+  if brandID := get("brand_id"); brandID != "" {
+	req.BrandID = &brandID
+  }
+
+  if err := s.validator.Struct(req); err != nil {
+	for field, message := range dto.FormatValidationErrors(err) {
+	  row.addError(field, message)
+	}
+  }
+
+  if len(row.errors) == 0 && req.Price <= req.CostPrice {
+	row.addError("price", ErrInvalidPrice.Error())
+  }
+
+  status := model.ProductStatus(get("status"))
+  switch status {
+  case "", model.ProductStatusActive, model.ProductStatusInactive, model.ProductStatusOutOfStock, model.ProductStatusDiscontinued:
+  default:
+	row.addError("status", "status must be one of active, inactive, out_of_stock, discontinued")
+  }
+
+  _, categoryErr := uuid.Parse(req.CategoryID)
+  row.validCategory = categoryErr == nil
+  if req.BrandID != nil {
+	_, brandErr := uuid.Parse(*req.BrandID)
+	row.validBrand = brandErr == nil
+  }
+
+  row.product = &model.Product{
+	ID: uuid.New().String(),
+	SKU: req.SKU,
+	Name: req.Name,
+	Description: req.Description,
+	Price: req.Price,
+	CostPrice: req.CostPrice,
+	Stock: req.Stock,
+	CategoryID: req.CategoryID,
+	BrandID: req.BrandID,
+	Weight: req.Weight,
+	Status: status,
+	Images: req.Images,
+	Tags: normalizeTags(req.Tags),
+  }
+
+  return row
+}
+
+func (s *ProductImportService) recordRowError(job *model.ImportJob, line int, sku string, errs map[string]string) {
+  job.FailedCount++
+  if len(job.Errors) < maxImportRowErrors {
+	job.Errors = append(job.Errors, model.ImportRowError{Row: line, SKU: sku, Errors: errs})
+  }
+}
+
+// ExportProducts writes every product matching req to w as CSV, in the same
+// layout imports accept. Rows are streamed, so the catalog is never held in
+// memory at once.
+func (s *ProductImportService) ExportProducts(ctx context.Context, req *dto.ExportProductsRequest, w io.Writer) error {
+  filter := repository.ProductFilter{
+	CategoryID: req.CategoryID,
+	BrandID: req.BrandID,
+	MinPrice: req.MinPrice,
+	MaxPrice: req.MaxPrice,
+	InStock: req.InStock,
+	Status: req.Status,
+	Tags: normalizeTags(req.Tags),
+	MatchAllTags: req.TagMatch == "all",
+  }
+
+  writer := csv.NewWriter(w)
+  if err := writer.Write(productCSVColumns); err != nil {
+	return fmt.Errorf("failed to write export header: %w", err)
+  }
+
+  err := s.products.StreamProducts(ctx, filter, func(p *model.Product) error {
+	brandID := ""
+	if p.BrandID != nil {
+	  brandID = *p.BrandID
+	}
+
+	return writer.Write([]string{
+	  p.SKU,
+	  p.Name,
+	  p.Description,
+	  strconv.FormatFloat(p.Price, 'f', 2, 64),
+	  strconv.FormatFloat(p.CostPrice, 'f', 2, 64),
+	  strconv.Itoa(p.Stock),
+	  p.CategoryID,
+	  brandID,
+	  strconv.FormatFloat(p.Weight, 'f', -1, 64),
+	  string(p.Status),
+	  strings.Join(p.Images, importListSeparator),
+	  strings.Join(p.Tags, importListSeparator),
+	})
+  })
+  if err != nil {
+	return fmt.Errorf("failed to export products: %w", err)
+  }
+
+  writer.Flush()
+  return writer.Error()
+}
+
+func importColumnIndex(header []string) (map[string]int, error) {
+  known := make(map[string]bool, len(productCSVColumns))
+  for _, c := range productCSVColumns {
+	known[c] = true
+  }
+
+  columns := make(map[string]int, len(header))
+  for i, name := range header {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+	if known[name] {
+	  columns[name] = i
+	}
+  }
+
+  missing := []string{}
+  for _, c := range requiredImportColumns {
+	if _, ok := columns[c]; !ok {
+	  missing = append(missing, c)
+	}
+  }
+  if len(missing) > 0 {
+	return nil, fmt.Errorf("%w: missing columns %s", ErrInvalidImportFile, strings.Join(missing, ", "))
+  }
+
+  return columns, nil
+}
+
+func splitImportList(value string) []string {
+  if value == "" {
+	return nil
+  }
+
+  items := []string{}
+  for _, item := range strings.Split(value, importListSeparator) {
+	if item = strings.TrimSpace(item); item != "" {
+	  items = append(items, item)
+	}
+  }
+  return items
+}
+
+func stockBelowReservedError(reserved int) map[string]string {
+  return map[string]string{
+	"stock": fmt.Sprintf("stock cannot be lower than the currently reserved stock (%d)", reserved),
+  }
+}
+
+func toImportJobResponse(job *model.ImportJob) *dto.ImportJobResponse {
+  progress := 0.0
+  if job.TotalRows > 0 {
+	progress = float64(job.ProcessedRows) / float64(job.TotalRows)
+  } else if job.Status == model.ImportJobStatusCompleted {
+	progress = 1
+  }
+
+  errs := job.Errors
+  if errs == nil {
+	errs = []model.ImportRowError{}
+  }
+
+  return &dto.ImportJobResponse{
+	ID: job.ID,
+	Status: job.Status,
+	DryRun: job.DryRun,
+	TotalRows: job.TotalRows,
+	ProcessedRows: job.ProcessedRows,
+	Progress: progress,
+	CreatedCount: job.CreatedCount,
+	UpdatedCount: job.UpdatedCount,
+	FailedCount: job.FailedCount,
+	Errors: errs,
+	FailureReason: job.FailureReason,
+	CreatedAt: job.CreatedAt,
+	StartedAt: job.StartedAt,
+	FinishedAt: job.FinishedAt,
+  }
+}
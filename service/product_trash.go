@@ -0,0 +1,108 @@
+package service
+
+import (
+  "fmt"
+  "log"
+  "time"
+  "errors"
+  "context"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+const productPurgeBatch = 100
+
+type ProductTrashRepository interface {
+  ListDeleted(ctx context.Context, limit, offset int) ([]*model.Product, int, error)
+  Restore(ctx context.Context, id string) (*model.Product, error)
+  PurgeDeleted(ctx context.Context, cutoff time.Time, limit int) (int, error)
+}
+
+// ProductTrashService manages soft-deleted products. They can be restored
+// until retention has passed, after which PurgeExpired removes them for good.
+type ProductTrashService struct {
+  repo ProductTrashRepository
+  retention time.Duration
+}
+
+func NewProductTrashService(repo ProductTrashRepository, retention time.Duration) *ProductTrashService {
+  return &ProductTrashService{
+	repo: repo,
+	retention: retention,
+  }
+}
+
+func (s *ProductTrashService) ListDeletedProducts(ctx context.Context, req dto.ListDeletedProductsRequest) (*dto.ListDeletedProductsResponse, error) {
+  products, total, err := s.repo.ListDeleted(ctx, req.Limit, req.Offset)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list deleted products: %w", err)
+  }
+
+  responses := make([]dto.DeletedProductResponse, len(products))
+  for i, p := range products {
+	responses[i] = dto.DeletedProductResponse{
+	  ProductResponse: newProductResponse(p),
+	  DeletedAt: *p.DeletedAt,
+	  PurgeAt: p.DeletedAt.Add(s.retention),
+	}
+  }
+
+  return &dto.ListDeletedProductsResponse{
+	Products: responses,
+	Total: total,
+	Limit: req.Limit,
+	Offset: req.Offset,
+  }, nil
+}
+
+func (s *ProductTrashService) RestoreProduct(ctx context.Context, prodID string) (*dto.RestoreProductResponse, error) {
+  if _, err := uuid.Parse(prodID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  product, err := s.repo.Restore(ctx, prodID)
+  if err != nil {
+	if errors.Is(err, repository.ErrNotFound) {
+	  return nil, ErrProductNotFound
+	}
+	if errors.Is(err, repository.ErrDuplicateSKU) {
+	  return nil, ErrDuplicateSKU
+	}
+	return nil, fmt.Errorf("failed to restore product: %w", err)
+  }
+
+  response := newProductResponse(product)
+  return &dto.RestoreProductResponse{
+	Product: &response,
+	Message: "Product restored successfully",
+  }, nil
+}
+
+// PurgeExpired permanently deletes products that have been in the trash for
+// longer than the retention period. Meant to be run by the job scheduler.
+func (s *ProductTrashService) PurgeExpired(ctx context.Context) error {
+  cutoff := time.Now().Add(-s.retention)
+
+  purged := 0
+  for {
+	n, err := s.repo.PurgeDeleted(ctx, cutoff, productPurgeBatch)
+	if err != nil {
+	  return err
+	}
+
+	purged += n
+	if n < productPurgeBatch {
+	  break
+	}
+  }
+
+  if purged > 0 {
+	log.Printf("purged %d deleted products", purged)
+  }
+
+  return nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+  "fmt"
+  "errors"
+  "context"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+var (
+  ErrReviewNotFound = errors.New("review not found")
+  ErrDuplicateReview = errors.New("product already reviewed")
+  ErrNotVerifiedPurchase = errors.New("only customers who received the product can review it")
+  ErrReviewNotPublished = errors.New("review is not published")
+  ErrOwnReviewVote = errors.New("cannot vote on own review")
+)
+
+type ReviewRepository interface {
+  HasDeliveredPurchase(ctx context.Context, userID, productID string) (bool, error)
+  Create(ctx context.Context, review *model.Review) error
+  GetByID(ctx context.Context, id string) (*model.Review, error)
+  ListPublished(ctx context.Context, productID, sortBy string, limit, offset int) ([]*model.Review, int, error)
+  ListByStatus(ctx context.Context, status model.ReviewStatus, limit, offset int) ([]*model.Review, int, error)
+  Moderate(ctx context.Context, id string, status model.ReviewStatus, note *string, moderatorID string) (*model.Review, error)
+  Vote(ctx context.Context, reviewID, userID string, helpful bool) (*model.Review, error)
+}
+
+type ReviewService struct {
+  repo ReviewRepository
+  products ProductGetter
+}
+
+func NewReviewService(repo ReviewRepository, products ProductGetter) *ReviewService {
+  return &ReviewService{
+	repo: repo,
+	products: products,
+  }
+}
+
+// CreateReview submits a review for moderation. Only users with a delivered
+// order containing the product may review it, once.
+func (s *ReviewService) CreateReview(ctx context.Context, productID string, req *dto.CreateReviewRequest) (*dto.CreateReviewResponse, error) {
+  if _, err := findProduct(ctx, s.products, productID); err != nil {
+	return nil, err
+  }
+
+  verified, err := s.repo.HasDeliveredPurchase(ctx, req.UserID, productID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to check purchase: %w", err)
+  }
+  if !verified {
+	return nil, ErrNotVerifiedPurchase
+  }
+
+  review := model.Review{
+	ID: uuid.New().String(),
+	ProductID: productID,
+	UserID: req.UserID,
+	Rating: req.Rating,
+	Title: req.Title,
+	Body: req.Body,
+	Status: model.ReviewStatusPending,
+  }
+
+  if err := s.repo.Create(ctx, &review); err != nil {
+	if errors.Is(err, repository.ErrDuplicateReview) {
+	  return nil, ErrDuplicateReview
+	}
+	return nil, fmt.Errorf("failed to create review: %w", err)
+  }
+
+  response := toReviewResponse(&review)
+  return &dto.CreateReviewResponse{
+	Review: &response,
+	Message: "Review submitted for moderation",
+  }, nil
+}
+
+// ListProductReviews returns the published reviews of a product along with
+// its rating summary.
+func (s *ReviewService) ListProductReviews(ctx context.Context, productID string, req dto.ListReviewsRequest) (*dto.ListReviewsResponse, error) {
+  product, err := findProduct(ctx, s.products, productID)
+  if err != nil {
+	return nil, err
+  }
+
+  reviews, total, err := s.repo.ListPublished(ctx, productID, req.SortBy, req.Limit, req.Offset)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list reviews: %w", err)
+  }
+
+  return &dto.ListReviewsResponse{
+	ProductID: productID,
+	RatingAverage: product.RatingAverage,
+	RatingCount: product.RatingCount,
+	Reviews: toReviewResponses(reviews),
+	Total: total,
+	Limit: req.Limit,
+	Offset: req.Offset,
+  }, nil
+}
+
+// ListReviewQueue returns the reviews in a moderation status, pending ones by
+// default.
+func (s *ReviewService) ListReviewQueue(ctx context.Context, req dto.ListReviewQueueRequest) (*dto.ReviewQueueResponse, error) {
+  status := req.Status
+  if status == "" {
+	status = model.ReviewStatusPending
+  }
+
+  reviews, total, err := s.repo.ListByStatus(ctx, status, req.Limit, req.Offset)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list reviews: %w", err)
+  }
+
+  return &dto.ReviewQueueResponse{
+	Status: status,
+	Reviews: toReviewResponses(reviews),
+	Total: total,
+	Limit: req.Limit,
+	Offset: req.Offset,
+  }, nil
+}
+
+// ModerateReview approves or rejects a review. Approved reviews can be
+// rejected later on, which takes them out of the product's rating again.
+func (s *ReviewService) ModerateReview(ctx context.Context, reviewID string, req *dto.ModerateReviewRequest) (*dto.ModerateReviewResponse, error) {
+  if _, err := uuid.Parse(reviewID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  review, err := s.repo.Moderate(ctx, reviewID, req.Status, req.Note, req.ModeratorID)
+  if err != nil {
+	if errors.Is(err, repository.ErrReviewNotFound) {
+	  return nil, ErrReviewNotFound
+	}
+	return nil, fmt.Errorf("failed to moderate review: %w", err)
+  }
+
+  response := toReviewResponse(review)
+  return &dto.ModerateReviewResponse{
+	Review: &response,
+	Message: fmt.Sprintf("Review %s", review.Status),
+  }, nil
+}
+
+// VoteReview records whether a user found a published review helpful. Voting
+// again replaces the earlier vote; authors can't vote on their own reviews.
+func (s *ReviewService) VoteReview(ctx context.Context, reviewID string, req *dto.VoteReviewRequest) (*dto.VoteReviewResponse, error) {
+  if _, err := uuid.Parse(reviewID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  review, err := s.repo.GetByID(ctx, reviewID)
+  if err != nil {
+	if errors.Is(err, repository.ErrReviewNotFound) {
+	  return nil, ErrReviewNotFound
+	}
+	return nil, fmt.Errorf("failed to get review: %w", err)
+  }
+
+  if review.Status != model.ReviewStatusApproved {
+	return nil, ErrReviewNotPublished
+  }
+  if review.UserID == req.UserID {
+	return nil, ErrOwnReviewVote
+  }
+
+  review, err = s.repo.Vote(ctx, reviewID, req.UserID, *req.Helpful)
+  if err != nil {
+	return nil, fmt.Errorf("failed to vote on review: %w", err)
+  }
+
+  return &dto.VoteReviewResponse{
+	ReviewID: review.ID,
+	HelpfulCount: review.HelpfulCount,
+	UnhelpfulCount: review.UnhelpfulCount,
+	Message: "Vote recorded",
+  }, nil
+}
+
+func toReviewResponse(r *model.Review) dto.ReviewResponse {
+  return dto.ReviewResponse{
+	ID: r.ID,
+	ProductID: r.ProductID,
+	UserID: r.UserID,
+	Rating: r.Rating,
+	Title: r.Title,
+	Body: r.Body,
+	Status: r.Status,
+	ModerationNote: r.ModerationNote,
+	ModeratedAt: r.ModeratedAt,
+	HelpfulCount: r.HelpfulCount,
+	UnhelpfulCount: r.UnhelpfulCount,
+	CreatedAt: r.CreatedAt,
+	UpdatedAt: r.UpdatedAt,
+  }
+}
+
+func toReviewResponses(reviews []*model.Review) []dto.ReviewResponse {
+  responses := make([]dto.ReviewResponse, len(reviews))
+  for i, r := range reviews {
+	responses[i] = toReviewResponse(r)
+  }
+  return responses
+}
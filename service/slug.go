@@ -0,0 +1,33 @@
+package service
+
+import (
+  "strings"
+  "unicode"
+
+  "golang.org/x/text/runes"
+  "golang.org/x/text/transform"
+  "golang.org/x/text/unicode/norm"
+)
+
+// slugify turns a display name like "Ropa & Calzado" into "ropa-calzado".
+func slugify(name string) string {
+  stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), name)
+  if err != nil {
+	stripped = name
+  }
+
+  var b strings.Builder
+  lastHyphen := true
+  for _, r := range strings.ToLower(stripped) {
+	switch {
+	case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+	  b.WriteRune(r)
+	  lastHyphen = false
+	case !lastHyphen:
+	  b.WriteRune('-')
+	  lastHyphen = true
+	}
+  }
+
+  return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+  "fmt"
+  "log"
+  "errors"
+  "context"
+  "strings"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/notify"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+var (
+  ErrSubscriptionNotFound = errors.New("subscription not found")
+  ErrAlreadyInStock = errors.New("product is already in stock")
+  ErrPriceAlreadyBelowTarget = errors.New("price is already at or below the target")
+  ErrVariantPriceSubscription = errors.New("price subscriptions apply to the whole product")
+)
+
+// subscriptionBatch is how many triggered subscriptions a single run delivers.
+const subscriptionBatch = 500
+
+type SubscriptionRepository interface {
+  Upsert(ctx context.Context, sub *model.ProductSubscription) error
+  ListByUser(ctx context.Context, userID string) ([]*model.ProductSubscription, error)
+  Delete(ctx context.Context, userID, id string) error
+  DeliverTriggered(ctx context.Context, limit int, deliver func(email string, subs []*model.TriggeredSubscription) error) (int, error)
+}
+
+type SubscriptionService struct {
+  repo SubscriptionRepository
+  products ProductGetter
+  variants VariantRepository
+  notifier notify.Notifier
+}
+
+func NewSubscriptionService(repo SubscriptionRepository, products ProductGetter, variants VariantRepository, notifier notify.Notifier) *SubscriptionService {
+  return &SubscriptionService{
+	repo: repo,
+	products: products,
+	variants: variants,
+	notifier: notifier,
+  }
+}
+
+// Subscribe asks for a notification when a product comes back in stock or
+// its price drops to the target. Conditions that already hold are refused,
+// they would never trigger.
+func (s *SubscriptionService) Subscribe(ctx context.Context, productID string, req *dto.CreateSubscriptionRequest) (*dto.CreateSubscriptionResponse, error) {
+  product, err := findProduct(ctx, s.products, productID)
+  if err != nil {
+	return nil, err
+  }
+
+  sub := model.ProductSubscription{
+	ID: uuid.New().String(),
+	UserID: req.UserID,
+	ProductID: productID,
+	Type: req.Type,
+  }
+
+  switch req.Type {
+  case model.SubscriptionBackInStock:
+	if req.VariantID != nil {
+	  variant, err := s.variants.GetByID(ctx, productID, *req.VariantID)
+	  if err != nil {
+		if errors.Is(err, repository.ErrVariantNotFound) {
+		  return nil, ErrVariantNotFound
+		}
+		return nil, fmt.Errorf("failed to get variant: %w", err)
+	  }
+	  if variant.Stock - variant.ReservedStock > 0 {
+		return nil, ErrAlreadyInStock
+	  }
+	  sub.VariantID = &variant.ID
+	} else if product.Status == model.ProductStatusActive {
+	  return nil, ErrAlreadyInStock
+	}
+  case model.SubscriptionPriceBelow:
+	if req.VariantID != nil {
+	  return nil, ErrVariantPriceSubscription
+	}
+	if product.Price <= *req.TargetPrice {
+	  return nil, ErrPriceAlreadyBelowTarget
+	}
+	sub.TargetPrice = req.TargetPrice
+  }
+
+  if err := s.repo.Upsert(ctx, &sub); err != nil {
+	return nil, fmt.Errorf("failed to subscribe: %w", err)
+  }
+
+  response := toSubscriptionResponse(&sub)
+  return &dto.CreateSubscriptionResponse{
+	Subscription: &response,
+	Message: "Subscribed successfully",
+  }, nil
+}
+
+func (s *SubscriptionService) ListSubscriptions(ctx context.Context, userID string) (*dto.ListSubscriptionsResponse, error) {
+  subs, err := s.repo.ListByUser(ctx, userID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+  }
+
+  responses := make([]dto.SubscriptionResponse, len(subs))
+  for i, sub := range subs {
+	responses[i] = toSubscriptionResponse(sub)
+  }
+
+  return &dto.ListSubscriptionsResponse{
+	Subscriptions: responses,
+  }, nil
+}
+
+func (s *SubscriptionService) Unsubscribe(ctx context.Context, userID, id string) error {
+  if _, err := uuid.Parse(id); err != nil {
+	return ErrInvalidID
+  }
+
+  if err := s.repo.Delete(ctx, userID, id); err != nil {
+	if errors.Is(err, repository.ErrSubscriptionNotFound) {
+	  return ErrSubscriptionNotFound
+	}
+	return fmt.Errorf("failed to unsubscribe: %w", err)
+  }
+
+  return nil
+}
+
+// SendNotifications tells users about their triggered subscriptions, one
+// message per user, and removes the subscriptions once delivered.
+func (s *SubscriptionService) SendNotifications(ctx context.Context) error {
+  sent, err := drainBatches(func() (int, error) {
+	return s.repo.DeliverTriggered(ctx, subscriptionBatch, func(email string, subs []*model.TriggeredSubscription) error {
+	  return s.notifier.Notify(ctx, subscriptionMessage(email, subs))
+	})
+  })
+  if sent > 0 {
+	log.Printf("product subscriptions: %d notified", sent)
+  }
+  if err != nil {
+	return fmt.Errorf("failed to send subscription notifications: %w", err)
+  }
+
+  return nil
+}
+
+func subscriptionMessage(email string, subs []*model.TriggeredSubscription) notify.Message {
+  var body strings.Builder
+  body.WriteString("Good news, products you are watching have changed:\n\n")
+  for _, sub := range subs {
+	switch sub.Type {
+	case model.SubscriptionBackInStock:
+	  fmt.Fprintf(&body, "- %s (%s) is back in stock.\n", sub.ProductName, sub.SKU)
+	case model.SubscriptionPriceBelow:
+	  fmt.Fprintf(&body, "- %s (%s) now costs %.2f, below your target of %.2f.\n", sub.ProductName, sub.SKU, sub.Price, *sub.TargetPrice)
+	}
+  }
+  body.WriteString("\nYou won't be notified again about these products unless you subscribe again.")
+
+  subject := fmt.Sprintf("%s: an update on a product you are watching", subs[0].ProductName)
+  if len(subs) > 1 {
+	subject = fmt.Sprintf("Updates on %d products you are watching", len(subs))
+  }
+
+  return notify.Message{
+	To: email,
+	Kind: "product_subscription",
+	Subject: subject,
+	Body: body.String(),
+	Data: map[string]string{
+	  "user_id": subs[0].UserID,
+	},
+  }
+}
+
+func toSubscriptionResponse(s *model.ProductSubscription) dto.SubscriptionResponse {
+  return dto.SubscriptionResponse{
+	ID: s.ID,
+	ProductID: s.ProductID,
+	VariantID: s.VariantID,
+	Type: s.Type,
+	TargetPrice: s.TargetPrice,
+	TriggeredAt: s.TriggeredAt,
+	CreatedAt: s.CreatedAt,
+  }
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+  "fmt"
+  "errors"
+  "context"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+)
+
+var (
+  ErrTagNotFound = errors.New("tag not found")
+  ErrDuplicateTag = errors.New("tag slug already exists")
+  ErrTagMergeSelf = errors.New("cannot merge a tag into itself")
+)
+
+type TagRepository interface {
+  ListWithProductCounts(ctx context.Context) ([]*repository.TagWithProductCount, error)
+  Rename(ctx context.Context, slug, name, newSlug string) (*model.Tag, int64, error)
+  Merge(ctx context.Context, source, target string) (*model.Tag, int64, error)
+}
+
+type TagService struct {
+  repo TagRepository
+}
+
+func NewTagService(repo TagRepository) *TagService {
+  return &TagService{
+	repo: repo,
+  }
+}
+
+func (s *TagService) ListTags(ctx context.Context) (*dto.ListTagsResponse, error) {
+  tags, err := s.repo.ListWithProductCounts(ctx)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list tags: %w", err)
+  }
+
+  responses := make([]dto.TagResponse, len(tags))
+  for i, t := range tags {
+	count := t.ProductCount
+	responses[i] = toTagResponse(&t.Tag)
+	responses[i].ProductCount = &count
+  }
+
+  return &dto.ListTagsResponse{
+	Tags: responses,
+	Total: len(responses),
+  }, nil
+}
+
+// RenameTag gives a tag a new name and, unless one is given, the slug of that
+// name. Products carrying the tag are moved to the new slug.
+func (s *TagService) RenameTag(ctx context.Context, slug string, req *dto.RenameTagRequest) (*dto.RenameTagResponse, error) {
+  newSlug := req.Slug
+  if newSlug == "" {
+	newSlug = slugify(req.Name)
+  }
+  if newSlug == "" {
+	return nil, ErrInvalidSlug
+  }
+
+  tag, rewritten, err := s.repo.Rename(ctx, slug, req.Name, newSlug)
+  if err != nil {
+	return nil, tagError(err, "rename")
+  }
+
+  response := toTagResponse(tag)
+  return &dto.RenameTagResponse{
+	Tag: &response,
+	ProductsUpdated: rewritten,
+	Message: "Tag renamed successfully",
+  }, nil
+}
+
+// MergeTag retags every product carrying slug with req.Into and deletes the
+// old tag.
+func (s *TagService) MergeTag(ctx context.Context, slug string, req *dto.MergeTagRequest) (*dto.MergeTagResponse, error) {
+  if slug == req.Into {
+	return nil, ErrTagMergeSelf
+  }
+
+  tag, rewritten, err := s.repo.Merge(ctx, slug, req.Into)
+  if err != nil {
+	return nil, tagError(err, "merge")
+  }
+
+  response := toTagResponse(tag)
+  return &dto.MergeTagResponse{
+	Tag: &response,
+	ProductsUpdated: rewritten,
+	Message: "Tags merged successfully",
+  }, nil
+}
+
+func tagError(err error, action string) error {
+  switch {
+  case errors.Is(err, repository.ErrTagNotFound):
+	return ErrTagNotFound
+  case errors.Is(err, repository.ErrDuplicateTag):
+	return ErrDuplicateTag
+  }
+  return fmt.Errorf("failed to %s tag: %w", action, err)
+}
+
+// normalizeTags turns free-text tags into the slugs products store, dropping
+// duplicates and tags with nothing left to slug.
+func normalizeTags(tags []string) []string {
+  if tags == nil {
+	return nil
+  }
+
+  slugs := make([]string, 0, len(tags))
+  seen := make(map[string]bool, len(tags))
+  for _, tag := range tags {
+	slug := slugify(tag)
+	if slug == "" || seen[slug] {
+	  continue
+	}
+	seen[slug] = true
+	slugs = append(slugs, slug)
+  }
+
+  return slugs
+}
+
+func toTagResponse(t *model.Tag) dto.TagResponse {
+  return dto.TagResponse{
+	ID: t.ID,
+	Name: t.Name,
+	Slug: t.Slug,
+	CreatedAt: t.CreatedAt,
+	UpdatedAt: t.UpdatedAt,
+  }
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+  "image"
+  "image/draw"
+)
+
+// toRGBA copies src into an RGBA image anchored at the origin so the resize
+// loop can work on the pixel buffer directly instead of going through At.
+func toRGBA(src image.Image) *image.RGBA {
+  if rgba, ok := src.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+	return rgba
+  }
+
+  b := src.Bounds()
+  dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+  draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+  return dst
+}
+
+// resizeToFit scales src down so neither side exceeds bound, keeping the
+// aspect ratio. Each destination pixel is the average of the source box it
+// covers, which is good enough for thumbnails and needs no extra dependency.
+// Images that already fit are returned unchanged.
+func resizeToFit(src *image.RGBA, bound int) *image.RGBA {
+  w, h := src.Bounds().Dx(), src.Bounds().Dy()
+  if w <= bound && h <= bound {
+	return src
+  }
+
+  dw, dh := bound, bound
+  if w >= h {
+	dh = h * bound / w
+  } else {
+	dw = w * bound / h
+  }
+  if dw < 1 {
+	dw = 1
+  }
+  if dh < 1 {
+	dh = 1
+  }
+
+  dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+  for y := 0; y < dh; y++ {
+	sy0, sy1 := y*h/dh, (y+1)*h/dh
+	if sy1 == sy0 {
+	  sy1 = sy0 + 1
+	}
+
+	for x := 0; x < dw; x++ {
+	  sx0, sx1 := x*w/dw, (x+1)*w/dw
+	  if sx1 == sx0 {
+		sx1 = sx0 + 1
+	  }
+
+	  var r, g, b, a, n uint64
+	  for sy := sy0; sy < sy1; sy++ {
+		off := sy*src.Stride + sx0*4
+		for sx := sx0; sx < sx1; sx++ {
+		  r += uint64(src.Pix[off])
+		  g += uint64(src.Pix[off+1])
+		  b += uint64(src.Pix[off+2])
+		  a += uint64(src.Pix[off+3])
+		  off += 4
+		  n++
+		}
+	  }
+
+	  d := y*dst.Stride + x*4
+	  dst.Pix[d] = uint8(r / n)
+	  dst.Pix[d+1] = uint8(g / n)
+	  dst.Pix[d+2] = uint8(b / n)
+	  dst.Pix[d+3] = uint8(a / n)
+	}
+  }
+
+  return dst
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+  "context"
+  "log"
+  "time"
+
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+)
+
+type TokenRevocationRepository interface {
+  DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// TokenRevocationService keeps the shared revocation store small; revocations
+// are only needed until the tokens they cover expire.
+type TokenRevocationService struct {
+  repo TokenRevocationRepository
+}
+
+func NewTokenRevocationService(repo TokenRevocationRepository) *TokenRevocationService {
+  return &TokenRevocationService{
+	repo: repo,
+  }
+}
+
+func (s *TokenRevocationService) PurgeExpired(ctx context.Context) error {
+  deleted, err := s.repo.DeleteExpired(ctx)
+  if err != nil {
+	return err
+  }
+
+  if deleted > 0 {
+	log.Printf("purged %d expired token revocations", deleted)
+  }
+
+  return nil
+}
+
+// revokeAccessTokens denylists access tokens by jti until they would have
+// expired anyway. Sessions created before jtis were issued have none.
+func revokeAccessTokens(ctx context.Context, store auth.RevocationStore, jtis ...string) error {
+  expiresAt := time.Now().Add(auth.AccessTokenTTL)
+  for _, jti := range jtis {
+	if jti == "" {
+	  continue
+	}
+	if err := store.RevokeToken(ctx, jti, expiresAt); err != nil {
+	  return err
+	}
+  }
+
+  return nil
+}
+
+// revokeUserTokens invalidates every access token already issued to a user,
+// for changes that make the claims in them stale or no longer trustworthy.
+func revokeUserTokens(ctx context.Context, store auth.RevocationStore, userID string) error {
+  now := time.Now()
+  return store.RevokeUser(ctx, userID, now, now.Add(auth.AccessTokenTTL))
+}
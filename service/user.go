@@ -2,11 +2,13 @@ package service
 
 import (
   "fmt"
+  "log"
   "time"
   "context"
   "errors"
 
   "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
   "github.com/F-Dupraz/ecommerce-with-go/model"
   "github.com/F-Dupraz/ecommerce-with-go/repository"
 
@@ -18,7 +20,6 @@ var (
   ErrUserNotFound = errors.New("user not found")
   ErrEmailAlreadyExists = errors.New("email already exists")
   ErrUsernameAlreadyExists = errors.New("username already exists")
-  ErrInvalidCredentials = errors.New("invalid credentials")
   ErrInvalidUserID = errors.New("invalid user id")
 )
 
@@ -27,18 +28,28 @@ type UserRepository interface {
   GetByID(ctx context.Context, id string) (*model.User, error)
   GetByEmail(ctx context.Context, email string) (*model.User, error)
   Update(ctx context.Context, id string, updates map[string]interface{}) (*model.User, error)
-  Delete(ctx context.Context, id string) error
+  UpdateRole(ctx context.Context, id string, role model.UserRole) (*model.User, error)
+  Delete(ctx context.Context, id string) (time.Time, error)
   ExistsByEmail(ctx context.Context, email string) (bool, error)
   ExistsByUsername(ctx context.Context, username string) (bool, error)
+  List(ctx context.Context, filter repository.UserFilter, sortBy, sortOrder string, page repository.Page) ([]*model.User, repository.PageInfo, error)
+}
+
+type EmailVerifier interface {
+  SendVerification(ctx context.Context, user *model.User) error
 }
 
 type UserService struct {
   repo UserRepository
+  revocations auth.RevocationStore
+  verifier EmailVerifier
 }
 
-func NewUserService(repo UserRepository) *UserService {
+func NewUserService(repo UserRepository, revocations auth.RevocationStore, verifier EmailVerifier) *UserService {
   return &UserService{
     repo: repo,
+    revocations: revocations,
+    verifier: verifier,
   }
 }
 
@@ -50,7 +61,7 @@ func (s *UserService) CreateUser(ctx context.Context, req dto.CreateUserRequest)
 
   newID := uuid.New().String()
 
-  var newUser model.User{
+  newUser := model.User{
 	ID: newID,
 	Username: req.Username,
 	Email: req.Email,
@@ -72,6 +83,11 @@ func (s *UserService) CreateUser(ctx context.Context, req dto.CreateUserRequest)
 	return nil, fmt.Errorf("failed to create user: %w", err)
   }
 
+  // The account works without it, the user can ask for another link.
+  if err := s.verifier.SendVerification(ctx, &newUser); err != nil {
+	log.Printf("failed to send verification email to user %s: %v", newUser.ID, err)
+  }
+
   return &dto.CreateUserResponse{
 	ID: newUser.ID,
 	User: s.modelToResponse(&newUser),
@@ -80,59 +96,44 @@ func (s *UserService) CreateUser(ctx context.Context, req dto.CreateUserRequest)
 }
 
 func (s *UserService) GetUserByID(ctx context.Context, req dto.GetUserByIDRequest) (*dto.UserResponse, error) {
-  if err := uuid.Parse(req.ID); err != nil {
+  if _, err := uuid.Parse(req.ID); err != nil {
 	return nil, ErrInvalidUserID
   }
 
   user, err := s.repo.GetByID(ctx, req.ID)
   if err != nil {
-	return nil, ErrInvalidUserID
+	if errors.Is(err, repository.ErrUserNotFound) {
+	  return nil, ErrUserNotFound
+	}
+	return nil, fmt.Errorf("failed to get user: %w", err)
   }
 
-  return &dto.UserResponse{
-	ID: user.ID,
-	Username: user.Username,
-	Email: user.Email,
-	Address: user.Email,
-	City: user.City,
-	Country: user.Country,
-	CreatedAt: user.CreatedAt,
-	UpdatedAt: user.UpdatedAt,
-  }, nil
+  return s.modelToResponse(user), nil
 }
 
 func (s *UserService) GetUserByEmail(ctx context.Context, req dto.GetUserByEmailRequest) (*dto.UserResponse, error) {
-  userEmail := req.Email
-
-  var user model.User
-
-  user, err := s.repo.GetUserByEmail(ctx, userEmail)
+  user, err := s.repo.GetByEmail(ctx, req.Email)
   if err != nil {
-	return nil, ErrUserNotFound
+	if errors.Is(err, repository.ErrUserNotFound) {
+	  return nil, ErrUserNotFound
+	}
+	return nil, fmt.Errorf("failed to get user: %w", err)
   }
 
-  return &dto.UserResponse{
-	ID: user.ID,
-	Username: user.Username,
-	Email: user.Email,
-	Address: user.Email,
-	City: user.City,
-	Country: user.Country,
-	CreatedAt: user.CreatedAt,
-	UpdatedAt: user.UpdatedAt,
-  }, nil
+  return s.modelToResponse(user), nil
 }
 
 func (s *UserService) UpdateUser(ctx context.Context, userID string, req dto.UpdateUserRequest) (*dto.UpdateUserResponse, error) {
-  if err := uuid.Parse(userID); err != nil {
+  if _, err := uuid.Parse(userID); err != nil {
 	return nil, ErrInvalidUserID
   }
 
-  var currentUser model.User
-
-  currentUser, err := s.repo.GetUserByID(ctx, userID)
+  currentUser, err := s.repo.GetByID(ctx, userID)
   if err != nil {
-	return nil, ErrInvalidUserID
+	if errors.Is(err, repository.ErrUserNotFound) {
+	  return nil, ErrUserNotFound
+	}
+	return nil, fmt.Errorf("failed to get user: %w", err)
   }
 
   if req.Email != nil && *req.Email != currentUser.Email {
@@ -147,6 +148,9 @@ func (s *UserService) UpdateUser(ctx context.Context, userID string, req dto.Upd
 
   if req.Username != nil && *req.Username != currentUser.Username {
 	  exists, err := s.repo.ExistsByUsername(ctx, *req.Username)
+	  if err != nil {
+		return nil, fmt.Errorf("failed to check username existence: %w", err)
+	  }
 	  if exists {
 		return nil, ErrUsernameAlreadyExists
 	  }
@@ -156,15 +160,10 @@ func (s *UserService) UpdateUser(ctx context.Context, userID string, req dto.Upd
   if req.Username != nil {
 	updates["username"] = *req.Username
   }
-  if req.Email != nil {
+  emailChanged := req.Email != nil && *req.Email != currentUser.Email
+  if emailChanged {
 	updates["email"] = *req.Email
-  }
-  if req.Password != nil {
-	hashedPass, err := bcrypt.GenerateFromPassword([]byte(*req.Password), 12)
-	if err != nil {
-	  return nil, fmt.Errorf("failed to hash password: %w", err)
-	}
-	updates["password"] = string(hashedPass)
+	updates["email_verified_at"] = nil
   }
   if req.Address != nil {
 	updates["address"] = *req.Address
@@ -181,6 +180,12 @@ func (s *UserService) UpdateUser(ctx context.Context, userID string, req dto.Upd
 	return nil, fmt.Errorf("failed to update user: %w", err)
   }
 
+  if emailChanged {
+	if err := s.verifier.SendVerification(ctx, updatedUser); err != nil {
+	  log.Printf("failed to send verification email to user %s: %v", userID, err)
+	}
+  }
+
   return &dto.UpdateUserResponse{
 	User: s.modelToResponse(updatedUser),
 	Message: "User updated successfully!",
@@ -188,17 +193,12 @@ func (s *UserService) UpdateUser(ctx context.Context, userID string, req dto.Upd
 }
 
 func (s *UserService) DeleteUser(ctx context.Context, req dto.DeleteUserRequest) (*dto.DeleteUserResponse, error) {
-  if err := uuid.Parse(req.ID); err != nil {
+  if _, err := uuid.Parse(req.ID); err != nil {
 	return nil, ErrInvalidUserID
   }
 
   userID := req.ID
 
-  userExists, err := s.repo.GetUserByID(ctx, userID)
-  if err != nil {
-	return nil, ErrInvalidUserID
-  }
-
   deletedAt, err := s.repo.Delete(ctx, userID)
   if err != nil {
 	if errors.Is(err, repository.ErrUserNotFound) {
@@ -207,10 +207,66 @@ func (s *UserService) DeleteUser(ctx context.Context, req dto.DeleteUserRequest)
 	return nil, fmt.Errorf("failed to delete user: %w", err)
   }
 
+  if err := revokeUserTokens(ctx, s.revocations, userID); err != nil {
+	return nil, fmt.Errorf("failed to revoke tokens: %w", err)
+  }
+
   return &dto.DeleteUserResponse{
 	ID: userID,
 	Message: "User deleted succesfully!",
-	DeletedAt: deletedAt
+	DeletedAt: deletedAt,
+  }, nil
+}
+
+func (s *UserService) ListUsers(ctx context.Context, req dto.ListUsersRequest) (*dto.ListUsersResponse, error) {
+  filter := repository.UserFilter{
+	Query: req.Query,
+  }
+  if req.Role != nil {
+	role := model.UserRole(*req.Role)
+	filter.Role = &role
+  }
+
+  users, info, err := s.repo.List(ctx, filter, req.SortBy, req.SortOrder, newPage(req.Limit, req.Offset, req.Cursor, req.Total))
+  if err != nil {
+	return nil, listError(err, "users")
+  }
+
+  responses := make([]dto.UserResponse, len(users))
+  for i, user := range users {
+	responses[i] = *s.modelToResponse(user)
+  }
+
+  return &dto.ListUsersResponse{
+	Users: responses,
+	Limit: req.Limit,
+	Offset: req.Offset,
+	PageInfo: toPageInfo(info),
+  }, nil
+}
+
+// UpdateUserRole changes the role of a user. Tokens already issued carry the
+// old role, so they are revoked and the user picks up the new one on refresh.
+func (s *UserService) UpdateUserRole(ctx context.Context, userID string, req dto.UpdateUserRoleRequest) (*dto.UpdateUserResponse, error) {
+  if _, err := uuid.Parse(userID); err != nil {
+	return nil, ErrInvalidUserID
+  }
+
+  user, err := s.repo.UpdateRole(ctx, userID, model.UserRole(req.Role))
+  if err != nil {
+	if errors.Is(err, repository.ErrUserNotFound) {
+	  return nil, ErrUserNotFound
+	}
+	return nil, err
+  }
+
+  if err := revokeUserTokens(ctx, s.revocations, userID); err != nil {
+	return nil, fmt.Errorf("failed to revoke tokens: %w", err)
+  }
+
+  return &dto.UpdateUserResponse{
+	User: s.modelToResponse(user),
+	Message: "User role updated successfully!",
   }, nil
 }
 
@@ -221,6 +277,8 @@ func (s *UserService) modelToResponse(user *model.User) *dto.UserResponse {
 	ID:        user.ID,
 	Username:  user.Username,
 	Email:     user.Email,
+	Role:      string(user.Role),
+	EmailVerifiedAt: user.EmailVerifiedAt,
 	Address:   user.Address,
 	City:      user.City,
 	Country:   user.Country,
@@ -0,0 +1,560 @@
+package service
+
+import (
+  "fmt"
+  "time"
+  "sort"
+  "errors"
+  "context"
+  "strings"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+var (
+  ErrVariantNotFound = errors.New("variant not found")
+  ErrDuplicateVariantSKU = errors.New("variant SKU already exists")
+  ErrDuplicateVariant = errors.New("a variant with these attributes already exists")
+  ErrInvalidVariantAttributes = errors.New("variant attributes don't match the product options")
+  ErrNoProductOptions = errors.New("product has no options defined")
+  ErrVariantRequired = errors.New("product has variants, a variant must be selected")
+  ErrProductUnavailable = errors.New("product is not available for sale")
+)
+
+type VariantRepository interface {
+  Create(ctx context.Context, variant *model.ProductVariant) error
+  CreateMany(ctx context.Context, variants []*model.ProductVariant) error
+  GetByID(ctx context.Context, productID, variantID string) (*model.ProductVariant, error)
+  ListByProduct(ctx context.Context, productID string) ([]*model.ProductVariant, error)
+  GetByProductIDs(ctx context.Context, productIDs []string) ([]*model.ProductVariant, error)
+  Update(ctx context.Context, productID, variantID string, updates map[string]interface{}) (*model.ProductVariant, error)
+  UpdateStock(ctx context.Context, m *model.StockMovement) error
+  Delete(ctx context.Context, productID, variantID string) (time.Time, error)
+  GetOptions(ctx context.Context, productID string) ([]*model.ProductOption, error)
+  ReplaceOptions(ctx context.Context, productID string, options []*model.ProductOption) error
+}
+
+type ProductGetter interface {
+  GetProductByID(ctx context.Context, id string) (*model.Product, error)
+}
+
+type VariantService struct {
+  repo VariantRepository
+  products ProductGetter
+  warehouses WarehouseStockReader
+}
+
+func NewVariantService(repo VariantRepository, products ProductGetter, warehouses WarehouseStockReader) *VariantService {
+  return &VariantService{
+	repo: repo,
+	products: products,
+	warehouses: warehouses,
+  }
+}
+
+func (s *VariantService) SetOptions(ctx context.Context, productID string, req *dto.SetProductOptionsRequest) (*dto.ProductOptionsResponse, error) {
+  if _, err := s.getProduct(ctx, productID); err != nil {
+	return nil, err
+  }
+
+  options := make([]*model.ProductOption, len(req.Options))
+  for i, o := range req.Options {
+	options[i] = &model.ProductOption{
+	  Name: strings.ToLower(strings.TrimSpace(o.Name)),
+	  Values: o.Values,
+	}
+  }
+
+  if err := s.repo.ReplaceOptions(ctx, productID, options); err != nil {
+	return nil, fmt.Errorf("failed to set product options: %w", err)
+  }
+
+  return &dto.ProductOptionsResponse{
+	ProductID: productID,
+	Options: toProductOptionResponses(options),
+  }, nil
+}
+
+func (s *VariantService) GetOptions(ctx context.Context, productID string) (*dto.ProductOptionsResponse, error) {
+  if _, err := s.getProduct(ctx, productID); err != nil {
+	return nil, err
+  }
+
+  options, err := s.repo.GetOptions(ctx, productID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to get product options: %w", err)
+  }
+
+  return &dto.ProductOptionsResponse{
+	ProductID: productID,
+	Options: toProductOptionResponses(options),
+  }, nil
+}
+
+func (s *VariantService) ListVariants(ctx context.Context, productID string) (*dto.ListVariantsResponse, error) {
+  if _, err := s.getProduct(ctx, productID); err != nil {
+	return nil, err
+  }
+
+  variants, err := s.repo.ListByProduct(ctx, productID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list variants: %w", err)
+  }
+
+  return &dto.ListVariantsResponse{
+	ProductID: productID,
+	Variants: toVariantResponses(variants),
+  }, nil
+}
+
+func (s *VariantService) GetVariant(ctx context.Context, productID, variantID string) (*dto.VariantResponse, error) {
+  variant, err := s.getVariant(ctx, productID, variantID)
+  if err != nil {
+	return nil, err
+  }
+
+  response := toVariantResponse(variant)
+  return &response, nil
+}
+
+func (s *VariantService) CreateVariant(ctx context.Context, productID string, req *dto.CreateVariantRequest) (*dto.CreateVariantResponse, error) {
+  product, err := s.getProduct(ctx, productID)
+  if err != nil {
+	return nil, err
+  }
+
+  options, err := s.repo.GetOptions(ctx, productID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to get product options: %w", err)
+  }
+
+  attributes := normalizeAttributes(req.Attributes)
+  if len(options) > 0 && !attributesMatchOptions(attributes, options) {
+	return nil, ErrInvalidVariantAttributes
+  }
+
+  name := req.Name
+  if name == "" {
+	name = variantName(product.Name, attributes, options)
+  }
+
+  variant := model.ProductVariant{
+	ID: uuid.New().String(),
+	ProductID: productID,
+	SKU: req.SKU,
+	Name: name,
+	Price: req.Price,
+	Stock: req.Stock,
+	Attributes: attributes,
+  }
+
+  if err := s.repo.Create(ctx, &variant); err != nil {
+	return nil, s.translateError(err, "failed to create variant")
+  }
+
+  response := toVariantResponse(&variant)
+  return &dto.CreateVariantResponse{
+	ID: variant.ID,
+	Variant: &response,
+	Message: "Variant created successfully",
+  }, nil
+}
+
+// GenerateVariants creates one variant per combination of the product's
+// option values. Combinations that already have a variant are skipped, so it
+// is safe to call again after adding a new option value.
+func (s *VariantService) GenerateVariants(ctx context.Context, productID string, req *dto.GenerateVariantsRequest) (*dto.GenerateVariantsResponse, error) {
+  product, err := s.getProduct(ctx, productID)
+  if err != nil {
+	return nil, err
+  }
+
+  options, err := s.repo.GetOptions(ctx, productID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to get product options: %w", err)
+  }
+  if len(options) == 0 {
+	return nil, ErrNoProductOptions
+  }
+
+  existing, err := s.repo.ListByProduct(ctx, productID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list variants: %w", err)
+  }
+
+  seen := make(map[string]bool, len(existing))
+  for _, v := range existing {
+	seen[attributesKey(v.Attributes)] = true
+  }
+
+  price := product.Price
+  if req.Price != nil {
+	price = *req.Price
+  }
+
+  created := []*model.ProductVariant{}
+  skipped := 0
+  for _, attributes := range variantMatrix(options) {
+	if seen[attributesKey(attributes)] {
+	  skipped++
+	  continue
+	}
+
+	created = append(created, &model.ProductVariant{
+	  ID: uuid.New().String(),
+	  ProductID: productID,
+	  SKU: variantSKU(product.SKU, attributes, options),
+	  Name: variantName(product.Name, attributes, options),
+	  Price: price,
+	  Stock: req.Stock,
+	  Attributes: attributes,
+	})
+  }
+
+  if len(created) > 0 {
+	if err := s.repo.CreateMany(ctx, created); err != nil {
+	  return nil, s.translateError(err, "failed to generate variants")
+	}
+  }
+
+  return &dto.GenerateVariantsResponse{
+	Created: toVariantResponses(created),
+	Skipped: skipped,
+	Message: fmt.Sprintf("%d variants created", len(created)),
+  }, nil
+}
+
+func (s *VariantService) UpdateVariant(ctx context.Context, productID, variantID string, req *dto.UpdateVariantRequest) (*dto.UpdateVariantResponse, error) {
+  if _, err := s.getVariant(ctx, productID, variantID); err != nil {
+	return nil, err
+  }
+
+  updates := make(map[string]interface{})
+  if req.SKU != nil {
+	updates["sku"] = *req.SKU
+  }
+  if req.Name != nil {
+	updates["name"] = *req.Name
+  }
+  if req.Price != nil {
+	updates["price"] = *req.Price
+  }
+
+  updated, err := s.repo.Update(ctx, productID, variantID, updates)
+  if err != nil {
+	return nil, s.translateError(err, "failed to update variant")
+  }
+
+  response := toVariantResponse(updated)
+  return &dto.UpdateVariantResponse{
+	Variant: &response,
+	Message: "Variant updated successfully",
+  }, nil
+}
+
+func (s *VariantService) UpdateVariantStock(ctx context.Context, productID, variantID string, req *dto.UpdateVariantStockRequest) (*dto.UpdateProductStockResponse, error) {
+  variant, err := s.getVariant(ctx, productID, variantID)
+  if err != nil {
+	return nil, err
+  }
+
+  warehouseID, delta, err := warehouseStockChange(ctx, s.warehouses, productID, &variant.ID, req.WarehouseID, req.Stock, req.Increment)
+  if err != nil {
+	return nil, err
+  }
+
+  movement := newStockMovement(productID, &variantID, warehouseID, delta, req.Type, req.Reason, req.ActorID)
+  if err := s.repo.UpdateStock(ctx, movement); err != nil {
+	if errors.Is(err, repository.ErrInsufficientStock) {
+	  return nil, ErrStockBelowReserved
+	}
+	return nil, fmt.Errorf("failed to update variant stock: %w", err)
+  }
+
+  return &dto.UpdateProductStockResponse{
+	ProductID: productID,
+	NewStock: movement.StockAfter,
+	Message: "Stock updated successfully",
+  }, nil
+}
+
+func (s *VariantService) DeleteVariant(ctx context.Context, productID, variantID string) (*dto.DeleteVariantResponse, error) {
+  if _, err := s.getVariant(ctx, productID, variantID); err != nil {
+	return nil, err
+  }
+
+  deletedAt, err := s.repo.Delete(ctx, productID, variantID)
+  if err != nil {
+	return nil, s.translateError(err, "failed to delete variant")
+  }
+
+  return &dto.DeleteVariantResponse{
+	ID: variantID,
+	Message: "Variant deleted successfully",
+	DeletedAt: deletedAt,
+  }, nil
+}
+
+func (s *VariantService) getProduct(ctx context.Context, productID string) (*model.Product, error) {
+  return findProduct(ctx, s.products, productID)
+}
+
+func (s *VariantService) getVariant(ctx context.Context, productID, variantID string) (*model.ProductVariant, error) {
+  if _, err := uuid.Parse(productID); err != nil {
+	return nil, ErrInvalidID
+  }
+  if _, err := uuid.Parse(variantID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  variant, err := s.repo.GetByID(ctx, productID, variantID)
+  if err != nil {
+	if errors.Is(err, repository.ErrVariantNotFound) {
+	  return nil, ErrVariantNotFound
+	}
+	return nil, fmt.Errorf("failed to get variant: %w", err)
+  }
+
+  return variant, nil
+}
+
+func (s *VariantService) translateError(err error, message string) error {
+  switch {
+  case errors.Is(err, repository.ErrVariantNotFound):
+	return ErrVariantNotFound
+  case errors.Is(err, repository.ErrDuplicateSKU):
+	return ErrDuplicateVariantSKU
+  case errors.Is(err, repository.ErrDuplicateVariant):
+	return ErrDuplicateVariant
+  }
+
+  return fmt.Errorf("%s: %w", message, err)
+}
+
+// Helpers
+
+// saleLine is a product, and optionally one of its variants, resolved for a
+// cart or order line. Variants override the product's SKU, name and price and
+// carry their own stock.
+type saleLine struct {
+  product *model.Product
+  variant *model.ProductVariant
+}
+
+func (l *saleLine) sku() string {
+  if l.variant != nil {
+	return l.variant.SKU
+  }
+  return l.product.SKU
+}
+
+func (l *saleLine) name() string {
+  if l.variant != nil {
+	return l.variant.Name
+  }
+  return l.product.Name
+}
+
+func (l *saleLine) image() string {
+  if len(l.product.Images) > 0 {
+	return l.product.Images[0]
+  }
+  return ""
+}
+
+func (l *saleLine) unitPrice() float64 {
+  if l.variant != nil {
+	return l.variant.Price
+  }
+  return l.product.Price
+}
+
+func (l *saleLine) available() int {
+  if l.variant != nil {
+	return l.variant.Stock - l.variant.ReservedStock
+  }
+  return l.product.Stock - l.product.ReservedStock
+}
+
+// resolveSaleLine loads what is being bought. Products that have variants
+// can't be bought without picking one, otherwise the parent stock would be
+// sold instead of the variant's.
+func resolveSaleLine(ctx context.Context, products ProductGetter, variants VariantRepository, productID string, variantID *string) (*saleLine, error) {
+  product, err := products.GetProductByID(ctx, productID)
+  if err != nil {
+	if errors.Is(err, repository.ErrNotFound) {
+	  return nil, ErrProductNotFound
+	}
+	return nil, fmt.Errorf("failed to get product: %w", err)
+  }
+
+  if product.Status != model.ProductStatusActive {
+	return nil, ErrProductUnavailable
+  }
+
+  line := &saleLine{product: product}
+
+  if variantID == nil {
+	existing, err := variants.ListByProduct(ctx, productID)
+	if err != nil {
+	  return nil, fmt.Errorf("failed to list variants: %w", err)
+	}
+	if len(existing) > 0 {
+	  return nil, ErrVariantRequired
+	}
+	return line, nil
+  }
+
+  variant, err := variants.GetByID(ctx, productID, *variantID)
+  if err != nil {
+	if errors.Is(err, repository.ErrVariantNotFound) {
+	  return nil, ErrVariantNotFound
+	}
+	return nil, fmt.Errorf("failed to get variant: %w", err)
+  }
+  line.variant = variant
+
+  return line, nil
+}
+
+func normalizeAttributes(attributes map[string]string) map[string]string {
+  normalized := make(map[string]string, len(attributes))
+  for k, v := range attributes {
+	normalized[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+  }
+  return normalized
+}
+
+func attributesMatchOptions(attributes map[string]string, options []*model.ProductOption) bool {
+  if len(attributes) != len(options) {
+	return false
+  }
+
+  for _, o := range options {
+	value, ok := attributes[o.Name]
+	if !ok {
+	  return false
+	}
+
+	allowed := false
+	for _, v := range o.Values {
+	  if v == value {
+		allowed = true
+		break
+	  }
+	}
+	if !allowed {
+	  return false
+	}
+  }
+
+  return true
+}
+
+// attributesKey gives a stable string for a set of attributes so
+// combinations can be compared regardless of map ordering.
+func attributesKey(attributes map[string]string) string {
+  keys := make([]string, 0, len(attributes))
+  for k := range attributes {
+	keys = append(keys, k)
+  }
+  sort.Strings(keys)
+
+  parts := make([]string, len(keys))
+  for i, k := range keys {
+	parts[i] = k + "=" + attributes[k]
+  }
+  return strings.Join(parts, ";")
+}
+
+// variantMatrix returns the cartesian product of all option values.
+func variantMatrix(options []*model.ProductOption) []map[string]string {
+  matrix := []map[string]string{{}}
+
+  for _, o := range options {
+	next := make([]map[string]string, 0, len(matrix)*len(o.Values))
+	for _, combination := range matrix {
+	  for _, value := range o.Values {
+		attributes := make(map[string]string, len(combination)+1)
+		for k, v := range combination {
+		  attributes[k] = v
+		}
+		attributes[o.Name] = value
+		next = append(next, attributes)
+	  }
+	}
+	matrix = next
+  }
+
+  return matrix
+}
+
+func orderedOptionValues(attributes map[string]string, options []*model.ProductOption) []string {
+  values := []string{}
+  if len(options) == 0 {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+	  keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+	  values = append(values, attributes[k])
+	}
+	return values
+  }
+
+  for _, o := range options {
+	values = append(values, attributes[o.Name])
+  }
+  return values
+}
+
+func variantName(productName string, attributes map[string]string, options []*model.ProductOption) string {
+  return productName + " - " + strings.Join(orderedOptionValues(attributes, options), " / ")
+}
+
+func variantSKU(productSKU string, attributes map[string]string, options []*model.ProductOption) string {
+  parts := []string{productSKU}
+  for _, v := range orderedOptionValues(attributes, options) {
+	parts = append(parts, strings.ToUpper(slugify(v)))
+  }
+  return strings.Join(parts, "-")
+}
+
+func toVariantResponse(v *model.ProductVariant) dto.VariantResponse {
+  return dto.VariantResponse{
+	ID: v.ID,
+	ProductID: v.ProductID,
+	SKU: v.SKU,
+	Name: v.Name,
+	Price: v.Price,
+	Stock: v.Stock,
+	Available: v.Stock - v.ReservedStock,
+	Attributes: v.Attributes,
+	CreatedAt: v.CreatedAt,
+	UpdatedAt: v.UpdatedAt,
+  }
+}
+
+func toVariantResponses(variants []*model.ProductVariant) []dto.VariantResponse {
+  responses := make([]dto.VariantResponse, len(variants))
+  for i, v := range variants {
+	responses[i] = toVariantResponse(v)
+  }
+  return responses
+}
+
+func toProductOptionResponses(options []*model.ProductOption) []dto.ProductOptionResponse {
+  responses := make([]dto.ProductOptionResponse, len(options))
+  for i, o := range options {
+	responses[i] = dto.ProductOptionResponse{
+	  Name: o.Name,
+	  Values: o.Values,
+	}
+  }
+  return responses
+}
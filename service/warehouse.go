@@ -0,0 +1,275 @@
+package service
+
+import (
+  "fmt"
+  "errors"
+  "context"
+
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+var (
+  ErrWarehouseNotFound = errors.New("warehouse not found")
+  ErrDuplicateWarehouseCode = errors.New("warehouse code already exists")
+  ErrWarehouseInactive = errors.New("warehouse is inactive")
+  ErrWarehouseHasStock = errors.New("warehouse still holds stock")
+  ErrDefaultWarehouseRequired = errors.New("the default warehouse can't be deactivated or unset, make another warehouse the default")
+)
+
+type WarehouseRepository interface {
+  Create(ctx context.Context, w *model.Warehouse) error
+  GetByID(ctx context.Context, id string) (*model.Warehouse, error)
+  List(ctx context.Context, activeOnly bool) ([]*model.Warehouse, error)
+  Update(ctx context.Context, id string, updates map[string]interface{}) (*model.Warehouse, error)
+  HasStock(ctx context.Context, id string) (bool, error)
+  GetDefault(ctx context.Context) (*model.Warehouse, error)
+  GetStock(ctx context.Context, warehouseID, productID string, variantID *string) (*model.WarehouseStock, error)
+  ListStockByProduct(ctx context.Context, productID string) ([]*model.WarehouseStock, error)
+}
+
+// WarehouseStockReader is what stock updates need to know about warehouses.
+type WarehouseStockReader interface {
+  GetByID(ctx context.Context, id string) (*model.Warehouse, error)
+  GetDefault(ctx context.Context) (*model.Warehouse, error)
+  GetStock(ctx context.Context, warehouseID, productID string, variantID *string) (*model.WarehouseStock, error)
+}
+
+type WarehouseService struct {
+  repo WarehouseRepository
+  products ProductGetter
+}
+
+func NewWarehouseService(repo WarehouseRepository, products ProductGetter) *WarehouseService {
+  return &WarehouseService{
+	repo: repo,
+	products: products,
+  }
+}
+
+func (s *WarehouseService) CreateWarehouse(ctx context.Context, req *dto.CreateWarehouseRequest) (*dto.CreateWarehouseResponse, error) {
+  warehouse := model.Warehouse{
+	ID: uuid.New().String(),
+	Code: req.Code,
+	Name: req.Name,
+	Country: req.Country,
+	ShipsTo: req.ShipsTo,
+	Priority: req.Priority,
+	IsDefault: req.IsDefault,
+	IsActive: true,
+  }
+  if warehouse.ShipsTo == nil {
+	warehouse.ShipsTo = []string{}
+  }
+
+  if err := s.repo.Create(ctx, &warehouse); err != nil {
+	if errors.Is(err, repository.ErrDuplicateWarehouseCode) {
+	  return nil, ErrDuplicateWarehouseCode
+	}
+	return nil, fmt.Errorf("failed to create warehouse: %w", err)
+  }
+
+  response := toWarehouseResponse(&warehouse)
+  return &dto.CreateWarehouseResponse{
+	ID: warehouse.ID,
+	Warehouse: &response,
+	Message: "Warehouse created successfully",
+  }, nil
+}
+
+func (s *WarehouseService) ListWarehouses(ctx context.Context) (*dto.ListWarehousesResponse, error) {
+  warehouses, err := s.repo.List(ctx, false)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list warehouses: %w", err)
+  }
+
+  responses := make([]dto.WarehouseResponse, len(warehouses))
+  for i, w := range warehouses {
+	responses[i] = toWarehouseResponse(w)
+  }
+
+  return &dto.ListWarehousesResponse{
+	Warehouses: responses,
+  }, nil
+}
+
+// UpdateWarehouse applies a partial update. A warehouse can only be
+// deactivated once it holds no stock, so the aggregated availability of every
+// product only ever counts stock that can be allocated.
+func (s *WarehouseService) UpdateWarehouse(ctx context.Context, id string, req *dto.UpdateWarehouseRequest) (*dto.UpdateWarehouseResponse, error) {
+  warehouse, err := s.getWarehouse(ctx, id)
+  if err != nil {
+	return nil, err
+  }
+
+  if warehouse.IsDefault {
+	if (req.IsDefault != nil && !*req.IsDefault) || (req.IsActive != nil && !*req.IsActive) {
+	  return nil, ErrDefaultWarehouseRequired
+	}
+  }
+
+  isActive := warehouse.IsActive
+  if req.IsActive != nil {
+	isActive = *req.IsActive
+  }
+  if req.IsDefault != nil && *req.IsDefault && !isActive {
+	return nil, ErrWarehouseInactive
+  }
+
+  if req.IsActive != nil && !*req.IsActive && warehouse.IsActive {
+	hasStock, err := s.repo.HasStock(ctx, id)
+	if err != nil {
+	  return nil, err
+	}
+	if hasStock {
+	  return nil, ErrWarehouseHasStock
+	}
+  }
+
+  updates := make(map[string]interface{})
+  if req.Name != nil {
+	updates["name"] = *req.Name
+  }
+  if req.Country != nil {
+	updates["country"] = *req.Country
+  }
+  if req.ShipsTo != nil {
+	updates["ships_to"] = req.ShipsTo
+  }
+  if req.Priority != nil {
+	updates["priority"] = *req.Priority
+  }
+  if req.IsDefault != nil {
+	updates["is_default"] = *req.IsDefault
+  }
+  if req.IsActive != nil {
+	updates["is_active"] = *req.IsActive
+  }
+
+  updated, err := s.repo.Update(ctx, id, updates)
+  if err != nil {
+	if errors.Is(err, repository.ErrWarehouseNotFound) {
+	  return nil, ErrWarehouseNotFound
+	}
+	return nil, fmt.Errorf("failed to update warehouse: %w", err)
+  }
+
+  response := toWarehouseResponse(updated)
+  return &dto.UpdateWarehouseResponse{
+	Warehouse: &response,
+	Message: "Warehouse updated successfully",
+  }, nil
+}
+
+// GetProductStock returns what every warehouse holds of a product and its
+// variants.
+func (s *WarehouseService) GetProductStock(ctx context.Context, productID string) (*dto.ProductWarehouseStockResponse, error) {
+  if _, err := findProduct(ctx, s.products, productID); err != nil {
+	return nil, err
+  }
+
+  warehouses, err := s.repo.List(ctx, false)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list warehouses: %w", err)
+  }
+  codes := make(map[string]string, len(warehouses))
+  for _, w := range warehouses {
+	codes[w.ID] = w.Code
+  }
+
+  stock, err := s.repo.ListStockByProduct(ctx, productID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to get warehouse stock: %w", err)
+  }
+
+  responses := make([]dto.WarehouseStockResponse, len(stock))
+  for i, ws := range stock {
+	responses[i] = dto.WarehouseStockResponse{
+	  WarehouseID: ws.WarehouseID,
+	  WarehouseCode: codes[ws.WarehouseID],
+	  VariantID: ws.VariantID,
+	  Stock: ws.Stock,
+	  ReservedStock: ws.ReservedStock,
+	  Available: ws.Available(),
+	}
+  }
+
+  return &dto.ProductWarehouseStockResponse{
+	ProductID: productID,
+	Stock: responses,
+  }, nil
+}
+
+func (s *WarehouseService) getWarehouse(ctx context.Context, id string) (*model.Warehouse, error) {
+  if _, err := uuid.Parse(id); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  warehouse, err := s.repo.GetByID(ctx, id)
+  if err != nil {
+	if errors.Is(err, repository.ErrWarehouseNotFound) {
+	  return nil, ErrWarehouseNotFound
+	}
+	return nil, fmt.Errorf("failed to get warehouse: %w", err)
+  }
+
+  return warehouse, nil
+}
+
+// warehouseStockChange works out the delta that sets, or increments with
+// increment, the stock a warehouse holds of a product or variant. Without a
+// warehouse the default one is used. The new stock can't drop below what the
+// warehouse has reserved.
+func warehouseStockChange(ctx context.Context, warehouses WarehouseStockReader, productID string, variantID, warehouseID *string, stock int, increment bool) (string, int, error) {
+  var warehouse *model.Warehouse
+  var err error
+  if warehouseID != nil {
+	warehouse, err = warehouses.GetByID(ctx, *warehouseID)
+  } else {
+	warehouse, err = warehouses.GetDefault(ctx)
+  }
+  if err != nil {
+	if errors.Is(err, repository.ErrWarehouseNotFound) {
+	  return "", 0, ErrWarehouseNotFound
+	}
+	return "", 0, fmt.Errorf("failed to get warehouse: %w", err)
+  }
+
+  if !warehouse.IsActive {
+	return "", 0, ErrWarehouseInactive
+  }
+
+  current, err := warehouses.GetStock(ctx, warehouse.ID, productID, variantID)
+  if err != nil {
+	return "", 0, fmt.Errorf("failed to get warehouse stock: %w", err)
+  }
+
+  delta := stock
+  if !increment {
+	delta = stock - current.Stock
+  }
+
+  if current.Stock + delta < current.ReservedStock {
+	return "", 0, ErrStockBelowReserved
+  }
+
+  return warehouse.ID, delta, nil
+}
+
+func toWarehouseResponse(w *model.Warehouse) dto.WarehouseResponse {
+  return dto.WarehouseResponse{
+	ID: w.ID,
+	Code: w.Code,
+	Name: w.Name,
+	Country: w.Country,
+	ShipsTo: w.ShipsTo,
+	Priority: w.Priority,
+	IsDefault: w.IsDefault,
+	IsActive: w.IsActive,
+	CreatedAt: w.CreatedAt,
+	UpdatedAt: w.UpdatedAt,
+  }
+}
@@ -0,0 +1,371 @@
+package service
+
+import (
+  "fmt"
+  "errors"
+  "context"
+
+  "github.com/F-Dupraz/ecommerce-with-go/auth"
+  "github.com/F-Dupraz/ecommerce-with-go/dto"
+  "github.com/F-Dupraz/ecommerce-with-go/model"
+  "github.com/F-Dupraz/ecommerce-with-go/repository"
+
+  "github.com/google/uuid"
+)
+
+var (
+  ErrWishlistNotFound = errors.New("wishlist not found")
+  ErrWishlistItemNotFound = errors.New("wishlist item not found")
+  ErrDuplicateWishlistName = errors.New("wishlist name already exists")
+)
+
+// shareTokenSize is the number of random bytes behind a wishlist share link.
+const shareTokenSize = 24
+
+type WishlistRepository interface {
+  Create(ctx context.Context, w *model.Wishlist) error
+  ListByUser(ctx context.Context, userID string) ([]*model.Wishlist, error)
+  GetByID(ctx context.Context, userID, id string) (*model.Wishlist, error)
+  GetByShareToken(ctx context.Context, token string) (*model.Wishlist, error)
+  Update(ctx context.Context, w *model.Wishlist) error
+  Delete(ctx context.Context, userID, id string) error
+  ListItems(ctx context.Context, wishlistID string) ([]*model.WishlistItem, error)
+  AddItem(ctx context.Context, item *model.WishlistItem) (*model.WishlistItem, error)
+  GetItem(ctx context.Context, wishlistID, itemID string) (*model.WishlistItem, error)
+  RemoveItem(ctx context.Context, wishlistID, itemID string) error
+}
+
+// CartAdder is the part of the cart service wishlists move items into.
+type CartAdder interface {
+  AddToCart(ctx context.Context, userID string, req *dto.AddToCartRequest) (*dto.AddToCartResponse, error)
+}
+
+type WishlistService struct {
+  repo WishlistRepository
+  products ProductGetter
+  variants VariantRepository
+  cart CartAdder
+}
+
+func NewWishlistService(repo WishlistRepository, products ProductGetter, variants VariantRepository, cart CartAdder) *WishlistService {
+  return &WishlistService{
+	repo: repo,
+	products: products,
+	variants: variants,
+	cart: cart,
+  }
+}
+
+func (s *WishlistService) CreateWishlist(ctx context.Context, userID string, req *dto.CreateWishlistRequest) (*dto.CreateWishlistResponse, error) {
+  wishlist := model.Wishlist{
+	ID: uuid.New().String(),
+	UserID: userID,
+	Name: req.Name,
+  }
+
+  if req.Shared {
+	token, err := auth.RandomToken(shareTokenSize)
+	if err != nil {
+	  return nil, err
+	}
+	wishlist.ShareToken = &token
+  }
+
+  if err := s.repo.Create(ctx, &wishlist); err != nil {
+	if errors.Is(err, repository.ErrDuplicateWishlistName) {
+	  return nil, ErrDuplicateWishlistName
+	}
+	return nil, fmt.Errorf("failed to create wishlist: %w", err)
+  }
+
+  response := toWishlistResponse(&wishlist)
+  return &dto.CreateWishlistResponse{
+	Wishlist: &response,
+	Message: "Wishlist created successfully",
+  }, nil
+}
+
+func (s *WishlistService) ListWishlists(ctx context.Context, userID string) (*dto.ListWishlistsResponse, error) {
+  wishlists, err := s.repo.ListByUser(ctx, userID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list wishlists: %w", err)
+  }
+
+  responses := make([]dto.WishlistResponse, len(wishlists))
+  for i, w := range wishlists {
+	responses[i] = toWishlistResponse(w)
+  }
+
+  return &dto.ListWishlistsResponse{
+	Wishlists: responses,
+  }, nil
+}
+
+func (s *WishlistService) GetWishlist(ctx context.Context, userID, wishlistID string) (*dto.WishlistResponse, error) {
+  wishlist, err := s.getWishlist(ctx, userID, wishlistID)
+  if err != nil {
+	return nil, err
+  }
+
+  return s.buildWishlistResponse(ctx, wishlist)
+}
+
+// GetSharedWishlist returns the wishlist behind a share link. The token is
+// left out of the response, visitors don't get to pass it on as the owner.
+func (s *WishlistService) GetSharedWishlist(ctx context.Context, token string) (*dto.WishlistResponse, error) {
+  wishlist, err := s.repo.GetByShareToken(ctx, token)
+  if err != nil {
+	if errors.Is(err, repository.ErrWishlistNotFound) {
+	  return nil, ErrWishlistNotFound
+	}
+	return nil, fmt.Errorf("failed to get wishlist: %w", err)
+  }
+
+  response, err := s.buildWishlistResponse(ctx, wishlist)
+  if err != nil {
+	return nil, err
+  }
+  response.ShareToken = nil
+
+  return response, nil
+}
+
+// UpdateWishlist renames a wishlist and turns its share link on or off.
+// Sharing again after turning it off issues a new token, so old links stay
+// dead.
+func (s *WishlistService) UpdateWishlist(ctx context.Context, userID, wishlistID string, req *dto.UpdateWishlistRequest) (*dto.UpdateWishlistResponse, error) {
+  wishlist, err := s.getWishlist(ctx, userID, wishlistID)
+  if err != nil {
+	return nil, err
+  }
+
+  if req.Name != nil {
+	wishlist.Name = *req.Name
+  }
+
+  if req.Shared != nil {
+	switch {
+	case *req.Shared && wishlist.ShareToken == nil:
+	  token, err := auth.RandomToken(shareTokenSize)
+	  if err != nil {
+		return nil, err
+	  }
+	  wishlist.ShareToken = &token
+	case !*req.Shared:
+	  wishlist.ShareToken = nil
+	}
+  }
+
+  if err := s.repo.Update(ctx, wishlist); err != nil {
+	switch {
+	case errors.Is(err, repository.ErrWishlistNotFound):
+	  return nil, ErrWishlistNotFound
+	case errors.Is(err, repository.ErrDuplicateWishlistName):
+	  return nil, ErrDuplicateWishlistName
+	}
+	return nil, fmt.Errorf("failed to update wishlist: %w", err)
+  }
+
+  response := toWishlistResponse(wishlist)
+  return &dto.UpdateWishlistResponse{
+	Wishlist: &response,
+	Message: "Wishlist updated successfully",
+  }, nil
+}
+
+func (s *WishlistService) DeleteWishlist(ctx context.Context, userID, wishlistID string) error {
+  if _, err := uuid.Parse(wishlistID); err != nil {
+	return ErrInvalidID
+  }
+
+  if err := s.repo.Delete(ctx, userID, wishlistID); err != nil {
+	if errors.Is(err, repository.ErrWishlistNotFound) {
+	  return ErrWishlistNotFound
+	}
+	return fmt.Errorf("failed to delete wishlist: %w", err)
+  }
+
+  return nil
+}
+
+// AddItem saves a product, or one of its variants, to a wishlist. Products
+// that are out of stock can be saved, that's what wishlists are for.
+func (s *WishlistService) AddItem(ctx context.Context, userID, wishlistID string, req *dto.AddWishlistItemRequest) (*dto.AddWishlistItemResponse, error) {
+  wishlist, err := s.getWishlist(ctx, userID, wishlistID)
+  if err != nil {
+	return nil, err
+  }
+
+  if _, err := findProduct(ctx, s.products, req.ProductID); err != nil {
+	return nil, err
+  }
+
+  if req.VariantID != nil {
+	if _, err := s.variants.GetByID(ctx, req.ProductID, *req.VariantID); err != nil {
+	  if errors.Is(err, repository.ErrVariantNotFound) {
+		return nil, ErrVariantNotFound
+	  }
+	  return nil, fmt.Errorf("failed to get variant: %w", err)
+	}
+  }
+
+  _, err = s.repo.AddItem(ctx, &model.WishlistItem{
+	ID: uuid.New().String(),
+	WishlistID: wishlist.ID,
+	ProductID: req.ProductID,
+	VariantID: req.VariantID,
+  })
+  if err != nil {
+	return nil, fmt.Errorf("failed to add to wishlist: %w", err)
+  }
+
+  response, err := s.GetWishlist(ctx, userID, wishlist.ID)
+  if err != nil {
+	return nil, err
+  }
+
+  return &dto.AddWishlistItemResponse{
+	Wishlist: response,
+	Message: "Item added to wishlist",
+  }, nil
+}
+
+func (s *WishlistService) RemoveItem(ctx context.Context, userID, wishlistID, itemID string) (*dto.WishlistResponse, error) {
+  wishlist, err := s.getWishlist(ctx, userID, wishlistID)
+  if err != nil {
+	return nil, err
+  }
+
+  if _, err := uuid.Parse(itemID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  if err := s.repo.RemoveItem(ctx, wishlist.ID, itemID); err != nil {
+	if errors.Is(err, repository.ErrWishlistItemNotFound) {
+	  return nil, ErrWishlistItemNotFound
+	}
+	return nil, fmt.Errorf("failed to remove wishlist item: %w", err)
+  }
+
+  return s.GetWishlist(ctx, userID, wishlist.ID)
+}
+
+// MoveToCart adds a wishlist item to the user's cart and takes it off the
+// wishlist. Availability is checked by the cart service; when the item can't
+// be added it stays on the wishlist.
+func (s *WishlistService) MoveToCart(ctx context.Context, userID, wishlistID, itemID string, req *dto.MoveToCartRequest) (*dto.AddToCartResponse, error) {
+  wishlist, err := s.getWishlist(ctx, userID, wishlistID)
+  if err != nil {
+	return nil, err
+  }
+
+  if _, err := uuid.Parse(itemID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  item, err := s.repo.GetItem(ctx, wishlist.ID, itemID)
+  if err != nil {
+	if errors.Is(err, repository.ErrWishlistItemNotFound) {
+	  return nil, ErrWishlistItemNotFound
+	}
+	return nil, fmt.Errorf("failed to get wishlist item: %w", err)
+  }
+
+  quantity := req.Quantity
+  if quantity == 0 {
+	quantity = 1
+  }
+
+  response, err := s.cart.AddToCart(ctx, userID, &dto.AddToCartRequest{
+	ProductID: item.ProductID,
+	VariantID: item.VariantID,
+	Quantity: quantity,
+  })
+  if err != nil {
+	return nil, err
+  }
+
+  if err := s.repo.RemoveItem(ctx, wishlist.ID, item.ID); err != nil && !errors.Is(err, repository.ErrWishlistItemNotFound) {
+	return nil, fmt.Errorf("failed to remove wishlist item: %w", err)
+  }
+
+  response.Message = "Item moved to cart"
+  return response, nil
+}
+
+func (s *WishlistService) getWishlist(ctx context.Context, userID, wishlistID string) (*model.Wishlist, error) {
+  if _, err := uuid.Parse(wishlistID); err != nil {
+	return nil, ErrInvalidID
+  }
+
+  wishlist, err := s.repo.GetByID(ctx, userID, wishlistID)
+  if err != nil {
+	if errors.Is(err, repository.ErrWishlistNotFound) {
+	  return nil, ErrWishlistNotFound
+	}
+	return nil, fmt.Errorf("failed to get wishlist: %w", err)
+  }
+
+  return wishlist, nil
+}
+
+// buildWishlistResponse shows every item at its current price. Items whose
+// product is no longer for sale or out of stock are flagged unavailable.
+func (s *WishlistService) buildWishlistResponse(ctx context.Context, wishlist *model.Wishlist) (*dto.WishlistResponse, error) {
+  items, err := s.repo.ListItems(ctx, wishlist.ID)
+  if err != nil {
+	return nil, fmt.Errorf("failed to list wishlist items: %w", err)
+  }
+
+  response := toWishlistResponse(wishlist)
+  response.ItemCount = len(items)
+  response.Items = make([]dto.WishlistItemResponse, 0, len(items))
+
+  for _, i := range items {
+	itemResponse := dto.WishlistItemResponse{
+	  ID: i.ID,
+	  ProductID: i.ProductID,
+	  VariantID: i.VariantID,
+	  AddedAt: i.CreatedAt,
+	}
+
+	product, err := s.products.GetProductByID(ctx, i.ProductID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+	  return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	if product != nil {
+	  line := &saleLine{product: product}
+	  if i.VariantID != nil {
+		line.variant, err = s.variants.GetByID(ctx, i.ProductID, *i.VariantID)
+		if err != nil && !errors.Is(err, repository.ErrVariantNotFound) {
+		  return nil, fmt.Errorf("failed to get variant: %w", err)
+		}
+	  }
+
+	  if i.VariantID == nil || line.variant != nil {
+		itemResponse.SKU = line.sku()
+		itemResponse.ProductName = line.name()
+		itemResponse.ProductImage = line.image()
+		itemResponse.Price = line.unitPrice()
+		itemResponse.Available = product.Status == model.ProductStatusActive && line.available() > 0
+	  }
+	}
+
+	response.Items = append(response.Items, itemResponse)
+  }
+
+  return &response, nil
+}
+
+func toWishlistResponse(w *model.Wishlist) dto.WishlistResponse {
+  return dto.WishlistResponse{
+	ID: w.ID,
+	Name: w.Name,
+	Shared: w.ShareToken != nil,
+	ShareToken: w.ShareToken,
+	ItemCount: w.ItemCount,
+	CreatedAt: w.CreatedAt,
+	UpdatedAt: w.UpdatedAt,
+  }
+}
@@ -0,0 +1,20 @@
+package storage
+
+import (
+  "context"
+  "errors"
+  "io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore persists binary objects under slash separated keys. Keys are
+// chosen by the caller, the store only has to map them to storage and to a
+// public URL.
+type BlobStore interface {
+  Put(ctx context.Context, key string, r io.Reader, contentType string) error
+  Get(ctx context.Context, key string) (io.ReadCloser, error)
+  Delete(ctx context.Context, key string) error
+  DeletePrefix(ctx context.Context, prefix string) error
+  URL(key string) string
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "io"
+  "os"
+  "path"
+  "path/filepath"
+  "strings"
+)
+
+// LocalStore keeps blobs on the local filesystem below root. Files are
+// expected to be served by a static file server mounted at baseURL.
+type LocalStore struct {
+  root    string
+  baseURL string
+}
+
+func NewLocalStore(root, baseURL string) (*LocalStore, error) {
+  if err := os.MkdirAll(root, 0o755); err != nil {
+	return nil, fmt.Errorf("failed to create storage root: %w", err)
+  }
+
+  return &LocalStore{
+	root: root,
+	baseURL: strings.TrimRight(baseURL, "/"),
+  }, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+  dst, err := s.path(key)
+  if err != nil {
+	return err
+  }
+
+  if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+	return fmt.Errorf("failed to create blob directory: %w", err)
+  }
+
+  // Write to a temporary file first so readers never see a partial blob.
+  tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+  if err != nil {
+	return fmt.Errorf("failed to create blob: %w", err)
+  }
+  defer os.Remove(tmp.Name())
+
+  if _, err := io.Copy(tmp, r); err != nil {
+	tmp.Close()
+	return fmt.Errorf("failed to write blob: %w", err)
+  }
+
+  if err := tmp.Close(); err != nil {
+	return fmt.Errorf("failed to write blob: %w", err)
+  }
+
+  if err := os.Rename(tmp.Name(), dst); err != nil {
+	return fmt.Errorf("failed to store blob: %w", err)
+  }
+
+  return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+  src, err := s.path(key)
+  if err != nil {
+	return nil, err
+  }
+
+  f, err := os.Open(src)
+  if err != nil {
+	if errors.Is(err, os.ErrNotExist) {
+	  return nil, ErrBlobNotFound
+	}
+	return nil, fmt.Errorf("failed to open blob: %w", err)
+  }
+
+  return f, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+  target, err := s.path(key)
+  if err != nil {
+	return err
+  }
+
+  if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+	return fmt.Errorf("failed to delete blob: %w", err)
+  }
+
+  return nil
+}
+
+func (s *LocalStore) DeletePrefix(ctx context.Context, prefix string) error {
+  target, err := s.path(prefix)
+  if err != nil {
+	return err
+  }
+
+  if err := os.RemoveAll(target); err != nil {
+	return fmt.Errorf("failed to delete blobs: %w", err)
+  }
+
+  return nil
+}
+
+func (s *LocalStore) URL(key string) string {
+  return s.baseURL + "/" + strings.TrimLeft(path.Clean("/" + key), "/")
+}
+
+// path maps a key to a file below root, rejecting keys that would escape it.
+func (s *LocalStore) path(key string) (string, error) {
+  cleaned := path.Clean("/" + key)
+  if cleaned == "/" {
+	return "", fmt.Errorf("invalid blob key %q", key)
+  }
+
+  return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}